@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -14,16 +15,33 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/smart-health/payments-api/internal/auth"
+	"github.com/smart-health/payments-api/internal/coupons"
 	"github.com/smart-health/payments-api/internal/database"
+	"github.com/smart-health/payments-api/internal/gateway"
+	"github.com/smart-health/payments-api/internal/gateway/banktransfer"
+	"github.com/smart-health/payments-api/internal/grpcapi"
+	"github.com/smart-health/payments-api/internal/idempotency"
+	"github.com/smart-health/payments-api/internal/invoicing"
 	"github.com/smart-health/payments-api/internal/messaging"
 	"github.com/smart-health/payments-api/internal/outbox"
 	completepayment "github.com/smart-health/payments-api/internal/payments/complete_payment"
+	confirmpayment "github.com/smart-health/payments-api/internal/payments/confirm_payment"
 	createpayment "github.com/smart-health/payments-api/internal/payments/create_payment"
 	"github.com/smart-health/payments-api/internal/payments/domain"
 	getpayment "github.com/smart-health/payments-api/internal/payments/get_payment"
 	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+	listpayments "github.com/smart-health/payments-api/internal/payments/list_payments"
+	refundpayment "github.com/smart-health/payments-api/internal/payments/refund_payment"
+	retrypending "github.com/smart-health/payments-api/internal/payments/retry_pending"
+	"github.com/smart-health/payments-api/internal/payments/statushub"
+	"github.com/smart-health/payments-api/internal/pricing"
+	"github.com/smart-health/payments-api/internal/problem"
+	"github.com/smart-health/payments-api/internal/resilience"
 	"github.com/smart-health/payments-api/internal/shared"
 	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+	"github.com/smart-health/payments-api/internal/stripe/simulator"
+	"github.com/smart-health/payments-api/internal/tax"
 )
 
 func main() {
@@ -37,10 +55,15 @@ func main() {
 	// Configuration
 	// ----------------------------------------------------------------
 	cfg := shared.LoadConfig()
+	if err := cfg.Validate(); err != nil {
+		logger.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
 	logger.Info("starting SmartHealth Payments API",
 		"port", cfg.Port,
 		"environment", cfg.Environment,
-		"useInMemoryBroker", cfg.UseInMemoryBroker)
+		"useInMemoryBroker", cfg.UseInMemoryBroker,
+		"stripeSimulated", cfg.StripeSimulated)
 
 	// ----------------------------------------------------------------
 	// Database
@@ -61,19 +84,96 @@ func main() {
 	}
 
 	// ----------------------------------------------------------------
-	// Infrastructure: Repositories, Stripe, Mediator
+	// Infrastructure: Repositories, payment providers, Mediator
 	// ----------------------------------------------------------------
 	outboxRepo := outbox.NewPostgresRepository(pool)
-	paymentRepo := infrastructure.NewPostgresPaymentRepository(pool, outboxRepo)
-	stripeClient := stripeservice.NewStripeService(cfg.StripeSecretKey, logger)
+	couponRepo := coupons.NewPostgresRepository(pool)
+	invoiceRepo := invoicing.NewPostgresRepository(pool, cfg.DefaultClinicID)
+	// Status changes are pushed to the clients following a payment, on every replica when shared
+	statusHub := statushub.New(10 * time.Minute)
+	var statusRelay *statushub.PostgresRelay
+	if cfg.StatusStreamShared {
+		statusRelay = statushub.NewPostgresRelay(pool, statusHub, logger)
+	}
+	paymentRepo := infrastructure.NewPostgresPaymentRepository(pool, outboxRepo, couponRepo, invoiceRepo, statusHub)
+	customerRepo := stripeservice.NewPostgresCustomerRepository(pool)
+	// Every request to Stripe waits for a token; shared by the replicas when configured
+	limiterOptions := resilience.LimiterOptions{
+		Rate:          cfg.StripeRateLimit,
+		Burst:         cfg.StripeRateBurst,
+		LowReserve:    resilience.DefaultLimiterOptions.LowReserve,
+		NormalReserve: resilience.DefaultLimiterOptions.NormalReserve,
+	}
+	var rateBucket resilience.Bucket
+	if cfg.RateLimitShared {
+		rateBucket = resilience.NewPostgresBucket(pool, stripeservice.ProviderName, limiterOptions, logger)
+	}
+	stripeLimiter := resilience.NewLimiter(stripeservice.ProviderName, limiterOptions, rateBucket)
+	stripeBackend := stripeservice.NewRateLimitedBackend(stripeservice.NewBackend(cfg.StripeAPIURL), stripeLimiter)
+	stripeClient := stripeservice.NewStripeService(cfg.StripeSecretKey, stripeBackend, stripeservice.RetryPolicy{
+		MaxAttempts: cfg.StripeMaxAttempts,
+		BaseDelay:   cfg.StripeRetryDelay,
+		MaxDelay:    stripeservice.DefaultRetryPolicy.MaxDelay,
+	}, customerRepo, logger)
+	// Payments go through the simulator when STRIPE_SIMULATED is set;
+	// customers and saved cards still use the client
+	var stripePayments stripeservice.Service = stripeClient
+	var stripeSimulator *simulator.Simulator
+	if cfg.StripeSimulated {
+		webhookURL := cfg.SimulatorWebhookURL
+		if webhookURL == "" {
+			webhookURL = "http://localhost:" + cfg.Port + "/api/webhooks/stripe"
+		}
+		stripeSimulator = simulator.New(simulator.Options{
+			Latency:      cfg.SimulatorLatency,
+			WebhookDelay: cfg.SimulatorWebhookDelay,
+		}, simulator.NewHTTPEmitter(webhookURL, cfg.StripeWebhookSecret, logger), logger)
+		stripePayments = stripeSimulator
+	}
+	// Circuit breaker, bulkhead and timeouts around every payment call to Stripe
+	stripePolicy := resilience.NewPolicy(stripeservice.ProviderName, resilience.Options{
+		Breaker: resilience.BreakerOptions{
+			WindowSize:   cfg.BreakerWindow,
+			MinCalls:     cfg.BreakerMinCalls,
+			FailureRate:  cfg.BreakerFailureRate,
+			OpenDuration: cfg.BreakerOpenDuration,
+			OnStateChange: func(name string, from, to resilience.State) {
+				logger.Warn("circuit breaker state changed", "provider", name, "from", from, "to", to)
+			},
+		},
+		MaxConcurrent: cfg.ProviderMaxConcurrent,
+		MaxWait:       cfg.ProviderMaxWait,
+		Timeout:       cfg.ProviderCallTimeout,
+	})
+	stripePayments = stripeservice.NewResilientService(stripePayments, stripePolicy)
+	routingRuleRepo := gateway.NewPostgresRuleRepository(pool)
+	gateways := []gateway.PaymentGateway{stripeservice.NewGateway(stripePayments)}
+	var bankTransfers *banktransfer.Gateway
+	if cfg.BankTransferIBAN != "" {
+		bankTransfers = banktransfer.NewGateway(banktransfer.NewPostgresRepository(pool), banktransfer.Account{
+			IBAN:        cfg.BankTransferIBAN,
+			Beneficiary: cfg.BankTransferBeneficiary,
+		})
+		gateways = append(gateways, bankTransfers)
+	}
+	paymentRouter := gateway.NewRouter(routingRuleRepo, cfg.DefaultPaymentProvider, gateways...)
+	feeScheduleRepo := pricing.NewPostgresRepository(pool)
+	pricingService := pricing.NewPricingService(feeScheduleRepo, cfg.PriceTolerance, cfg.RequireFeeSchedule, logger)
+	couponService := coupons.NewCouponService(couponRepo)
+	taxRateRepo := tax.NewPostgresRepository(pool)
+	taxService := tax.NewTaxService(taxRateRepo)
 	mediator := shared.NewMediator()
 
 	// ----------------------------------------------------------------
 	// Feature handlers (Vertical Slices)
 	// ----------------------------------------------------------------
-	completeHandler := completepayment.NewHandler(paymentRepo, stripeClient, logger)
-	createHandler := createpayment.NewHandler(paymentRepo, mediator, logger)
+	completeHandler := completepayment.NewHandler(paymentRepo, paymentRouter, logger)
+	confirmHandler := confirmpayment.NewHandler(paymentRepo, paymentRouter, logger)
+	createHandler := createpayment.NewHandler(paymentRepo, pricingService, couponService, taxService, paymentRouter, mediator, logger)
 	getHandler := getpayment.NewHandler(paymentRepo)
+	listHandler := listpayments.NewHandler(paymentRepo)
+	refundHandler := refundpayment.NewHandler(paymentRepo, paymentRouter, invoiceRepo, logger)
+	retryHandler := retrypending.NewHandler(paymentRepo, mediator, logger)
 
 	// Register handlers in mediator
 	mediator.Register(
@@ -88,22 +188,60 @@ func main() {
 			return completeHandler.Handle(ctx, req.(completepayment.Command))
 		},
 	)
+	mediator.Register(
+		fmt.Sprintf("%T", confirmpayment.Command{}),
+		func(ctx context.Context, req shared.Request) (shared.Response, error) {
+			return confirmHandler.Handle(ctx, req.(confirmpayment.Command))
+		},
+	)
+	mediator.Register(
+		fmt.Sprintf("%T", confirmpayment.SyncCommand{}),
+		func(ctx context.Context, req shared.Request) (shared.Response, error) {
+			return confirmHandler.HandleSync(ctx, req.(confirmpayment.SyncCommand))
+		},
+	)
+	mediator.Register(
+		fmt.Sprintf("%T", confirmpayment.ClientSecretQuery{}),
+		func(ctx context.Context, req shared.Request) (shared.Response, error) {
+			return confirmHandler.HandleClientSecret(ctx, req.(confirmpayment.ClientSecretQuery))
+		},
+	)
 	mediator.Register(
 		fmt.Sprintf("%T", getpayment.Query{}),
 		func(ctx context.Context, req shared.Request) (shared.Response, error) {
 			return getHandler.Handle(ctx, req.(getpayment.Query))
 		},
 	)
+	mediator.Register(
+		fmt.Sprintf("%T", getpayment.ByAppointmentQuery{}),
+		func(ctx context.Context, req shared.Request) (shared.Response, error) {
+			return getHandler.HandleByAppointment(ctx, req.(getpayment.ByAppointmentQuery))
+		},
+	)
+	mediator.Register(
+		fmt.Sprintf("%T", listpayments.Query{}),
+		func(ctx context.Context, req shared.Request) (shared.Response, error) {
+			return listHandler.Handle(ctx, req.(listpayments.Query))
+		},
+	)
+	mediator.Register(
+		fmt.Sprintf("%T", refundpayment.Command{}),
+		func(ctx context.Context, req shared.Request) (shared.Response, error) {
+			return refundHandler.Handle(ctx, req.(refundpayment.Command))
+		},
+	)
 
 	// ----------------------------------------------------------------
-	// Messaging: Publisher + Consumer
+	// Messaging: Publisher + Consumer + Responder
 	// ----------------------------------------------------------------
 	var publisher messaging.Publisher
 	var consumer messaging.Consumer
+	var responder messaging.Responder
 
 	if cfg.UseInMemoryBroker {
 		publisher = messaging.NewInMemoryPublisher(logger)
 		consumer = messaging.NewInMemoryConsumer(logger)
+		responder = messaging.NewInMemoryResponder(logger)
 	} else {
 		publisher, err = messaging.NewRabbitMQPublisher(cfg.RabbitMQURL, cfg.OutgoingExchange, logger)
 		if err != nil {
@@ -112,18 +250,33 @@ func main() {
 		}
 		defer publisher.Close()
 
-		consumer, err = messaging.NewRabbitMQConsumer(cfg.RabbitMQURL, cfg.IncomingQueue, logger)
+		consumer, err = messaging.NewRabbitMQConsumer(cfg.RabbitMQURL, cfg.IncomingQueue, cfg.DeadLetterQueue, logger)
 		if err != nil {
 			logger.Error("failed to create RabbitMQ consumer", "error", err)
 			os.Exit(1)
 		}
 		defer consumer.Close()
+
+		responder, err = messaging.NewRabbitMQResponder(cfg.RabbitMQURL, cfg.StatusQueue, cfg.StatusDeadLetterQueue, cfg.StatusWorkers, cfg.StatusTimeout, logger)
+		if err != nil {
+			logger.Error("failed to create RabbitMQ responder", "error", err)
+			os.Exit(1)
+		}
+		defer responder.Close()
 	}
 
 	// ----------------------------------------------------------------
 	// Outbox background worker
 	// ----------------------------------------------------------------
-	outboxWorker := outbox.NewWorker(outboxRepo, publisher, logger)
+	legacyVersions, err := messaging.Schemas.LegacyVersions(cfg.LegacyEventVersions)
+	if err != nil {
+		logger.Error("invalid LEGACY_EVENT_VERSIONS", "error", err)
+		os.Exit(1)
+	}
+	outboxWorker := outbox.NewWorker(outboxRepo, publisher, legacyVersions, logger)
+	retryWorker := retrypending.NewWorker(retryHandler, cfg.PendingRetryInterval, cfg.PendingRetryBatch, logger)
+	idempotencyStore := idempotency.NewPostgresStore(pool)
+	idempotencyCleaner := idempotency.NewCleaner(idempotencyStore, time.Hour, logger)
 
 	// ----------------------------------------------------------------
 	// HTTP server (Gin)
@@ -132,84 +285,47 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	router := gin.New()
-	router.Use(gin.Recovery())
-	router.Use(ginLogger(logger))
-
-	// Health endpoints
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
-	})
-	router.GET("/readiness", func(c *gin.Context) {
-		if err := pool.Ping(c.Request.Context()); err != nil {
-			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"status": "ready"})
-	})
-	router.GET("/liveness", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "alive", "timestamp": time.Now().UTC()})
-	})
-
-	// Payments API
-	api := router.Group("/api/payments")
-	{
-		// GET /api/payments/:id – get payment by ID
-		api.GET("/:id", func(c *gin.Context) {
-			id, err := uuid.Parse(c.Param("id"))
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payment id"})
-				return
-			}
-
-			resp, err := mediator.Send(c.Request.Context(), getpayment.Query{PaymentID: id})
-			if err != nil {
-				var notFound *domain.ErrPaymentNotFound
-				if errors.As(err, &notFound) {
-					c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-					return
-				}
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
-
-			c.JSON(http.StatusOK, resp)
-		})
-
-		// POST /api/payments/trigger – manual trigger for dev/testing
-		// In production this is driven by AppointmentSlotReserved events
-		api.POST("/trigger", func(c *gin.Context) {
-			var req struct {
-				AppointmentID string  `json:"appointmentId" binding:"required"`
-				UserID        string  `json:"userId"        binding:"required"`
-				Amount        float64 `json:"amount"        binding:"required,gt=0"`
-				Currency      string  `json:"currency"      binding:"required,len=3"`
-			}
-			if err := c.ShouldBindJSON(&req); err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-				return
-			}
-
-			appointmentID, err := uuid.Parse(req.AppointmentID)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid appointmentId"})
-				return
-			}
+	verifier, err := newTokenVerifier(cfg, logger)
+	if err != nil {
+		logger.Error("failed to configure authentication", "error", err)
+		os.Exit(1)
+	}
 
-			resp, err := mediator.Send(c.Request.Context(), createpayment.Command{
-				AppointmentID: appointmentID,
-				UserID:        req.UserID,
-				Amount:        req.Amount,
-				Currency:      req.Currency,
-			})
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
+	// Error responses are problem details; each package maps the errors it owns
+	problem.Register(
+		paymentProblem,
+		pricing.Problem,
+		coupons.Problem,
+		tax.Problem,
+		invoicing.Problem,
+		gateway.Problem,
+		banktransfer.Problem,
+		stripeservice.Problem,
+		simulator.Problem,
+	)
 
-			c.JSON(http.StatusCreated, resp)
-		})
-	}
+	router := newRouter(routeDeps{
+		cfg:              cfg,
+		logger:           logger,
+		db:               pool,
+		stripePolicy:     stripePolicy,
+		stripeLimiter:    stripeLimiter,
+		mediator:         mediator,
+		verifier:         verifier,
+		idempotencyStore: idempotencyStore,
+		feeSchedules:     feeScheduleRepo,
+		pricing:          pricingService,
+		coupons:          couponRepo,
+		customers:        stripeClient,
+		stripeSimulator:  stripeSimulator,
+		routingRules:     routingRuleRepo,
+		paymentRouter:    paymentRouter,
+		bankTransfers:    bankTransfers,
+		invoices:         invoiceRepo,
+		taxRates:         taxRateRepo,
+		tax:              taxService,
+		statusHub:        statusHub,
+	})
 
 	// ----------------------------------------------------------------
 	// Start background goroutines
@@ -220,22 +336,59 @@ func main() {
 	// Outbox worker
 	go outboxWorker.Run(appCtx)
 
+	// Charges payments left Pending by a provider outage
+	go retryWorker.Run(resilience.WithPriority(appCtx, resilience.PriorityLow))
+
+	// Deletes expired idempotency keys
+	go idempotencyCleaner.Run(appCtx)
+
+	// Receives the status changes of the other replicas
+	if statusRelay != nil {
+		go statusRelay.Run(appCtx)
+	}
+
 	// Message consumer
 	go func() {
-		if err := consumer.Start(appCtx, func(ctx context.Context, event messaging.AppointmentSlotReservedEvent) error {
+		// Consumed events may be a backlog replay: they yield to patients at the rate limiter
+		if err := consumer.Start(resilience.WithPriority(appCtx, resilience.PriorityLow), func(ctx context.Context, event messaging.AppointmentSlotReservedEvent) error {
 			appointmentID, err := uuid.Parse(event.AppointmentID)
 			if err != nil {
-				return fmt.Errorf("invalid appointmentId in event: %w", err)
+				return fmt.Errorf("%w: invalid appointmentId: %w", messaging.ErrUnprocessable, err)
+			}
+
+			currency := event.Currency
+			if currency == "" {
+				currency = cfg.DefaultCurrency
+			}
+			jurisdiction := event.Jurisdiction
+			if jurisdiction == "" {
+				jurisdiction = cfg.DefaultTaxJurisdiction
+			}
+
+			lineItems := make([]createpayment.LineItem, 0, len(event.LineItems))
+			for _, item := range event.LineItems {
+				lineItems = append(lineItems, createpayment.LineItem(item))
 			}
 
 			cmd := createpayment.Command{
-				AppointmentID: appointmentID,
-				UserID:        event.UserID,
-				Amount:        event.Amount,
-				Currency:      event.Currency,
+				AppointmentID:   appointmentID,
+				UserID:          event.UserID,
+				Amount:          event.Amount,
+				Currency:        currency,
+				DoctorID:        event.DoctorID,
+				Specialty:       event.Specialty,
+				VisitType:       event.VisitType,
+				CouponCode:      event.CouponCode,
+				ClinicID:        event.ClinicID,
+				Jurisdiction:    jurisdiction,
+				ServiceCategory: event.ServiceCategory,
+				LineItems:       lineItems,
 			}
 
 			if _, err := mediator.Send(ctx, cmd); err != nil {
+				if isPermanent(err) {
+					return fmt.Errorf("%w: %w", messaging.ErrUnprocessable, err)
+				}
 				return fmt.Errorf("handle AppointmentSlotReserved: %w", err)
 			}
 			return nil
@@ -244,6 +397,19 @@ func main() {
 		}
 	}()
 
+	// Request/reply: payment status queries from the Appointments saga
+	go func() {
+		if err := responder.Serve(appCtx, func(ctx context.Context, req messaging.PaymentStatusRequest) (any, error) {
+			appointmentID, err := uuid.Parse(req.AppointmentID)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid appointmentId: %v", messaging.ErrInvalidRequest, err)
+			}
+			return mediator.Send(ctx, getpayment.ByAppointmentQuery{AppointmentID: appointmentID})
+		}); err != nil {
+			logger.Error("responder error", "error", err)
+		}
+	}()
+
 	// ----------------------------------------------------------------
 	// HTTP server with graceful shutdown
 	// ----------------------------------------------------------------
@@ -254,6 +420,8 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Shutdown waits for open requests: end the status streams
+	srv.RegisterOnShutdown(statusHub.Close)
 
 	// Run server in a goroutine
 	go func() {
@@ -264,6 +432,24 @@ func main() {
 		}
 	}()
 
+	// gRPC API for internal services, over the same mediator
+	grpcServer := grpcapi.NewServer(mediator, statusHub, currentStatus(mediator), verifier, grpcapi.Options{
+		DefaultCurrency:     cfg.DefaultCurrency,
+		DefaultJurisdiction: cfg.DefaultTaxJurisdiction,
+	}, logger)
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		logger.Error("failed to listen for gRPC", "error", err)
+		os.Exit(1)
+	}
+	go func() {
+		logger.Info("gRPC server listening", "addr", grpcListener.Addr().String())
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Error("gRPC server error", "error", err)
+			os.Exit(1)
+		}
+	}()
+
 	// Wait for OS signal (SIGINT / SIGTERM)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -279,6 +465,18 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		logger.Error("server forced to shutdown", "error", err)
 	}
+	// Shutdown closed the status hub, which ends the watch streams too
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		logger.Error("gRPC server forced to shutdown")
+		grpcServer.Stop()
+	}
 
 	logger.Info("server exited")
 }
@@ -312,11 +510,248 @@ func runMigrations(ctx context.Context, pool *pgxpool.Pool, logger *slog.Logger)
 		retry_count  INT          NOT NULL DEFAULT 0
 	);
 	CREATE INDEX IF NOT EXISTS idx_outbox_unprocessed ON outbox_messages(processed, retry_count, created_at) WHERE processed = false;
+	CREATE TABLE IF NOT EXISTS fee_schedules (
+		id             UUID          PRIMARY KEY,
+		doctor_id      VARCHAR(256),
+		specialty      VARCHAR(128),
+		visit_type     VARCHAR(128),
+		currency       VARCHAR(3)    NOT NULL,
+		amount         NUMERIC(18,2) NOT NULL,
+		effective_from TIMESTAMPTZ   NOT NULL,
+		effective_to   TIMESTAMPTZ,
+		created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+		updated_at     TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS idx_fee_schedules_lookup ON fee_schedules(currency, effective_from);
+	CREATE TABLE IF NOT EXISTS fee_schedule_history (
+		id              UUID          PRIMARY KEY,
+		fee_schedule_id UUID          NOT NULL REFERENCES fee_schedules(id),
+		action          VARCHAR(32)   NOT NULL,
+		amount          NUMERIC(18,2) NOT NULL,
+		currency        VARCHAR(3)    NOT NULL,
+		effective_from  TIMESTAMPTZ   NOT NULL,
+		effective_to    TIMESTAMPTZ,
+		changed_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+	);
+	CREATE INDEX IF NOT EXISTS idx_fee_schedule_history_schedule ON fee_schedule_history(fee_schedule_id, changed_at);
+	CREATE TABLE IF NOT EXISTS coupons (
+		id                       UUID          PRIMARY KEY,
+		code                     VARCHAR(64)   NOT NULL UNIQUE,
+		kind                     VARCHAR(16)   NOT NULL,
+		value                    NUMERIC(18,2) NOT NULL,
+		currency                 VARCHAR(3),
+		valid_from               TIMESTAMPTZ   NOT NULL,
+		valid_to                 TIMESTAMPTZ,
+		max_redemptions          INT           NOT NULL DEFAULT 0,
+		max_redemptions_per_user INT           NOT NULL DEFAULT 0,
+		active                   BOOLEAN       NOT NULL DEFAULT true,
+		created_at               TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+		updated_at               TIMESTAMPTZ
+	);
+	CREATE TABLE IF NOT EXISTS coupon_redemptions (
+		id              UUID          PRIMARY KEY,
+		coupon_id       UUID          NOT NULL REFERENCES coupons(id),
+		payment_id      UUID          NOT NULL UNIQUE,
+		user_id         VARCHAR(256)  NOT NULL,
+		discount_amount NUMERIC(18,2) NOT NULL,
+		currency        VARCHAR(3)    NOT NULL,
+		status          VARCHAR(16)   NOT NULL,
+		created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+		settled_at      TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon ON coupon_redemptions(coupon_id, status, user_id);
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS subtotal           NUMERIC(18,2);
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_coupon_id UUID;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_code      VARCHAR(64);
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_kind      VARCHAR(16);
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_value     NUMERIC(18,2);
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_amount    NUMERIC(18,2);
+	CREATE TABLE IF NOT EXISTS tax_rates (
+		id           UUID         PRIMARY KEY,
+		jurisdiction VARCHAR(64)  NOT NULL,
+		category     VARCHAR(128),
+		name         VARCHAR(128) NOT NULL,
+		percent      NUMERIC(7,4) NOT NULL,
+		inclusive    BOOLEAN      NOT NULL DEFAULT false,
+		rounding     VARCHAR(16)  NOT NULL DEFAULT 'half_up',
+		active       BOOLEAN      NOT NULL DEFAULT true,
+		created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+		updated_at   TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS idx_tax_rates_jurisdiction ON tax_rates(jurisdiction) WHERE active = true;
+	CREATE TABLE IF NOT EXISTS payment_tax_lines (
+		payment_id     UUID          NOT NULL REFERENCES payments(id),
+		position       INT           NOT NULL,
+		name           VARCHAR(128)  NOT NULL,
+		jurisdiction   VARCHAR(64)   NOT NULL,
+		category       VARCHAR(128),
+		percent        NUMERIC(7,4)  NOT NULL,
+		inclusive      BOOLEAN       NOT NULL,
+		taxable_amount NUMERIC(18,2) NOT NULL,
+		amount         NUMERIC(18,2) NOT NULL,
+		PRIMARY KEY (payment_id, position)
+	);
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(18,2);
+
+	CREATE TABLE IF NOT EXISTS payment_line_items (
+		payment_id      UUID          NOT NULL REFERENCES payments(id),
+		position        INT           NOT NULL,
+		code            VARCHAR(64)   NOT NULL,
+		description     VARCHAR(255)  NOT NULL DEFAULT '',
+		category        VARCHAR(128),
+		quantity        INT           NOT NULL,
+		unit_price      NUMERIC(18,2) NOT NULL,
+		discount_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+		tax_amount      NUMERIC(18,2) NOT NULL DEFAULT 0,
+		total           NUMERIC(18,2) NOT NULL,
+		PRIMARY KEY (payment_id, position),
+		UNIQUE (payment_id, code)
+	);
+	ALTER TABLE payment_tax_lines ADD COLUMN IF NOT EXISTS item_code VARCHAR(64);
+
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS clinic_id VARCHAR(64);
+
+	CREATE TABLE IF NOT EXISTS invoice_sequences (
+		clinic_id   VARCHAR(64) NOT NULL,
+		kind        VARCHAR(16) NOT NULL,
+		last_number BIGINT      NOT NULL,
+		PRIMARY KEY (clinic_id, kind)
+	);
+	CREATE TABLE IF NOT EXISTS invoices (
+		id                  UUID          PRIMARY KEY,
+		kind                VARCHAR(16)   NOT NULL,
+		clinic_id           VARCHAR(64)   NOT NULL,
+		sequence            BIGINT        NOT NULL,
+		number              VARCHAR(96)   NOT NULL UNIQUE,
+		payment_id          UUID          NOT NULL REFERENCES payments(id),
+		appointment_id      UUID          NOT NULL,
+		patient_id          VARCHAR(255)  NOT NULL,
+		currency            VARCHAR(3)    NOT NULL,
+		subtotal            NUMERIC(18,2) NOT NULL,
+		discount_amount     NUMERIC(18,2) NOT NULL,
+		tax_amount          NUMERIC(18,2) NOT NULL,
+		total               NUMERIC(18,2) NOT NULL,
+		transaction_id      VARCHAR(255),
+		credited_invoice_id UUID          REFERENCES invoices(id),
+		reason              TEXT,
+		snapshot            JSONB         NOT NULL,
+		issued_at           TIMESTAMPTZ   NOT NULL,
+		UNIQUE (clinic_id, kind, sequence)
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_payment ON invoices(payment_id) WHERE kind = 'invoice';
+	CREATE INDEX IF NOT EXISTS idx_invoices_credited ON invoices(credited_invoice_id) WHERE credited_invoice_id IS NOT NULL;
+	CREATE OR REPLACE FUNCTION forbid_invoice_change() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'invoices are immutable; issue a credit note instead';
+	END;
+	$$ LANGUAGE plpgsql;
+	DROP TRIGGER IF EXISTS invoices_immutable ON invoices;
+	CREATE TRIGGER invoices_immutable BEFORE UPDATE OR DELETE ON invoices
+		FOR EACH ROW EXECUTE FUNCTION forbid_invoice_change();
+
+	CREATE TABLE IF NOT EXISTS customers (
+		user_id                   VARCHAR(255) PRIMARY KEY,
+		stripe_customer_id        VARCHAR(255) NOT NULL UNIQUE,
+		default_payment_method_id VARCHAR(255),
+		created_at                TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+		updated_at                TIMESTAMPTZ
+	);
+
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider VARCHAR(32);
+	CREATE TABLE IF NOT EXISTS payment_routing_rules (
+		id         UUID        PRIMARY KEY,
+		provider   VARCHAR(32) NOT NULL,
+		currency   VARCHAR(3),
+		country    VARCHAR(2),
+		clinic_id  VARCHAR(64),
+		active     BOOLEAN     NOT NULL DEFAULT true,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		updated_at TIMESTAMPTZ
+	);
+	CREATE TABLE IF NOT EXISTS bank_transfers (
+		reference       VARCHAR(32)   PRIMARY KEY,
+		payment_id      UUID          NOT NULL UNIQUE REFERENCES payments(id),
+		amount          NUMERIC(18,2) NOT NULL,
+		currency        VARCHAR(3)    NOT NULL,
+		status          VARCHAR(16)   NOT NULL,
+		received_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+		refunded_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+		created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+		updated_at      TIMESTAMPTZ
+	);
+
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS failure_code VARCHAR(32);
+
+	CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+		name       VARCHAR(64)      PRIMARY KEY,
+		tokens     DOUBLE PRECISION NOT NULL,
+		updated_at TIMESTAMPTZ      NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_payments_created ON payments(created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_payments_amount ON payments(amount, id);
+	CREATE INDEX IF NOT EXISTS idx_payments_user_created ON payments(user_id, created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_payments_status_created ON payments(status, created_at, id);
+	CREATE INDEX IF NOT EXISTS idx_payments_provider_reference ON payments(stripe_payment_intent_id) WHERE stripe_payment_intent_id IS NOT NULL;
+
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key                   VARCHAR(255)  PRIMARY KEY,
+		fingerprint           CHAR(64)      NOT NULL,
+		response_status       INT,
+		response_content_type VARCHAR(255),
+		response_location     VARCHAR(1000),
+		response_body         BYTEA,
+		locked_until          TIMESTAMPTZ   NOT NULL,
+		created_at            TIMESTAMPTZ   NOT NULL,
+		expires_at            TIMESTAMPTZ   NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+	ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS scope VARCHAR(256) NOT NULL DEFAULT '';
+	DO $$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM information_schema.key_column_usage
+		               WHERE table_name = 'idempotency_keys' AND constraint_name = 'idempotency_keys_pkey' AND column_name = 'scope') THEN
+			ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+			ALTER TABLE idempotency_keys ADD CONSTRAINT idempotency_keys_pkey PRIMARY KEY (scope, key);
+		END IF;
+	END $$;
+
+	ALTER TABLE outbox_messages ADD COLUMN IF NOT EXISTS schema_version INT NOT NULL DEFAULT 1;
+
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS charge_attempt INT NOT NULL DEFAULT 0;
+	ALTER TABLE payments ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 0;
+
+	ALTER TABLE fee_schedules ADD COLUMN IF NOT EXISTS item_code VARCHAR(64);
 	`
 	_, err := pool.Exec(ctx, migrations)
 	return err
 }
 
+// isPermanent reports whether creating a payment failed because of the
+// event itself – invalid fields, no price for the visit, an amount that
+// differs from it, an unusable coupon – so handling it again would fail
+// again. So would a charge the provider refused: the payment was created
+// and stays Pending for the pending-payment retrier.
+func isPermanent(err error) bool {
+	var invalid *shared.ValidationError
+	var refused *stripeservice.ErrInvalidRequest
+	var noSchedule *pricing.ErrNoFeeSchedule
+	var mismatch *pricing.ErrAmountMismatch
+	var couponNotFound *coupons.ErrCouponNotFound
+	var couponNotApplicable *coupons.ErrCouponNotApplicable
+	return errors.As(err, &invalid) ||
+		errors.As(err, &noSchedule) ||
+		errors.As(err, &mismatch) ||
+		errors.As(err, &couponNotFound) ||
+		errors.As(err, &couponNotApplicable) ||
+		errors.Is(err, coupons.ErrInvalidCoupon) ||
+		errors.Is(err, domain.ErrInvalidAmount) ||
+		errors.Is(err, domain.ErrInvalidCurrency) ||
+		errors.Is(err, domain.ErrInvalidLineItem) ||
+		errors.Is(err, domain.ErrDiscountExceedsAmount) ||
+		errors.As(err, &refused)
+}
+
 func newLogger() *slog.Logger {
 	env := os.Getenv("ENVIRONMENT")
 	if env == "production" {
@@ -329,15 +764,29 @@ func newLogger() *slog.Logger {
 	}))
 }
 
-func ginLogger(logger *slog.Logger) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		start := time.Now()
-		c.Next()
-		logger.Info("http request",
-			"method", c.Request.Method,
-			"path", c.Request.URL.Path,
-			"status", c.Writer.Status(),
-			"duration_ms", time.Since(start).Milliseconds(),
-		)
+// newTokenVerifier verifies bearer tokens with the configured JWKS endpoint,
+// JWKS file and shared secret.
+func newTokenVerifier(cfg *shared.Config, logger *slog.Logger) (*auth.Verifier, error) {
+	var sources auth.Sources
+	if cfg.JWTJWKSURL != "" {
+		sources = append(sources, auth.NewRemoteKeySet(cfg.JWTJWKSURL, cfg.JWTJWKSRefresh, logger))
+	}
+	if cfg.JWTJWKSFile != "" {
+		keys, err := auth.LoadJWKSFile(cfg.JWTJWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, keys)
+	}
+	if cfg.JWTSecret != "" {
+		sources = append(sources, auth.NewKeySet(nil, []byte(cfg.JWTSecret)))
+	}
+	if len(sources) == 0 {
+		return nil, errors.New("no token keys configured: set JWT_JWKS_URL, JWT_JWKS_FILE or JWT_SECRET")
 	}
+	return auth.NewVerifier(sources, auth.VerifierOptions{
+		Issuer:   cfg.JWTIssuer,
+		Audience: cfg.JWTAudience,
+		Leeway:   30 * time.Second,
+	}), nil
 }
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/coupons"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/pricing"
+	"github.com/smart-health/payments-api/internal/shared"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+)
+
+func TestIsPermanent(t *testing.T) {
+	permanent := []error{
+		&shared.ValidationError{Fields: []shared.FieldError{{Field: "userId", Code: "required"}}},
+		&pricing.ErrNoFeeSchedule{},
+		&pricing.ErrAmountMismatch{Supplied: 90, Expected: 100, Currency: "usd"},
+		&coupons.ErrCouponNotFound{Code: "WELCOME10"},
+		&coupons.ErrCouponNotApplicable{Code: "WELCOME10", Reason: "coupon has been fully redeemed"},
+		fmt.Errorf("apply discount: %w", domain.ErrDiscountExceedsAmount),
+		fmt.Errorf("%w: unknown code", domain.ErrInvalidLineItem),
+		fmt.Errorf("complete payment: %w", &stripeservice.ErrInvalidRequest{Code: "parameter_invalid"}),
+	}
+	for _, err := range permanent {
+		if !isPermanent(fmt.Errorf("create payment: %w", err)) {
+			t.Errorf("%v is not permanent, want it dead-lettered", err)
+		}
+	}
+
+	transient := []error{
+		context.DeadlineExceeded,
+		errors.New("connection refused"),
+		// Handled again, the payment is loaded afresh
+		&domain.ErrConcurrentUpdate{},
+	}
+	for _, err := range transient {
+		if isPermanent(err) {
+			t.Errorf("%v is permanent, want it requeued", err)
+		}
+	}
+}
@@ -0,0 +1,539 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/asyncapi"
+	"github.com/smart-health/payments-api/internal/auth"
+	"github.com/smart-health/payments-api/internal/coupons"
+	"github.com/smart-health/payments-api/internal/gateway"
+	"github.com/smart-health/payments-api/internal/gateway/banktransfer"
+	"github.com/smart-health/payments-api/internal/idempotency"
+	"github.com/smart-health/payments-api/internal/invoicing"
+	"github.com/smart-health/payments-api/internal/messaging"
+	"github.com/smart-health/payments-api/internal/openapi"
+	confirmpayment "github.com/smart-health/payments-api/internal/payments/confirm_payment"
+	createpayment "github.com/smart-health/payments-api/internal/payments/create_payment"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	getpayment "github.com/smart-health/payments-api/internal/payments/get_payment"
+	listpayments "github.com/smart-health/payments-api/internal/payments/list_payments"
+	"github.com/smart-health/payments-api/internal/payments/statushub"
+	"github.com/smart-health/payments-api/internal/pricing"
+	"github.com/smart-health/payments-api/internal/problem"
+	"github.com/smart-health/payments-api/internal/resilience"
+	"github.com/smart-health/payments-api/internal/shared"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+	"github.com/smart-health/payments-api/internal/stripe/simulator"
+	"github.com/smart-health/payments-api/internal/tax"
+)
+
+// routeDeps is what the HTTP endpoints are served with. The simulator and
+// bank transfers are optional: their routes are mounted when they are set.
+type routeDeps struct {
+	cfg    *shared.Config
+	logger *slog.Logger
+	db     interface {
+		Ping(ctx context.Context) error
+	}
+	stripePolicy     *resilience.Policy
+	stripeLimiter    *resilience.Limiter
+	mediator         *shared.Mediator
+	verifier         *auth.Verifier
+	idempotencyStore idempotency.Store
+	feeSchedules     pricing.Repository
+	pricing          pricing.Service
+	coupons          coupons.Repository
+	customers        stripeservice.CustomerService
+	stripeSimulator  *simulator.Simulator
+	routingRules     gateway.RuleRepository
+	paymentRouter    *gateway.Router
+	bankTransfers    *banktransfer.Gateway
+	invoices         invoicing.Repository
+	taxRates         tax.Repository
+	tax              tax.Service
+	statusHub        *statushub.Hub
+}
+
+// triggerRequest is the body of POST /api/payments/trigger.
+type triggerRequest struct {
+	AppointmentID   string  `json:"appointmentId" binding:"required"`
+	UserID          string  `json:"userId"        binding:"required"`
+	Amount          float64 `json:"amount"        binding:"omitempty,gt=0"`
+	Currency        string  `json:"currency"      binding:"required,len=3"`
+	DoctorID        string  `json:"doctorId"`
+	Specialty       string  `json:"specialty"`
+	VisitType       string  `json:"visitType"`
+	CouponCode      string  `json:"couponCode"`
+	ClinicID        string  `json:"clinicId"`
+	Jurisdiction    string  `json:"jurisdiction"`
+	ServiceCategory string  `json:"serviceCategory"`
+	LineItems       []struct {
+		Code        string  `json:"code"        binding:"required"`
+		Description string  `json:"description"`
+		Category    string  `json:"category"`
+		Quantity    int     `json:"quantity"    binding:"required,gt=0"`
+		UnitPrice   float64 `json:"unitPrice"   binding:"required,gt=0"`
+	} `json:"lineItems" binding:"omitempty,dive"`
+}
+
+// newRouter mounts the HTTP endpoints and serves their OpenAPI document at
+// /openapi.json. Every group is declared in the document where it is
+// mounted, so the two cannot drift apart (see routes_test.go).
+func newRouter(d routeDeps) *gin.Engine {
+	cfg, logger, mediator := d.cfg, d.logger, d.mediator
+	docs := openapi.NewBuilder(openapi.Info{
+		Title:       "SmartHealth Payments API",
+		Version:     "1.0.0",
+		Description: "Payments, pricing, coupons, tax and invoicing of SmartHealth appointments. Errors are RFC 7807 problem details.",
+	})
+
+	router := gin.New()
+	router.Use(gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		problem.Abort(c, fmt.Errorf("panic: %v", recovered))
+	}))
+	router.Use(ginLogger(logger))
+	// Bearer tokens identify the caller; each route group requires its roles below
+	router.Use(auth.Authenticate(d.verifier, logger))
+	// Patient-facing requests and webhooks take precedence over background work at the rate limiter
+	router.Use(withPriority(resilience.PriorityHigh))
+	// Retried mutating requests with an Idempotency-Key get the first response
+	router.Use(idempotency.Middleware(d.idempotencyStore, idempotency.Options{
+		TTL:         cfg.IdempotencyKeyTTL,
+		LockTimeout: cfg.IdempotencyLockTimeout,
+		Scope:       auth.SubjectOf,
+	}, logger))
+
+	// Health endpoints
+	router.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+	})
+	router.GET("/readiness", func(c *gin.Context) {
+		if err := d.db.Ping(c.Request.Context()); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": err.Error()})
+			return
+		}
+		// An open breaker degrades charging but the API still serves: stay ready
+		status := "ready"
+		breaker := d.stripePolicy.Breaker().State()
+		if breaker != resilience.StateClosed {
+			status = "degraded"
+		}
+		c.JSON(http.StatusOK, gin.H{"status": status, "providers": gin.H{d.stripePolicy.Name(): breaker.String()}})
+	})
+	router.GET("/liveness", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "alive", "timestamp": time.Now().UTC()})
+	})
+	router.GET("/metrics", func(c *gin.Context) {
+		c.Header("Content-Type", "text/plain; version=0.0.4")
+		if err := resilience.WriteMetrics(c.Writer, d.stripePolicy); err != nil {
+			logger.Error("failed to write metrics", "error", err)
+			return
+		}
+		if err := d.stripeLimiter.WriteMetrics(c.Writer); err != nil {
+			logger.Error("failed to write metrics", "error", err)
+		}
+	})
+	docs.Add(openapi.Group{Tag: "Operations", Public: true},
+		openapi.Route{Method: http.MethodGet, Path: "/health", Summary: "Process is up"},
+		openapi.Route{Method: http.MethodGet, Path: "/readiness", Summary: "Database reachable; degraded while a provider's circuit breaker is open",
+			Errors: []int{http.StatusServiceUnavailable}},
+		openapi.Route{Method: http.MethodGet, Path: "/liveness", Summary: "Process is alive"},
+		openapi.Route{Method: http.MethodGet, Path: "/metrics", Summary: "Resilience and rate limiter metrics",
+			ContentTypes: []string{"text/plain"}},
+		openapi.Route{Method: http.MethodGet, Path: "/openapi.json", Summary: "This document"},
+		openapi.Route{Method: http.MethodGet, Path: "/docs", Summary: "API documentation browser",
+			ContentTypes: []string{"text/html"}},
+		openapi.Route{Method: http.MethodGet, Path: "/asyncapi.json", Summary: "AsyncAPI document of the events published and consumed"},
+	)
+
+	// Payments API
+	api := router.Group("/api/payments", auth.Require())
+	{
+		// GET /api/payments – search payments (filters, sort, cursor pagination) for support staff
+		api.GET("", auth.Require(auth.RoleStaff, auth.RoleAdmin), func(c *gin.Context) {
+			query, err := listpayments.ParseQuery(c.Request.URL.Query())
+			if err != nil {
+				problem.Write(c, err)
+				return
+			}
+
+			resp, err := mediator.Send(c.Request.Context(), query)
+			if err != nil {
+				problem.Write(c, err)
+				return
+			}
+
+			c.JSON(http.StatusOK, resp)
+		})
+
+		// GET /api/payments/:id – get payment by ID
+		api.GET("/:id", func(c *gin.Context) {
+			id, err := uuid.Parse(c.Param("id"))
+			if err != nil {
+				problem.Write(c, problem.Invalid("id", "uuid", "invalid payment id"))
+				return
+			}
+
+			resp, err := mediator.Send(c.Request.Context(), getpayment.Query{
+				PaymentID: id,
+				Language:  domain.NegotiateLanguage(c.GetHeader("Accept-Language")),
+			})
+			if err != nil {
+				problem.Write(c, err)
+				return
+			}
+
+			// Patients see only their own payments
+			if result, ok := resp.(*getpayment.Result); ok && !auth.PrincipalFrom(c).CanAccess(result.UserID) {
+				problem.Write(c, confirmpayment.ErrNotPaymentOwner)
+				return
+			}
+
+			c.JSON(http.StatusOK, resp)
+		})
+
+		// GET /api/payments/:id/client-secret – client secret (or instructions) of a payment awaiting the patient
+		api.GET("/:id/client-secret", func(c *gin.Context) {
+			id, err := uuid.Parse(c.Param("id"))
+			if err != nil {
+				problem.Write(c, problem.Invalid("id", "uuid", "invalid payment id"))
+				return
+			}
+
+			resp, err := mediator.Send(c.Request.Context(), confirmpayment.ClientSecretQuery{PaymentID: id, UserID: auth.SubjectOf(c)})
+			if err != nil {
+				problem.Write(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, resp)
+		})
+
+		// POST /api/payments/:id/confirm – called by the frontend after confirming with Stripe.js
+		api.POST("/:id/confirm", func(c *gin.Context) {
+			id, err := uuid.Parse(c.Param("id"))
+			if err != nil {
+				problem.Write(c, problem.Invalid("id", "uuid", "invalid payment id"))
+				return
+			}
+
+			resp, err := mediator.Send(c.Request.Context(), confirmpayment.Command{PaymentID: id, UserID: auth.SubjectOf(c)})
+			if err != nil {
+				problem.Write(c, err)
+				return
+			}
+			c.JSON(http.StatusOK, resp)
+		})
+
+		// GET /api/payments/:id/events – status changes pushed as Server-Sent Events
+		api.GET("/:id/events", requirePaymentAccess(mediator), statushub.StreamHandler(d.statusHub, currentStatus(mediator),
+			statushub.StreamOptions{Heartbeat: cfg.StatusStreamHeartbeat, Retry: statusStreamRetry}, logger))
+
+		// GET /api/payments/:id/receipt – invoice of a completed payment (HTML, or PDF with ?format=pdf)
+		api.GET("/:id/receipt", requirePaymentAccess(mediator), invoicing.ReceiptHandler(d.invoices))
+
+		// POST /api/payments/trigger – manual trigger for dev/testing
+		// In production this is driven by AppointmentSlotReserved events
+		api.POST("/trigger", auth.Require(auth.RoleStaff, auth.RoleAdmin, auth.RoleService), func(c *gin.Context) {
+			var req triggerRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				problem.Write(c, problem.Binding(err))
+				return
+			}
+
+			appointmentID, err := uuid.Parse(req.AppointmentID)
+			if err != nil {
+				problem.Write(c, problem.Invalid("appointmentId", "uuid", "invalid appointmentId"))
+				return
+			}
+			if req.Jurisdiction == "" {
+				req.Jurisdiction = cfg.DefaultTaxJurisdiction
+			}
+
+			lineItems := make([]createpayment.LineItem, 0, len(req.LineItems))
+			for _, item := range req.LineItems {
+				lineItems = append(lineItems, createpayment.LineItem(item))
+			}
+
+			resp, err := mediator.Send(c.Request.Context(), createpayment.Command{
+				AppointmentID:   appointmentID,
+				UserID:          req.UserID,
+				Amount:          req.Amount,
+				Currency:        req.Currency,
+				DoctorID:        req.DoctorID,
+				Specialty:       req.Specialty,
+				VisitType:       req.VisitType,
+				CouponCode:      req.CouponCode,
+				ClinicID:        req.ClinicID,
+				Jurisdiction:    req.Jurisdiction,
+				ServiceCategory: req.ServiceCategory,
+				LineItems:       lineItems,
+			})
+			if err != nil {
+				problem.Write(c, err)
+				return
+			}
+
+			// The client secret and instructions are only handed to the patient the payment belongs to
+			if result, ok := resp.(*createpayment.Result); ok && auth.SubjectOf(c) != req.UserID {
+				result.ClientSecret = ""
+				result.Instructions = ""
+			}
+
+			c.JSON(http.StatusCreated, resp)
+		})
+	}
+	docs.Add(openapi.Group{Prefix: "/api/payments", Tag: "Payments"}, paymentRoutes()...)
+
+	// Back-office APIs are for support staff and administrators
+	backOffice := auth.Require(auth.RoleStaff, auth.RoleAdmin)
+	backOfficeRoles := []string{auth.RoleStaff, auth.RoleAdmin}
+
+	// Pricing administration API (fee schedules, price history, quotes)
+	pricing.RegisterRoutes(router.Group("/api/pricing", backOffice), d.feeSchedules, d.pricing)
+	docs.Add(openapi.Group{Prefix: "/api/pricing", Tag: "Pricing", Roles: backOfficeRoles}, pricing.Routes()...)
+
+	// Coupon administration API (discount codes, redemptions)
+	coupons.RegisterRoutes(router.Group("/api/coupons", backOffice), d.coupons)
+	docs.Add(openapi.Group{Prefix: "/api/coupons", Tag: "Coupons", Roles: backOfficeRoles}, coupons.Routes()...)
+
+	// Stripe customers and saved payment methods: the patient's own, or any for staff
+	stripeservice.RegisterCustomerRoutes(router.Group("/api/customers", auth.RequireSelfOr("userId", auth.RoleStaff, auth.RoleAdmin)), d.customers)
+	docs.Add(openapi.Group{Prefix: "/api/customers", Tag: "Customers", Roles: []string{"patient (own userId)", auth.RoleStaff, auth.RoleAdmin}},
+		stripeservice.CustomerRoutes()...)
+
+	// Stripe webhooks: re-sync the payment of every PaymentIntent event
+	router.POST("/api/webhooks/stripe", stripeservice.WebhookHandler(cfg.StripeWebhookSecret, func(ctx context.Context, paymentID uuid.UUID) error {
+		_, err := mediator.Send(ctx, confirmpayment.SyncCommand{PaymentID: paymentID})
+		return err
+	}, logger))
+	docs.Add(openapi.Group{Tag: "Webhooks", Public: true}, openapi.Route{
+		Method: http.MethodPost, Path: "/api/webhooks/stripe", Summary: "Stripe webhook",
+		Description: "Authenticated by the Stripe-Signature header; re-syncs the payment of every payment_intent.* event.",
+		Errors:      []int{http.StatusBadRequest},
+	})
+
+	// Simulated Stripe controls (development only)
+	if d.stripeSimulator != nil {
+		simulator.RegisterRoutes(router.Group("/api/simulator", auth.Require(auth.RoleStaff, auth.RoleAdmin, auth.RoleService)), d.stripeSimulator)
+		docs.Add(openapi.Group{Prefix: "/api/simulator", Tag: "Simulator", Roles: []string{auth.RoleStaff, auth.RoleAdmin, auth.RoleService}},
+			simulator.Routes()...)
+	}
+
+	// Payment provider routing rules
+	gateway.RegisterRoutes(router.Group("/api/gateways", backOffice), d.routingRules, d.paymentRouter)
+	docs.Add(openapi.Group{Prefix: "/api/gateways", Tag: "Gateways", Roles: backOfficeRoles}, gateway.Routes()...)
+
+	// Bank transfer back office: record received funds
+	if d.bankTransfers != nil {
+		banktransfer.RegisterRoutes(router.Group("/api/bank-transfers", backOffice), d.bankTransfers, func(ctx context.Context, paymentID uuid.UUID) error {
+			_, err := mediator.Send(ctx, confirmpayment.SyncCommand{PaymentID: paymentID})
+			return err
+		})
+		docs.Add(openapi.Group{Prefix: "/api/bank-transfers", Tag: "Bank transfers", Roles: backOfficeRoles}, banktransfer.Routes()...)
+	}
+
+	// Invoices and credit notes
+	invoicing.RegisterRoutes(router.Group("/api/invoices", backOffice), d.invoices)
+	docs.Add(openapi.Group{Prefix: "/api/invoices", Tag: "Invoices", Roles: backOfficeRoles}, invoicing.Routes()...)
+
+	// Tax administration API (rates per jurisdiction and service category)
+	tax.RegisterRoutes(router.Group("/api/tax", backOffice), d.taxRates, d.tax)
+	docs.Add(openapi.Group{Prefix: "/api/tax", Tag: "Tax", Roles: backOfficeRoles}, tax.Routes()...)
+
+	// API documentation
+	router.GET("/openapi.json", openapi.Handler(docs.Document()))
+	router.GET("/docs", openapi.DocsHandler())
+	router.GET("/asyncapi.json", asyncapi.Handler(eventDocument(cfg)))
+
+	return router
+}
+
+// eventDocument describes the messages exchanged over the broker, on the
+// configured exchange and queues, with the legacy versions still published.
+func eventDocument(cfg *shared.Config) *asyncapi.Document {
+	published := append([]messaging.Contract{}, messaging.Published...)
+	legacy, _ := messaging.Schemas.LegacyVersions(cfg.LegacyEventVersions) // checked at startup
+	for _, c := range messaging.Published {
+		for _, v := range legacy[c.Name] {
+			old, _ := c.At(v)
+			published = append(published, old)
+		}
+	}
+
+	return asyncapi.NewBuilder(asyncapi.Info{
+		Title:       "SmartHealth Payments API events",
+		Version:     "1.0.0",
+		Description: "Integration events payments.api publishes through its outbox and consumes from the Appointments service.",
+	}).
+		Publish(cfg.OutgoingExchange, published...).
+		Consume(cfg.IncomingQueue, messaging.Consumed...).
+		Reply(cfg.StatusQueue, messaging.StatusRequest, messaging.StatusReply).
+		Document()
+}
+
+// paymentRoutes declares the endpoints of the payments API.
+func paymentRoutes() []openapi.Route {
+	staff := []string{auth.RoleStaff, auth.RoleAdmin}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "", Summary: "Search payments", Roles: staff,
+			Description: "Filters combine; results are sorted and paged with an opaque cursor.",
+			Query: []openapi.Param{
+				{Name: "userId"},
+				{Name: "appointmentId", Format: "uuid"},
+				{Name: "status", Description: "repeated or comma-separated, e.g. Pending,Completed"},
+				{Name: "currency"},
+				{Name: "transactionId", Description: "the provider's transaction ID"},
+				{Name: "minAmount", Type: "number"},
+				{Name: "maxAmount", Type: "number"},
+				{Name: "createdFrom", Format: "date-time"},
+				{Name: "createdTo", Format: "date-time"},
+				{Name: "sort", Description: "-createdAt (default), createdAt, -amount or amount"},
+				{Name: "limit", Type: "integer"},
+				{Name: "cursor", Description: "nextCursor of the previous page"},
+			},
+			Response: listpayments.Result{}},
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get a payment",
+			Description: "Patients see only their own payments. Descriptions follow Accept-Language.",
+			Response:    getpayment.Result{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/:id/client-secret", Summary: "Client secret or instructions of a payment awaiting the patient",
+			Response: confirmpayment.Result{}, Errors: []int{http.StatusNotFound, http.StatusConflict}},
+		{Method: http.MethodPost, Path: "/:id/confirm", Summary: "Sync a payment after the patient confirmed it with Stripe.js",
+			Response: confirmpayment.Result{}, Errors: []int{http.StatusNotFound, http.StatusConflict, http.StatusPaymentRequired}},
+		{Method: http.MethodGet, Path: "/:id/events", Summary: "Follow the status of a payment",
+			Description: "Server-Sent Events stream of `status` events, e.g. `data: {\"paymentId\": \"...\", \"status\": \"Completed\", \"occurredAt\": \"...\"}`. " +
+				"The first event is the current status. Event IDs are status names: reconnecting with Last-Event-ID resumes after it. " +
+				"The stream ends after Completed or Failed; resuming after either answers 204. Patients follow only their own payments.",
+			ContentTypes: []string{"text/event-stream"}, Errors: []int{http.StatusNotFound}},
+		invoicing.ReceiptRoute("/:id/receipt"),
+		{Method: http.MethodPost, Path: "/trigger", Summary: "Create a payment for an appointment",
+			Description: "For development and testing; in production payments are created from AppointmentSlotReserved events.",
+			Roles:       []string{auth.RoleStaff, auth.RoleAdmin, auth.RoleService},
+			Request:     triggerRequest{}, Response: createpayment.Result{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusConflict, http.StatusUnprocessableEntity, http.StatusPaymentRequired, http.StatusBadGateway}},
+	}
+}
+
+func ginLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+		}
+		// Server errors reach the client as a generic problem; the cause is logged here
+		if err := c.Errors.Last(); err != nil {
+			logger.Error("http request", append(attrs, "error", err.Err)...)
+			return
+		}
+		logger.Info("http request", attrs...)
+	}
+}
+
+// withPriority sets the rate limiter priority of the provider calls a request makes.
+func withPriority(p resilience.Priority) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(resilience.WithPriority(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+// statusStreamRetry is the reconnection delay suggested to status stream clients.
+const statusStreamRetry = 3 * time.Second
+
+// currentStatus reads the status of a payment for the status streams.
+func currentStatus(mediator *shared.Mediator) statushub.Current {
+	return func(ctx context.Context, id uuid.UUID) (statushub.Change, error) {
+		resp, err := mediator.Send(ctx, getpayment.Query{PaymentID: id})
+		if err != nil {
+			return statushub.Change{}, err
+		}
+		result, ok := resp.(*getpayment.Result)
+		if !ok {
+			return statushub.Change{}, fmt.Errorf("unexpected payment read model %T", resp)
+		}
+		status, _ := domain.ParsePaymentStatus(result.Status)
+		at := result.CreatedAt
+		if result.UpdatedAt != nil {
+			at = *result.UpdatedAt
+		}
+		return statushub.Change{
+			PaymentID:   id,
+			UserID:      result.UserID,
+			Status:      status,
+			FailureCode: domain.FailureCode(result.FailureCode),
+			At:          at,
+		}, nil
+	}
+}
+
+// requirePaymentAccess admits the owner of the payment in the :id parameter,
+// and staff, administrators and services.
+func requirePaymentAccess(mediator *shared.Mediator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			problem.Abort(c, problem.Invalid("id", "uuid", "invalid payment id"))
+			return
+		}
+
+		resp, err := mediator.Send(c.Request.Context(), getpayment.Query{PaymentID: id})
+		if err != nil {
+			problem.Abort(c, err)
+			return
+		}
+		if result, ok := resp.(*getpayment.Result); !ok || !auth.PrincipalFrom(c).CanAccess(result.UserID) {
+			problem.Abort(c, confirmpayment.ErrNotPaymentOwner)
+			return
+		}
+		c.Next()
+	}
+}
+
+// paymentProblem maps the errors of the payment aggregate and its slices to
+// problem responses.
+func paymentProblem(err error) *problem.Problem {
+	var notFound *domain.ErrPaymentNotFound
+	var transition *domain.ErrInvalidTransition
+	var duplicate *domain.ErrDuplicatePayment
+	var concurrent *domain.ErrConcurrentUpdate
+	var invalidQuery *listpayments.ErrInvalidQuery
+	switch {
+	case errors.As(err, &notFound):
+		return problem.New(http.StatusNotFound, "payment_not_found", notFound.Error())
+	case errors.As(err, &transition):
+		return problem.New(http.StatusConflict, "invalid_payment_state", transition.Error())
+	case errors.As(err, &duplicate):
+		return problem.New(http.StatusConflict, "duplicate_payment", duplicate.Error())
+	case errors.As(err, &concurrent):
+		return problem.New(http.StatusConflict, "payment_changed", concurrent.Error())
+	case errors.Is(err, confirmpayment.ErrNotPaymentOwner):
+		return problem.New(http.StatusForbidden, "not_payment_owner", confirmpayment.ErrNotPaymentOwner.Error())
+	case errors.Is(err, confirmpayment.ErrNoActionRequired):
+		return problem.New(http.StatusConflict, "no_action_required", confirmpayment.ErrNoActionRequired.Error())
+	case errors.Is(err, domain.ErrDiscountAlreadyApplied):
+		return problem.New(http.StatusConflict, "discount_already_applied", domain.ErrDiscountAlreadyApplied.Error())
+	case errors.Is(err, domain.ErrTaxAlreadyApplied):
+		return problem.New(http.StatusConflict, "tax_already_applied", domain.ErrTaxAlreadyApplied.Error())
+	case errors.Is(err, domain.ErrDiscountExceedsAmount):
+		return problem.New(http.StatusUnprocessableEntity, "discount_exceeds_amount", domain.ErrDiscountExceedsAmount.Error())
+	case errors.Is(err, domain.ErrInvalidAmount):
+		return problem.Invalid("amount", "gt", domain.ErrInvalidAmount.Error())
+	case errors.Is(err, domain.ErrInvalidCurrency):
+		return problem.Invalid("currency", "required", domain.ErrInvalidCurrency.Error())
+	case errors.Is(err, domain.ErrInvalidLineItem):
+		return problem.New(http.StatusUnprocessableEntity, "invalid_line_item", err.Error())
+	case errors.As(err, &invalidQuery):
+		return problem.New(http.StatusBadRequest, "invalid_query", invalidQuery.Reason)
+	}
+	return nil
+}
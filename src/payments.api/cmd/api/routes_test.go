@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway/banktransfer"
+	"github.com/smart-health/payments-api/internal/openapi"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/shared"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+	"github.com/smart-health/payments-api/internal/stripe/simulator"
+)
+
+// testRouter mounts every route, the optional ones included. Handlers are
+// not called, so the dependencies they use may be nil.
+func testRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	return newRouter(routeDeps{
+		cfg:             &shared.Config{},
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		mediator:        shared.NewMediator(),
+		stripeSimulator: &simulator.Simulator{},
+		bankTransfers:   &banktransfer.Gateway{},
+	})
+}
+
+func fetchDocument(t *testing.T, router *gin.Engine) (*openapi.Document, []byte) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status = %d, body = %s", w.Code, w.Body)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	return &doc, w.Body.Bytes()
+}
+
+func TestRouter_MatchesOpenAPIDocument(t *testing.T) {
+	router := testRouter()
+	doc, _ := fetchDocument(t, router)
+
+	served := map[string]bool{}
+	for _, r := range router.Routes() {
+		path, _ := openapi.Path(r.Path)
+		served[r.Method+" "+path] = true
+	}
+	documented := map[string]bool{}
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	var undocumented, unserved []string
+	for route := range served {
+		if !documented[route] {
+			undocumented = append(undocumented, route)
+		}
+	}
+	for route := range documented {
+		if !served[route] {
+			unserved = append(unserved, route)
+		}
+	}
+	sort.Strings(undocumented)
+	sort.Strings(unserved)
+	if len(undocumented) > 0 {
+		t.Errorf("routes missing from the OpenAPI document: %v", undocumented)
+	}
+	if len(unserved) > 0 {
+		t.Errorf("documented routes the router does not serve: %v", unserved)
+	}
+}
+
+func TestRouter_OpenAPIDocumentReferencesResolve(t *testing.T) {
+	doc, body := fetchDocument(t, testRouter())
+
+	if doc.OpenAPI != openapi.Version {
+		t.Errorf("openapi = %q, want %q", doc.OpenAPI, openapi.Version)
+	}
+	for _, m := range regexp.MustCompile(`"\$ref": "#/components/schemas/([^"]+)"`).FindAllSubmatch(body, -1) {
+		if _, ok := doc.Components.Schemas[string(m[1])]; !ok {
+			t.Errorf("dangling reference to schema %s", m[1])
+		}
+	}
+
+	op := doc.Paths["/api/payments/{id}"]["get"]
+	if op == nil {
+		t.Fatal("GET /api/payments/{id} is not documented")
+	}
+	if got := op.Responses["200"].Content["application/json"].Schema.Ref; got != "#/components/schemas/getpayment.Result" {
+		t.Errorf("GET /api/payments/{id} response schema = %q", got)
+	}
+	if len(op.Security) == 0 {
+		t.Error("GET /api/payments/{id} should require a bearer token")
+	}
+	if webhook := doc.Paths["/api/webhooks/stripe"]["post"]; webhook == nil || len(webhook.Security) != 0 {
+		t.Error("the Stripe webhook should be documented as public")
+	}
+}
+
+func TestRouter_ServesDocsPage(t *testing.T) {
+	w := httptest.NewRecorder()
+	testRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("GET /docs: status = %d, content type = %q", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func TestPaymentProblem_ConcurrentUpdateIsAConflict(t *testing.T) {
+	p := paymentProblem(fmt.Errorf("update payment: %w", &domain.ErrConcurrentUpdate{ID: uuid.New()}))
+	if p == nil || p.Status != http.StatusConflict || p.Code != "payment_changed" {
+		t.Errorf("problem = %+v, want 409 payment_changed", p)
+	}
+}
+
+func TestStripeProblem_RefusedChargeIsABadGateway(t *testing.T) {
+	p := stripeservice.Problem(fmt.Errorf("complete payment: %w", &stripeservice.ErrInvalidRequest{Code: "parameter_invalid"}))
+	if p == nil || p.Status != http.StatusBadGateway || p.Code != "provider_rejected_request" {
+		t.Errorf("problem = %+v, want 502 provider_rejected_request", p)
+	}
+}
@@ -0,0 +1,36 @@
+// Command fakestripe serves the in-memory fake Stripe API for local
+// development. Point the payments API at it with
+// STRIPE_API_URL=http://localhost:12111.
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smart-health/payments-api/internal/stripe/fakeapi"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	gin.SetMode(gin.ReleaseMode)
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "12111"
+	}
+
+	srv := &http.Server{
+		Addr:              ":" + port,
+		Handler:           fakeapi.New(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	logger.Info("fake Stripe API listening", "port", port)
+	if err := srv.ListenAndServe(); err != nil {
+		logger.Error("fake Stripe API stopped", "error", err)
+		os.Exit(1)
+	}
+}
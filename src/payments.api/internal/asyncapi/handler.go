@@ -0,0 +1,20 @@
+package asyncapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler serves the document as JSON, encoded once.
+func Handler(doc *Document) gin.HandlerFunc {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	return func(c *gin.Context) {
+		if err != nil {
+			c.String(http.StatusInternalServerError, "asyncapi document could not be encoded: %v", err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json", body)
+	}
+}
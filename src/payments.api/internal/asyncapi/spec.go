@@ -0,0 +1,319 @@
+package asyncapi
+
+import (
+	"fmt"
+
+	"github.com/smart-health/payments-api/internal/jsonschema"
+	"github.com/smart-health/payments-api/internal/messaging"
+)
+
+// -----------------------------------------------------------------------
+// AsyncAPI document
+//
+// Architectural Decision: The broker contract is described from the
+// messaging catalog (messaging.Published, messaging.Consumed and the
+// status request/reply pair) and the payload schemas are generated from
+// the Go structs the messages are encoded from (see jsonschema) – the
+// same approach as the OpenAPI document, so consumers of our events read
+// the shapes the code actually sends. Channels carry AMQP bindings: the
+// topic exchange and routing key of published events, the queues of
+// consumed ones.
+//
+// Messages are keyed by contract version (PaymentCompletedIntegrationEvent.v1)
+// and carry the schema-version header and the catalog's examples. Received
+// messages are described with request schemas, like messaging.Schemas
+// validates them: only the fields tagged binding:"required" are required.
+// -----------------------------------------------------------------------
+
+// Version is the AsyncAPI version of the documents built here.
+const Version = "3.0.0"
+
+// amqpBindingVersion is the version of the AMQP bindings used.
+const amqpBindingVersion = "0.3.0"
+
+// Info describes the application.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an AsyncAPI document.
+type Document struct {
+	AsyncAPI           string                `json:"asyncapi"`
+	Info               Info                  `json:"info"`
+	DefaultContentType string                `json:"defaultContentType"`
+	Channels           map[string]*Channel   `json:"channels"`
+	Operations         map[string]*Operation `json:"operations"`
+	Components         Components            `json:"components"`
+}
+
+// Ref is a reference to another part of the document.
+type Ref struct {
+	Ref string `json:"$ref"`
+}
+
+// Channel is where messages travel: a routing key or a queue. A nil
+// Address is decided at runtime, like the reply_to queue of a request.
+type Channel struct {
+	Address     *string          `json:"address"`
+	Description string           `json:"description,omitempty"`
+	Messages    map[string]Ref   `json:"messages"`
+	Bindings    *ChannelBindings `json:"bindings,omitempty"`
+}
+
+// ChannelBindings holds the protocol details of a channel.
+type ChannelBindings struct {
+	AMQP *AMQPChannel `json:"amqp,omitempty"`
+}
+
+// AMQPChannel binds a channel to an exchange and routing key, or to a queue.
+type AMQPChannel struct {
+	Is             string        `json:"is"` // "routingKey" or "queue"
+	Exchange       *AMQPExchange `json:"exchange,omitempty"`
+	Queue          *AMQPQueue    `json:"queue,omitempty"`
+	BindingVersion string        `json:"bindingVersion"`
+}
+
+// AMQPExchange is the exchange events are published to.
+type AMQPExchange struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Durable    bool   `json:"durable"`
+	AutoDelete bool   `json:"autoDelete"`
+}
+
+// AMQPQueue is a queue messages are consumed from.
+type AMQPQueue struct {
+	Name       string `json:"name"`
+	Durable    bool   `json:"durable"`
+	Exclusive  bool   `json:"exclusive"`
+	AutoDelete bool   `json:"autoDelete"`
+}
+
+// Operation is what the application does on a channel.
+type Operation struct {
+	Action   string `json:"action"` // "send" or "receive"
+	Channel  Ref    `json:"channel"`
+	Summary  string `json:"summary,omitempty"`
+	Messages []Ref  `json:"messages"`
+	Reply    *Reply `json:"reply,omitempty"`
+}
+
+// Reply is the response an operation sends back to the requester.
+type Reply struct {
+	Address  *Location `json:"address,omitempty"`
+	Channel  Ref       `json:"channel"`
+	Messages []Ref     `json:"messages"`
+}
+
+// Location points into a message, e.g. "$message.header#/reply_to".
+type Location struct {
+	Location string `json:"location"`
+}
+
+// Components holds the messages and schemas channels refer to.
+type Components struct {
+	Messages map[string]*Message           `json:"messages"`
+	Schemas  map[string]*jsonschema.Schema `json:"schemas"`
+}
+
+// Message is a message type and the schema of its body.
+type Message struct {
+	Name          string             `json:"name"`
+	Title         string             `json:"title,omitempty"`
+	Summary       string             `json:"summary,omitempty"`
+	ContentType   string             `json:"contentType"`
+	Headers       *jsonschema.Schema `json:"headers,omitempty"`
+	Payload       *jsonschema.Schema `json:"payload"`
+	CorrelationID *Location          `json:"correlationId,omitempty"`
+	Examples      []Example          `json:"examples,omitempty"`
+}
+
+// Example is a sample message.
+type Example struct {
+	Name    string         `json:"name,omitempty"`
+	Headers map[string]any `json:"headers,omitempty"`
+	Payload any            `json:"payload"`
+}
+
+const (
+	contentType   = "application/json"
+	schemaPrefix  = "#/components/schemas/"
+	messagePrefix = "#/components/messages/"
+	channelPrefix = "#/channels/"
+)
+
+// correlationID is where request/reply messages carry their correlation.
+var correlationID = &Location{Location: "$message.header#/correlation_id"}
+
+// Builder assembles a document from the messaging catalog.
+type Builder struct {
+	doc    *Document
+	schema *jsonschema.Generator
+}
+
+// NewBuilder starts a document.
+func NewBuilder(info Info) *Builder {
+	g := jsonschema.NewGenerator(schemaPrefix)
+	return &Builder{
+		doc: &Document{
+			AsyncAPI:           Version,
+			Info:               info,
+			DefaultContentType: contentType,
+			Channels:           map[string]*Channel{},
+			Operations:         map[string]*Operation{},
+			Components: Components{
+				Messages: map[string]*Message{},
+				Schemas:  g.Defs,
+			},
+		},
+		schema: g,
+	}
+}
+
+// Publish declares events sent to a topic exchange, each with its name as
+// routing key. Versions of an event published side by side (c.At) share
+// its channel and operation.
+func (b *Builder) Publish(exchange string, contracts ...messaging.Contract) *Builder {
+	for _, c := range contracts {
+		channel := b.channel(c.Name, ptr(c.Name), &AMQPChannel{
+			Is:       "routingKey",
+			Exchange: &AMQPExchange{Name: exchange, Type: "topic", Durable: true},
+		}, b.schema.Output, c)
+		op := b.doc.Operations["send"+c.Name]
+		if op == nil {
+			op = &Operation{Action: "send", Channel: Ref{Ref: channelPrefix + channel}, Summary: c.Summary}
+			b.doc.Operations["send"+c.Name] = op
+		}
+		op.Messages = append(op.Messages, Ref{Ref: channelPrefix + channel + "/messages/" + c.ID()})
+	}
+	return b
+}
+
+// Consume declares events received from a queue, at every version the
+// consumer still reads.
+func (b *Builder) Consume(queue string, contracts ...messaging.Contract) *Builder {
+	contracts = versions(contracts)
+	channel := b.channel(queue, ptr(queue), queueBinding(queue), b.schema.Input, contracts...)
+	op := &Operation{Action: "receive", Channel: Ref{Ref: channelPrefix + channel}}
+	for _, c := range contracts {
+		op.Messages = append(op.Messages, Ref{Ref: channelPrefix + channel + "/messages/" + c.ID()})
+	}
+	b.doc.Operations["receive"+queueID(queue)] = op
+	return b
+}
+
+// Reply declares requests received from a queue, at every version still
+// read, and answered on the requester's reply_to queue with the request's
+// correlation_id.
+func (b *Builder) Reply(queue string, request, reply messaging.Contract) *Builder {
+	requestVersions := request.Versions()
+	requests := b.channel(queue, ptr(queue), queueBinding(queue), b.schema.Input, requestVersions...)
+	replies := b.channel(reply.Name, nil, nil, b.schema.Output, reply)
+	var messages []Ref
+	for _, c := range requestVersions {
+		b.doc.Components.Messages[c.ID()].CorrelationID = correlationID
+		messages = append(messages, Ref{Ref: channelPrefix + requests + "/messages/" + c.ID()})
+	}
+	b.doc.Components.Messages[reply.ID()].CorrelationID = correlationID
+	b.doc.Channels[replies].Description = "The requester's reply_to queue, on the default exchange."
+
+	b.doc.Operations["reply"+queueID(queue)] = &Operation{
+		Action:   "receive",
+		Channel:  Ref{Ref: channelPrefix + requests},
+		Summary:  request.Summary,
+		Messages: messages,
+		Reply: &Reply{
+			Address:  &Location{Location: "$message.header#/reply_to"},
+			Channel:  Ref{Ref: channelPrefix + replies},
+			Messages: []Ref{{Ref: channelPrefix + replies + "/messages/" + reply.ID()}},
+		},
+	}
+	return b
+}
+
+// Document returns the assembled document.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// channel adds a channel carrying the contracts' messages, their payloads
+// described by schema, or adds the messages to the channel if it exists,
+// and returns its ID.
+func (b *Builder) channel(id string, address *string, binding *AMQPChannel, schema func(any) *jsonschema.Schema, contracts ...messaging.Contract) string {
+	ch := b.doc.Channels[id]
+	if ch == nil {
+		ch = &Channel{Address: address, Messages: map[string]Ref{}}
+		if binding != nil {
+			binding.BindingVersion = amqpBindingVersion
+			ch.Bindings = &ChannelBindings{AMQP: binding}
+		}
+	}
+	for _, c := range contracts {
+		msg := &Message{
+			Name:        c.Name,
+			Title:       c.ID(),
+			Summary:     c.Summary,
+			ContentType: contentType,
+			Headers:     versionHeader(c.Version),
+			Payload:     schema(c.Payload),
+		}
+		for i, example := range c.Examples {
+			msg.Examples = append(msg.Examples, Example{
+				Name:    fmt.Sprintf("%s-%d", c.ID(), i+1),
+				Headers: map[string]any{messaging.HeaderSchemaVersion: c.Version},
+				Payload: example,
+			})
+		}
+		b.doc.Components.Messages[c.ID()] = msg
+		ch.Messages[c.ID()] = Ref{Ref: messagePrefix + c.ID()}
+	}
+	b.doc.Channels[id] = ch
+	return id
+}
+
+// versionHeader describes the schema-version header of a contract version.
+func versionHeader(version int) *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type: "object",
+		Properties: map[string]*jsonschema.Schema{
+			messaging.HeaderSchemaVersion: {Type: "integer", Enum: []any{version}},
+		},
+	}
+}
+
+// versions lists every version of the contracts.
+func versions(contracts []messaging.Contract) []messaging.Contract {
+	var all []messaging.Contract
+	for _, c := range contracts {
+		all = append(all, c.Versions()...)
+	}
+	return all
+}
+
+func queueBinding(queue string) *AMQPChannel {
+	return &AMQPChannel{Is: "queue", Queue: &AMQPQueue{Name: queue, Durable: true}}
+}
+
+// queueID turns a queue name into part of an operation ID:
+// "appointment.slot.reserved" is "AppointmentSlotReserved".
+func queueID(queue string) string {
+	var id []byte
+	upper := true
+	for i := 0; i < len(queue); i++ {
+		switch ch := queue[i]; {
+		case ch == '.' || ch == '-' || ch == '_':
+			upper = true
+		case upper && ch >= 'a' && ch <= 'z':
+			id = append(id, ch-'a'+'A')
+			upper = false
+		default:
+			id = append(id, ch)
+			upper = false
+		}
+	}
+	return string(id)
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,192 @@
+package asyncapi_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/asyncapi"
+	"github.com/smart-health/payments-api/internal/jsonschema"
+	"github.com/smart-health/payments-api/internal/messaging"
+)
+
+func document(t *testing.T) *asyncapi.Document {
+	t.Helper()
+	doc := asyncapi.NewBuilder(asyncapi.Info{Title: "test", Version: "1"}).
+		Publish("payment.completed", messaging.Published...).
+		Consume("appointment.slot.reserved", messaging.Consumed...).
+		Reply("payment.status.requests", messaging.StatusRequest, messaging.StatusReply).
+		Document()
+
+	// Round-trip through JSON: the tests read the document consumers download
+	body, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("encode document: %v", err)
+	}
+	var decoded asyncapi.Document
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	return &decoded
+}
+
+func contracts() []messaging.Contract {
+	all := append([]messaging.Contract{}, messaging.Published...)
+	all = append(all, messaging.Consumed...)
+	return append(all, messaging.StatusRequest, messaging.StatusReply)
+}
+
+func TestDocument_SchemasMatchTheGoStructs(t *testing.T) {
+	doc := document(t)
+	g := &jsonschema.Generator{RefPrefix: "#/components/schemas/", Defs: doc.Components.Schemas}
+
+	for _, c := range contracts() {
+		t.Run(c.ID(), func(t *testing.T) {
+			msg := doc.Components.Messages[c.ID()]
+			if msg == nil {
+				t.Fatalf("message %s is not described", c.ID())
+			}
+			def := msg.Payload
+			if def.Ref != "" {
+				def = doc.Components.Schemas[strings.TrimPrefix(def.Ref, "#/components/schemas/")]
+				if def == nil {
+					t.Fatalf("payload %q does not resolve", msg.Payload.Ref)
+				}
+			}
+			if len(msg.Examples) != len(c.Examples) {
+				t.Fatalf("examples = %d, the catalog has %d", len(msg.Examples), len(c.Examples))
+			}
+
+			// The examples match the schema and, between them, set every
+			// field the struct encodes: each is a property of the schema
+			encoded := map[string]bool{}
+			for i, example := range c.Examples {
+				body, err := json.Marshal(example)
+				if err != nil {
+					t.Fatalf("encode example: %v", err)
+				}
+				if err := g.Validate(msg.Payload, body); err != nil {
+					t.Errorf("example %d does not match its schema: %v", i+1, err)
+				}
+				if err := messaging.Schemas.Validate(c.Name, c.Version, body); err != nil {
+					t.Errorf("example %d breaks its contract: %v", i+1, err)
+				}
+				var fields map[string]any
+				_ = json.Unmarshal(body, &fields)
+				for name := range fields {
+					encoded[name] = true
+				}
+			}
+			var names, described []string
+			for name := range encoded {
+				names = append(names, name)
+			}
+			for name := range def.Properties {
+				described = append(described, name)
+			}
+			sort.Strings(names)
+			sort.Strings(described)
+			if !reflect.DeepEqual(names, described) {
+				t.Errorf("properties = %v, the examples set %v", described, names)
+			}
+
+			if len(def.Required) > 0 {
+				if err := g.Validate(msg.Payload, []byte(`{}`)); err == nil {
+					t.Errorf("an empty object matches %s, which requires %v", c.ID(), def.Required)
+				}
+			}
+
+			version := msg.Headers.Properties[messaging.HeaderSchemaVersion]
+			if version == nil || len(version.Enum) != 1 || version.Enum[0] != float64(c.Version) {
+				t.Errorf("schema-version header = %+v, want %d", version, c.Version)
+			}
+		})
+	}
+}
+
+func TestDocument_Channels(t *testing.T) {
+	doc := document(t)
+
+	if doc.AsyncAPI != asyncapi.Version || doc.DefaultContentType != "application/json" {
+		t.Errorf("asyncapi = %q, defaultContentType = %q", doc.AsyncAPI, doc.DefaultContentType)
+	}
+
+	for _, c := range messaging.Published {
+		ch := doc.Channels[c.Name]
+		if ch == nil || ch.Address == nil || *ch.Address != c.Name {
+			t.Fatalf("channel of %s = %+v, want the routing key %s", c.Name, ch, c.Name)
+		}
+		if b := ch.Bindings.AMQP; b.Is != "routingKey" || b.Exchange.Name != "payment.completed" || b.Exchange.Type != "topic" {
+			t.Errorf("binding of %s = %+v", c.Name, b)
+		}
+		op := doc.Operations["send"+c.Name]
+		if op == nil || op.Action != "send" || op.Channel.Ref != "#/channels/"+c.Name {
+			t.Errorf("operation of %s = %+v", c.Name, op)
+		}
+	}
+
+	consumed := doc.Operations["receiveAppointmentSlotReserved"]
+	if consumed == nil || consumed.Action != "receive" || consumed.Channel.Ref != "#/channels/appointment.slot.reserved" {
+		t.Fatalf("receive operation = %+v", consumed)
+	}
+	if q := doc.Channels["appointment.slot.reserved"].Bindings.AMQP.Queue; q == nil || q.Name != "appointment.slot.reserved" || !q.Durable {
+		t.Errorf("consumed queue = %+v", q)
+	}
+
+	reply := doc.Operations["replyPaymentStatusRequests"]
+	if reply == nil || reply.Reply == nil || reply.Reply.Address.Location != "$message.header#/reply_to" {
+		t.Fatalf("request/reply operation = %+v", reply)
+	}
+	if ch := doc.Channels["PaymentStatusReply"]; ch == nil || ch.Address != nil {
+		t.Errorf("reply channel = %+v, want a runtime address", ch)
+	}
+	if doc.Components.Messages[messaging.StatusRequest.ID()].CorrelationID == nil {
+		t.Error("requests carry a correlation_id")
+	}
+
+	// Operation and channel references resolve
+	for id, op := range doc.Operations {
+		for _, m := range op.Messages {
+			parts := strings.Split(strings.TrimPrefix(m.Ref, "#/channels/"), "/messages/")
+			if ch := doc.Channels[parts[0]]; ch == nil || ch.Messages[parts[1]].Ref == "" {
+				t.Errorf("operation %s: message %s does not resolve", id, m.Ref)
+			}
+		}
+	}
+}
+
+func TestDocument_Versions(t *testing.T) {
+	type refundV1 struct {
+		Amount float64 `json:"amount"`
+	}
+	type refund struct {
+		AmountMinor int64 `json:"amountMinor"`
+	}
+	c := messaging.Contract{Name: "RefundIssued", Version: 2, Payload: refund{},
+		Revisions: []messaging.Revision{{Version: 1, Payload: refundV1{}}}}
+	old, _ := c.At(1)
+
+	doc := asyncapi.NewBuilder(asyncapi.Info{Title: "test", Version: "1"}).
+		Publish("payment.completed", c, old).
+		Consume("refunds", c).
+		Document()
+
+	// Both published versions share the routing key
+	if ch := doc.Channels["RefundIssued"]; ch == nil || len(ch.Messages) != 2 {
+		t.Fatalf("published channel = %+v, want both versions", ch)
+	}
+	if op := doc.Operations["sendRefundIssued"]; op == nil || len(op.Messages) != 2 {
+		t.Errorf("send operation = %+v, want both versions", op)
+	}
+	// Consumers read every version
+	if op := doc.Operations["receiveRefunds"]; op == nil || len(op.Messages) != 2 {
+		t.Errorf("receive operation = %+v, want both versions", op)
+	}
+	for _, id := range []string{"RefundIssued.v1", "RefundIssued.v2"} {
+		if doc.Components.Messages[id] == nil {
+			t.Errorf("message %s is not described", id)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is returned for a token that is malformed, badly signed,
+// expired or meant for another audience.
+type ErrInvalidToken struct {
+	Reason string
+}
+
+func (e *ErrInvalidToken) Error() string {
+	return "invalid token: " + e.Reason
+}
+
+// Claims are the registered claims (RFC 7519) we check plus the roles.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	Roles     []string `json:"roles"`
+}
+
+// audience is a single string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// VerifierOptions configures the claims a token must carry.
+type VerifierOptions struct {
+	Issuer   string        // required "iss"; empty accepts any
+	Audience string        // required in "aud"; empty accepts any
+	Leeway   time.Duration // clock skew tolerated on exp and nbf
+}
+
+// Verifier validates signed JWTs (JWS compact serialization).
+type Verifier struct {
+	keys KeySource
+	opts VerifierOptions
+	now  func() time.Time
+}
+
+// NewVerifier creates a verifier for tokens signed with keys from the source.
+func NewVerifier(keys KeySource, opts VerifierOptions) *Verifier {
+	return &Verifier{keys: keys, opts: opts, now: time.Now}
+}
+
+// signingMethods are the accepted "alg" values. "none" is never accepted.
+var signingMethods = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+	"HS256": crypto.SHA256, "HS384": crypto.SHA384, "HS512": crypto.SHA512,
+}
+
+// Verify checks the token's signature and claims and returns its principal.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, &ErrInvalidToken{Reason: "malformed token"}
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, &ErrInvalidToken{Reason: "malformed header"}
+	}
+	hash, ok := signingMethods[header.Alg]
+	if !ok {
+		return nil, &ErrInvalidToken{Reason: fmt.Sprintf("unsupported algorithm %q", header.Alg)}
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, &ErrInvalidToken{Reason: "malformed signature"}
+	}
+
+	keys, err := v.keys.Keys(ctx, header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf("load verification keys: %w", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	if !slices.ContainsFunc(keys, func(key any) bool { return verifySignature(header.Alg, hash, key, signed, signature) }) {
+		return nil, &ErrInvalidToken{Reason: "signature does not verify"}
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, &ErrInvalidToken{Reason: "malformed claims"}
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return nil, err
+	}
+	return &Principal{Subject: claims.Subject, Roles: claims.Roles}, nil
+}
+
+func (v *Verifier) checkClaims(c Claims) error {
+	now := v.now()
+	if c.ExpiresAt == nil {
+		return &ErrInvalidToken{Reason: "token has no expiry"}
+	}
+	if now.After(unixTime(*c.ExpiresAt).Add(v.opts.Leeway)) {
+		return &ErrInvalidToken{Reason: "token has expired"}
+	}
+	if c.NotBefore != nil && now.Add(v.opts.Leeway).Before(unixTime(*c.NotBefore)) {
+		return &ErrInvalidToken{Reason: "token is not valid yet"}
+	}
+	if c.Subject == "" {
+		return &ErrInvalidToken{Reason: "token has no subject"}
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return &ErrInvalidToken{Reason: "unexpected issuer"}
+	}
+	if v.opts.Audience != "" && !slices.Contains(c.Audience, v.opts.Audience) {
+		return &ErrInvalidToken{Reason: "token is meant for another audience"}
+	}
+	return nil
+}
+
+// verifySignature checks signature over signed with key, if key suits the algorithm.
+func verifySignature(alg string, hash crypto.Hash, key any, signed, signature []byte) bool {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return false
+		}
+		return rsa.VerifyPKCS1v15(k, hash, digest(hash, signed), signature) == nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(k, digest(hash, signed), r, s)
+	case []byte:
+		if !strings.HasPrefix(alg, "HS") {
+			return false
+		}
+		mac := hmac.New(hash.New, k)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), signature)
+	default:
+		return false
+	}
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	switch hash {
+	case crypto.SHA384:
+		sum := sha512.Sum384(data)
+		return sum[:]
+	case crypto.SHA512:
+		sum := sha512.Sum512(data)
+		return sum[:]
+	default:
+		sum := sha256.Sum256(data)
+		return sum[:]
+	}
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("invalid JSON")
+	}
+	return nil
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,166 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/auth"
+)
+
+// The testdata JWKS holds the public halves of test-rsa.pem and test-ec.pem.
+const jwksFile = "testdata/jwks.json"
+
+var secret = []byte("change-me-in-production-use-a-long-secret")
+
+func loadKey(t *testing.T, name string) crypto.Signer {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name + ".pem")
+	if err != nil {
+		t.Fatalf("read key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	return key.(crypto.Signer)
+}
+
+// sign mints a token; key is a crypto.Signer (RS256, ES256) or an HMAC secret (HS256).
+func sign(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func claims(sub string, roles ...string) map[string]any {
+	return map[string]any{
+		"sub":   sub,
+		"iss":   "https://id.smarthealth.test",
+		"aud":   []string{"payments-api", "audit-api"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": roles,
+	}
+}
+
+func newVerifier(t *testing.T) *auth.Verifier {
+	t.Helper()
+	keys, err := auth.LoadJWKSFile(jwksFile)
+	if err != nil {
+		t.Fatalf("load JWKS: %v", err)
+	}
+	return auth.NewVerifier(auth.Sources{keys, auth.NewKeySet(nil, secret)}, auth.VerifierOptions{
+		Issuer:   "https://id.smarthealth.test",
+		Audience: "payments-api",
+	})
+}
+
+func TestVerify_AcceptsSupportedKeys(t *testing.T) {
+	v := newVerifier(t)
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"RS256 from JWKS", sign(t, "RS256", "test-rsa", loadKey(t, "test-rsa"), claims("user-1", auth.RolePatient))},
+		{"ES256 from JWKS", sign(t, "ES256", "test-ec", loadKey(t, "test-ec"), claims("user-1", auth.RolePatient))},
+		{"HS256 shared secret", sign(t, "HS256", "", secret, claims("user-1", auth.RolePatient))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(context.Background(), tt.token)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Subject != "user-1" || !p.HasRole(auth.RolePatient) {
+				t.Errorf("unexpected principal %+v", p)
+			}
+		})
+	}
+}
+
+func TestVerify_RejectsInvalidTokens(t *testing.T) {
+	v := newVerifier(t)
+	rsaKey := loadKey(t, "test-rsa")
+	valid := sign(t, "RS256", "test-rsa", rsaKey, claims("user-1"))
+
+	expired := claims("user-1")
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	notYet := claims("user-1")
+	notYet["nbf"] = time.Now().Add(time.Hour).Unix()
+	noExpiry := claims("user-1")
+	delete(noExpiry, "exp")
+	otherAudience := claims("user-1")
+	otherAudience["aud"] = "audit-api"
+	otherIssuer := claims("user-1")
+	otherIssuer["iss"] = "https://evil.example"
+
+	parts := strings.Split(valid, ".")
+	tampered := claims("admin-1", auth.RoleAdmin)
+	body, _ := json.Marshal(tampered)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not.a-token"},
+		{"alg none", strings.Join([]string{base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)), parts[1], ""}, ".")},
+		{"tampered claims", parts[0] + "." + base64.RawURLEncoding.EncodeToString(body) + "." + parts[2]},
+		{"unknown key ID", sign(t, "RS256", "rotated-away", rsaKey, claims("user-1"))},
+		{"wrong secret", sign(t, "HS256", "", []byte("guessed"), claims("user-1"))},
+		{"RSA key used as HMAC secret", sign(t, "HS256", "test-rsa", []byte("anything"), claims("user-1"))},
+		{"expired", sign(t, "RS256", "test-rsa", rsaKey, expired)},
+		{"not valid yet", sign(t, "RS256", "test-rsa", rsaKey, notYet)},
+		{"no expiry", sign(t, "RS256", "test-rsa", rsaKey, noExpiry)},
+		{"other audience", sign(t, "RS256", "test-rsa", rsaKey, otherAudience)},
+		{"other issuer", sign(t, "RS256", "test-rsa", rsaKey, otherIssuer)},
+		{"no subject", sign(t, "RS256", "test-rsa", rsaKey, claims(""))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := v.Verify(context.Background(), tt.token)
+			var invalid *auth.ErrInvalidToken
+			if !errors.As(err, &invalid) {
+				t.Fatalf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// KeySource provides the keys tokens are verified with.
+type KeySource interface {
+	// Keys returns the candidate keys for a token's key ID (empty when the
+	// token names none): *rsa.PublicKey, *ecdsa.PublicKey or []byte (HMAC).
+	Keys(ctx context.Context, kid string) ([]any, error)
+}
+
+// KeySet is a fixed set of keys: a JWKS document and/or a shared secret.
+type KeySet struct {
+	byID map[string]any
+	all  []any
+}
+
+// NewKeySet creates a key set from parsed JWKS keys (see ParseJWKS) and an
+// optional HMAC secret, which has no key ID.
+func NewKeySet(jwks map[string]any, secret []byte) *KeySet {
+	s := &KeySet{byID: make(map[string]any, len(jwks))}
+	for kid, key := range jwks {
+		s.byID[kid] = key
+		s.all = append(s.all, key)
+	}
+	if len(secret) > 0 {
+		s.all = append(s.all, secret)
+	}
+	return s
+}
+
+// LoadJWKSFile creates a key set from a local JWKS file.
+func LoadJWKSFile(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read JWKS file: %w", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySet(keys, nil), nil
+}
+
+// Keys returns the key with the ID, or every key when kid is empty.
+func (s *KeySet) Keys(_ context.Context, kid string) ([]any, error) {
+	if kid == "" {
+		return s.all, nil
+	}
+	if key, ok := s.byID[kid]; ok {
+		return []any{key}, nil
+	}
+	return nil, nil
+}
+
+// ParseJWKS parses a JSON Web Key Set (RFC 7517) into its signing keys by key
+// ID. RSA, EC (P-256, P-384, P-521) and symmetric keys are supported; keys
+// meant for encryption are skipped.
+func ParseJWKS(data []byte) (map[string]any, error) {
+	var doc struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]any, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key any
+		var err error
+		switch k.Kty {
+		case "RSA":
+			key, err = rsaKey(k.N, k.E)
+		case "EC":
+			key, err = ecKey(k.Crv, k.X, k.Y)
+		case "oct":
+			key, err = base64.RawURLEncoding.DecodeString(k.K)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse JWKS key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func rsaKey(n, e string) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, fmt.Errorf("modulus: %w", err)
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, fmt.Errorf("exponent: %w", err)
+	}
+	exponent := new(big.Int).SetBytes(eb)
+	if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("unsupported exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(nb), E: int(exponent.Int64())}, nil
+}
+
+func ecKey(crv, x, y string) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", crv)
+	}
+	xb, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil {
+		return nil, fmt.Errorf("x: %w", err)
+	}
+	yb, err := base64.RawURLEncoding.DecodeString(y)
+	if err != nil {
+		return nil, fmt.Errorf("y: %w", err)
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(xb), Y: new(big.Int).SetBytes(yb)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("point is not on curve %s", crv)
+	}
+	return key, nil
+}
+
+// ---------------------------------------------------------------------------
+// Remote JWKS
+// ---------------------------------------------------------------------------
+
+// RemoteKeySet fetches a JWKS document from the identity provider and caches
+// it. The cache is refreshed every refresh interval, and early when a token
+// names an unknown key ID (the provider rotated its keys) – at most once a
+// minute, so forged key IDs cannot make us hammer the provider.
+//
+// One caller fetches at a time, outside the lock: the others keep verifying
+// with the cached keys meanwhile, and only wait for the fetch when there are
+// none yet. A failed refresh is not tried again for a minute, so an outage of
+// the provider does not put a fetch in front of every request.
+type RemoteKeySet struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+	logger  *slog.Logger
+
+	mu        sync.Mutex
+	keys      *KeySet
+	fetchedAt time.Time
+	retryAt   time.Time     // no refresh before, after a failed one
+	fetching  chan struct{} // closed when the fetch in flight ends; nil when none is
+	fetchErr  error         // error of the last fetch
+}
+
+// minRefetch bounds how often an unknown key ID triggers a refetch, and how
+// soon a failed refresh is tried again.
+const minRefetch = time.Minute
+
+// NewRemoteKeySet creates a key set served from url.
+func NewRemoteKeySet(url string, refresh time.Duration, logger *slog.Logger) *RemoteKeySet {
+	return &RemoteKeySet{url: url, refresh: refresh, client: &http.Client{Timeout: 10 * time.Second}, logger: logger}
+}
+
+// Keys returns the cached keys for kid, fetching the document when needed.
+func (s *RemoteKeySet) Keys(ctx context.Context, kid string) ([]any, error) {
+	s.mu.Lock()
+	keys := s.keys
+	if !s.stale(ctx, kid) {
+		s.mu.Unlock()
+		return keys.Keys(ctx, kid)
+	}
+	done := s.fetching
+	if done == nil {
+		done = make(chan struct{})
+		s.fetching = done
+		s.mu.Unlock()
+		s.refreshKeys(ctx, done)
+	} else {
+		s.mu.Unlock()
+		if keys != nil {
+			// Another caller is refreshing; the cached keys are good until it is done
+			return keys.Keys(ctx, kid)
+		}
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	s.mu.Lock()
+	keys, err := s.keys, s.fetchErr
+	s.mu.Unlock()
+	if keys == nil {
+		return nil, err
+	}
+	return keys.Keys(ctx, kid)
+}
+
+// stale reports whether the keys must be fetched for kid. s.mu must be held.
+func (s *RemoteKeySet) stale(ctx context.Context, kid string) bool {
+	if s.keys == nil {
+		return true
+	}
+	now := time.Now()
+	if now.Before(s.retryAt) {
+		return false
+	}
+	if now.Sub(s.fetchedAt) > s.refresh {
+		return true
+	}
+	if kid == "" || now.Sub(s.fetchedAt) <= minRefetch {
+		return false
+	}
+	keys, _ := s.keys.Keys(ctx, kid)
+	return len(keys) == 0
+}
+
+// refreshKeys fetches the document and stores the outcome, then closes done.
+// The fetch outlives the caller's cancellation: others may be waiting for it.
+func (s *RemoteKeySet) refreshKeys(ctx context.Context, done chan struct{}) {
+	keys, err := s.fetch(context.WithoutCancel(ctx))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fetching = nil
+	s.fetchErr = err
+	if err == nil {
+		s.keys = keys
+		s.fetchedAt = time.Now()
+	} else if s.keys != nil {
+		// Keep verifying with the keys we have while the provider is unreachable
+		s.retryAt = time.Now().Add(minRefetch)
+		s.logger.Warn("failed to refresh JWKS, using cached keys", "url", s.url, "error", err, "retryIn", minRefetch)
+	}
+	close(done)
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) (*KeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("fetch JWKS: %w", err)
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySet(keys, nil), nil
+}
+
+// ---------------------------------------------------------------------------
+// Combined sources
+// ---------------------------------------------------------------------------
+
+// Sources tries each key source in turn, so a JWKS and a shared secret can
+// be configured together.
+type Sources []KeySource
+
+// Keys returns the keys of all sources for kid.
+func (s Sources) Keys(ctx context.Context, kid string) ([]any, error) {
+	var keys []any
+	var firstErr error
+	for _, source := range s {
+		k, err := source.Keys(ctx, kid)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		keys = append(keys, k...)
+	}
+	if len(keys) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+	return keys, nil
+}
@@ -0,0 +1,100 @@
+package auth_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/auth"
+)
+
+// jwksServer serves the testdata JWKS until it is made to fail; while
+// blocked, requests wait for the release channel to be closed.
+type jwksServer struct {
+	*httptest.Server
+	requests atomic.Int32
+	failing  atomic.Bool
+	blocked  chan struct{}
+	release  chan struct{}
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	body, err := os.ReadFile(jwksFile)
+	if err != nil {
+		t.Fatalf("read JWKS: %v", err)
+	}
+	s := &jwksServer{blocked: make(chan struct{}, 1), release: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		if s.failing.Load() {
+			s.blocked <- struct{}{}
+			<-s.release
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRemoteKeySet_OutageDoesNotBlockVerification(t *testing.T) {
+	server := newJWKSServer(t)
+	// Every call finds the keys due for a refresh
+	keys := auth.NewRemoteKeySet(server.URL, time.Nanosecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	if k, err := keys.Keys(ctx, "test-rsa"); err != nil || len(k) != 1 {
+		t.Fatalf("first fetch = %v, %v", k, err)
+	}
+
+	server.failing.Store(true)
+	refreshed := make(chan error)
+	go func() {
+		_, err := keys.Keys(ctx, "test-rsa")
+		refreshed <- err
+	}()
+	<-server.blocked
+
+	// While one caller waits for the provider, the others use the cached keys
+	start := time.Now()
+	if k, err := keys.Keys(ctx, "test-ec"); err != nil || len(k) != 1 {
+		t.Errorf("during refresh = %v, %v, want the cached key", k, err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("waited %s for the refresh in flight", waited)
+	}
+
+	close(server.release)
+	if err := <-refreshed; err != nil {
+		t.Errorf("failed refresh = %v, want the cached keys", err)
+	}
+
+	// The failed refresh is not retried on every call
+	for range 5 {
+		if _, err := keys.Keys(ctx, "test-rsa"); err != nil {
+			t.Fatalf("after failed refresh: %v", err)
+		}
+	}
+	if n := server.requests.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", n)
+	}
+}
+
+func TestRemoteKeySet_FailsWithoutKeys(t *testing.T) {
+	server := newJWKSServer(t)
+	server.failing.Store(true)
+	close(server.release)
+	keys := auth.NewRemoteKeySet(server.URL, time.Hour, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	if _, err := keys.Keys(context.Background(), "test-rsa"); err == nil {
+		t.Error("expected an error without any keys")
+	}
+}
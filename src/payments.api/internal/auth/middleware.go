@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// -----------------------------------------------------------------------
+// Authentication and authorization
+//
+// Architectural Decision: Like the audit service's JwtAuthenticationFilter,
+// Authenticate runs on every request: a valid bearer token makes its subject
+// and "roles" claim the request's Principal, a request without a token stays
+// anonymous, and an invalid token is rejected. Authorization is declared per
+// route group with Require – the routes read as their policy – while rules
+// that depend on the resource (a patient sees only their own payments) are
+// checked where the resource is loaded, with Principal.CanAccess.
+// -----------------------------------------------------------------------
+
+// Roles carried in the "roles" claim.
+const (
+	RolePatient = "patient" // sees and confirms their own payments
+	RoleStaff   = "staff"   // support: searches payments, operates back-office routes
+	RoleAdmin   = "admin"   // everything staff can do
+	RoleService = "service" // other services of the platform, calling internal endpoints
+)
+
+// Principal is the authenticated caller.
+type Principal struct {
+	Subject string
+	Roles   []string
+}
+
+// HasRole reports whether the principal has any of the roles.
+func (p *Principal) HasRole(roles ...string) bool {
+	return p != nil && slices.ContainsFunc(p.Roles, func(r string) bool { return slices.Contains(roles, r) })
+}
+
+// CanAccess reports whether the principal may access a resource owned by
+// ownerID: its owner, staff, admins and services may.
+func (p *Principal) CanAccess(ownerID string) bool {
+	if p == nil {
+		return false
+	}
+	return p.Subject == ownerID || p.HasRole(RoleStaff, RoleAdmin, RoleService)
+}
+
+const principalKey = "auth.principal"
+
+// PrincipalFrom returns the authenticated caller, nil for an anonymous request.
+func PrincipalFrom(c *gin.Context) *Principal {
+	p, _ := c.Get(principalKey)
+	principal, _ := p.(*Principal)
+	return principal
+}
+
+// SubjectOf returns the authenticated caller's subject, empty for an anonymous request.
+func SubjectOf(c *gin.Context) string {
+	if p := PrincipalFrom(c); p != nil {
+		return p.Subject
+	}
+	return ""
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the principal, for transports other
+// than the Gin router (see PrincipalFrom).
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx, nil for an anonymous call.
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
+
+// Authenticate verifies the request's bearer token, if any.
+func Authenticate(verifier *Verifier, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			unauthorized(c, "Authorization header must be a Bearer token")
+			return
+		}
+
+		principal, err := verifier.Verify(c.Request.Context(), strings.TrimSpace(token))
+		if err != nil {
+			var invalid *ErrInvalidToken
+			if errors.As(err, &invalid) {
+				logger.Debug("rejected bearer token", "error", err)
+				unauthorized(c, err.Error())
+				return
+			}
+			logger.Error("failed to verify bearer token", "error", err)
+			problem.Abort(c, problem.New(http.StatusServiceUnavailable, "token_verification_unavailable", "token could not be verified"))
+			return
+		}
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// Require admits authenticated callers; with roles, only callers having one of them.
+func Require(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := PrincipalFrom(c)
+		if principal == nil {
+			challenge(c)
+			return
+		}
+		if len(roles) > 0 && !principal.HasRole(roles...) {
+			problem.Abort(c, problem.New(http.StatusForbidden, "forbidden", "requires role "+strings.Join(roles, " or ")))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSelfOr admits the caller whose subject is the route parameter param,
+// and callers having one of the roles.
+func RequireSelfOr(param string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := PrincipalFrom(c)
+		if principal == nil {
+			challenge(c)
+			return
+		}
+		if principal.Subject != c.Param(param) && !principal.HasRole(roles...) {
+			problem.Abort(c, problem.New(http.StatusForbidden, "forbidden", "access to another user's resources is not allowed"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// challenge rejects an anonymous request.
+func challenge(c *gin.Context) {
+	c.Header("WWW-Authenticate", "Bearer")
+	problem.Abort(c, problem.New(http.StatusUnauthorized, "unauthenticated", "authentication required"))
+}
+
+// unauthorized rejects a request with an unusable token.
+func unauthorized(c *gin.Context, reason string) {
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	problem.Abort(c, problem.New(http.StatusUnauthorized, "invalid_token", reason))
+}
@@ -0,0 +1,86 @@
+package auth_test
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smart-health/payments-api/internal/auth"
+)
+
+func newRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(auth.Authenticate(newVerifier(t), slog.New(slog.NewTextHandler(io.Discard, nil))))
+	r.GET("/public", func(c *gin.Context) { c.String(http.StatusOK, auth.SubjectOf(c)) })
+	r.GET("/search", auth.Require(auth.RoleStaff, auth.RoleAdmin), func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/users/:userId/cards", auth.RequireSelfOr("userId", auth.RoleStaff), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func get(r *gin.Engine, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_Policies(t *testing.T) {
+	r := newRouter(t)
+	rsaKey := loadKey(t, "test-rsa")
+	patient := sign(t, "RS256", "test-rsa", rsaKey, claims("user-1", auth.RolePatient))
+	staff := sign(t, "RS256", "test-rsa", rsaKey, claims("staff-1", auth.RoleStaff))
+
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{"anonymous public route", "/public", "", http.StatusOK},
+		{"invalid token on public route", "/public", "garbage", http.StatusUnauthorized},
+		{"anonymous search", "/search", "", http.StatusUnauthorized},
+		{"patient search", "/search", patient, http.StatusForbidden},
+		{"staff search", "/search", staff, http.StatusOK},
+		{"patient's own cards", "/users/user-1/cards", patient, http.StatusOK},
+		{"another patient's cards", "/users/user-2/cards", patient, http.StatusForbidden},
+		{"staff on a patient's cards", "/users/user-2/cards", staff, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := get(r, tt.path, tt.token); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestMiddleware_SetsSubject(t *testing.T) {
+	r := newRouter(t)
+	token := sign(t, "HS256", "", secret, claims("user-1", auth.RolePatient))
+	if w := get(r, "/public", token); w.Body.String() != "user-1" {
+		t.Errorf("expected the token's subject, got %q", w.Body)
+	}
+}
+
+func TestPrincipal_CanAccess(t *testing.T) {
+	patient := &auth.Principal{Subject: "user-1", Roles: []string{auth.RolePatient}}
+	service := &auth.Principal{Subject: "appointments-api", Roles: []string{auth.RoleService}}
+	var anonymous *auth.Principal
+
+	if !patient.CanAccess("user-1") || patient.CanAccess("user-2") {
+		t.Errorf("expected a patient to access only their own resources")
+	}
+	if !service.CanAccess("user-2") {
+		t.Errorf("expected a service account to access any resource")
+	}
+	if anonymous.CanAccess("user-1") {
+		t.Errorf("expected an anonymous caller to access nothing")
+	}
+}
@@ -0,0 +1,193 @@
+package coupons
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Coupon kinds.
+const (
+	KindPercentage = "percentage" // Value is a percentage of the amount (0–100]
+	KindFixed      = "fixed"      // Value is an amount in Currency
+)
+
+// ErrInvalidCoupon is returned when a coupon definition violates its invariants.
+var ErrInvalidCoupon = errors.New("invalid coupon")
+
+// ErrCouponNotFound is returned when no coupon exists for a code or ID.
+type ErrCouponNotFound struct {
+	Code string
+}
+
+func (e *ErrCouponNotFound) Error() string {
+	return fmt.Sprintf("coupon %q was not found", e.Code)
+}
+
+// ErrCouponNotApplicable is returned when a coupon exists but cannot be used
+// for a payment (inactive, outside its validity window, wrong currency, or
+// its usage limits are exhausted).
+type ErrCouponNotApplicable struct {
+	Code   string
+	Reason string
+}
+
+func (e *ErrCouponNotApplicable) Error() string {
+	return fmt.Sprintf("coupon %q cannot be applied: %s", e.Code, e.Reason)
+}
+
+// -----------------------------------------------------------------------
+// Coupon
+//
+// Architectural Decision: A coupon is a reusable discount definition;
+// every use is a Redemption tied to one payment. Usage limits count
+// redemptions that are reserved or redeemed, so a redemption released
+// after a failed payment gives the use back. Limits are re-checked under
+// a row lock when the redemption is written, in the same transaction as
+// the payment itself (see RedemptionStore).
+// -----------------------------------------------------------------------
+
+// Coupon is a discount code clinics hand out for promotions.
+type Coupon struct {
+	ID                    uuid.UUID
+	Code                  string // stored upper-case; codes are case-insensitive
+	Kind                  string
+	Value                 float64
+	Currency              string // required for fixed coupons, empty for percentage coupons
+	ValidFrom             time.Time
+	ValidTo               *time.Time // nil = no expiry
+	MaxRedemptions        int        // 0 = unlimited
+	MaxRedemptionsPerUser int        // 0 = unlimited
+	Active                bool
+	CreatedAt             time.Time
+	UpdatedAt             *time.Time
+}
+
+// NewCoupon is the factory function – enforces invariants on creation.
+func NewCoupon(code, kind string, value float64, currency string, validFrom time.Time, validTo *time.Time, maxRedemptions, maxPerUser int) (*Coupon, error) {
+	c := &Coupon{
+		ID:                    uuid.New(),
+		Code:                  NormalizeCode(code),
+		Kind:                  kind,
+		Value:                 value,
+		Currency:              strings.ToLower(currency),
+		ValidFrom:             validFrom.UTC(),
+		ValidTo:               utcPtr(validTo),
+		MaxRedemptions:        maxRedemptions,
+		MaxRedemptionsPerUser: maxPerUser,
+		Active:                true,
+		CreatedAt:             time.Now().UTC(),
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reconfigure changes the validity window, usage limits and active flag.
+// The code, kind and value are immutable once redemptions may exist.
+func (c *Coupon) Reconfigure(validFrom time.Time, validTo *time.Time, maxRedemptions, maxPerUser int, active bool) error {
+	updated := *c
+	updated.ValidFrom = validFrom.UTC()
+	updated.ValidTo = utcPtr(validTo)
+	updated.MaxRedemptions = maxRedemptions
+	updated.MaxRedemptionsPerUser = maxPerUser
+	updated.Active = active
+	if err := updated.validate(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	updated.UpdatedAt = &now
+	*c = updated
+	return nil
+}
+
+// Discount computes the discount for an amount, rejecting coupons that are
+// inactive, outside their validity window or in another currency.
+// Usage limits are checked separately against the redemption counts.
+func (c *Coupon) Discount(amount float64, currency string, at time.Time) (float64, error) {
+	if !c.Active {
+		return 0, &ErrCouponNotApplicable{Code: c.Code, Reason: "coupon is inactive"}
+	}
+	if at.Before(c.ValidFrom) {
+		return 0, &ErrCouponNotApplicable{Code: c.Code, Reason: "coupon is not valid yet"}
+	}
+	if c.ValidTo != nil && !at.Before(*c.ValidTo) {
+		return 0, &ErrCouponNotApplicable{Code: c.Code, Reason: "coupon has expired"}
+	}
+
+	var discount float64
+	switch c.Kind {
+	case KindPercentage:
+		discount = roundCents(amount * c.Value / 100)
+	case KindFixed:
+		if !strings.EqualFold(c.Currency, currency) {
+			return 0, &ErrCouponNotApplicable{Code: c.Code, Reason: "coupon is for " + c.Currency}
+		}
+		discount = c.Value
+	}
+
+	// A discount never exceeds the amount it is applied to.
+	return math.Min(discount, amount), nil
+}
+
+// CheckLimits verifies the usage limits against the current redemption counts.
+func (c *Coupon) CheckLimits(total, byUser int) error {
+	if c.MaxRedemptions > 0 && total >= c.MaxRedemptions {
+		return &ErrCouponNotApplicable{Code: c.Code, Reason: "coupon has been fully redeemed"}
+	}
+	if c.MaxRedemptionsPerUser > 0 && byUser >= c.MaxRedemptionsPerUser {
+		return &ErrCouponNotApplicable{Code: c.Code, Reason: "coupon has already been used by this user"}
+	}
+	return nil
+}
+
+func (c *Coupon) validate() error {
+	if c.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidCoupon)
+	}
+	switch c.Kind {
+	case KindPercentage:
+		if c.Value <= 0 || c.Value > 100 {
+			return fmt.Errorf("%w: percentage must be in (0, 100]", ErrInvalidCoupon)
+		}
+	case KindFixed:
+		if c.Value <= 0 {
+			return fmt.Errorf("%w: fixed discount must be greater than zero", ErrInvalidCoupon)
+		}
+		if len(c.Currency) != 3 {
+			return fmt.Errorf("%w: fixed discount requires a 3-letter currency", ErrInvalidCoupon)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be %q or %q", ErrInvalidCoupon, KindPercentage, KindFixed)
+	}
+	if c.ValidTo != nil && !c.ValidTo.After(c.ValidFrom) {
+		return fmt.Errorf("%w: validTo must be after validFrom", ErrInvalidCoupon)
+	}
+	if c.MaxRedemptions < 0 || c.MaxRedemptionsPerUser < 0 {
+		return fmt.Errorf("%w: usage limits must not be negative", ErrInvalidCoupon)
+	}
+	return nil
+}
+
+// NormalizeCode returns the canonical form of a coupon code.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
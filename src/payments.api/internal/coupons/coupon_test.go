@@ -0,0 +1,66 @@
+package coupons_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/coupons"
+)
+
+func TestCoupon_PercentageDiscount(t *testing.T) {
+	c, err := coupons.NewCoupon("welcome10", coupons.KindPercentage, 10, "", time.Now().Add(-time.Hour), nil, 0, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Code != "WELCOME10" {
+		t.Errorf("expected normalized code WELCOME10, got %s", c.Code)
+	}
+	discount, err := c.Discount(85.50, "usd", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if discount != 8.55 {
+		t.Errorf("expected discount 8.55, got %v", discount)
+	}
+}
+
+func TestCoupon_FixedDiscount_CappedAtAmount(t *testing.T) {
+	c, _ := coupons.NewCoupon("VOUCHER50", coupons.KindFixed, 50, "usd", time.Now().Add(-time.Hour), nil, 0, 0)
+	discount, err := c.Discount(30, "usd", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if discount != 30 {
+		t.Errorf("expected discount capped at 30, got %v", discount)
+	}
+	if _, err := c.Discount(30, "eur", time.Now()); err == nil {
+		t.Error("expected error for fixed coupon in another currency")
+	}
+}
+
+func TestCoupon_Expired(t *testing.T) {
+	to := time.Now().Add(-time.Minute)
+	c, _ := coupons.NewCoupon("OLD", coupons.KindPercentage, 10, "", time.Now().Add(-time.Hour), &to, 0, 0)
+	if _, err := c.Discount(100, "usd", time.Now()); err == nil {
+		t.Error("expected error for expired coupon")
+	}
+}
+
+func TestCoupon_CheckLimits(t *testing.T) {
+	c, _ := coupons.NewCoupon("STAFF", coupons.KindPercentage, 20, "", time.Now(), nil, 100, 1)
+	if err := c.CheckLimits(5, 0); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := c.CheckLimits(5, 1); err == nil {
+		t.Error("expected error when the user already redeemed the coupon")
+	}
+	if err := c.CheckLimits(100, 0); err == nil {
+		t.Error("expected error when the coupon is fully redeemed")
+	}
+}
+
+func TestNewCoupon_InvalidPercentage(t *testing.T) {
+	if _, err := coupons.NewCoupon("TOO_MUCH", coupons.KindPercentage, 150, "", time.Now(), nil, 0, 0); err == nil {
+		t.Error("expected error for percentage above 100")
+	}
+}
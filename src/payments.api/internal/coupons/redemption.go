@@ -0,0 +1,28 @@
+package coupons
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Redemption statuses.
+// Transitions: Reserved → Redeemed (payment completed) | Released (payment failed)
+const (
+	RedemptionReserved = "reserved"
+	RedemptionRedeemed = "redeemed"
+	RedemptionReleased = "released"
+)
+
+// Redemption records one use of a coupon by one payment.
+type Redemption struct {
+	ID             uuid.UUID
+	CouponID       uuid.UUID
+	PaymentID      uuid.UUID
+	UserID         string
+	DiscountAmount float64
+	Currency       string
+	Status         string
+	CreatedAt      time.Time
+	SettledAt      *time.Time
+}
@@ -0,0 +1,258 @@
+package coupons
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository defines the persistence contract for coupons and their redemptions.
+type Repository interface {
+	Create(ctx context.Context, coupon *Coupon) error
+	Update(ctx context.Context, coupon *Coupon) error
+	FindByID(ctx context.Context, id uuid.UUID) (*Coupon, error)
+	FindByCode(ctx context.Context, code string) (*Coupon, error)
+	List(ctx context.Context) ([]*Coupon, error)
+	// CountRedemptions counts the reserved and redeemed uses of a coupon, in total and by one user.
+	CountRedemptions(ctx context.Context, couponID uuid.UUID, userID string) (total, byUser int, err error)
+	// Redemptions returns all redemptions of a coupon, newest first.
+	Redemptions(ctx context.Context, couponID uuid.UUID) ([]*Redemption, error)
+}
+
+// RedemptionStore writes redemptions inside the payment's transaction, so a
+// coupon use exists exactly when the discounted payment does.
+type RedemptionStore interface {
+	// Reserve locks the coupon, re-checks its usage limits and records the redemption.
+	Reserve(ctx context.Context, tx pgx.Tx, redemption *Redemption) error
+	// Settle moves the payment's reserved redemption to Redeemed or Released.
+	Settle(ctx context.Context, tx pgx.Tx, paymentID uuid.UUID, status string) error
+}
+
+// PostgresRepository implements Repository and RedemptionStore using PostgreSQL.
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new PostgreSQL-backed coupon repository.
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+const couponColumns = `id, code, kind, value, COALESCE(currency, ''), valid_from, valid_to,
+	max_redemptions, max_redemptions_per_user, active, created_at, updated_at`
+
+// Create persists a new coupon.
+func (r *PostgresRepository) Create(ctx context.Context, c *Coupon) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO coupons (id, code, kind, value, currency, valid_from, valid_to, max_redemptions, max_redemptions_per_user, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		c.ID,
+		c.Code,
+		c.Kind,
+		c.Value,
+		nilIfEmpty(c.Currency),
+		c.ValidFrom,
+		c.ValidTo,
+		c.MaxRedemptions,
+		c.MaxRedemptionsPerUser,
+		c.Active,
+		c.CreatedAt,
+		c.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert coupon: %w", err)
+	}
+	return nil
+}
+
+// Update persists changes to a coupon's validity window, limits and active flag.
+func (r *PostgresRepository) Update(ctx context.Context, c *Coupon) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE coupons SET
+			valid_from = $2,
+			valid_to = $3,
+			max_redemptions = $4,
+			max_redemptions_per_user = $5,
+			active = $6,
+			updated_at = $7
+		WHERE id = $1`,
+		c.ID,
+		c.ValidFrom,
+		c.ValidTo,
+		c.MaxRedemptions,
+		c.MaxRedemptionsPerUser,
+		c.Active,
+		c.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update coupon: %w", err)
+	}
+	return nil
+}
+
+// FindByID retrieves a coupon by its primary key.
+func (r *PostgresRepository) FindByID(ctx context.Context, id uuid.UUID) (*Coupon, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+couponColumns+` FROM coupons WHERE id = $1`, id)
+	return scanCoupon(row)
+}
+
+// FindByCode retrieves a coupon by its (case-insensitive) code.
+func (r *PostgresRepository) FindByCode(ctx context.Context, code string) (*Coupon, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+couponColumns+` FROM coupons WHERE code = $1`, NormalizeCode(code))
+	return scanCoupon(row)
+}
+
+// List retrieves all coupons, newest first.
+func (r *PostgresRepository) List(ctx context.Context) ([]*Coupon, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+couponColumns+` FROM coupons ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("query coupons: %w", err)
+	}
+	defer rows.Close()
+
+	var coupons []*Coupon
+	for rows.Next() {
+		c, err := scanCoupon(rows)
+		if err != nil {
+			return nil, err
+		}
+		coupons = append(coupons, c)
+	}
+	return coupons, rows.Err()
+}
+
+// CountRedemptions counts the uses of a coupon that still hold a slot.
+func (r *PostgresRepository) CountRedemptions(ctx context.Context, couponID uuid.UUID, userID string) (int, int, error) {
+	return countRedemptions(ctx, r.pool, couponID, userID)
+}
+
+// Redemptions retrieves all redemptions of a coupon.
+func (r *PostgresRepository) Redemptions(ctx context.Context, couponID uuid.UUID) ([]*Redemption, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, coupon_id, payment_id, user_id, discount_amount, currency, status, created_at, settled_at
+		FROM coupon_redemptions
+		WHERE coupon_id = $1
+		ORDER BY created_at DESC`, couponID)
+	if err != nil {
+		return nil, fmt.Errorf("query redemptions: %w", err)
+	}
+	defer rows.Close()
+
+	var redemptions []*Redemption
+	for rows.Next() {
+		var rd Redemption
+		if err := rows.Scan(
+			&rd.ID, &rd.CouponID, &rd.PaymentID, &rd.UserID, &rd.DiscountAmount,
+			&rd.Currency, &rd.Status, &rd.CreatedAt, &rd.SettledAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan redemption: %w", err)
+		}
+		redemptions = append(redemptions, &rd)
+	}
+	return redemptions, rows.Err()
+}
+
+// Reserve records a redemption within the payment's transaction.
+// The coupon row is locked so concurrent payments cannot both take the last use.
+func (r *PostgresRepository) Reserve(ctx context.Context, tx pgx.Tx, rd *Redemption) error {
+	coupon, err := scanCoupon(tx.QueryRow(ctx,
+		`SELECT `+couponColumns+` FROM coupons WHERE id = $1 FOR UPDATE`, rd.CouponID))
+	if err != nil {
+		return err
+	}
+	if coupon == nil {
+		return &ErrCouponNotFound{Code: rd.CouponID.String()}
+	}
+
+	total, byUser, err := countRedemptions(ctx, tx, rd.CouponID, rd.UserID)
+	if err != nil {
+		return err
+	}
+	if err := coupon.CheckLimits(total, byUser); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO coupon_redemptions (id, coupon_id, payment_id, user_id, discount_amount, currency, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		rd.ID,
+		rd.CouponID,
+		rd.PaymentID,
+		rd.UserID,
+		rd.DiscountAmount,
+		rd.Currency,
+		RedemptionReserved,
+		rd.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert redemption: %w", err)
+	}
+	return nil
+}
+
+// Settle moves a reserved redemption to its final status. Payments without
+// a redemption, or whose redemption is already settled, are left untouched.
+func (r *PostgresRepository) Settle(ctx context.Context, tx pgx.Tx, paymentID uuid.UUID, status string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE coupon_redemptions SET status = $2, settled_at = $3
+		WHERE payment_id = $1 AND status = $4`,
+		paymentID, status, time.Now().UTC(), RedemptionReserved)
+	if err != nil {
+		return fmt.Errorf("settle redemption: %w", err)
+	}
+	return nil
+}
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func countRedemptions(ctx context.Context, q querier, couponID uuid.UUID, userID string) (int, int, error) {
+	var total, byUser int
+	err := q.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE user_id = $2)
+		FROM coupon_redemptions
+		WHERE coupon_id = $1 AND status IN ($3, $4)`,
+		couponID, userID, RedemptionReserved, RedemptionRedeemed).Scan(&total, &byUser)
+	if err != nil {
+		return 0, 0, fmt.Errorf("count redemptions: %w", err)
+	}
+	return total, byUser, nil
+}
+
+func scanCoupon(row pgx.Row) (*Coupon, error) {
+	var c Coupon
+	err := row.Scan(
+		&c.ID,
+		&c.Code,
+		&c.Kind,
+		&c.Value,
+		&c.Currency,
+		&c.ValidFrom,
+		&c.ValidTo,
+		&c.MaxRedemptions,
+		&c.MaxRedemptionsPerUser,
+		&c.Active,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan coupon: %w", err)
+	}
+	return &c, nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,247 @@
+package coupons
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/openapi"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// CouponResult is the read model returned by the coupon endpoints.
+type CouponResult struct {
+	ID                    string     `json:"id"`
+	Code                  string     `json:"code"`
+	Kind                  string     `json:"kind"`
+	Value                 float64    `json:"value"`
+	Currency              string     `json:"currency,omitempty"`
+	ValidFrom             time.Time  `json:"validFrom"`
+	ValidTo               *time.Time `json:"validTo,omitempty"`
+	MaxRedemptions        int        `json:"maxRedemptions"`
+	MaxRedemptionsPerUser int        `json:"maxRedemptionsPerUser"`
+	Active                bool       `json:"active"`
+	CreatedAt             time.Time  `json:"createdAt"`
+	UpdatedAt             *time.Time `json:"updatedAt,omitempty"`
+}
+
+// RedemptionResult is a coupon use returned by the redemptions endpoint.
+type RedemptionResult struct {
+	PaymentID      string     `json:"paymentId"`
+	UserID         string     `json:"userId"`
+	DiscountAmount float64    `json:"discountAmount"`
+	Currency       string     `json:"currency"`
+	Status         string     `json:"status"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	SettledAt      *time.Time `json:"settledAt,omitempty"`
+}
+
+type createCouponRequest struct {
+	Code                  string     `json:"code"                  binding:"required"`
+	Kind                  string     `json:"kind"                  binding:"required,oneof=percentage fixed"`
+	Value                 float64    `json:"value"                 binding:"required,gt=0"`
+	Currency              string     `json:"currency"              binding:"omitempty,len=3"`
+	ValidFrom             *time.Time `json:"validFrom"`
+	ValidTo               *time.Time `json:"validTo"`
+	MaxRedemptions        int        `json:"maxRedemptions"        binding:"gte=0"`
+	MaxRedemptionsPerUser int        `json:"maxRedemptionsPerUser" binding:"gte=0"`
+}
+
+type updateCouponRequest struct {
+	ValidFrom             *time.Time `json:"validFrom"`
+	ValidTo               *time.Time `json:"validTo"`
+	MaxRedemptions        int        `json:"maxRedemptions"        binding:"gte=0"`
+	MaxRedemptionsPerUser int        `json:"maxRedemptionsPerUser" binding:"gte=0"`
+	Active                *bool      `json:"active"                binding:"required"`
+}
+
+// RegisterRoutes mounts the coupon administration endpoints on the group.
+//
+//	GET  /                 – list coupons
+//	POST /                 – create a coupon
+//	GET  /:id              – get by ID
+//	PUT  /:id              – change validity window, usage limits, active flag
+//	GET  /:id/redemptions  – coupon uses
+func RegisterRoutes(rg *gin.RouterGroup, repo Repository) {
+	rg.GET("", func(c *gin.Context) {
+		coupons, err := repo.List(c.Request.Context())
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		results := make([]*CouponResult, 0, len(coupons))
+		for _, cp := range coupons {
+			results = append(results, toResult(cp))
+		}
+		c.JSON(http.StatusOK, results)
+	})
+
+	rg.POST("", func(c *gin.Context) {
+		var req createCouponRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		from := time.Now().UTC()
+		if req.ValidFrom != nil {
+			from = *req.ValidFrom
+		}
+		coupon, err := NewCoupon(req.Code, req.Kind, req.Value, req.Currency, from, req.ValidTo,
+			req.MaxRedemptions, req.MaxRedemptionsPerUser)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		existing, err := repo.FindByCode(c.Request.Context(), coupon.Code)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		if existing != nil {
+			problem.Write(c, problem.New(http.StatusConflict, "coupon_code_taken", "coupon code already exists"))
+			return
+		}
+
+		if err := repo.Create(c.Request.Context(), coupon); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, toResult(coupon))
+	})
+
+	rg.GET("/:id", func(c *gin.Context) {
+		coupon, ok := loadCoupon(c, repo)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, toResult(coupon))
+	})
+
+	rg.PUT("/:id", func(c *gin.Context) {
+		var req updateCouponRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		coupon, ok := loadCoupon(c, repo)
+		if !ok {
+			return
+		}
+
+		from := coupon.ValidFrom
+		if req.ValidFrom != nil {
+			from = *req.ValidFrom
+		}
+		if err := coupon.Reconfigure(from, req.ValidTo, req.MaxRedemptions, req.MaxRedemptionsPerUser, *req.Active); err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		if err := repo.Update(c.Request.Context(), coupon); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toResult(coupon))
+	})
+
+	rg.GET("/:id/redemptions", func(c *gin.Context) {
+		coupon, ok := loadCoupon(c, repo)
+		if !ok {
+			return
+		}
+
+		redemptions, err := repo.Redemptions(c.Request.Context(), coupon.ID)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		results := make([]RedemptionResult, 0, len(redemptions))
+		for _, rd := range redemptions {
+			results = append(results, RedemptionResult{
+				PaymentID:      rd.PaymentID.String(),
+				UserID:         rd.UserID,
+				DiscountAmount: rd.DiscountAmount,
+				Currency:       rd.Currency,
+				Status:         rd.Status,
+				CreatedAt:      rd.CreatedAt,
+				SettledAt:      rd.SettledAt,
+			})
+		}
+		c.JSON(http.StatusOK, results)
+	})
+}
+
+// Routes declares the endpoints RegisterRoutes mounts.
+func Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "", Summary: "List coupons", Response: []*CouponResult{}},
+		{Method: http.MethodPost, Path: "", Summary: "Create a coupon",
+			Request: createCouponRequest{}, Response: CouponResult{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusConflict}},
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get a coupon",
+			Response: CouponResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/:id", Summary: "Change the validity window, usage limits or active flag of a coupon",
+			Request: updateCouponRequest{}, Response: CouponResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/:id/redemptions", Summary: "Uses of a coupon",
+			Response: []RedemptionResult{}, Errors: []int{http.StatusNotFound}},
+	}
+}
+
+// Problem maps the package's errors to problem responses.
+func Problem(err error) *problem.Problem {
+	var notFound *ErrCouponNotFound
+	var notApplicable *ErrCouponNotApplicable
+	switch {
+	case errors.Is(err, ErrInvalidCoupon):
+		return problem.New(http.StatusBadRequest, "invalid_coupon", err.Error())
+	case errors.As(err, &notFound):
+		return problem.New(http.StatusNotFound, "coupon_not_found", notFound.Error())
+	case errors.As(err, &notApplicable):
+		return problem.New(http.StatusUnprocessableEntity, "coupon_not_applicable", notApplicable.Error())
+	}
+	return nil
+}
+
+// loadCoupon resolves the :id route parameter, writing the error response on failure.
+func loadCoupon(c *gin.Context, repo Repository) (*Coupon, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		problem.Write(c, problem.Invalid("id", "uuid", "invalid coupon id"))
+		return nil, false
+	}
+
+	coupon, err := repo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		problem.Write(c, err)
+		return nil, false
+	}
+	if coupon == nil {
+		problem.Write(c, &ErrCouponNotFound{Code: id.String()})
+		return nil, false
+	}
+	return coupon, true
+}
+
+func toResult(c *Coupon) *CouponResult {
+	return &CouponResult{
+		ID:                    c.ID.String(),
+		Code:                  c.Code,
+		Kind:                  c.Kind,
+		Value:                 c.Value,
+		Currency:              c.Currency,
+		ValidFrom:             c.ValidFrom,
+		ValidTo:               c.ValidTo,
+		MaxRedemptions:        c.MaxRedemptions,
+		MaxRedemptionsPerUser: c.MaxRedemptionsPerUser,
+		Active:                c.Active,
+		CreatedAt:             c.CreatedAt,
+		UpdatedAt:             c.UpdatedAt,
+	}
+}
@@ -0,0 +1,60 @@
+package coupons
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+// Service defines the coupon contract used when creating payments.
+type Service interface {
+	// Apply checks that code can be used by userID and returns the discount
+	// it grants on amount. The use itself is recorded later, atomically with
+	// the payment, through RedemptionStore.Reserve.
+	Apply(ctx context.Context, code, userID string, amount float64, currency string, at time.Time) (*domain.Discount, error)
+}
+
+// CouponService implements Service on top of the coupon repository.
+type CouponService struct {
+	repo Repository
+}
+
+// NewCouponService creates a new coupon service.
+func NewCouponService(repo Repository) *CouponService {
+	return &CouponService{repo: repo}
+}
+
+// Apply checks the coupon and computes its discount.
+func (s *CouponService) Apply(ctx context.Context, code, userID string, amount float64, currency string, at time.Time) (*domain.Discount, error) {
+	coupon, err := s.repo.FindByCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("find coupon: %w", err)
+	}
+	if coupon == nil {
+		return nil, &ErrCouponNotFound{Code: NormalizeCode(code)}
+	}
+
+	discount, err := coupon.Discount(amount, currency, at)
+	if err != nil {
+		return nil, err
+	}
+
+	// Early check for a clear error; the authoritative check happens under lock on Reserve.
+	total, byUser, err := s.repo.CountRedemptions(ctx, coupon.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := coupon.CheckLimits(total, byUser); err != nil {
+		return nil, err
+	}
+
+	return &domain.Discount{
+		CouponID: coupon.ID,
+		Code:     coupon.Code,
+		Kind:     coupon.Kind,
+		Value:    coupon.Value,
+		Amount:   discount,
+	}, nil
+}
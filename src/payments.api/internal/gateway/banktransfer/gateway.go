@@ -0,0 +1,130 @@
+package banktransfer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/smart-health/payments-api/internal/gateway"
+)
+
+// ProviderName identifies bank transfers in routing rules and on payments.
+const ProviderName = "bank_transfer"
+
+// Account is the bank account patients transfer to.
+type Account struct {
+	IBAN        string
+	Beneficiary string
+}
+
+// Gateway implements gateway.PaymentGateway for manual bank transfers.
+// A transaction ID is a transfer reference.
+type Gateway struct {
+	repo    Repository
+	account Account
+}
+
+// NewGateway creates the bank transfer payment gateway.
+func NewGateway(repo Repository, account Account) *Gateway {
+	return &Gateway{repo: repo, account: account}
+}
+
+// Name returns "bank_transfer".
+func (g *Gateway) Name() string { return ProviderName }
+
+// Authorize records the expected transfer and returns the payment
+// instructions. Transfers are always captured on receipt, so req.Capture is
+// ignored. Authorizing a payment twice returns its existing transfer.
+func (g *Gateway) Authorize(ctx context.Context, req gateway.AuthorizeRequest) (*gateway.Transaction, error) {
+	existing, err := g.repo.FindByPaymentID(ctx, req.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing.Transaction(g.instructions(existing)), nil
+	}
+
+	t, err := NewTransfer(req.PaymentID, req.Amount, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.repo.Create(ctx, t); err != nil {
+		return nil, err
+	}
+	return t.Transaction(g.instructions(t)), nil
+}
+
+// Capture is not supported: funds are captured when the transfer is received.
+func (g *Gateway) Capture(ctx context.Context, transactionID string, amount float64) (*gateway.Transaction, error) {
+	return nil, fmt.Errorf("bank transfer capture: %w", gateway.ErrUnsupported)
+}
+
+// Refund records the refund on the transfer; the payout is made manually,
+// so the refund stays pending. The refund is identified by the transfer reference.
+func (g *Gateway) Refund(ctx context.Context, transactionID string, amount float64, reason, reference string) (*gateway.Refund, error) {
+	t, err := g.load(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Refund(amount); err != nil {
+		return nil, err
+	}
+	if err := g.repo.Update(ctx, t); err != nil {
+		return nil, err
+	}
+	return &gateway.Refund{ID: t.Reference, TransactionID: t.Reference, Amount: amount, Status: "pending"}, nil
+}
+
+// Void stops waiting for a transfer that has not arrived.
+func (g *Gateway) Void(ctx context.Context, transactionID string) (*gateway.Transaction, error) {
+	t, err := g.load(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Cancel(); err != nil {
+		return nil, err
+	}
+	if err := g.repo.Update(ctx, t); err != nil {
+		return nil, err
+	}
+	return t.Transaction(""), nil
+}
+
+// Status returns the state of the transfer.
+func (g *Gateway) Status(ctx context.Context, transactionID string) (*gateway.Transaction, error) {
+	t, err := g.load(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	return t.Transaction(g.instructions(t)), nil
+}
+
+// Receive records the funds of a transfer arriving.
+func (g *Gateway) Receive(ctx context.Context, reference string, amount float64) (*Transfer, error) {
+	t, err := g.load(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Receive(amount); err != nil {
+		return nil, err
+	}
+	if err := g.repo.Update(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (g *Gateway) load(ctx context.Context, reference string) (*Transfer, error) {
+	t, err := g.repo.FindByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, &ErrTransferNotFound{Reference: reference}
+	}
+	return t, nil
+}
+
+func (g *Gateway) instructions(t *Transfer) string {
+	return fmt.Sprintf("Transfer %.2f %s to %s (IBAN %s) quoting reference %s.",
+		t.Amount, t.Currency, g.account.Beneficiary, g.account.IBAN, t.Reference)
+}
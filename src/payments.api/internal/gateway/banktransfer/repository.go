@@ -0,0 +1,106 @@
+package banktransfer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository defines the persistence contract for bank transfers.
+type Repository interface {
+	Create(ctx context.Context, t *Transfer) error
+	Update(ctx context.Context, t *Transfer) error
+	FindByReference(ctx context.Context, reference string) (*Transfer, error)
+	FindByPaymentID(ctx context.Context, paymentID uuid.UUID) (*Transfer, error)
+}
+
+// PostgresRepository implements Repository using PostgreSQL.
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new PostgreSQL-backed bank transfer repository.
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+const transferColumns = `reference, payment_id, amount, currency, status, received_amount, refunded_amount, created_at, updated_at`
+
+// Create persists a new expected transfer.
+func (r *PostgresRepository) Create(ctx context.Context, t *Transfer) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO bank_transfers (reference, payment_id, amount, currency, status, received_amount, refunded_amount, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		t.Reference,
+		t.PaymentID,
+		t.Amount,
+		t.Currency,
+		t.Status,
+		t.ReceivedAmount,
+		t.RefundedAmount,
+		t.CreatedAt,
+		t.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert bank transfer: %w", err)
+	}
+	return nil
+}
+
+// Update persists the status and amounts of a transfer.
+func (r *PostgresRepository) Update(ctx context.Context, t *Transfer) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE bank_transfers SET
+			status = $2,
+			received_amount = $3,
+			refunded_amount = $4,
+			updated_at = $5
+		WHERE reference = $1`,
+		t.Reference,
+		t.Status,
+		t.ReceivedAmount,
+		t.RefundedAmount,
+		t.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update bank transfer: %w", err)
+	}
+	return nil
+}
+
+// FindByReference retrieves a transfer by the reference the patient quotes.
+func (r *PostgresRepository) FindByReference(ctx context.Context, reference string) (*Transfer, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+transferColumns+` FROM bank_transfers WHERE reference = UPPER($1)`, reference)
+	return scanTransfer(row)
+}
+
+// FindByPaymentID retrieves the transfer expected for a payment.
+func (r *PostgresRepository) FindByPaymentID(ctx context.Context, paymentID uuid.UUID) (*Transfer, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+transferColumns+` FROM bank_transfers WHERE payment_id = $1`, paymentID)
+	return scanTransfer(row)
+}
+
+func scanTransfer(row pgx.Row) (*Transfer, error) {
+	var t Transfer
+	err := row.Scan(
+		&t.Reference,
+		&t.PaymentID,
+		&t.Amount,
+		&t.Currency,
+		&t.Status,
+		&t.ReceivedAmount,
+		&t.RefundedAmount,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan bank transfer: %w", err)
+	}
+	return &t, nil
+}
@@ -0,0 +1,105 @@
+package banktransfer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/openapi"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// TransferResult is the read model returned by the bank transfer endpoints.
+type TransferResult struct {
+	Reference      string     `json:"reference"`
+	PaymentID      string     `json:"paymentId"`
+	Amount         float64    `json:"amount"`
+	Currency       string     `json:"currency"`
+	Status         string     `json:"status"`
+	ReceivedAmount float64    `json:"receivedAmount"`
+	RefundedAmount float64    `json:"refundedAmount"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"`
+}
+
+type receiptRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+// RegisterRoutes mounts the bank transfer back-office endpoints on the group.
+// onReceived is called with the payment of a received transfer, to complete it.
+//
+//	GET  /:reference           – get transfer
+//	POST /:reference/receipts  – record the funds as received
+func RegisterRoutes(rg *gin.RouterGroup, g *Gateway, onReceived func(ctx context.Context, paymentID uuid.UUID) error) {
+	rg.GET("/:reference", func(c *gin.Context) {
+		t, err := g.load(c.Request.Context(), c.Param("reference"))
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toResult(t))
+	})
+
+	rg.POST("/:reference/receipts", func(c *gin.Context) {
+		var req receiptRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		t, err := g.Receive(c.Request.Context(), c.Param("reference"), req.Amount)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		// The transfer is recorded either way; a failed sync is retried by confirming the payment
+		if err := onReceived(c.Request.Context(), t.PaymentID); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toResult(t))
+	})
+}
+
+// Routes declares the endpoints RegisterRoutes mounts.
+func Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/:reference", Summary: "Get a bank transfer",
+			Response: TransferResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: "/:reference/receipts", Summary: "Record the funds of a bank transfer as received",
+			Description: "Completes the payment once the expected amount has been received.",
+			Request:     receiptRequest{}, Response: TransferResult{},
+			Errors: []int{http.StatusNotFound, http.StatusConflict}},
+	}
+}
+
+// Problem maps the package's errors to problem responses.
+func Problem(err error) *problem.Problem {
+	var notFound *ErrTransferNotFound
+	switch {
+	case errors.As(err, &notFound):
+		return problem.New(http.StatusNotFound, "bank_transfer_not_found", notFound.Error())
+	case errors.Is(err, ErrInvalidTransfer):
+		return problem.New(http.StatusConflict, "invalid_bank_transfer", err.Error())
+	}
+	return nil
+}
+
+func toResult(t *Transfer) *TransferResult {
+	return &TransferResult{
+		Reference:      t.Reference,
+		PaymentID:      t.PaymentID.String(),
+		Amount:         t.Amount,
+		Currency:       t.Currency,
+		Status:         t.Status,
+		ReceivedAmount: t.ReceivedAmount,
+		RefundedAmount: t.RefundedAmount,
+		CreatedAt:      t.CreatedAt,
+		UpdatedAt:      t.UpdatedAt,
+	}
+}
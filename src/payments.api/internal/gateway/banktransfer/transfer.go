@@ -0,0 +1,150 @@
+package banktransfer
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway"
+)
+
+// Transfer statuses.
+const (
+	StatusAwaitingFunds = "awaiting_funds"
+	StatusReceived      = "received"
+	StatusCanceled      = "canceled"
+)
+
+// ErrInvalidTransfer is returned when a transfer cannot change as requested.
+var ErrInvalidTransfer = errors.New("invalid bank transfer operation")
+
+// ErrTransferNotFound is returned when no transfer carries a reference.
+type ErrTransferNotFound struct {
+	Reference string
+}
+
+func (e *ErrTransferNotFound) Error() string {
+	return fmt.Sprintf("bank transfer %s was not found", e.Reference)
+}
+
+// -----------------------------------------------------------------------
+// Transfer
+//
+// Architectural Decision: A bank transfer is paid by the patient outside any
+// API: Authorize only records the expected transfer and hands out a short
+// reference the patient quotes. The reference is the transaction ID. Funds are
+// matched by an operator (or a bank statement import) reporting the receipt,
+// which then syncs the payment. Refunds are recorded for manual payout.
+// -----------------------------------------------------------------------
+
+// Transfer is an expected or received bank transfer for one payment.
+type Transfer struct {
+	Reference      string // quoted by the patient, e.g. "SH-7K2QF9XM"
+	PaymentID      uuid.UUID
+	Amount         float64
+	Currency       string
+	Status         string
+	ReceivedAmount float64
+	RefundedAmount float64
+	CreatedAt      time.Time
+	UpdatedAt      *time.Time
+}
+
+// NewTransfer records a transfer expected for a payment.
+func NewTransfer(paymentID uuid.UUID, amount float64, currency string) (*Transfer, error) {
+	reference, err := newReference()
+	if err != nil {
+		return nil, err
+	}
+	return &Transfer{
+		Reference: reference,
+		PaymentID: paymentID,
+		Amount:    amount,
+		Currency:  strings.ToUpper(currency),
+		Status:    StatusAwaitingFunds,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Receive records the funds arriving. Less than the expected amount is
+// rejected: partial transfers are resolved by the operator, not the patient's payment.
+func (t *Transfer) Receive(amount float64) error {
+	if t.Status != StatusAwaitingFunds {
+		return fmt.Errorf("%w: transfer %s is %s", ErrInvalidTransfer, t.Reference, t.Status)
+	}
+	if cents(amount) < cents(t.Amount) {
+		return fmt.Errorf("%w: received %.2f, expected %.2f %s", ErrInvalidTransfer, amount, t.Amount, t.Currency)
+	}
+	t.ReceivedAmount = amount
+	t.Status = StatusReceived
+	t.touch()
+	return nil
+}
+
+// Cancel stops waiting for a transfer that has not arrived.
+func (t *Transfer) Cancel() error {
+	if t.Status == StatusCanceled {
+		return nil
+	}
+	if t.Status != StatusAwaitingFunds {
+		return fmt.Errorf("%w: transfer %s is %s", ErrInvalidTransfer, t.Reference, t.Status)
+	}
+	t.Status = StatusCanceled
+	t.touch()
+	return nil
+}
+
+// Refund records an amount to pay back to the patient.
+func (t *Transfer) Refund(amount float64) error {
+	if t.Status != StatusReceived {
+		return fmt.Errorf("%w: transfer %s is %s", ErrInvalidTransfer, t.Reference, t.Status)
+	}
+	if amount <= 0 || cents(t.RefundedAmount+amount) > cents(t.ReceivedAmount) {
+		return fmt.Errorf("%w: refund exceeds the received amount", ErrInvalidTransfer)
+	}
+	t.RefundedAmount = math.Round((t.RefundedAmount+amount)*100) / 100
+	t.touch()
+	return nil
+}
+
+// Transaction is the provider-neutral state of the transfer.
+func (t *Transfer) Transaction(instructions string) *gateway.Transaction {
+	tx := &gateway.Transaction{ID: t.Reference}
+	switch t.Status {
+	case StatusAwaitingFunds:
+		tx.Status = gateway.StatusRequiresAction
+		tx.Instructions = instructions
+	case StatusReceived:
+		tx.Status = gateway.StatusSucceeded
+	default:
+		tx.Status = gateway.StatusCanceled
+	}
+	return tx
+}
+
+func (t *Transfer) touch() {
+	now := time.Now().UTC()
+	t.UpdatedAt = &now
+}
+
+func cents(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+// referenceAlphabet leaves out characters easily confused when typed into a banking app.
+const referenceAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func newReference() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate transfer reference: %w", err)
+	}
+	for i := range b {
+		b[i] = referenceAlphabet[int(b[i])%len(referenceAlphabet)]
+	}
+	return "SH-" + string(b), nil
+}
@@ -0,0 +1,60 @@
+package banktransfer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway"
+	"github.com/smart-health/payments-api/internal/gateway/banktransfer"
+)
+
+func TestTransfer_Lifecycle(t *testing.T) {
+	tr, err := banktransfer.NewTransfer(uuid.New(), 120.50, "eur")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(tr.Reference, "SH-") || len(tr.Reference) != 11 {
+		t.Errorf("unexpected reference %q", tr.Reference)
+	}
+
+	tx := tr.Transaction("pay us")
+	if tx.Status != gateway.StatusRequiresAction || tx.Instructions != "pay us" || tx.ID != tr.Reference {
+		t.Errorf("expected requires_action with instructions, got %+v", tx)
+	}
+
+	if err := tr.Receive(100); !errors.Is(err, banktransfer.ErrInvalidTransfer) {
+		t.Errorf("expected underpayment to be rejected, got %v", err)
+	}
+	if err := tr.Receive(120.50); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx := tr.Transaction("pay us"); tx.Status != gateway.StatusSucceeded || tx.Instructions != "" {
+		t.Errorf("expected succeeded without instructions, got %+v", tx)
+	}
+	if err := tr.Cancel(); !errors.Is(err, banktransfer.ErrInvalidTransfer) {
+		t.Errorf("expected a received transfer not to cancel, got %v", err)
+	}
+}
+
+func TestTransfer_Refund(t *testing.T) {
+	tr, _ := banktransfer.NewTransfer(uuid.New(), 50, "eur")
+	if err := tr.Refund(10); !errors.Is(err, banktransfer.ErrInvalidTransfer) {
+		t.Errorf("expected refund before receipt to fail, got %v", err)
+	}
+
+	_ = tr.Receive(50)
+	if err := tr.Refund(30); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := tr.Refund(20.01); !errors.Is(err, banktransfer.ErrInvalidTransfer) {
+		t.Errorf("expected refund beyond the received amount to fail, got %v", err)
+	}
+	if err := tr.Refund(20); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tr.RefundedAmount != 50 {
+		t.Errorf("expected 50 refunded, got %v", tr.RefundedAmount)
+	}
+}
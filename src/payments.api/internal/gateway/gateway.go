@@ -0,0 +1,114 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// -----------------------------------------------------------------------
+// PaymentGateway
+//
+// Architectural Decision: Payment slices talk to providers only through this
+// interface, in the major currency unit and with provider-neutral statuses.
+// Each adapter translates to its provider's API (Stripe PaymentIntents, bank
+// transfer references, ...) and back, so adding a provider never touches the
+// payment aggregate or the slices. Which provider charges a payment is decided
+// once, at creation, by the routing rules (see Router) and stored on the payment.
+// -----------------------------------------------------------------------
+
+// PaymentGateway is the contract every payment provider adapter implements.
+type PaymentGateway interface {
+	// Name identifies the provider; it is stored on each payment it charges.
+	Name() string
+	// Authorize starts charging a payment. With Capture set, funds are captured
+	// as soon as they are authorized. Declines are reported as a Failed
+	// transaction; errors mean the provider could not be reached or refused
+	// the request, and are transient when IsTemporary reports so.
+	Authorize(ctx context.Context, req AuthorizeRequest) (*Transaction, error)
+	// Capture collects an authorized amount (at most the authorized amount).
+	Capture(ctx context.Context, transactionID string, amount float64) (*Transaction, error)
+	// Refund returns part or all of a captured amount to the patient.
+	// reference identifies the refund, e.g. the payment ID and the refund's
+	// position: where the provider supports it, a refund retried with the
+	// same reference is issued once.
+	Refund(ctx context.Context, transactionID string, amount float64, reason, reference string) (*Refund, error)
+	// Void cancels a transaction that has not been captured.
+	Void(ctx context.Context, transactionID string) (*Transaction, error)
+	// Status fetches the current state of a transaction from the provider.
+	Status(ctx context.Context, transactionID string) (*Transaction, error)
+}
+
+// AuthorizeRequest describes the charge of one payment.
+type AuthorizeRequest struct {
+	PaymentID     uuid.UUID
+	AppointmentID uuid.UUID
+	UserID        string
+	Amount        float64 // in the major currency unit
+	Currency      string
+	LineItems     []LineItem
+	Metadata      map[string]string // provider-specific hints, forwarded where supported
+	Capture       bool              // capture immediately once authorized
+	// Attempt numbers the charges of the payment, from 0. Providers that
+	// deduplicate requests treat a request repeated within an attempt as
+	// the same charge, and a new attempt as a new one.
+	Attempt int
+}
+
+// LineItem is a billed service, forwarded to providers that display it.
+type LineItem struct {
+	Code        string
+	Description string
+	Quantity    int
+	Total       float64 // amount charged for the line, in the major currency unit
+}
+
+// Transaction statuses, common to all providers.
+const (
+	StatusRequiresAction = "requires_action" // waits for the patient (3-D Secure, card entry, bank transfer)
+	StatusPending        = "pending"         // submitted; the outcome is not known yet
+	StatusAuthorized     = "authorized"      // funds held, awaiting Capture
+	StatusSucceeded      = "succeeded"       // funds captured
+	StatusFailed         = "failed"          // declined; FailureReason says why
+	StatusCanceled       = "canceled"        // voided before capture
+)
+
+// Transaction is the provider-neutral state of a charge.
+type Transaction struct {
+	ID            string
+	Status        string
+	ClientSecret  string // lets the frontend complete a required action; never persisted
+	Instructions  string // what the patient must do, for actions outside the frontend
+	FailureReason string
+	FailureCode   string // provider reason of a decline or cancellation, e.g. "insufficient_funds"
+}
+
+// Refund is a refund issued against a transaction.
+type Refund struct {
+	ID            string
+	TransactionID string
+	Amount        float64
+	Status        string // provider refund status, e.g. "succeeded" or "pending"
+}
+
+// ErrUnsupported is returned by adapters for operations their provider does not offer.
+var ErrUnsupported = errors.New("operation not supported by payment provider")
+
+// IsTemporary reports whether a provider error is transient – a rate limit,
+// an outage, a timeout – so the operation may succeed when retried later.
+// Adapters mark such errors with a Temporary() bool method.
+func IsTemporary(err error) bool {
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
+
+// ErrUnknownProvider is returned when no adapter is registered under a name.
+type ErrUnknownProvider struct {
+	Name string
+}
+
+func (e *ErrUnknownProvider) Error() string {
+	return fmt.Sprintf("payment provider %q is not configured", e.Name)
+}
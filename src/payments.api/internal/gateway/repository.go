@@ -0,0 +1,132 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// RuleRepository defines the persistence contract for routing rules.
+type RuleRepository interface {
+	Create(ctx context.Context, rule *Rule) error
+	Update(ctx context.Context, rule *Rule) error
+	FindByID(ctx context.Context, id uuid.UUID) (*Rule, error)
+	List(ctx context.Context) ([]*Rule, error)
+	FindActive(ctx context.Context) ([]*Rule, error)
+}
+
+// PostgresRuleRepository implements RuleRepository using PostgreSQL.
+type PostgresRuleRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRuleRepository creates a new PostgreSQL-backed routing rule repository.
+func NewPostgresRuleRepository(pool *pgxpool.Pool) *PostgresRuleRepository {
+	return &PostgresRuleRepository{pool: pool}
+}
+
+const ruleColumns = `id, provider, COALESCE(currency, ''), COALESCE(country, ''), COALESCE(clinic_id, ''), active, created_at, updated_at`
+
+// Create persists a new routing rule.
+func (r *PostgresRuleRepository) Create(ctx context.Context, rule *Rule) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO payment_routing_rules (id, provider, currency, country, clinic_id, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		rule.ID,
+		rule.Provider,
+		nilIfEmpty(rule.Currency),
+		nilIfEmpty(rule.Country),
+		nilIfEmpty(rule.ClinicID),
+		rule.Active,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert routing rule: %w", err)
+	}
+	return nil
+}
+
+// Update persists changes to a routing rule.
+func (r *PostgresRuleRepository) Update(ctx context.Context, rule *Rule) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE payment_routing_rules SET
+			provider = $2,
+			active = $3,
+			updated_at = $4
+		WHERE id = $1`,
+		rule.ID,
+		rule.Provider,
+		rule.Active,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update routing rule: %w", err)
+	}
+	return nil
+}
+
+// FindByID retrieves a routing rule by its primary key.
+func (r *PostgresRuleRepository) FindByID(ctx context.Context, id uuid.UUID) (*Rule, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+ruleColumns+` FROM payment_routing_rules WHERE id = $1`, id)
+	return scanRule(row)
+}
+
+// List retrieves all routing rules, newest first.
+func (r *PostgresRuleRepository) List(ctx context.Context) ([]*Rule, error) {
+	return r.query(ctx, `SELECT `+ruleColumns+` FROM payment_routing_rules ORDER BY created_at DESC`)
+}
+
+// FindActive retrieves the rules considered when routing a payment.
+func (r *PostgresRuleRepository) FindActive(ctx context.Context) ([]*Rule, error) {
+	return r.query(ctx, `SELECT `+ruleColumns+` FROM payment_routing_rules WHERE active = true`)
+}
+
+func (r *PostgresRuleRepository) query(ctx context.Context, query string, args ...any) ([]*Rule, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query routing rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func scanRule(row pgx.Row) (*Rule, error) {
+	var rule Rule
+	err := row.Scan(
+		&rule.ID,
+		&rule.Provider,
+		&rule.Currency,
+		&rule.Country,
+		&rule.ClinicID,
+		&rule.Active,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan routing rule: %w", err)
+	}
+	return &rule, nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Selector chooses the provider of a new payment.
+type Selector interface {
+	Select(ctx context.Context, c Criteria) (string, error)
+}
+
+// Registry looks up the adapter of a provider by name.
+type Registry interface {
+	Get(name string) (PaymentGateway, error)
+}
+
+// Router implements Selector and Registry over the configured adapters and
+// the routing rules.
+type Router struct {
+	gateways map[string]PaymentGateway
+	rules    RuleRepository
+	fallback string
+}
+
+// NewRouter creates a router. Payments no rule matches go to the fallback provider.
+func NewRouter(rules RuleRepository, fallback string, gateways ...PaymentGateway) *Router {
+	r := &Router{gateways: make(map[string]PaymentGateway, len(gateways)), rules: rules, fallback: fallback}
+	for _, g := range gateways {
+		r.gateways[g.Name()] = g
+	}
+	return r
+}
+
+// Get returns the adapter registered under the name.
+func (r *Router) Get(name string) (PaymentGateway, error) {
+	g, ok := r.gateways[name]
+	if !ok {
+		return nil, &ErrUnknownProvider{Name: name}
+	}
+	return g, nil
+}
+
+// Providers lists the names of the configured adapters.
+func (r *Router) Providers() []string {
+	names := make([]string, 0, len(r.gateways))
+	for name := range r.gateways {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select returns the provider of the most specific matching rule, or the
+// fallback provider. A rule naming a provider that is not configured in this
+// deployment is an error rather than a silent reroute.
+func (r *Router) Select(ctx context.Context, c Criteria) (string, error) {
+	rules, err := r.rules.FindActive(ctx)
+	if err != nil {
+		return "", fmt.Errorf("find routing rules: %w", err)
+	}
+
+	provider := r.fallback
+	if rule := SelectRule(rules, c); rule != nil {
+		provider = rule.Provider
+	}
+	if _, err := r.Get(provider); err != nil {
+		return "", err
+	}
+	return provider, nil
+}
@@ -0,0 +1,240 @@
+package gateway
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/openapi"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// RuleResult is the read model returned by the routing rule endpoints.
+type RuleResult struct {
+	ID        string     `json:"id"`
+	Provider  string     `json:"provider"`
+	Currency  string     `json:"currency,omitempty"`
+	Country   string     `json:"country,omitempty"`
+	ClinicID  string     `json:"clinicId,omitempty"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// ProvidersResult lists the configured payment providers.
+type ProvidersResult struct {
+	Providers []string `json:"providers"`
+	Default   string   `json:"default"` // chosen when no rule matches
+}
+
+// RouteResult is the provider chosen by the routing preview.
+type RouteResult struct {
+	Provider string `json:"provider"`
+}
+
+type createRuleRequest struct {
+	Provider string `json:"provider" binding:"required"`
+	Currency string `json:"currency" binding:"omitempty,len=3"`
+	Country  string `json:"country"  binding:"omitempty,len=2"`
+	ClinicID string `json:"clinicId"`
+}
+
+type updateRuleRequest struct {
+	Provider string `json:"provider" binding:"required"`
+	Active   *bool  `json:"active"   binding:"required"`
+}
+
+// RegisterRoutes mounts the payment routing administration endpoints on the group.
+//
+//	GET    /providers  – configured providers
+//	GET    /rules      – list
+//	POST   /rules      – create
+//	GET    /rules/:id  – get by ID
+//	PUT    /rules/:id  – change provider, active flag
+//	DELETE /rules/:id  – deactivate
+//	GET    /route      – preview the provider chosen for currency, country, clinicId
+func RegisterRoutes(rg *gin.RouterGroup, repo RuleRepository, router *Router) {
+	rg.GET("/providers", func(c *gin.Context) {
+		c.JSON(http.StatusOK, ProvidersResult{Providers: router.Providers(), Default: router.fallback})
+	})
+
+	rg.GET("/rules", func(c *gin.Context) {
+		rules, err := repo.List(c.Request.Context())
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		results := make([]*RuleResult, 0, len(rules))
+		for _, r := range rules {
+			results = append(results, toResult(r))
+		}
+		c.JSON(http.StatusOK, results)
+	})
+
+	rg.POST("/rules", func(c *gin.Context) {
+		var req createRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+		if _, err := router.Get(req.Provider); err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		rule, err := NewRule(req.Provider, req.Currency, req.Country, req.ClinicID)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		if err := repo.Create(c.Request.Context(), rule); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, toResult(rule))
+	})
+
+	rg.GET("/rules/:id", func(c *gin.Context) {
+		rule, ok := loadRule(c, repo)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, toResult(rule))
+	})
+
+	rg.PUT("/rules/:id", func(c *gin.Context) {
+		var req updateRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+		if _, err := router.Get(req.Provider); err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		rule, ok := loadRule(c, repo)
+		if !ok {
+			return
+		}
+
+		if err := rule.Change(req.Provider, *req.Active); err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		if err := repo.Update(c.Request.Context(), rule); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toResult(rule))
+	})
+
+	rg.DELETE("/rules/:id", func(c *gin.Context) {
+		rule, ok := loadRule(c, repo)
+		if !ok {
+			return
+		}
+
+		if err := rule.Change(rule.Provider, false); err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		if err := repo.Update(c.Request.Context(), rule); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toResult(rule))
+	})
+
+	rg.GET("/route", func(c *gin.Context) {
+		provider, err := router.Select(c.Request.Context(), Criteria{
+			Currency: c.Query("currency"),
+			Country:  c.Query("country"),
+			ClinicID: c.Query("clinicId"),
+		})
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, RouteResult{Provider: provider})
+	})
+}
+
+// Routes declares the endpoints RegisterRoutes mounts.
+func Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/providers", Summary: "Configured payment providers", Response: ProvidersResult{}},
+		{Method: http.MethodGet, Path: "/rules", Summary: "List routing rules", Response: []*RuleResult{}},
+		{Method: http.MethodPost, Path: "/rules", Summary: "Create a routing rule",
+			Request: createRuleRequest{}, Response: RuleResult{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/rules/:id", Summary: "Get a routing rule",
+			Response: RuleResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/rules/:id", Summary: "Change the provider or active flag of a routing rule",
+			Request: updateRuleRequest{}, Response: RuleResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodDelete, Path: "/rules/:id", Summary: "Deactivate a routing rule",
+			Response: RuleResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/route", Summary: "Preview the provider chosen for a payment",
+			Query: []openapi.Param{
+				{Name: "currency", Description: "3-letter ISO code"},
+				{Name: "country", Description: "2-letter ISO code"},
+				{Name: "clinicId", Description: "the billing clinic"},
+			},
+			Response: RouteResult{}},
+	}
+}
+
+// Problem maps the package's errors to problem responses.
+func Problem(err error) *problem.Problem {
+	var notFound *ErrRuleNotFound
+	var unknown *ErrUnknownProvider
+	switch {
+	case errors.Is(err, ErrInvalidRule):
+		return problem.New(http.StatusBadRequest, "invalid_routing_rule", err.Error())
+	case errors.As(err, &notFound):
+		return problem.New(http.StatusNotFound, "routing_rule_not_found", notFound.Error())
+	case errors.As(err, &unknown):
+		return problem.Invalid("provider", "oneof", unknown.Error())
+	case errors.Is(err, ErrUnsupported):
+		return problem.New(http.StatusUnprocessableEntity, "provider_unsupported", ErrUnsupported.Error())
+	}
+	return nil
+}
+
+// loadRule resolves the :id route parameter, writing the error response on failure.
+func loadRule(c *gin.Context, repo RuleRepository) (*Rule, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		problem.Write(c, problem.Invalid("id", "uuid", "invalid routing rule id"))
+		return nil, false
+	}
+
+	rule, err := repo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		problem.Write(c, err)
+		return nil, false
+	}
+	if rule == nil {
+		problem.Write(c, &ErrRuleNotFound{ID: id})
+		return nil, false
+	}
+	return rule, true
+}
+
+func toResult(r *Rule) *RuleResult {
+	return &RuleResult{
+		ID:        r.ID.String(),
+		Provider:  r.Provider,
+		Currency:  r.Currency,
+		Country:   r.Country,
+		ClinicID:  r.ClinicID,
+		Active:    r.Active,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
@@ -0,0 +1,147 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidRule is returned when a routing rule violates its invariants.
+var ErrInvalidRule = errors.New("invalid routing rule")
+
+// ErrRuleNotFound is returned when a routing rule record cannot be found.
+type ErrRuleNotFound struct {
+	ID uuid.UUID
+}
+
+func (e *ErrRuleNotFound) Error() string {
+	return fmt.Sprintf("routing rule %s was not found", e.ID)
+}
+
+// -----------------------------------------------------------------------
+// Rule
+//
+// Architectural Decision: Routing rules follow the fee schedule model: empty
+// fields are wildcards and the most specific matching rule wins (clinic over
+// country over currency), the newest rule breaking ties. Payments no rule
+// matches go to the default provider, so an empty rule table routes
+// everything to Stripe as before.
+// -----------------------------------------------------------------------
+
+// Rule routes the payments matching its criteria to a provider.
+type Rule struct {
+	ID        uuid.UUID
+	Provider  string
+	Currency  string // empty = any currency
+	Country   string // empty = any country; ISO code, the first segment of the tax jurisdiction
+	ClinicID  string // empty = any clinic
+	Active    bool
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+// Criteria describes the payment being routed.
+type Criteria struct {
+	Currency string
+	Country  string
+	ClinicID string
+}
+
+// NewRule is the factory function – enforces invariants on creation.
+func NewRule(provider, currency, country, clinicID string) (*Rule, error) {
+	r := &Rule{
+		ID:        uuid.New(),
+		Provider:  strings.TrimSpace(provider),
+		Currency:  strings.ToUpper(strings.TrimSpace(currency)),
+		Country:   strings.ToUpper(strings.TrimSpace(country)),
+		ClinicID:  strings.TrimSpace(clinicID),
+		Active:    true,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Change updates the provider and active flag of the rule.
+func (r *Rule) Change(provider string, active bool) error {
+	updated := *r
+	updated.Provider = strings.TrimSpace(provider)
+	updated.Active = active
+	if err := updated.validate(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	updated.UpdatedAt = &now
+	*r = updated
+	return nil
+}
+
+// Matches reports whether the rule routes a payment with the given criteria.
+func (r *Rule) Matches(c Criteria) bool {
+	if !r.Active {
+		return false
+	}
+	if r.Currency != "" && !strings.EqualFold(r.Currency, c.Currency) {
+		return false
+	}
+	if r.Country != "" && !strings.EqualFold(r.Country, c.Country) {
+		return false
+	}
+	return r.ClinicID == "" || r.ClinicID == c.ClinicID
+}
+
+// specificity ranks how narrowly the rule is targeted.
+func (r *Rule) specificity() int {
+	rank := 0
+	if r.ClinicID != "" {
+		rank += 4
+	}
+	if r.Country != "" {
+		rank += 2
+	}
+	if r.Currency != "" {
+		rank++
+	}
+	return rank
+}
+
+func (r *Rule) validate() error {
+	if r.Provider == "" {
+		return fmt.Errorf("%w: provider is required", ErrInvalidRule)
+	}
+	if r.Currency != "" && len(r.Currency) != 3 {
+		return fmt.Errorf("%w: currency must be a 3-letter ISO code", ErrInvalidRule)
+	}
+	if r.Country != "" && len(r.Country) != 2 {
+		return fmt.Errorf("%w: country must be a 2-letter ISO code", ErrInvalidRule)
+	}
+	return nil
+}
+
+// SelectRule returns the rule that routes the payment, or nil when none matches.
+func SelectRule(rules []*Rule, c Criteria) *Rule {
+	var best *Rule
+	for _, r := range rules {
+		if !r.Matches(c) {
+			continue
+		}
+		if best == nil ||
+			r.specificity() > best.specificity() ||
+			(r.specificity() == best.specificity() && r.CreatedAt.After(best.CreatedAt)) {
+			best = r
+		}
+	}
+	return best
+}
+
+// CountryOf returns the country of a tax jurisdiction ("US-CA-SF" → "US").
+func CountryOf(jurisdiction string) string {
+	country, _, _ := strings.Cut(strings.TrimSpace(jurisdiction), "-")
+	return strings.ToUpper(country)
+}
@@ -0,0 +1,80 @@
+package gateway_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/gateway"
+)
+
+func rule(t *testing.T, provider, currency, country, clinic string) *gateway.Rule {
+	t.Helper()
+	r, err := gateway.NewRule(provider, currency, country, clinic)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return r
+}
+
+func TestSelectRule_MostSpecificWins(t *testing.T) {
+	byCurrency := rule(t, "stripe", "eur", "", "")
+	byCountry := rule(t, "bank_transfer", "", "de", "")
+	byClinic := rule(t, "stripe", "", "", "BERLIN-1")
+	rules := []*gateway.Rule{byCurrency, byCountry, byClinic}
+
+	tests := []struct {
+		name     string
+		criteria gateway.Criteria
+		want     *gateway.Rule
+	}{
+		{"clinic over country", gateway.Criteria{Currency: "eur", Country: "DE", ClinicID: "BERLIN-1"}, byClinic},
+		{"country over currency", gateway.Criteria{Currency: "eur", Country: "DE", ClinicID: "MUNICH-1"}, byCountry},
+		{"currency only", gateway.Criteria{Currency: "EUR", Country: "FR"}, byCurrency},
+		{"no match", gateway.Criteria{Currency: "usd", Country: "US"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gateway.SelectRule(rules, tt.criteria); got != tt.want {
+				t.Errorf("expected rule %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSelectRule_NewestBreaksTiesAndInactiveIgnored(t *testing.T) {
+	older := rule(t, "stripe", "eur", "", "")
+	newer := rule(t, "bank_transfer", "eur", "", "")
+	newer.CreatedAt = older.CreatedAt.Add(time.Minute)
+
+	criteria := gateway.Criteria{Currency: "eur"}
+	if got := gateway.SelectRule([]*gateway.Rule{newer, older}, criteria); got != newer {
+		t.Errorf("expected the newest rule, got %v", got)
+	}
+
+	if err := newer.Change(newer.Provider, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := gateway.SelectRule([]*gateway.Rule{newer, older}, criteria); got != older {
+		t.Errorf("expected the active rule, got %v", got)
+	}
+}
+
+func TestNewRule_Validation(t *testing.T) {
+	if _, err := gateway.NewRule("", "eur", "", ""); err == nil {
+		t.Error("expected an error without provider")
+	}
+	if _, err := gateway.NewRule("stripe", "euro", "", ""); err == nil {
+		t.Error("expected an error for a 4-letter currency")
+	}
+	if _, err := gateway.NewRule("stripe", "", "DEU", ""); err == nil {
+		t.Error("expected an error for a 3-letter country")
+	}
+}
+
+func TestCountryOf(t *testing.T) {
+	for jurisdiction, want := range map[string]string{"US-CA-SF": "US", "de": "DE", "": ""} {
+		if got := gateway.CountryOf(jurisdiction); got != want {
+			t.Errorf("CountryOf(%q) = %q, want %q", jurisdiction, got, want)
+		}
+	}
+}
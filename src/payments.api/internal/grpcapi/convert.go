@@ -0,0 +1,218 @@
+package grpcapi
+
+import (
+	"github.com/google/uuid"
+	createpayment "github.com/smart-health/payments-api/internal/payments/create_payment"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	getpayment "github.com/smart-health/payments-api/internal/payments/get_payment"
+	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+	listpayments "github.com/smart-health/payments-api/internal/payments/list_payments"
+	"github.com/smart-health/payments-api/internal/payments/statushub"
+	"github.com/smart-health/payments-api/internal/problem"
+	paymentsv1 "github.com/smart-health/payments-api/proto/payments/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// statuses maps the payment statuses to their protobuf values.
+var statuses = map[domain.PaymentStatus]paymentsv1.PaymentStatus{
+	domain.PaymentStatusPending:        paymentsv1.PaymentStatus_PAYMENT_STATUS_PENDING,
+	domain.PaymentStatusRequiresAction: paymentsv1.PaymentStatus_PAYMENT_STATUS_REQUIRES_ACTION,
+	domain.PaymentStatusProcessing:     paymentsv1.PaymentStatus_PAYMENT_STATUS_PROCESSING,
+	domain.PaymentStatusCompleted:      paymentsv1.PaymentStatus_PAYMENT_STATUS_COMPLETED,
+	domain.PaymentStatusFailed:         paymentsv1.PaymentStatus_PAYMENT_STATUS_FAILED,
+}
+
+func toProtoStatus(s domain.PaymentStatus) paymentsv1.PaymentStatus {
+	return statuses[s] // unknown statuses are unspecified
+}
+
+// toStatusName converts the status name of a read model.
+func toStatusName(name string) paymentsv1.PaymentStatus {
+	s, ok := domain.ParsePaymentStatus(name)
+	if !ok {
+		return paymentsv1.PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
+	}
+	return toProtoStatus(s)
+}
+
+func fromProtoStatus(s paymentsv1.PaymentStatus) (domain.PaymentStatus, bool) {
+	for status, value := range statuses {
+		if value == s {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+func toPayment(r *getpayment.Result) *paymentsv1.Payment {
+	p := &paymentsv1.Payment{
+		PaymentId:      r.PaymentID,
+		AppointmentId:  r.AppointmentID,
+		UserId:         r.UserID,
+		Subtotal:       r.Subtotal,
+		TaxAmount:      r.TaxAmount,
+		Amount:         r.Amount,
+		Currency:       r.Currency,
+		Status:         toStatusName(r.Status),
+		Provider:       r.Provider,
+		TransactionId:  r.TransactionID,
+		FailureCode:    r.FailureCode,
+		FailureMessage: r.FailureMessage,
+		CreatedAt:      timestamppb.New(r.CreatedAt),
+	}
+	if r.UpdatedAt != nil {
+		p.UpdatedAt = timestamppb.New(*r.UpdatedAt)
+	}
+	if d := r.Discount; d != nil {
+		p.Discount = &paymentsv1.Discount{Code: d.Code, Kind: d.Kind, Value: d.Value, Amount: d.Amount}
+	}
+	for _, item := range r.LineItems {
+		p.LineItems = append(p.LineItems, &paymentsv1.LineItem{
+			Code:        item.Code,
+			Description: item.Description,
+			Category:    item.Category,
+			Quantity:    int32(item.Quantity),
+			UnitPrice:   item.UnitPrice,
+			Discount:    item.Discount,
+			Tax:         item.Tax,
+			Total:       item.Total,
+		})
+	}
+	for _, l := range r.TaxLines {
+		p.TaxLines = append(p.TaxLines, &paymentsv1.TaxLine{
+			Name:          l.Name,
+			Jurisdiction:  l.Jurisdiction,
+			Category:      l.Category,
+			ItemCode:      l.ItemCode,
+			Percent:       l.Percent,
+			Inclusive:     l.Inclusive,
+			TaxableAmount: l.TaxableAmount,
+			Amount:        l.Amount,
+		})
+	}
+	return p
+}
+
+// toListQuery reads the search of ListPayments; the handler validates the
+// values as for GET /api/payments.
+func toListQuery(req *paymentsv1.ListPaymentsRequest) (listpayments.Query, error) {
+	q := listpayments.Query{
+		UserID:        req.UserId,
+		Currency:      req.Currency,
+		MinAmount:     req.MinAmount,
+		MaxAmount:     req.MaxAmount,
+		TransactionID: req.TransactionId,
+		Sort:          infrastructure.PaymentSort(req.OrderBy),
+		Limit:         int(req.PageSize),
+		Cursor:        req.PageToken,
+	}
+	if req.AppointmentId != "" {
+		id, err := uuid.Parse(req.AppointmentId)
+		if err != nil {
+			return q, problem.Invalid("appointment_id", "uuid", "invalid appointment_id")
+		}
+		q.AppointmentID = id
+	}
+	for _, s := range req.Statuses {
+		status, ok := fromProtoStatus(s)
+		if !ok {
+			return q, problem.Invalid("statuses", "enum", "unknown payment status")
+		}
+		q.Statuses = append(q.Statuses, status)
+	}
+	if req.CreatedFrom != nil {
+		at := req.CreatedFrom.AsTime()
+		q.CreatedFrom = &at
+	}
+	if req.CreatedTo != nil {
+		at := req.CreatedTo.AsTime()
+		q.CreatedTo = &at
+	}
+	if req.PageSize < 0 {
+		return q, problem.Invalid("page_size", "gte", "page_size must not be negative")
+	}
+	return q, nil
+}
+
+func toListResponse(r *listpayments.Result) *paymentsv1.ListPaymentsResponse {
+	out := &paymentsv1.ListPaymentsResponse{
+		Payments:      make([]*paymentsv1.PaymentSummary, 0, len(r.Payments)),
+		NextPageToken: r.NextCursor,
+	}
+	for _, p := range r.Payments {
+		s := &paymentsv1.PaymentSummary{
+			PaymentId:     p.PaymentID,
+			AppointmentId: p.AppointmentID,
+			UserId:        p.UserID,
+			ClinicId:      p.ClinicID,
+			Amount:        p.Amount,
+			Currency:      p.Currency,
+			Status:        toStatusName(p.Status),
+			Provider:      p.Provider,
+			TransactionId: p.TransactionID,
+			FailureCode:   p.FailureCode,
+			CreatedAt:     timestamppb.New(p.CreatedAt),
+		}
+		if p.UpdatedAt != nil {
+			s.UpdatedAt = timestamppb.New(*p.UpdatedAt)
+		}
+		out.Payments = append(out.Payments, s)
+	}
+	return out
+}
+
+// toCreateCommand reads CreatePayment with the defaults of the
+// AppointmentSlotReserved consumer; the command validates the rest.
+func toCreateCommand(req *paymentsv1.CreatePaymentRequest, opts Options) (createpayment.Command, error) {
+	appointmentID, err := uuid.Parse(req.AppointmentId)
+	if err != nil {
+		return createpayment.Command{}, problem.Invalid("appointment_id", "uuid", "invalid appointment_id")
+	}
+	currency := req.Currency
+	if currency == "" {
+		currency = opts.DefaultCurrency
+	}
+	jurisdiction := req.Jurisdiction
+	if jurisdiction == "" {
+		jurisdiction = opts.DefaultJurisdiction
+	}
+
+	lineItems := make([]createpayment.LineItem, 0, len(req.LineItems))
+	for _, item := range req.LineItems {
+		lineItems = append(lineItems, createpayment.LineItem{
+			Code:        item.Code,
+			Description: item.Description,
+			Category:    item.Category,
+			Quantity:    int(item.Quantity),
+			UnitPrice:   item.UnitPrice,
+		})
+	}
+
+	return createpayment.Command{
+		AppointmentID:   appointmentID,
+		UserID:          req.UserId,
+		Amount:          req.Amount,
+		Currency:        currency,
+		DoctorID:        req.DoctorId,
+		Specialty:       req.Specialty,
+		VisitType:       req.VisitType,
+		CouponCode:      req.CouponCode,
+		ClinicID:        req.ClinicId,
+		Jurisdiction:    jurisdiction,
+		ServiceCategory: req.ServiceCategory,
+		LineItems:       lineItems,
+	}, nil
+}
+
+func toStatusEvent(c statushub.Change, language string) *paymentsv1.PaymentStatusEvent {
+	e := &paymentsv1.PaymentStatusEvent{
+		PaymentId:   c.PaymentID.String(),
+		Status:      toProtoStatus(c.Status),
+		FailureCode: string(c.FailureCode),
+		OccurredAt:  timestamppb.New(c.At),
+	}
+	if c.FailureCode != "" {
+		e.FailureMessage = c.FailureCode.Message(language)
+	}
+	return e
+}
@@ -0,0 +1,215 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/auth"
+	"github.com/smart-health/payments-api/internal/problem"
+	"github.com/smart-health/payments-api/internal/resilience"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// CorrelationHeader is the metadata key of the correlation ID of a call.
+const CorrelationHeader = "x-correlation-id"
+
+// ErrorDomain is the domain of the ErrorInfo details of errors.
+const ErrorDomain = "smarthealth.payments"
+
+// interceptor runs around a call, unary or streaming, with the call's
+// context; call runs the rest of the chain with the context it is given.
+type interceptor func(ctx context.Context, method string, call func(context.Context) error) error
+
+func (i interceptor) unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var resp any
+		err := i(ctx, info.FullMethod, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+func (i interceptor) stream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return i(ss.Context(), info.FullMethod, func(ctx context.Context) error {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		})
+	}
+}
+
+// serverStream is a stream with the context set by the interceptors.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+type correlationKey struct{}
+
+// correlationID returns the correlation ID of the call.
+func correlationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
+
+// logCalls gives every call a correlation ID – the caller's, or a new one –
+// returns it in the response headers and logs the call with it.
+func logCalls(logger *slog.Logger) interceptor {
+	return func(ctx context.Context, method string, call func(context.Context) error) error {
+		start := time.Now()
+		id := firstValue(ctx, CorrelationHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(CorrelationHeader, id))
+
+		err := call(context.WithValue(ctx, correlationKey{}, id))
+		logger.Info("grpc request",
+			"method", method,
+			"code", status.Code(err).String(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"correlationId", id)
+		return err
+	}
+}
+
+// mapErrors turns errors into statuses, through the problem each error
+// maps to: the problem's HTTP status selects the code, and its stable code
+// is the reason of an ErrorInfo detail. Server errors reach the caller as
+// a generic status; the error itself is logged here. Panics are recovered
+// as server errors.
+func mapErrors(logger *slog.Logger) interceptor {
+	return func(ctx context.Context, method string, call func(context.Context) error) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = fmt.Errorf("panic: %v", recovered)
+			}
+			if err == nil {
+				return
+			}
+			if _, ok := status.FromError(err); ok {
+				return // already a status, e.g. from the gRPC runtime
+			}
+			if errors.Is(err, context.Canceled) {
+				err = status.Error(codes.Canceled, "the call was canceled")
+				return
+			}
+			p := problem.From(err)
+			if p.Status >= http.StatusInternalServerError && p.Status != http.StatusNotImplemented {
+				logger.Error("grpc request failed", "method", method, "correlationId", correlationID(ctx), "error", err)
+			}
+			err = toStatus(p).Err()
+		}()
+		return call(ctx)
+	}
+}
+
+// toStatus converts a problem, with its field errors as a BadRequest detail.
+func toStatus(p *problem.Problem) *status.Status {
+	s := status.New(codeOf(p.Status), p.Detail)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: p.Code, Domain: ErrorDomain}}
+	if len(p.Errors) > 0 {
+		invalid := &errdetails.BadRequest{}
+		for _, f := range p.Errors {
+			invalid.FieldViolations = append(invalid.FieldViolations,
+				&errdetails.BadRequest_FieldViolation{Field: f.Field, Reason: f.Code, Description: f.Message})
+		}
+		details = append(details, invalid)
+	}
+	withDetails, err := s.WithDetails(details...)
+	if err != nil {
+		return s
+	}
+	return withDetails
+}
+
+// codeOf maps an HTTP status to the gRPC code of the same meaning.
+func codeOf(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusInternalServerError:
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// withPriority sets the rate limiter priority of the provider calls a call makes.
+func withPriority(p resilience.Priority) interceptor {
+	return func(ctx context.Context, _ string, call func(context.Context) error) error {
+		return call(resilience.WithPriority(ctx, p))
+	}
+}
+
+// authenticate verifies the bearer token of the "authorization" metadata
+// and admits authenticated callers having one of the roles of the method,
+// if it has any. The principal is in the context of the call (see
+// auth.FromContext).
+func authenticate(verifier *auth.Verifier, roles map[string][]string, logger *slog.Logger) interceptor {
+	return func(ctx context.Context, method string, call func(context.Context) error) error {
+		header := firstValue(ctx, "authorization")
+		if header == "" {
+			return problem.New(http.StatusUnauthorized, "unauthenticated", "authentication required")
+		}
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return problem.New(http.StatusUnauthorized, "invalid_token", "authorization metadata must be a Bearer token")
+		}
+
+		principal, err := verifier.Verify(ctx, strings.TrimSpace(token))
+		if err != nil {
+			var invalid *auth.ErrInvalidToken
+			if errors.As(err, &invalid) {
+				logger.Debug("rejected bearer token", "error", err)
+				return problem.New(http.StatusUnauthorized, "invalid_token", err.Error())
+			}
+			logger.Error("failed to verify bearer token", "error", err)
+			return problem.New(http.StatusServiceUnavailable, "token_verification_unavailable", "token could not be verified")
+		}
+		if required := roles[method]; len(required) > 0 && !principal.HasRole(required...) {
+			return problem.New(http.StatusForbidden, "forbidden", "requires role "+strings.Join(required, " or "))
+		}
+		return call(auth.NewContext(ctx, principal))
+	}
+}
+
+// firstValue returns the first value of a metadata key of the call.
+func firstValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,257 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/auth"
+	confirmpayment "github.com/smart-health/payments-api/internal/payments/confirm_payment"
+	createpayment "github.com/smart-health/payments-api/internal/payments/create_payment"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	getpayment "github.com/smart-health/payments-api/internal/payments/get_payment"
+	listpayments "github.com/smart-health/payments-api/internal/payments/list_payments"
+	refundpayment "github.com/smart-health/payments-api/internal/payments/refund_payment"
+	"github.com/smart-health/payments-api/internal/payments/statushub"
+	"github.com/smart-health/payments-api/internal/problem"
+	"github.com/smart-health/payments-api/internal/resilience"
+	"github.com/smart-health/payments-api/internal/shared"
+	paymentsv1 "github.com/smart-health/payments-api/proto/payments/v1"
+	"google.golang.org/grpc"
+)
+
+// ---------------------------------------------------------------------------
+// gRPC API
+//
+// Architectural Decision: Internal Go and .NET services call payments over
+// gRPC with the typed contract of proto/payments/v1, next to the Gin HTTP
+// API and on a port of its own. Both are thin transports over the same
+// mediator: each RPC translates its message into the command or query the
+// HTTP handler sends and the result back, so the slices never know which
+// transport called them. What the Gin middleware does for HTTP, interceptors
+// do here, in the same order: a correlation ID and the call log, errors
+// mapped to status codes from their problem codes (so both APIs report an
+// error the same way), then the bearer token and the roles of the method.
+// Rules that depend on the payment (a patient sees only their own) are
+// checked in the methods with Principal.CanAccess, as on HTTP.
+// ---------------------------------------------------------------------------
+
+// Options configures the gRPC API.
+type Options struct {
+	DefaultCurrency     string // currency of created payments that name none
+	DefaultJurisdiction string // tax jurisdiction of created payments that name none
+}
+
+// methodRoles are the roles admitted by each method. Methods not listed
+// admit every authenticated caller and check it against the payment.
+var methodRoles = map[string][]string{
+	paymentsv1.Payments_ListPayments_FullMethodName:  {auth.RoleStaff, auth.RoleAdmin},
+	paymentsv1.Payments_CreatePayment_FullMethodName: {auth.RoleStaff, auth.RoleAdmin, auth.RoleService},
+	paymentsv1.Payments_RefundPayment_FullMethodName: {auth.RoleStaff, auth.RoleAdmin},
+}
+
+// NewServer creates the gRPC server of the Payments service. current loads
+// the status WatchPayment starts from, as for the HTTP status stream.
+func NewServer(mediator *shared.Mediator, hub *statushub.Hub, current statushub.Current, verifier *auth.Verifier, opts Options, logger *slog.Logger) *grpc.Server {
+	interceptors := []interceptor{
+		logCalls(logger),
+		mapErrors(logger),
+		// Calls of other services take precedence over background work at the rate limiter
+		withPriority(resilience.PriorityHigh),
+		authenticate(verifier, methodRoles, logger),
+	}
+	unary := make([]grpc.UnaryServerInterceptor, 0, len(interceptors))
+	stream := make([]grpc.StreamServerInterceptor, 0, len(interceptors))
+	for _, i := range interceptors {
+		unary = append(unary, i.unary())
+		stream = append(stream, i.stream())
+	}
+
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	paymentsv1.RegisterPaymentsServer(s, &paymentsServer{
+		mediator: mediator,
+		hub:      hub,
+		current:  current,
+		opts:     opts,
+	})
+	return s
+}
+
+// paymentsServer implements the Payments service with the mediator.
+type paymentsServer struct {
+	paymentsv1.UnimplementedPaymentsServer
+
+	mediator *shared.Mediator
+	hub      *statushub.Hub
+	current  statushub.Current
+	opts     Options
+}
+
+func (s *paymentsServer) GetPayment(ctx context.Context, req *paymentsv1.GetPaymentRequest) (*paymentsv1.Payment, error) {
+	id, err := uuid.Parse(req.PaymentId)
+	if err != nil {
+		return nil, problem.Invalid("payment_id", "uuid", "invalid payment_id")
+	}
+	result, err := s.payment(ctx, getpayment.Query{PaymentID: id, Language: domain.NegotiateLanguage(req.Language)})
+	if err != nil {
+		return nil, err
+	}
+	return toPayment(result), nil
+}
+
+func (s *paymentsServer) GetPaymentByAppointment(ctx context.Context, req *paymentsv1.GetPaymentByAppointmentRequest) (*paymentsv1.Payment, error) {
+	id, err := uuid.Parse(req.AppointmentId)
+	if err != nil {
+		return nil, problem.Invalid("appointment_id", "uuid", "invalid appointment_id")
+	}
+	result, err := s.payment(ctx, getpayment.ByAppointmentQuery{AppointmentID: id, Language: domain.NegotiateLanguage(req.Language)})
+	if err != nil {
+		return nil, err
+	}
+	return toPayment(result), nil
+}
+
+func (s *paymentsServer) ListPayments(ctx context.Context, req *paymentsv1.ListPaymentsRequest) (*paymentsv1.ListPaymentsResponse, error) {
+	query, err := toListQuery(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.mediator.Send(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := resp.(*listpayments.Result)
+	if !ok {
+		return nil, fmt.Errorf("unexpected payment list read model %T", resp)
+	}
+	return toListResponse(result), nil
+}
+
+func (s *paymentsServer) CreatePayment(ctx context.Context, req *paymentsv1.CreatePaymentRequest) (*paymentsv1.CreatePaymentResponse, error) {
+	cmd, err := toCreateCommand(req, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.mediator.Send(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := resp.(*createpayment.Result)
+	if !ok {
+		return nil, fmt.Errorf("unexpected create payment result %T", resp)
+	}
+
+	out := &paymentsv1.CreatePaymentResponse{PaymentId: result.PaymentID, Status: toStatusName(result.Status)}
+	// The client secret and instructions are only handed to the patient the payment belongs to
+	if p := auth.FromContext(ctx); p != nil && p.Subject == req.UserId {
+		out.ClientSecret = result.ClientSecret
+		out.Instructions = result.Instructions
+	}
+	return out, nil
+}
+
+func (s *paymentsServer) RefundPayment(ctx context.Context, req *paymentsv1.RefundPaymentRequest) (*paymentsv1.RefundPaymentResponse, error) {
+	id, err := uuid.Parse(req.PaymentId)
+	if err != nil {
+		return nil, problem.Invalid("payment_id", "uuid", "invalid payment_id")
+	}
+	resp, err := s.mediator.Send(ctx, refundpayment.Command{PaymentID: id, Amount: req.Amount, Reason: req.Reason})
+	if err != nil {
+		return nil, err
+	}
+	result, ok := resp.(*refundpayment.Result)
+	if !ok {
+		return nil, fmt.Errorf("unexpected refund result %T", resp)
+	}
+	return &paymentsv1.RefundPaymentResponse{
+		RefundId:         result.RefundID,
+		PaymentId:        result.PaymentID,
+		Amount:           result.Amount,
+		Status:           result.Status,
+		CreditNoteId:     result.CreditNoteID,
+		CreditNoteNumber: result.CreditNoteNumber,
+	}, nil
+}
+
+// WatchPayment streams the statuses of a payment as the HTTP status stream
+// does (see statushub.StreamHandler), with the status the caller already
+// has in the request instead of Last-Event-ID.
+func (s *paymentsServer) WatchPayment(req *paymentsv1.WatchPaymentRequest, stream grpc.ServerStreamingServer[paymentsv1.PaymentStatusEvent]) error {
+	ctx := stream.Context()
+	id, err := uuid.Parse(req.PaymentId)
+	if err != nil {
+		return problem.Invalid("payment_id", "uuid", "invalid payment_id")
+	}
+	sent := 0 // stage of the last status the caller has
+	if req.After != paymentsv1.PaymentStatus_PAYMENT_STATUS_UNSPECIFIED {
+		after, ok := fromProtoStatus(req.After)
+		if !ok {
+			return problem.Invalid("after", "enum", "unknown payment status")
+		}
+		if after.IsFinal() {
+			return nil
+		}
+		sent = after.Stage()
+	}
+	if _, err := s.payment(ctx, getpayment.Query{PaymentID: id}); err != nil {
+		return err
+	}
+
+	sub, backlog, err := s.hub.Follow(ctx, id, s.current)
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	language := domain.NegotiateLanguage(req.Language)
+	// send streams a change the caller does not have yet and reports
+	// whether the stream goes on
+	send := func(change statushub.Change) (bool, error) {
+		if change.Status.Stage() <= sent {
+			return true, nil
+		}
+		if err := stream.Send(toStatusEvent(change, language)); err != nil {
+			return false, err
+		}
+		sent = change.Status.Stage()
+		return !change.Status.IsFinal(), nil
+	}
+
+	for _, change := range backlog {
+		if more, err := send(change); !more {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change, ok := <-sub.C:
+			if !ok {
+				return problem.New(http.StatusServiceUnavailable, "shutting_down", "the server is shutting down; watch again after the last status received")
+			}
+			if more, err := send(change); !more {
+				return err
+			}
+		}
+	}
+}
+
+// payment loads a payment the caller may access: its owner, staff,
+// administrators and services.
+func (s *paymentsServer) payment(ctx context.Context, query shared.Request) (*getpayment.Result, error) {
+	resp, err := s.mediator.Send(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	result, ok := resp.(*getpayment.Result)
+	if !ok {
+		return nil, fmt.Errorf("unexpected payment read model %T", resp)
+	}
+	if !auth.FromContext(ctx).CanAccess(result.UserID) {
+		return nil, confirmpayment.ErrNotPaymentOwner
+	}
+	return result, nil
+}
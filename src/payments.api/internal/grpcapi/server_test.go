@@ -0,0 +1,427 @@
+package grpcapi_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/auth"
+	"github.com/smart-health/payments-api/internal/grpcapi"
+	"github.com/smart-health/payments-api/internal/invoicing"
+	confirmpayment "github.com/smart-health/payments-api/internal/payments/confirm_payment"
+	createpayment "github.com/smart-health/payments-api/internal/payments/create_payment"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	getpayment "github.com/smart-health/payments-api/internal/payments/get_payment"
+	listpayments "github.com/smart-health/payments-api/internal/payments/list_payments"
+	refundpayment "github.com/smart-health/payments-api/internal/payments/refund_payment"
+	"github.com/smart-health/payments-api/internal/payments/statushub"
+	"github.com/smart-health/payments-api/internal/problem"
+	"github.com/smart-health/payments-api/internal/shared"
+	paymentsv1 "github.com/smart-health/payments-api/proto/payments/v1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const secret = "grpc-test-secret"
+
+func TestMain(m *testing.M) {
+	// As registered by main for the payment slices and invoices
+	problem.Register(invoicing.Problem, func(err error) *problem.Problem {
+		var notFound *domain.ErrPaymentNotFound
+		switch {
+		case errors.As(err, &notFound):
+			return problem.New(http.StatusNotFound, "payment_not_found", notFound.Error())
+		case errors.Is(err, confirmpayment.ErrNotPaymentOwner):
+			return problem.New(http.StatusForbidden, "not_payment_owner", err.Error())
+		}
+		return nil
+	})
+	os.Exit(m.Run())
+}
+
+// fixture is a gRPC API over a mediator with canned handlers, served on an
+// in-process listener.
+type fixture struct {
+	client  paymentsv1.PaymentsClient
+	hub     *statushub.Hub
+	payment *getpayment.Result
+	current statushub.Change
+	queries []listpayments.Query
+	created []createpayment.Command
+	refunds []refundpayment.Command
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := &fixture{hub: statushub.New(time.Minute)}
+	id := uuid.New()
+	f.payment = &getpayment.Result{
+		PaymentID:      id.String(),
+		AppointmentID:  uuid.NewString(),
+		UserID:         "user-1",
+		LineItems:      []getpayment.LineItemResult{{Code: "CONSULTATION", Quantity: 1, UnitPrice: 120, Total: 120}},
+		Amount:         120,
+		Currency:       "eur",
+		Status:         "Failed",
+		Provider:       "stripe",
+		FailureCode:    "card_declined",
+		FailureMessage: "Ihre Karte wurde abgelehnt.",
+		CreatedAt:      time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	f.current = statushub.Change{PaymentID: id, UserID: "user-1", Status: domain.PaymentStatusPending, At: time.Now()}
+
+	mediator := shared.NewMediator()
+	find := func(paymentID string) (shared.Response, error) {
+		if paymentID != f.payment.PaymentID {
+			return nil, &domain.ErrPaymentNotFound{}
+		}
+		return f.payment, nil
+	}
+	mediator.Register(fmt.Sprintf("%T", getpayment.Query{}), func(_ context.Context, req shared.Request) (shared.Response, error) {
+		return find(req.(getpayment.Query).PaymentID.String())
+	})
+	mediator.Register(fmt.Sprintf("%T", getpayment.ByAppointmentQuery{}), func(_ context.Context, req shared.Request) (shared.Response, error) {
+		if req.(getpayment.ByAppointmentQuery).AppointmentID.String() != f.payment.AppointmentID {
+			return nil, &domain.ErrPaymentNotFound{}
+		}
+		return f.payment, nil
+	})
+	mediator.Register(fmt.Sprintf("%T", listpayments.Query{}), func(_ context.Context, req shared.Request) (shared.Response, error) {
+		f.queries = append(f.queries, req.(listpayments.Query))
+		return &listpayments.Result{
+			Payments:   []listpayments.Summary{{PaymentID: f.payment.PaymentID, UserID: "user-1", Status: "Completed", CreatedAt: f.payment.CreatedAt}},
+			NextCursor: "next",
+		}, nil
+	})
+	mediator.Register(fmt.Sprintf("%T", createpayment.Command{}), func(_ context.Context, req shared.Request) (shared.Response, error) {
+		cmd := req.(createpayment.Command)
+		if err := cmd.Validate(); err != nil {
+			return nil, err
+		}
+		f.created = append(f.created, cmd)
+		return &createpayment.Result{PaymentID: uuid.NewString(), Status: "RequiresAction", ClientSecret: "pi_secret"}, nil
+	})
+	mediator.Register(fmt.Sprintf("%T", refundpayment.Command{}), func(_ context.Context, req shared.Request) (shared.Response, error) {
+		cmd := req.(refundpayment.Command)
+		if cmd.PaymentID.String() != f.payment.PaymentID {
+			return nil, &domain.ErrPaymentNotFound{ID: cmd.PaymentID}
+		}
+		if cmd.Reason == "" {
+			return nil, fmt.Errorf("%w: a reason is required", invoicing.ErrInvalidCreditNote)
+		}
+		f.refunds = append(f.refunds, cmd)
+		return &refundpayment.Result{
+			RefundID:         "re_1",
+			PaymentID:        f.payment.PaymentID,
+			Amount:           120,
+			Status:           "succeeded",
+			CreditNoteID:     uuid.NewString(),
+			CreditNoteNumber: "CN-DOWNTOWN-000001",
+		}, nil
+	})
+
+	verifier := auth.NewVerifier(auth.NewKeySet(nil, []byte(secret)), auth.VerifierOptions{})
+	current := func(context.Context, uuid.UUID) (statushub.Change, error) { return f.current, nil }
+	server := grpcapi.NewServer(mediator, f.hub, current, verifier, grpcapi.Options{DefaultCurrency: "eur", DefaultJurisdiction: "DE"},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	f.client = paymentsv1.NewPaymentsClient(conn)
+	return f
+}
+
+// as returns a context calling with a token of the subject and roles.
+func as(t *testing.T, subject string, roles ...string) context.Context {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{"sub": subject, "roles": roles, "exp": time.Now().Add(time.Hour).Unix()})
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	token := signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+// expectError checks the code of a call's error and the reason of its ErrorInfo.
+func expectError(t *testing.T, err error, code codes.Code, reason string) *status.Status {
+	t.Helper()
+	s := status.Convert(err)
+	if s.Code() != code {
+		t.Fatalf("code = %v (%v), want %v", s.Code(), err, code)
+	}
+	for _, d := range s.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			if info.Reason != reason || info.Domain != grpcapi.ErrorDomain {
+				t.Errorf("ErrorInfo = %v, want reason %q", info, reason)
+			}
+			return s
+		}
+	}
+	t.Errorf("no ErrorInfo in %v", s.Details())
+	return s
+}
+
+func TestGetPayment(t *testing.T) {
+	f := newFixture(t)
+
+	got, err := f.client.GetPayment(as(t, "user-1", auth.RolePatient), &paymentsv1.GetPaymentRequest{PaymentId: f.payment.PaymentID})
+	if err != nil {
+		t.Fatalf("GetPayment: %v", err)
+	}
+	if got.Status != paymentsv1.PaymentStatus_PAYMENT_STATUS_FAILED || got.FailureCode != "card_declined" ||
+		len(got.LineItems) != 1 || got.LineItems[0].Total != 120 || !got.CreatedAt.AsTime().Equal(f.payment.CreatedAt) || got.UpdatedAt != nil {
+		t.Errorf("GetPayment = %v", got)
+	}
+
+	byAppointment, err := f.client.GetPaymentByAppointment(as(t, "svc", auth.RoleService), &paymentsv1.GetPaymentByAppointmentRequest{AppointmentId: f.payment.AppointmentID})
+	if err != nil || byAppointment.PaymentId != f.payment.PaymentID {
+		t.Errorf("GetPaymentByAppointment = %v, %v", byAppointment, err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	f := newFixture(t)
+	patient := as(t, "user-1", auth.RolePatient)
+
+	_, err := f.client.GetPayment(context.Background(), &paymentsv1.GetPaymentRequest{PaymentId: f.payment.PaymentID})
+	expectError(t, err, codes.Unauthenticated, "unauthenticated")
+
+	_, err = f.client.GetPayment(as(t, "user-2", auth.RolePatient), &paymentsv1.GetPaymentRequest{PaymentId: f.payment.PaymentID})
+	expectError(t, err, codes.PermissionDenied, "not_payment_owner")
+
+	_, err = f.client.GetPayment(patient, &paymentsv1.GetPaymentRequest{PaymentId: uuid.NewString()})
+	expectError(t, err, codes.NotFound, "payment_not_found")
+
+	_, err = f.client.ListPayments(patient, &paymentsv1.ListPaymentsRequest{})
+	expectError(t, err, codes.PermissionDenied, "forbidden")
+
+	// Invalid fields are listed
+	s := expectError(t, func() error {
+		_, err := f.client.GetPayment(patient, &paymentsv1.GetPaymentRequest{PaymentId: "not-a-uuid"})
+		return err
+	}(), codes.InvalidArgument, problem.CodeValidationFailed)
+	var fields []string
+	for _, d := range s.Details() {
+		if bad, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range bad.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	if len(fields) != 1 || fields[0] != "payment_id" {
+		t.Errorf("field violations = %v, want payment_id", fields)
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	f := newFixture(t)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(as(t, "user-1", auth.RolePatient), grpcapi.CorrelationHeader, "corr-1")
+	if _, err := f.client.GetPayment(ctx, &paymentsv1.GetPaymentRequest{PaymentId: f.payment.PaymentID}, grpc.Header(&header)); err != nil {
+		t.Fatalf("GetPayment: %v", err)
+	}
+	if got := header.Get(grpcapi.CorrelationHeader); len(got) != 1 || got[0] != "corr-1" {
+		t.Errorf("correlation ID = %v, want the caller's", got)
+	}
+
+	// A failed call without one gets a new one
+	header = nil
+	_, _ = f.client.GetPayment(context.Background(), &paymentsv1.GetPaymentRequest{}, grpc.Header(&header))
+	if got := header.Get(grpcapi.CorrelationHeader); len(got) != 1 || uuid.Validate(got[0]) != nil {
+		t.Errorf("correlation ID = %v, want a new UUID", got)
+	}
+}
+
+func TestListPayments(t *testing.T) {
+	f := newFixture(t)
+	minAmount := 10.0
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got, err := f.client.ListPayments(as(t, "staff-1", auth.RoleStaff), &paymentsv1.ListPaymentsRequest{
+		Statuses:    []paymentsv1.PaymentStatus{paymentsv1.PaymentStatus_PAYMENT_STATUS_FAILED, paymentsv1.PaymentStatus_PAYMENT_STATUS_PENDING},
+		MinAmount:   &minAmount,
+		CreatedFrom: timestamppb.New(from),
+		OrderBy:     "-amount",
+		PageSize:    10,
+		PageToken:   "cursor",
+	})
+	if err != nil {
+		t.Fatalf("ListPayments: %v", err)
+	}
+	if len(got.Payments) != 1 || got.Payments[0].Status != paymentsv1.PaymentStatus_PAYMENT_STATUS_COMPLETED || got.NextPageToken != "next" {
+		t.Errorf("ListPayments = %v", got)
+	}
+
+	q := f.queries[0]
+	if len(q.Statuses) != 2 || q.Statuses[0] != domain.PaymentStatusFailed || q.Statuses[1] != domain.PaymentStatusPending ||
+		q.MinAmount == nil || *q.MinAmount != 10 || q.MaxAmount != nil || q.CreatedFrom == nil || !q.CreatedFrom.Equal(from) ||
+		q.Sort != "-amount" || q.Limit != 10 || q.Cursor != "cursor" {
+		t.Errorf("query = %+v", q)
+	}
+}
+
+func TestCreatePayment(t *testing.T) {
+	f := newFixture(t)
+	req := &paymentsv1.CreatePaymentRequest{
+		AppointmentId: uuid.NewString(),
+		UserId:        "user-1",
+		Amount:        80,
+		LineItems:     []*paymentsv1.CreateLineItem{{Code: "XRAY", Quantity: 1, UnitPrice: 40}},
+	}
+
+	// Only the patient gets the client secret
+	got, err := f.client.CreatePayment(as(t, "svc", auth.RoleService), req)
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	if got.Status != paymentsv1.PaymentStatus_PAYMENT_STATUS_REQUIRES_ACTION || got.ClientSecret != "" {
+		t.Errorf("CreatePayment = %v", got)
+	}
+	cmd := f.created[0]
+	if cmd.Currency != "eur" || cmd.Jurisdiction != "DE" || len(cmd.LineItems) != 1 || cmd.LineItems[0].UnitPrice != 40 {
+		t.Errorf("command = %+v, want the defaults and the line item", cmd)
+	}
+
+	_, err = f.client.CreatePayment(as(t, "user-1", auth.RolePatient), req)
+	expectError(t, err, codes.PermissionDenied, "forbidden")
+
+	req.UserId = ""
+	_, err = f.client.CreatePayment(as(t, "svc", auth.RoleService), req)
+	expectError(t, err, codes.InvalidArgument, problem.CodeValidationFailed)
+}
+
+func TestRefundPayment(t *testing.T) {
+	f := newFixture(t)
+	req := &paymentsv1.RefundPaymentRequest{PaymentId: f.payment.PaymentID, Reason: "appointment cancelled"}
+
+	got, err := f.client.RefundPayment(as(t, "staff-1", auth.RoleStaff), req)
+	if err != nil {
+		t.Fatalf("RefundPayment: %v", err)
+	}
+	if got.RefundId != "re_1" || got.PaymentId != f.payment.PaymentID || got.Amount != 120 || got.Status != "succeeded" ||
+		got.CreditNoteId == "" || got.CreditNoteNumber != "CN-DOWNTOWN-000001" {
+		t.Errorf("RefundPayment = %v", got)
+	}
+	if len(f.refunds) != 1 || f.refunds[0].Amount != 0 || f.refunds[0].Reason != "appointment cancelled" {
+		t.Errorf("commands = %+v, want one refunding the remainder", f.refunds)
+	}
+
+	// Patients cannot refund, not even their own payments
+	_, err = f.client.RefundPayment(as(t, "user-1", auth.RolePatient), req)
+	expectError(t, err, codes.PermissionDenied, "forbidden")
+
+	_, err = f.client.RefundPayment(as(t, "staff-1", auth.RoleStaff), &paymentsv1.RefundPaymentRequest{PaymentId: f.payment.PaymentID, Amount: 20})
+	expectError(t, err, codes.InvalidArgument, "invalid_credit_note")
+
+	_, err = f.client.RefundPayment(as(t, "staff-1", auth.RoleStaff), &paymentsv1.RefundPaymentRequest{PaymentId: uuid.NewString(), Reason: "unknown"})
+	expectError(t, err, codes.NotFound, "payment_not_found")
+
+	_, err = f.client.RefundPayment(as(t, "staff-1", auth.RoleStaff), &paymentsv1.RefundPaymentRequest{PaymentId: "not-a-uuid"})
+	expectError(t, err, codes.InvalidArgument, problem.CodeValidationFailed)
+
+	if len(f.refunds) != 1 {
+		t.Errorf("refunds = %d, want only the first", len(f.refunds))
+	}
+}
+
+func TestWatchPayment(t *testing.T) {
+	f := newFixture(t)
+	id := f.current.PaymentID
+
+	stream, err := f.client.WatchPayment(as(t, "user-1", auth.RolePatient), &paymentsv1.WatchPaymentRequest{PaymentId: id.String(), Language: "de"})
+	if err != nil {
+		t.Fatalf("WatchPayment: %v", err)
+	}
+	if e, err := stream.Recv(); err != nil || e.Status != paymentsv1.PaymentStatus_PAYMENT_STATUS_PENDING {
+		t.Fatalf("first event = %v, %v, want the current status", e, err)
+	}
+
+	f.hub.Publish(statushub.Change{PaymentID: id, Status: domain.PaymentStatusProcessing})
+	f.hub.Publish(statushub.Change{PaymentID: id, Status: domain.PaymentStatusFailed, FailureCode: domain.FailureCardDeclined})
+	if e, err := stream.Recv(); err != nil || e.Status != paymentsv1.PaymentStatus_PAYMENT_STATUS_PROCESSING {
+		t.Errorf("event = %v, %v, want Processing", e, err)
+	}
+	e, err := stream.Recv()
+	if err != nil || e.Status != paymentsv1.PaymentStatus_PAYMENT_STATUS_FAILED || e.FailureMessage != domain.FailureCardDeclined.Message("de") {
+		t.Errorf("event = %v, %v, want Failed in German", e, err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("after a final status: err = %v, want the end of the stream", err)
+	}
+}
+
+func TestWatchPayment_Resumes(t *testing.T) {
+	f := newFixture(t)
+	id := f.current.PaymentID
+	f.hub.Publish(statushub.Change{PaymentID: id, Status: domain.PaymentStatusPending})
+	f.hub.Publish(statushub.Change{PaymentID: id, Status: domain.PaymentStatusProcessing})
+
+	stream, err := f.client.WatchPayment(as(t, "svc", auth.RoleService), &paymentsv1.WatchPaymentRequest{
+		PaymentId: id.String(),
+		After:     paymentsv1.PaymentStatus_PAYMENT_STATUS_PENDING,
+	})
+	if err != nil {
+		t.Fatalf("WatchPayment: %v", err)
+	}
+	if e, err := stream.Recv(); err != nil || e.Status != paymentsv1.PaymentStatus_PAYMENT_STATUS_PROCESSING {
+		t.Errorf("event = %v, %v, want Processing", e, err)
+	}
+
+	// Nothing follows a final status
+	stream, err = f.client.WatchPayment(as(t, "svc", auth.RoleService), &paymentsv1.WatchPaymentRequest{
+		PaymentId: id.String(),
+		After:     paymentsv1.PaymentStatus_PAYMENT_STATUS_COMPLETED,
+	})
+	if err != nil {
+		t.Fatalf("WatchPayment: %v", err)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("after Completed: err = %v, want the end of the stream", err)
+	}
+}
+
+func TestWatchPayment_EndsOnShutdown(t *testing.T) {
+	f := newFixture(t)
+	stream, err := f.client.WatchPayment(as(t, "user-1", auth.RolePatient), &paymentsv1.WatchPaymentRequest{PaymentId: f.current.PaymentID.String()})
+	if err != nil {
+		t.Fatalf("WatchPayment: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("first event: %v", err)
+	}
+	f.hub.Close()
+	_, err = stream.Recv()
+	expectError(t, err, codes.Unavailable, "shutting_down")
+}
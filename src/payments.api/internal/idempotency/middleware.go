@@ -0,0 +1,261 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// -----------------------------------------------------------------------
+// Request idempotency
+//
+// Architectural Decision: A client that times out cannot tell whether its
+// request took effect, so it retries. A mutating request carrying an
+// Idempotency-Key header claims the key in Postgres before the handler
+// runs and stores the response afterwards; a retry with the same key gets
+// the stored response instead of running the handler again – on any
+// replica. Keys are claimed per caller, so callers choosing the same key
+// do not collide. The key is bound to a fingerprint of the request
+// (method, path, caller, body): reusing it for a different request is a
+// client bug (422).
+// A retry arriving while the first request still runs gets 409 and should
+// retry later. Only outcomes are stored: server errors (5xx) release the
+// claim, so a retry after a failure runs the request again, and so do
+// refusals that say "not now" rather than "no" – a conflict with the
+// payment's current state (409, e.g. payment_changed), a rate limit (429),
+// a timeout (408) – since a retry may well succeed. Nor are 401 and 403
+// stored: the middleware runs before the route's role checks, and a request
+// refused for its credentials did not take effect, so a retry with a valid
+// token must not replay the refusal.
+// -----------------------------------------------------------------------
+
+// Header is the request header carrying the client's idempotency key.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marks a response replayed from the store.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxKeyLength bounds the keys clients may choose (a UUID is 36 characters).
+const maxKeyLength = 255
+
+// Options configures the middleware.
+type Options struct {
+	TTL          time.Duration // how long a key and its response are kept
+	LockTimeout  time.Duration // how long a request may hold its key before it is presumed dead
+	MaxBodyBytes int64         // largest request body read to fingerprint the request (413 beyond)
+
+	// Scope returns the caller the request is made for; keys are stored per
+	// caller, so two callers cannot replay each other's responses.
+	Scope func(c *gin.Context) string
+}
+
+// DefaultOptions keeps keys for a day, like most payment APIs, and accepts
+// bodies up to 1 MiB – far more than any request of this API carries.
+var DefaultOptions = Options{TTL: 24 * time.Hour, LockTimeout: time.Minute, MaxBodyBytes: 1 << 20}
+
+// Middleware makes POST, PUT, PATCH and DELETE requests carrying an
+// Idempotency-Key header idempotent. Requests without the header pass through.
+func Middleware(store Store, opts Options, logger *slog.Logger) gin.HandlerFunc {
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultOptions.TTL
+	}
+	if opts.LockTimeout <= 0 {
+		opts.LockTimeout = DefaultOptions.LockTimeout
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = DefaultOptions.MaxBodyBytes
+	}
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(Header)
+		if key == "" || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			problem.Abort(c, problem.Invalid(Header, "max", "Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		// The body is held in memory to fingerprint it, so its size is bounded
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, opts.MaxBodyBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Abort(c, problem.New(http.StatusRequestEntityTooLarge, "body_too_large",
+				fmt.Sprintf("request body must be at most %d bytes", tooLarge.Limit)))
+			return
+		}
+		if err != nil {
+			problem.Abort(c, problem.New(http.StatusBadRequest, problem.CodeMalformedBody, "failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		var scope string
+		if opts.Scope != nil {
+			scope = opts.Scope(c)
+		}
+		fingerprint := Fingerprint(c.Request.Method, c.Request.URL.RequestURI(), scope, body)
+
+		// The outcome is stored even if the client gives up waiting
+		ctx := context.WithoutCancel(c.Request.Context())
+		record, err := store.Begin(ctx, scope, key, fingerprint, opts.LockTimeout, opts.TTL)
+		if err != nil {
+			logger.Error("failed to claim idempotency key", "key", key, "error", err)
+			problem.Abort(c, problem.New(http.StatusInternalServerError, "idempotency_unavailable", "idempotency key could not be checked"))
+			return
+		}
+
+		switch {
+		case record != nil && record.Fingerprint != fingerprint:
+			problem.Abort(c, problem.New(http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used for a different request"))
+			return
+		case record != nil && record.Response == nil:
+			problem.Abort(c, problem.New(http.StatusConflict, "idempotency_key_in_progress", "a request with this Idempotency-Key is in progress; retry later"))
+			return
+		case record != nil:
+			replay(c, record.Response)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		completed := false
+		defer func() {
+			// Reached without completing when the handler failed or panicked
+			if !completed {
+				if err := store.Release(ctx, scope, key); err != nil {
+					logger.Error("failed to release idempotency key", "key", key, "error", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if !storable(status) {
+			return
+		}
+		if err := store.Complete(ctx, scope, key, Response{
+			Status:      status,
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Location:    c.Writer.Header().Get("Location"),
+			Body:        recorder.body.Bytes(),
+		}); err != nil {
+			logger.Error("failed to store idempotent response", "key", key, "error", err)
+			return
+		}
+		completed = true
+	}
+}
+
+// Fingerprint identifies a request: the same key may only be reused for an
+// identical request.
+func Fingerprint(method, uri, scope string, body []byte) string {
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(method), []byte(uri), []byte(scope), body} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// storable reports whether a response is the outcome of the request, to be
+// replayed to retries.
+func storable(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden,
+		http.StatusRequestTimeout, http.StatusConflict, http.StatusTooEarly, http.StatusTooManyRequests:
+		return false
+	default:
+		return status < http.StatusInternalServerError
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func replay(c *gin.Context, resp *Response) {
+	c.Header(ReplayedHeader, "true")
+	if resp.Location != "" {
+		c.Header("Location", resp.Location)
+	}
+	contentType := resp.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Data(resp.Status, contentType, resp.Body)
+	c.Abort()
+}
+
+// responseRecorder keeps a copy of the response body.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// ---------------------------------------------------------------------------
+// Cleaner
+// ---------------------------------------------------------------------------
+
+// Cleaner deletes expired keys periodically. Expired keys are already
+// ignored by Begin; the cleaner only keeps the table small.
+type Cleaner struct {
+	store    Store
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+// NewCleaner creates a cleaner running every interval.
+func NewCleaner(store Store, interval time.Duration, logger *slog.Logger) *Cleaner {
+	return &Cleaner{store: store, interval: interval, logger: logger}
+}
+
+// Run starts the cleanup loop. It blocks until ctx is cancelled.
+// Designed to be run as a goroutine.
+func (w *Cleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := w.store.DeleteExpired(ctx)
+			if err != nil {
+				w.logger.Error("failed to delete expired idempotency keys", "error", err)
+				continue
+			}
+			if n > 0 {
+				w.logger.Info("deleted expired idempotency keys", "count", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package idempotency_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smart-health/payments-api/internal/idempotency"
+)
+
+// memoryStore is a Store in a map, honouring expiry like the Postgres store.
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*idempotency.Record
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]*idempotency.Record)}
+}
+
+func (s *memoryStore) Begin(_ context.Context, scope, key, fingerprint string, _, ttl time.Duration) (*idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if r, ok := s.records[scope+"/"+key]; ok && r.ExpiresAt.After(now) {
+		copied := *r
+		return &copied, nil
+	}
+	s.records[scope+"/"+key] = &idempotency.Record{Scope: scope, Key: key, Fingerprint: fingerprint, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, scope, key string, resp idempotency.Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[scope+"/"+key].Response = &resp
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, scope+"/"+key)
+	return nil
+}
+
+func (s *memoryStore) DeleteExpired(context.Context) (int64, error) { return 0, nil }
+
+type testServer struct {
+	router  *gin.Engine
+	calls   int
+	started chan struct{} // when set, the handler signals it runs...
+	release chan struct{} // ...and blocks until release is closed
+	status  int
+}
+
+func newServer(store idempotency.Store, ttl time.Duration) *testServer {
+	gin.SetMode(gin.TestMode)
+	s := &testServer{router: gin.New(), status: http.StatusCreated}
+	s.router.Use(idempotency.Middleware(store, idempotency.Options{
+		TTL:   ttl,
+		Scope: func(c *gin.Context) string { return c.GetHeader("X-User-ID") },
+	}, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	s.router.POST("/payments", func(c *gin.Context) {
+		s.calls++
+		if s.release != nil {
+			s.started <- struct{}{}
+			<-s.release
+		}
+		c.JSON(s.status, gin.H{"call": s.calls})
+	})
+	return s
+}
+
+func (s *testServer) post(key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.Header, key)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_ReplaysStoredResponse(t *testing.T) {
+	s := newServer(newMemoryStore(), time.Hour)
+
+	first := s.post("key-1", `{"amount":10}`)
+	second := s.post("key-1", `{"amount":10}`)
+
+	if s.calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", s.calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("expected the first response replayed, got %d %s", second.Code, second.Body)
+	}
+	if second.Header().Get(idempotency.ReplayedHeader) != "true" {
+		t.Errorf("expected the replayed response to be marked")
+	}
+	if first.Header().Get(idempotency.ReplayedHeader) != "" {
+		t.Errorf("expected the original response not to be marked")
+	}
+}
+
+func TestMiddleware_RejectsKeyReusedForDifferentRequest(t *testing.T) {
+	s := newServer(newMemoryStore(), time.Hour)
+
+	s.post("key-1", `{"amount":10}`)
+	if w := s.post("key-1", `{"amount":20}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a different body, got %d", w.Code)
+	}
+	if s.calls != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", s.calls)
+	}
+}
+
+func TestMiddleware_ConflictWhileInProgress(t *testing.T) {
+	s := newServer(newMemoryStore(), time.Hour)
+	s.started = make(chan struct{})
+	s.release = make(chan struct{})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- s.post("key-1", `{}`) }()
+	<-s.started
+
+	if w := s.post("key-1", `{}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 while the first request runs, got %d", w.Code)
+	}
+
+	close(s.release)
+	if w := <-done; w.Code != http.StatusCreated {
+		t.Errorf("expected the first request to complete, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_ServerErrorsAreNotStored(t *testing.T) {
+	s := newServer(newMemoryStore(), time.Hour)
+	s.status = http.StatusServiceUnavailable
+	s.post("key-1", `{}`)
+
+	s.status = http.StatusCreated
+	if w := s.post("key-1", `{}`); w.Code != http.StatusCreated || s.calls != 2 {
+		t.Errorf("expected the retry to run again, got %d after %d calls", w.Code, s.calls)
+	}
+}
+
+func TestMiddleware_AuthFailuresAreNotStored(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		s := newServer(newMemoryStore(), time.Hour)
+		s.status = status
+		s.post("key-1", `{}`)
+
+		// Retried with a token that is accepted
+		s.status = http.StatusCreated
+		if w := s.post("key-1", `{}`); w.Code != http.StatusCreated || s.calls != 2 {
+			t.Errorf("after %d: expected the retry to run again, got %d after %d calls", status, w.Code, s.calls)
+		}
+	}
+}
+
+func TestMiddleware_TransientRefusalsAreNotStored(t *testing.T) {
+	for _, status := range []int{http.StatusConflict, http.StatusTooManyRequests, http.StatusRequestTimeout} {
+		s := newServer(newMemoryStore(), time.Hour)
+		s.status = status
+		s.post("key-1", `{}`)
+
+		// e.g. retried after the concurrent update or the rate limit passed
+		s.status = http.StatusCreated
+		if w := s.post("key-1", `{}`); w.Code != http.StatusCreated || s.calls != 2 {
+			t.Errorf("after %d: expected the retry to run again, got %d after %d calls", status, w.Code, s.calls)
+		}
+	}
+}
+
+func TestMiddleware_ValidationFailuresAreStored(t *testing.T) {
+	s := newServer(newMemoryStore(), time.Hour)
+	s.status = http.StatusUnprocessableEntity
+	s.post("key-1", `{}`)
+
+	s.status = http.StatusCreated
+	if w := s.post("key-1", `{}`); w.Code != http.StatusUnprocessableEntity || s.calls != 1 {
+		t.Errorf("expected the refusal replayed, got %d after %d calls", w.Code, s.calls)
+	}
+}
+
+func TestMiddleware_ExpiredKeysRunAgain(t *testing.T) {
+	s := newServer(newMemoryStore(), time.Millisecond)
+	s.post("key-1", `{}`)
+	time.Sleep(5 * time.Millisecond)
+	s.post("key-1", `{}`)
+	if s.calls != 2 {
+		t.Errorf("expected an expired key to run the request again, ran %d times", s.calls)
+	}
+}
+
+func TestMiddleware_ScopesKeysByCaller(t *testing.T) {
+	s := newServer(newMemoryStore(), time.Hour)
+	s.post("key-1", `{}`)
+
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(`{}`))
+	req.Header.Set(idempotency.Header, "key-1")
+	req.Header.Set("X-User-ID", "someone-else")
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || w.Header().Get(idempotency.ReplayedHeader) != "" || s.calls != 2 {
+		t.Errorf("expected another caller's request with the same key to run, got %d after %d calls", w.Code, s.calls)
+	}
+}
+
+func TestMiddleware_RejectsOversizedBodies(t *testing.T) {
+	s := newServer(newMemoryStore(), time.Hour)
+
+	w := s.post("key-1", `{"notes":"`+strings.Repeat("x", int(idempotency.DefaultOptions.MaxBodyBytes))+`"}`)
+	if w.Code != http.StatusRequestEntityTooLarge || s.calls != 0 {
+		t.Errorf("expected 413 without running the handler, got %d after %d calls", w.Code, s.calls)
+	}
+	// The key was never claimed
+	if w := s.post("key-1", `{}`); w.Code != http.StatusCreated {
+		t.Errorf("expected the key to stay free, got %d", w.Code)
+	}
+}
+
+func TestMiddleware_WithoutKeyPassesThrough(t *testing.T) {
+	s := newServer(newMemoryStore(), time.Hour)
+	s.post("", `{}`)
+	s.post("", `{}`)
+	if s.calls != 2 {
+		t.Errorf("expected requests without a key to run every time, ran %d times", s.calls)
+	}
+}
@@ -0,0 +1,158 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Response is a stored HTTP response, replayed for repeated keys.
+type Response struct {
+	Status      int
+	ContentType string
+	Location    string
+	Body        []byte
+}
+
+// Record is the state of an idempotency key.
+type Record struct {
+	Scope       string // caller the key belongs to; keys are unique per caller
+	Key         string
+	Fingerprint string    // hash of the request the key was first used with
+	Response    *Response // nil while the first request is in progress
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Store persists idempotency keys. Keys are chosen by clients, so they are
+// stored per scope (the caller): two callers may use the same key.
+type Store interface {
+	// Begin claims the key of a scope for a request. It returns nil when the
+	// request may run, or the record already stored under the key. Expired
+	// keys, and keys whose request has held them longer than lockTimeout (the
+	// replica crashed), are claimed anew.
+	Begin(ctx context.Context, scope, key, fingerprint string, lockTimeout, ttl time.Duration) (*Record, error)
+	// Complete stores the response of the request that claimed the key.
+	Complete(ctx context.Context, scope, key string, resp Response) error
+	// Release gives up a claim without a response, so the key can be retried.
+	Release(ctx context.Context, scope, key string) error
+	// DeleteExpired removes expired keys and returns how many there were.
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// PostgresStore implements Store using PostgreSQL.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore creates a new PostgreSQL-backed idempotency key store.
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// Begin claims the key of a scope for a request, or returns the record stored under it.
+func (s *PostgresStore) Begin(ctx context.Context, scope, key, fingerprint string, lockTimeout, ttl time.Duration) (*Record, error) {
+	// A concurrent claim may free the key between the insert and the read: try again once
+	for attempt := 0; attempt < 2; attempt++ {
+		now := time.Now().UTC()
+		if _, err := s.pool.Exec(ctx, `
+			DELETE FROM idempotency_keys
+			WHERE scope = $1 AND key = $2 AND (expires_at <= $3 OR (response_status IS NULL AND locked_until <= $3))`,
+			scope, key, now); err != nil {
+			return nil, fmt.Errorf("free idempotency key: %w", err)
+		}
+
+		tag, err := s.pool.Exec(ctx, `
+			INSERT INTO idempotency_keys (scope, key, fingerprint, locked_until, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (scope, key) DO NOTHING`,
+			scope, key, fingerprint, now.Add(lockTimeout), now, now.Add(ttl))
+		if err != nil {
+			return nil, fmt.Errorf("claim idempotency key: %w", err)
+		}
+		if tag.RowsAffected() == 1 {
+			return nil, nil
+		}
+
+		record, err := s.find(ctx, scope, key)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			return record, nil
+		}
+	}
+	return nil, fmt.Errorf("claim idempotency key: key %q changed concurrently", key)
+}
+
+func (s *PostgresStore) find(ctx context.Context, scope, key string) (*Record, error) {
+	var r Record
+	var status *int
+	var contentType, location *string
+	var body []byte
+	err := s.pool.QueryRow(ctx, `
+		SELECT scope, key, fingerprint, response_status, response_content_type, response_location, response_body, created_at, expires_at
+		FROM idempotency_keys WHERE scope = $1 AND key = $2`, scope, key).
+		Scan(&r.Scope, &r.Key, &r.Fingerprint, &status, &contentType, &location, &body, &r.CreatedAt, &r.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find idempotency key: %w", err)
+	}
+
+	if status != nil {
+		r.Response = &Response{Status: *status, Body: body}
+		if contentType != nil {
+			r.Response.ContentType = *contentType
+		}
+		if location != nil {
+			r.Response.Location = *location
+		}
+	}
+	return &r, nil
+}
+
+// Complete stores the response of the request that claimed the key.
+func (s *PostgresStore) Complete(ctx context.Context, scope, key string, resp Response) error {
+	_, err := s.pool.Exec(ctx, `
+		UPDATE idempotency_keys SET
+			response_status = $3,
+			response_content_type = $4,
+			response_location = $5,
+			response_body = $6
+		WHERE scope = $1 AND key = $2`,
+		scope, key, resp.Status, nilIfEmpty(resp.ContentType), nilIfEmpty(resp.Location), resp.Body)
+	if err != nil {
+		return fmt.Errorf("store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the claim of a request that produced no response to keep.
+func (s *PostgresStore) Release(ctx context.Context, scope, key string) error {
+	if _, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND response_status IS NULL`, scope, key); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes expired keys.
+func (s *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,221 @@
+package invoicing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+// Document kinds. Each kind has its own gapless number series per clinic.
+const (
+	KindInvoice    = "invoice"
+	KindCreditNote = "credit_note"
+)
+
+// ErrInvalidCreditNote is returned when a credit note violates its invariants.
+var ErrInvalidCreditNote = errors.New("invalid credit note")
+
+// ErrInvoiceNotFound is returned when an invoice record cannot be found.
+// Either ID or PaymentID is set, depending on how the invoice was looked up.
+type ErrInvoiceNotFound struct {
+	ID        uuid.UUID
+	PaymentID uuid.UUID
+}
+
+func (e *ErrInvoiceNotFound) Error() string {
+	if e.ID == uuid.Nil && e.PaymentID != uuid.Nil {
+		return fmt.Sprintf("invoice for payment %s was not found", e.PaymentID)
+	}
+	return fmt.Sprintf("invoice %s was not found", e.ID)
+}
+
+// -----------------------------------------------------------------------
+// Invoice
+//
+// Architectural Decision: An invoice is an immutable snapshot of the payment
+// at completion time – patient, line items, taxes and totals are copied, not
+// referenced, so later changes to fee schedules, tax rates or the payment
+// itself never alter an issued document. Corrections are made by issuing a
+// credit note against the invoice, never by editing it.
+//
+// Numbers are allocated from a per-clinic, per-kind counter row inside the
+// transaction that inserts the document. The row lock serialises concurrent
+// issuers and a rollback returns the number, so the series has no gaps.
+// -----------------------------------------------------------------------
+
+// Line is a billed service as printed on the invoice.
+type Line struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unitPrice"`
+	Discount    float64 `json:"discount"`
+	Tax         float64 `json:"tax"`
+	Total       float64 `json:"total"`
+}
+
+// TaxLine is an itemized tax as printed on the invoice.
+type TaxLine struct {
+	Name         string  `json:"name"`
+	Jurisdiction string  `json:"jurisdiction"`
+	ItemCode     string  `json:"itemCode,omitempty"`
+	Percent      float64 `json:"percent"`
+	Inclusive    bool    `json:"inclusive"`
+	Amount       float64 `json:"amount"`
+}
+
+// Invoice is an issued invoice or credit note.
+type Invoice struct {
+	ID                uuid.UUID
+	Kind              string
+	ClinicID          string
+	Sequence          int64  // position in the clinic's series for this kind
+	Number            string // printed number, e.g. "INV-DOWNTOWN-000042"
+	PaymentID         uuid.UUID
+	AppointmentID     uuid.UUID
+	PatientID         string
+	Currency          string
+	Lines             []Line
+	TaxLines          []TaxLine
+	Subtotal          float64
+	Discount          float64
+	TaxAmount         float64
+	Total             float64
+	TransactionID     string     // payment provider reference, e.g. the Stripe PaymentIntent ID
+	CreditedInvoiceID *uuid.UUID // credit notes only
+	Reason            string     // credit notes only
+	IssuedAt          time.Time
+}
+
+// NewInvoice snapshots a completed payment into an unnumbered invoice.
+// The repository assigns the number when the invoice is issued.
+func NewInvoice(payment *domain.Payment, clinicID string, now time.Time) *Invoice {
+	inv := &Invoice{
+		ID:            uuid.New(),
+		Kind:          KindInvoice,
+		ClinicID:      clinicID,
+		PaymentID:     payment.ID,
+		AppointmentID: payment.AppointmentID,
+		PatientID:     payment.UserID,
+		Currency:      payment.Currency,
+		Subtotal:      payment.Subtotal,
+		TaxAmount:     payment.TaxAmount,
+		Total:         payment.Amount,
+		TransactionID: payment.TransactionID,
+		IssuedAt:      now,
+	}
+	if payment.Discount != nil {
+		inv.Discount = payment.Discount.Amount
+	}
+
+	for _, item := range payment.LineItems {
+		inv.Lines = append(inv.Lines, Line{
+			Code:        item.Code,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Discount:    item.Discount,
+			Tax:         item.Tax,
+			Total:       item.Total,
+		})
+	}
+	if len(inv.Lines) == 0 {
+		// Single-amount payments predating line items are printed as one line
+		inv.Lines = []Line{{
+			Code:        "PAYMENT",
+			Description: "Appointment payment",
+			Quantity:    1,
+			UnitPrice:   payment.Subtotal,
+			Discount:    inv.Discount,
+			Tax:         payment.TaxAmount,
+			Total:       payment.Amount,
+		}}
+	}
+
+	for _, l := range payment.TaxLines {
+		inv.TaxLines = append(inv.TaxLines, TaxLine{
+			Name:         l.Name,
+			Jurisdiction: l.Jurisdiction,
+			ItemCode:     l.ItemCode,
+			Percent:      l.Percent,
+			Inclusive:    l.Inclusive,
+			Amount:       l.Amount,
+		})
+	}
+	return inv
+}
+
+// NewCreditNote creates an unnumbered credit note refunding amount of the
+// invoice. credited is the total of the credit notes already issued against
+// it; the invoice cannot be credited beyond its total. Taxes are reversed in
+// proportion to the credited amount.
+func NewCreditNote(invoice *Invoice, credited, amount float64, reason string, now time.Time) (*Invoice, error) {
+	if invoice.Kind != KindInvoice {
+		return nil, fmt.Errorf("%w: only invoices can be credited", ErrInvalidCreditNote)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidCreditNote)
+	}
+	remaining := roundCents(invoice.Total - credited)
+	if amount <= 0 || roundCents(amount) > remaining {
+		return nil, fmt.Errorf("%w: amount must be in (0, %.2f]", ErrInvalidCreditNote, remaining)
+	}
+	amount = roundCents(amount)
+
+	ratio := amount / invoice.Total
+	creditedID := invoice.ID
+	note := &Invoice{
+		ID:                uuid.New(),
+		Kind:              KindCreditNote,
+		ClinicID:          invoice.ClinicID,
+		PaymentID:         invoice.PaymentID,
+		AppointmentID:     invoice.AppointmentID,
+		PatientID:         invoice.PatientID,
+		Currency:          invoice.Currency,
+		TaxAmount:         roundCents(invoice.TaxAmount * ratio),
+		Total:             amount,
+		TransactionID:     invoice.TransactionID,
+		CreditedInvoiceID: &creditedID,
+		Reason:            reason,
+		IssuedAt:          now,
+	}
+	note.Subtotal = amount
+	note.Lines = []Line{{
+		Code:        "CREDIT",
+		Description: fmt.Sprintf("Credit for invoice %s: %s", invoice.Number, reason),
+		Quantity:    1,
+		UnitPrice:   amount,
+		Tax:         note.TaxAmount,
+		Total:       amount,
+	}}
+
+	var taxed float64
+	for i, l := range invoice.TaxLines {
+		l.Amount = roundCents(l.Amount * ratio)
+		if i == len(invoice.TaxLines)-1 {
+			// Rounding residual goes to the last line so the lines add up
+			l.Amount = roundCents(note.TaxAmount - taxed)
+		}
+		taxed += l.Amount
+		note.TaxLines = append(note.TaxLines, l)
+	}
+	return note, nil
+}
+
+// FormatNumber renders the printed number of a document in a clinic's series.
+func FormatNumber(kind, clinicID string, sequence int64) string {
+	prefix := "INV"
+	if kind == KindCreditNote {
+		prefix = "CN"
+	}
+	return fmt.Sprintf("%s-%s-%06d", prefix, strings.ToUpper(clinicID), sequence)
+}
+
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
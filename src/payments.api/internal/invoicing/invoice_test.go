@@ -0,0 +1,86 @@
+package invoicing_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/invoicing"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+func completedPayment(t *testing.T) *domain.Payment {
+	t.Helper()
+	p, err := domain.NewPaymentWithLineItems(uuid.New(), "user-1", []domain.LineItem{
+		{Code: "CONSULTATION", Description: "Consultation", Quantity: 1, UnitPrice: 80},
+		{Code: "CBC", Description: "Complete blood count", Quantity: 1, UnitPrice: 20},
+	}, "usd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.ApplyTax([]domain.TaxLine{{Name: "Lab tax", ItemCode: "CBC", Percent: 10, Amount: 2}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = p.MarkProcessing("pi_123")
+	_ = p.MarkCompleted()
+	return p
+}
+
+func TestNewInvoice_SnapshotsPayment(t *testing.T) {
+	p := completedPayment(t)
+	inv := invoicing.NewInvoice(p, "DOWNTOWN", time.Now())
+
+	if inv.Total != 102 || inv.Subtotal != 100 || inv.TaxAmount != 2 {
+		t.Errorf("unexpected totals: subtotal %v, tax %v, total %v", inv.Subtotal, inv.TaxAmount, inv.Total)
+	}
+	if len(inv.Lines) != 2 || inv.Lines[1].Total != 22 {
+		t.Errorf("unexpected lines: %+v", inv.Lines)
+	}
+	if inv.TransactionID != "pi_123" || inv.PatientID != "user-1" {
+		t.Errorf("unexpected references: %+v", inv)
+	}
+
+	// The snapshot must not change with the payment
+	p.LineItems[0].Description = "changed"
+	if inv.Lines[0].Description != "Consultation" {
+		t.Error("expected invoice lines to be copied, not shared")
+	}
+}
+
+func TestNewCreditNote_ReversesTaxProportionally(t *testing.T) {
+	inv := invoicing.NewInvoice(completedPayment(t), "DOWNTOWN", time.Now())
+	inv.Number = "INV-DOWNTOWN-000001"
+
+	note, err := invoicing.NewCreditNote(inv, 0, 51, "lab test cancelled", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if note.Kind != invoicing.KindCreditNote || note.Total != 51 || note.TaxAmount != 1 {
+		t.Errorf("unexpected credit note: kind %v, total %v, tax %v", note.Kind, note.Total, note.TaxAmount)
+	}
+	if note.CreditedInvoiceID == nil || *note.CreditedInvoiceID != inv.ID {
+		t.Error("expected credit note to reference the invoice")
+	}
+}
+
+func TestNewCreditNote_CannotExceedRemaining(t *testing.T) {
+	inv := invoicing.NewInvoice(completedPayment(t), "DOWNTOWN", time.Now())
+
+	_, err := invoicing.NewCreditNote(inv, 100, 2.01, "refund", time.Now())
+	if !errors.Is(err, invoicing.ErrInvalidCreditNote) {
+		t.Fatalf("expected ErrInvalidCreditNote, got %v", err)
+	}
+	if _, err := invoicing.NewCreditNote(inv, 100, 2, "refund", time.Now()); err != nil {
+		t.Errorf("unexpected error crediting the remainder: %v", err)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	if got := invoicing.FormatNumber(invoicing.KindInvoice, "downtown", 42); got != "INV-DOWNTOWN-000042" {
+		t.Errorf("unexpected invoice number %q", got)
+	}
+	if got := invoicing.FormatNumber(invoicing.KindCreditNote, "DOWNTOWN", 7); got != "CN-DOWNTOWN-000007" {
+		t.Errorf("unexpected credit note number %q", got)
+	}
+}
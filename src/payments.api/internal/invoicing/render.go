@@ -0,0 +1,177 @@
+package invoicing
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// Receipt is an invoice together with the credit notes issued against it.
+type Receipt struct {
+	Invoice     *Invoice
+	CreditNotes []*Invoice
+}
+
+// Credited returns the total of the receipt's credit notes.
+func (r Receipt) Credited() float64 {
+	var total float64
+	for _, n := range r.CreditNotes {
+		total += n.Total
+	}
+	return roundCents(total)
+}
+
+// Balance returns the amount the patient has paid net of credits.
+func (r Receipt) Balance() float64 {
+	return roundCents(r.Invoice.Total - r.Credited())
+}
+
+func money(amount float64, currency string) string {
+	return fmt.Sprintf("%.2f %s", amount, strings.ToUpper(currency))
+}
+
+var receiptTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"money": money,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Receipt {{.Invoice.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; margin: 1em 0; }
+th, td { border-bottom: 1px solid #ddd; padding: 6px 8px; text-align: left; }
+td.num, th.num { text-align: right; }
+.totals td { border: none; }
+</style>
+</head>
+<body>
+{{with .Invoice}}
+<h1>Receipt</h1>
+<p>
+Invoice number: <strong>{{.Number}}</strong><br>
+Issued: {{.IssuedAt.Format "2006-01-02 15:04 MST"}}<br>
+Clinic: {{.ClinicID}}<br>
+Patient: {{.PatientID}}<br>
+Appointment: {{.AppointmentID}}<br>
+Payment: {{.PaymentID}}{{if .TransactionID}}<br>
+Transaction: {{.TransactionID}}{{end}}
+</p>
+<table>
+<thead><tr><th>Code</th><th>Description</th><th class="num">Qty</th><th class="num">Unit price</th><th class="num">Discount</th><th class="num">Tax</th><th class="num">Total</th></tr></thead>
+<tbody>
+{{- $currency := .Currency}}
+{{- range .Lines}}
+<tr><td>{{.Code}}</td><td>{{.Description}}</td><td class="num">{{.Quantity}}</td><td class="num">{{money .UnitPrice $currency}}</td><td class="num">{{money .Discount $currency}}</td><td class="num">{{money .Tax $currency}}</td><td class="num">{{money .Total $currency}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{if .TaxLines}}
+<table>
+<thead><tr><th>Tax</th><th>Jurisdiction</th><th>Item</th><th class="num">Rate</th><th class="num">Amount</th></tr></thead>
+<tbody>
+{{- range .TaxLines}}
+<tr><td>{{.Name}}{{if .Inclusive}} (included){{end}}</td><td>{{.Jurisdiction}}</td><td>{{.ItemCode}}</td><td class="num">{{printf "%.2f" .Percent}} %</td><td class="num">{{money .Amount $currency}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+{{end}}
+<table class="totals">
+<tr><td class="num">Subtotal</td><td class="num">{{money .Subtotal .Currency}}</td></tr>
+{{- if .Discount}}
+<tr><td class="num">Discount</td><td class="num">-{{money .Discount .Currency}}</td></tr>
+{{- end}}
+<tr><td class="num">Tax</td><td class="num">{{money .TaxAmount .Currency}}</td></tr>
+<tr><td class="num"><strong>Total paid</strong></td><td class="num"><strong>{{money .Total .Currency}}</strong></td></tr>
+</table>
+{{end}}
+{{if .CreditNotes}}
+<h2>Credit notes</h2>
+<table>
+<thead><tr><th>Number</th><th>Issued</th><th>Reason</th><th class="num">Amount</th></tr></thead>
+<tbody>
+{{- range .CreditNotes}}
+<tr><td>{{.Number}}</td><td>{{.IssuedAt.Format "2006-01-02"}}</td><td>{{.Reason}}</td><td class="num">-{{money .Total .Currency}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<p><strong>Balance: {{money .Balance .Invoice.Currency}}</strong></p>
+{{end}}
+</body>
+</html>
+`))
+
+// RenderHTML writes the receipt as a standalone HTML page.
+func RenderHTML(w io.Writer, r Receipt) error {
+	return receiptTemplate.Execute(w, r)
+}
+
+// RenderPDF writes the receipt as a single-font, text-only PDF document.
+func RenderPDF(w io.Writer, r Receipt) error {
+	return writePDF(w, receiptText(r))
+}
+
+// receiptText lays out the receipt as monospaced text lines for the PDF.
+func receiptText(r Receipt) []string {
+	inv := r.Invoice
+	cur := inv.Currency
+	lines := []string{
+		"RECEIPT",
+		"",
+		"Invoice number: " + inv.Number,
+		"Issued:         " + inv.IssuedAt.Format("2006-01-02 15:04 MST"),
+		"Clinic:         " + inv.ClinicID,
+		"Patient:        " + inv.PatientID,
+		"Appointment:    " + inv.AppointmentID.String(),
+		"Payment:        " + inv.PaymentID.String(),
+	}
+	if inv.TransactionID != "" {
+		lines = append(lines, "Transaction:    "+inv.TransactionID)
+	}
+
+	lines = append(lines, "", fmt.Sprintf("%-14s %-30s %4s %14s %14s", "Code", "Description", "Qty", "Tax", "Total"))
+	for _, l := range inv.Lines {
+		lines = append(lines, fmt.Sprintf("%-14s %-30s %4d %14s %14s",
+			clip(l.Code, 14), clip(l.Description, 30), l.Quantity, money(l.Tax, cur), money(l.Total, cur)))
+	}
+
+	if len(inv.TaxLines) > 0 {
+		lines = append(lines, "", "Taxes")
+		for _, t := range inv.TaxLines {
+			name := t.Name
+			if t.Inclusive {
+				name += " (included)"
+			}
+			lines = append(lines, fmt.Sprintf("  %-40s %-14s %6.2f %% %14s",
+				clip(name, 40), clip(t.ItemCode, 14), t.Percent, money(t.Amount, cur)))
+		}
+	}
+
+	lines = append(lines, "", fmt.Sprintf("%66s %14s", "Subtotal", money(inv.Subtotal, cur)))
+	if inv.Discount != 0 {
+		lines = append(lines, fmt.Sprintf("%66s %14s", "Discount", "-"+money(inv.Discount, cur)))
+	}
+	lines = append(lines,
+		fmt.Sprintf("%66s %14s", "Tax", money(inv.TaxAmount, cur)),
+		fmt.Sprintf("%66s %14s", "Total paid", money(inv.Total, cur)),
+	)
+
+	if len(r.CreditNotes) > 0 {
+		lines = append(lines, "", "Credit notes")
+		for _, n := range r.CreditNotes {
+			lines = append(lines, fmt.Sprintf("  %-24s %-10s %-28s %14s",
+				n.Number, n.IssuedAt.Format("2006-01-02"), clip(n.Reason, 28), "-"+money(n.Total, cur)))
+		}
+		lines = append(lines, "", fmt.Sprintf("%66s %14s", "Balance", money(r.Balance(), cur)))
+	}
+	return lines
+}
+
+func clip(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "~"
+}
@@ -0,0 +1,61 @@
+package invoicing_test
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/invoicing"
+)
+
+func TestRenderHTML_EscapesSnapshot(t *testing.T) {
+	inv := invoicing.NewInvoice(completedPayment(t), "DOWNTOWN", time.Now())
+	inv.Number = "INV-DOWNTOWN-000001"
+	inv.Lines[0].Description = "<script>alert(1)</script>"
+
+	var buf bytes.Buffer
+	if err := invoicing.RenderHTML(&buf, invoicing.Receipt{Invoice: inv}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	html := buf.String()
+	if strings.Contains(html, "<script>") {
+		t.Error("expected line descriptions to be escaped")
+	}
+	if !strings.Contains(html, "INV-DOWNTOWN-000001") || !strings.Contains(html, "102.00 USD") {
+		t.Error("expected invoice number and total in the receipt")
+	}
+}
+
+func TestRenderPDF_ValidCrossReference(t *testing.T) {
+	inv := invoicing.NewInvoice(completedPayment(t), "DOWNTOWN", time.Now())
+	inv.Number = "INV-DOWNTOWN-000001"
+
+	var buf bytes.Buffer
+	if err := invoicing.RenderPDF(&buf, invoicing.Receipt{Invoice: inv}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pdf := buf.String()
+	if !strings.HasPrefix(pdf, "%PDF-1.4") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatal("expected a PDF header and trailer")
+	}
+
+	// startxref must point at the xref table, and every entry at its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(pdf)
+	if m == nil {
+		t.Fatal("missing startxref")
+	}
+	xref, _ := strconv.Atoi(m[1])
+	if !strings.HasPrefix(pdf[xref:], "xref\n") {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllStringSubmatch(pdf[xref:], -1)
+	for i, e := range entries {
+		off, _ := strconv.Atoi(e[1])
+		if want := strconv.Itoa(i+1) + " 0 obj"; !strings.HasPrefix(pdf[off:], want) {
+			t.Errorf("xref entry %d points at %q", i+1, pdf[off:off+10])
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package invoicing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+// Repository defines the read and credit note contract for invoices.
+// Invoices are immutable: there is no update operation.
+type Repository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*Invoice, error)
+	// FindByPaymentID returns the invoice (not the credit notes) issued for a payment.
+	FindByPaymentID(ctx context.Context, paymentID uuid.UUID) (*Invoice, error)
+	// CreditNotes returns the credit notes issued against an invoice, oldest first.
+	CreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*Invoice, error)
+	// IssueCreditNote credits amount of the invoice, numbering the credit note
+	// in the same transaction that checks the remaining creditable amount.
+	IssueCreditNote(ctx context.Context, invoiceID uuid.UUID, amount float64, reason string) (*Invoice, error)
+}
+
+// Issuer issues the invoice of a completed payment inside the payment's
+// transaction, so an invoice exists exactly when the completed payment does.
+type Issuer interface {
+	// Issue snapshots and numbers the payment's invoice. It is a no-op when
+	// the payment has already been invoiced.
+	Issue(ctx context.Context, tx pgx.Tx, payment *domain.Payment) error
+}
+
+// PostgresRepository implements Repository and Issuer using PostgreSQL.
+type PostgresRepository struct {
+	pool            *pgxpool.Pool
+	defaultClinicID string
+}
+
+// NewPostgresRepository creates a new PostgreSQL-backed invoice repository.
+// Payments without a clinic are invoiced in defaultClinicID's series.
+func NewPostgresRepository(pool *pgxpool.Pool, defaultClinicID string) *PostgresRepository {
+	return &PostgresRepository{pool: pool, defaultClinicID: defaultClinicID}
+}
+
+// snapshot is the JSONB document holding the printed lines of an invoice.
+type snapshot struct {
+	Lines    []Line    `json:"lines"`
+	TaxLines []TaxLine `json:"taxLines"`
+}
+
+const invoiceColumns = `id, kind, clinic_id, sequence, number, payment_id, appointment_id, patient_id, currency,
+	subtotal, discount_amount, tax_amount, total, COALESCE(transaction_id, ''), credited_invoice_id,
+	COALESCE(reason, ''), snapshot, issued_at`
+
+// Issue snapshots the payment into an invoice numbered in its clinic's series.
+func (r *PostgresRepository) Issue(ctx context.Context, tx pgx.Tx, payment *domain.Payment) error {
+	var exists bool
+	err := tx.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM invoices WHERE payment_id = $1 AND kind = $2)`,
+		payment.ID, KindInvoice,
+	).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check existing invoice: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	clinicID := payment.ClinicID
+	if clinicID == "" {
+		clinicID = r.defaultClinicID
+	}
+	return r.insert(ctx, tx, NewInvoice(payment, clinicID, time.Now().UTC()))
+}
+
+// IssueCreditNote credits part or all of an invoice.
+func (r *PostgresRepository) IssueCreditNote(ctx context.Context, invoiceID uuid.UUID, amount float64, reason string) (*Invoice, error) {
+	var note *Invoice
+	err := r.withTransaction(ctx, func(tx pgx.Tx) error {
+		// Lock the invoice so concurrent credit notes see each other's amounts
+		invoice, err := scanInvoice(tx.QueryRow(ctx,
+			`SELECT `+invoiceColumns+` FROM invoices WHERE id = $1 FOR UPDATE`, invoiceID))
+		if err != nil {
+			return err
+		}
+		if invoice == nil {
+			return &ErrInvoiceNotFound{ID: invoiceID}
+		}
+
+		var credited float64
+		if err := tx.QueryRow(ctx,
+			`SELECT COALESCE(SUM(total), 0) FROM invoices WHERE credited_invoice_id = $1`, invoiceID,
+		).Scan(&credited); err != nil {
+			return fmt.Errorf("sum credit notes: %w", err)
+		}
+
+		note, err = NewCreditNote(invoice, credited, amount, reason, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		return r.insert(ctx, tx, note)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// insert allocates the next number of the document's series and stores it.
+func (r *PostgresRepository) insert(ctx context.Context, tx pgx.Tx, inv *Invoice) error {
+	// The upsert locks the counter row until the transaction ends; a rollback
+	// discards the increment, which is what keeps the series gapless.
+	err := tx.QueryRow(ctx, `
+		INSERT INTO invoice_sequences (clinic_id, kind, last_number)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (clinic_id, kind) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number`,
+		inv.ClinicID, inv.Kind,
+	).Scan(&inv.Sequence)
+	if err != nil {
+		return fmt.Errorf("allocate invoice number: %w", err)
+	}
+	inv.Number = FormatNumber(inv.Kind, inv.ClinicID, inv.Sequence)
+
+	doc, err := json.Marshal(snapshot{Lines: inv.Lines, TaxLines: inv.TaxLines})
+	if err != nil {
+		return fmt.Errorf("marshal invoice snapshot: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO invoices (id, kind, clinic_id, sequence, number, payment_id, appointment_id, patient_id, currency,
+		                      subtotal, discount_amount, tax_amount, total, transaction_id, credited_invoice_id, reason, snapshot, issued_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)`,
+		inv.ID,
+		inv.Kind,
+		inv.ClinicID,
+		inv.Sequence,
+		inv.Number,
+		inv.PaymentID,
+		inv.AppointmentID,
+		inv.PatientID,
+		inv.Currency,
+		inv.Subtotal,
+		inv.Discount,
+		inv.TaxAmount,
+		inv.Total,
+		nilIfEmpty(inv.TransactionID),
+		inv.CreditedInvoiceID,
+		nilIfEmpty(inv.Reason),
+		doc,
+		inv.IssuedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert invoice: %w", err)
+	}
+	return nil
+}
+
+// FindByID retrieves an invoice or credit note by its primary key.
+func (r *PostgresRepository) FindByID(ctx context.Context, id uuid.UUID) (*Invoice, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+invoiceColumns+` FROM invoices WHERE id = $1`, id)
+	return scanInvoice(row)
+}
+
+// FindByPaymentID retrieves the invoice issued for a payment.
+func (r *PostgresRepository) FindByPaymentID(ctx context.Context, paymentID uuid.UUID) (*Invoice, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+invoiceColumns+` FROM invoices WHERE payment_id = $1 AND kind = $2`, paymentID, KindInvoice)
+	return scanInvoice(row)
+}
+
+// CreditNotes retrieves the credit notes of an invoice in issue order.
+func (r *PostgresRepository) CreditNotes(ctx context.Context, invoiceID uuid.UUID) ([]*Invoice, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+invoiceColumns+`
+		FROM invoices
+		WHERE credited_invoice_id = $1
+		ORDER BY sequence`, invoiceID)
+	if err != nil {
+		return nil, fmt.Errorf("query credit notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []*Invoice
+	for rows.Next() {
+		note, err := scanInvoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
+
+func (r *PostgresRepository) withTransaction(ctx context.Context, fn func(pgx.Tx) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func scanInvoice(row pgx.Row) (*Invoice, error) {
+	var inv Invoice
+	var doc []byte
+	err := row.Scan(
+		&inv.ID,
+		&inv.Kind,
+		&inv.ClinicID,
+		&inv.Sequence,
+		&inv.Number,
+		&inv.PaymentID,
+		&inv.AppointmentID,
+		&inv.PatientID,
+		&inv.Currency,
+		&inv.Subtotal,
+		&inv.Discount,
+		&inv.TaxAmount,
+		&inv.Total,
+		&inv.TransactionID,
+		&inv.CreditedInvoiceID,
+		&inv.Reason,
+		&doc,
+		&inv.IssuedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan invoice: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(doc, &snap); err != nil {
+		return nil, fmt.Errorf("unmarshal invoice snapshot: %w", err)
+	}
+	inv.Lines = snap.Lines
+	inv.TaxLines = snap.TaxLines
+	return &inv, nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,232 @@
+package invoicing
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/openapi"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// InvoiceResult is the read model returned by the invoice endpoints.
+type InvoiceResult struct {
+	ID                string    `json:"id"`
+	Kind              string    `json:"kind"`
+	Number            string    `json:"number"`
+	ClinicID          string    `json:"clinicId"`
+	PaymentID         string    `json:"paymentId"`
+	AppointmentID     string    `json:"appointmentId"`
+	PatientID         string    `json:"patientId"`
+	Currency          string    `json:"currency"`
+	Lines             []Line    `json:"lines"`
+	TaxLines          []TaxLine `json:"taxLines,omitempty"`
+	Subtotal          float64   `json:"subtotal"`
+	Discount          float64   `json:"discount"`
+	TaxAmount         float64   `json:"taxAmount"`
+	Total             float64   `json:"total"`
+	TransactionID     string    `json:"transactionId,omitempty"`
+	CreditedInvoiceID string    `json:"creditedInvoiceId,omitempty"`
+	Reason            string    `json:"reason,omitempty"`
+	IssuedAt          time.Time `json:"issuedAt"`
+}
+
+type creditNoteRequest struct {
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+// RegisterRoutes mounts the invoice endpoints on the group.
+//
+//	GET  /:id               – get invoice or credit note
+//	GET  /:id/credit-notes  – credit notes issued against an invoice
+//	POST /:id/credit-notes  – credit part or all of an invoice (refunds)
+func RegisterRoutes(rg *gin.RouterGroup, repo Repository) {
+	rg.GET("/:id", func(c *gin.Context) {
+		inv, ok := loadInvoice(c, repo)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, toResult(inv))
+	})
+
+	rg.GET("/:id/credit-notes", func(c *gin.Context) {
+		inv, ok := loadInvoice(c, repo)
+		if !ok {
+			return
+		}
+
+		notes, err := repo.CreditNotes(c.Request.Context(), inv.ID)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		results := make([]*InvoiceResult, 0, len(notes))
+		for _, n := range notes {
+			results = append(results, toResult(n))
+		}
+		c.JSON(http.StatusOK, results)
+	})
+
+	rg.POST("/:id/credit-notes", func(c *gin.Context) {
+		var req creditNoteRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			problem.Write(c, problem.Invalid("id", "uuid", "invalid invoice id"))
+			return
+		}
+
+		note, err := repo.IssueCreditNote(c.Request.Context(), id, req.Amount, req.Reason)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, toResult(note))
+	})
+}
+
+// ReceiptHandler serves the receipt of a payment identified by the :id route
+// parameter, as HTML by default or as PDF with ?format=pdf or Accept: application/pdf.
+func ReceiptHandler(repo Repository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		paymentID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			problem.Write(c, problem.Invalid("id", "uuid", "invalid payment id"))
+			return
+		}
+
+		inv, err := repo.FindByPaymentID(c.Request.Context(), paymentID)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		if inv == nil {
+			// Invoices are issued on completion – pending and failed payments have none
+			problem.Write(c, &ErrInvoiceNotFound{PaymentID: paymentID})
+			return
+		}
+
+		notes, err := repo.CreditNotes(c.Request.Context(), inv.ID)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		receipt := Receipt{Invoice: inv, CreditNotes: notes}
+
+		var buf bytes.Buffer
+		if wantsPDF(c) {
+			if err := RenderPDF(&buf, receipt); err != nil {
+				problem.Write(c, err)
+				return
+			}
+			c.Header("Content-Disposition", `inline; filename="`+inv.Number+`.pdf"`)
+			c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+			return
+		}
+
+		if err := RenderHTML(&buf, receipt); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	}
+}
+
+func wantsPDF(c *gin.Context) bool {
+	if format := c.Query("format"); format != "" {
+		return strings.EqualFold(format, "pdf")
+	}
+	return strings.Contains(c.GetHeader("Accept"), "application/pdf")
+}
+
+// Routes declares the endpoints RegisterRoutes mounts.
+func Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/:id", Summary: "Get an invoice or credit note",
+			Response: InvoiceResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/:id/credit-notes", Summary: "Credit notes issued against an invoice",
+			Response: []*InvoiceResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: "/:id/credit-notes", Summary: "Credit part or all of an invoice (refunds)",
+			Request: creditNoteRequest{}, Response: InvoiceResult{}, Status: http.StatusCreated,
+			Errors: []int{http.StatusNotFound, http.StatusUnprocessableEntity}},
+	}
+}
+
+// ReceiptRoute declares the endpoint serving ReceiptHandler at path.
+func ReceiptRoute(path string) openapi.Route {
+	return openapi.Route{
+		Method: http.MethodGet, Path: path, Summary: "Receipt of a completed payment",
+		Description:  "HTML by default; PDF with ?format=pdf or Accept: application/pdf.",
+		Query:        []openapi.Param{{Name: "format", Description: "pdf for a PDF receipt"}},
+		ContentTypes: []string{"text/html", "application/pdf"},
+		Errors:       []int{http.StatusNotFound},
+	}
+}
+
+// Problem maps the package's errors to problem responses.
+func Problem(err error) *problem.Problem {
+	var notFound *ErrInvoiceNotFound
+	switch {
+	case errors.Is(err, ErrInvalidCreditNote):
+		return problem.New(http.StatusUnprocessableEntity, "invalid_credit_note", err.Error())
+	case errors.As(err, &notFound):
+		return problem.New(http.StatusNotFound, "invoice_not_found", notFound.Error())
+	}
+	return nil
+}
+
+// loadInvoice resolves the :id route parameter, writing the error response on failure.
+func loadInvoice(c *gin.Context, repo Repository) (*Invoice, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		problem.Write(c, problem.Invalid("id", "uuid", "invalid invoice id"))
+		return nil, false
+	}
+
+	inv, err := repo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		problem.Write(c, err)
+		return nil, false
+	}
+	if inv == nil {
+		problem.Write(c, &ErrInvoiceNotFound{ID: id})
+		return nil, false
+	}
+	return inv, true
+}
+
+func toResult(inv *Invoice) *InvoiceResult {
+	result := &InvoiceResult{
+		ID:            inv.ID.String(),
+		Kind:          inv.Kind,
+		Number:        inv.Number,
+		ClinicID:      inv.ClinicID,
+		PaymentID:     inv.PaymentID.String(),
+		AppointmentID: inv.AppointmentID.String(),
+		PatientID:     inv.PatientID,
+		Currency:      inv.Currency,
+		Lines:         inv.Lines,
+		TaxLines:      inv.TaxLines,
+		Subtotal:      inv.Subtotal,
+		Discount:      inv.Discount,
+		TaxAmount:     inv.TaxAmount,
+		Total:         inv.Total,
+		TransactionID: inv.TransactionID,
+		Reason:        inv.Reason,
+		IssuedAt:      inv.IssuedAt,
+	}
+	if inv.CreditedInvoiceID != nil {
+		result.CreditedInvoiceID = inv.CreditedInvoiceID.String()
+	}
+	return result
+}
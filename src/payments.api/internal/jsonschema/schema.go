@@ -0,0 +1,278 @@
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// -----------------------------------------------------------------------
+// JSON Schema from Go types
+//
+// Architectural Decision: The API and event contracts are the Go structs
+// encoding/json serializes, so their schemas are generated from those
+// structs by reflection rather than written by hand: a field added to a
+// result type shows up in the published contract with the same name and
+// type it has on the wire. The rules encoding/json applies are mirrored –
+// json tags, omitempty, embedded structs, MarshalText – and binding tags
+// (required, gt, len, oneof, ...) become validation keywords of request
+// schemas.
+// -----------------------------------------------------------------------
+
+// Schema is a JSON Schema (draft 2020-12, the dialect of OpenAPI 3.1 and AsyncAPI 3).
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"` // a type name, or a list of them
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Generator builds schemas of Go types. Named struct types of responses
+// become definitions in Defs, referenced as RefPrefix + the type's name
+// ("getpayment.Result"); request schemas are inlined.
+type Generator struct {
+	RefPrefix string
+	Defs      map[string]*Schema
+}
+
+// NewGenerator creates a generator whose references start with refPrefix,
+// e.g. "#/components/schemas/".
+func NewGenerator(refPrefix string) *Generator {
+	return &Generator{RefPrefix: refPrefix, Defs: map[string]*Schema{}}
+}
+
+// Output returns the schema of v as encoding/json serializes it: fields
+// without omitempty are always present, so they are required. Binding
+// rules constrain the values, as in Input.
+func (g *Generator) Output(v any) *Schema {
+	return g.schema(reflect.TypeOf(v), false)
+}
+
+// Input returns the schema of a request body bound into v: fields with a
+// binding:"required" tag are required, and the other binding rules
+// constrain the values.
+func (g *Generator) Input(v any) *Schema {
+	return g.schema(reflect.TypeOf(v), true)
+}
+
+func (g *Generator) schema(t reflect.Type, input bool) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case t == rawMessageType:
+		return &Schema{}
+	case t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(jsonMarshalerType),
+		t.Implements(jsonMarshalerType):
+		return &Schema{} // custom encoding: any JSON value
+	case t.Kind() != reflect.Pointer && (t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType)):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem(), input)
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"} // base64
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem(), input)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem(), input)}
+	case reflect.Struct:
+		if input || t.Name() == "" {
+			return g.object(t, input)
+		}
+		name := t.String()
+		if _, ok := g.Defs[name]; !ok {
+			g.Defs[name] = &Schema{} // placeholder: breaks recursion
+			g.Defs[name] = g.object(t, false)
+		}
+		return &Schema{Ref: g.RefPrefix + name}
+	default:
+		return &Schema{} // interfaces: any JSON value
+	}
+}
+
+// object returns the schema of a struct's fields, as encoding/json sees them.
+func (g *Generator) object(t reflect.Type, input bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(s, t, input)
+	return s
+}
+
+func (g *Generator) fields(s *Schema, t reflect.Type, input bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a name are flattened, like encoding/json does
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(s, ft, input)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		field := g.schema(f.Type, input)
+		omitempty := strings.Contains(","+options+",", ",omitempty,")
+		required := constrain(field, f.Type, f.Tag.Get("binding"))
+		if !input && !omitempty {
+			required = true
+			if f.Type.Kind() == reflect.Pointer {
+				field = nullable(field)
+			}
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = field
+	}
+}
+
+// nullable allows null besides the values of s.
+func nullable(s *Schema) *Schema {
+	if name, ok := s.Type.(string); ok {
+		s.Type = []string{name, "null"}
+		return s
+	}
+	return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+}
+
+// constrain applies binding rules to a field's schema and reports whether
+// the field is required. Rules after "dive" apply to the items of a slice;
+// "omitempty" admits the zero value, so the rules after it constrain nothing.
+func constrain(s *Schema, t reflect.Type, rules string) bool {
+	if rules == "" {
+		return false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	list := strings.Split(rules, ",")
+	for i, rule := range list {
+		name, param, _ := strings.Cut(rule, "=")
+		isString := t.Kind() == reflect.String
+		switch name {
+		case "required":
+			required = true
+			if isString && s.MinLength == nil {
+				one := 1
+				s.MinLength = &one // the validator rejects empty strings
+			}
+		case "uuid":
+			s.Format = "uuid"
+		case "omitempty":
+			if !slices.Contains(list[i+1:], "dive") {
+				return required
+			}
+		case "dive":
+			if s.Items != nil {
+				constrain(s.Items, t.Elem(), strings.Join(list[i+1:], ","))
+			}
+			return required
+		case "gt", "gte", "min", "lt", "lte", "max", "len":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case isString:
+				length(s, name, int(n))
+			case t.Kind() != reflect.Slice && t.Kind() != reflect.Map:
+				bound(s, name, n)
+			}
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				if isString {
+					s.Enum = append(s.Enum, v)
+				} else if n, err := strconv.ParseFloat(v, 64); err == nil {
+					s.Enum = append(s.Enum, n)
+				}
+			}
+		}
+	}
+	return required
+}
+
+func bound(s *Schema, rule string, n float64) {
+	switch rule {
+	case "gt":
+		s.ExclusiveMinimum = &n
+	case "gte", "min":
+		s.Minimum = &n
+	case "lt":
+		s.ExclusiveMaximum = &n
+	case "lte", "max":
+		s.Maximum = &n
+	}
+}
+
+func length(s *Schema, rule string, n int) {
+	switch rule {
+	case "gt":
+		n++
+		s.MinLength = &n
+	case "gte", "min":
+		s.MinLength = &n
+	case "lt":
+		n--
+		s.MaxLength = &n
+	case "lte", "max":
+		s.MaxLength = &n
+	case "len":
+		s.MinLength, s.MaxLength = &n, &n
+	}
+}
@@ -0,0 +1,114 @@
+package jsonschema_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/jsonschema"
+)
+
+type item struct {
+	Code     string  `json:"code"     binding:"required"`
+	Quantity int     `json:"quantity" binding:"required,gt=0"`
+	Price    float64 `json:"price"    binding:"omitempty,gt=0"`
+}
+
+type request struct {
+	ID       uuid.UUID `json:"id"       binding:"required"`
+	Currency string    `json:"currency" binding:"required,len=3"`
+	Kind     string    `json:"kind"     binding:"oneof=percentage fixed"`
+	Items    []item    `json:"items"    binding:"omitempty,dive"`
+	ParentID string    `json:"parentId" binding:"uuid"`
+	Ignored  string    `json:"-"`
+}
+
+type base struct {
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+type result struct {
+	base
+	Status   string  `json:"status"`
+	Parent   *result `json:"parent"`
+	Note     string  `json:",omitempty"`
+	internal string
+}
+
+func TestOutput_NamedStructsBecomeDefinitions(t *testing.T) {
+	g := jsonschema.NewGenerator("#/defs/")
+	s := g.Output(result{})
+
+	if s.Ref != "#/defs/jsonschema_test.result" {
+		t.Fatalf("ref = %q, want #/defs/jsonschema_test.result", s.Ref)
+	}
+	def := g.Defs["jsonschema_test.result"]
+	if def == nil {
+		t.Fatalf("definitions = %v, want jsonschema_test.result", g.Defs)
+	}
+	if want := []string{"createdAt", "status", "parent"}; !reflect.DeepEqual(def.Required, want) {
+		t.Errorf("required = %v, want %v", def.Required, want)
+	}
+	for _, name := range []string{"createdAt", "updatedAt", "status", "parent", "Note"} {
+		if def.Properties[name] == nil {
+			t.Errorf("property %s is missing", name)
+		}
+	}
+	if def.Properties["internal"] != nil {
+		t.Error("unexported fields are not serialized")
+	}
+	if got := def.Properties["createdAt"]; got.Type != "string" || got.Format != "date-time" {
+		t.Errorf("createdAt = %+v, want a date-time string", got)
+	}
+	// A pointer without omitempty is serialized as null when nil
+	if got := def.Properties["parent"]; len(got.AnyOf) != 2 || got.AnyOf[0].Ref != s.Ref || got.AnyOf[1].Type != "null" {
+		t.Errorf("parent = %+v, want a nullable reference to the same definition", got)
+	}
+}
+
+func TestInput_BindingRulesConstrainTheSchema(t *testing.T) {
+	s := jsonschema.NewGenerator("#/defs/").Input(request{})
+
+	if s.Ref != "" || s.Type != "object" {
+		t.Fatalf("request schemas are inlined, got %+v", s)
+	}
+	if want := []string{"id", "currency"}; !reflect.DeepEqual(s.Required, want) {
+		t.Errorf("required = %v, want %v", s.Required, want)
+	}
+	if _, ok := s.Properties["Ignored"]; ok {
+		t.Error(`fields tagged json:"-" are not bound`)
+	}
+	if got := s.Properties["id"]; got.Format != "uuid" {
+		t.Errorf("id format = %q, want uuid", got.Format)
+	}
+	if got := s.Properties["currency"]; got.MinLength == nil || *got.MinLength != 3 || got.MaxLength == nil || *got.MaxLength != 3 {
+		t.Errorf("currency = %+v, want length 3", got)
+	}
+	if got := s.Properties["parentId"]; got.Format != "uuid" {
+		t.Errorf("parentId format = %q, want uuid", got.Format)
+	}
+	if got := s.Properties["kind"].Enum; !reflect.DeepEqual(got, []any{"percentage", "fixed"}) {
+		t.Errorf("kind enum = %v", got)
+	}
+
+	items := s.Properties["items"]
+	if items.MinLength != nil || items.Items == nil {
+		t.Fatalf("items = %+v, want an array of items", items)
+	}
+	if want := []string{"code", "quantity"}; !reflect.DeepEqual(items.Items.Required, want) {
+		t.Errorf("item required = %v, want %v", items.Items.Required, want)
+	}
+	// Required strings may not be empty, as with the binding validator
+	if got := items.Items.Properties["code"].MinLength; got == nil || *got != 1 {
+		t.Errorf("code minLength = %v, want 1", got)
+	}
+	if got := items.Items.Properties["quantity"].ExclusiveMinimum; got == nil || *got != 0 {
+		t.Errorf("quantity exclusiveMinimum = %v, want 0", got)
+	}
+	// omitempty admits zero: the rules after it do not apply
+	if got := items.Items.Properties["price"].ExclusiveMinimum; got != nil {
+		t.Errorf("price exclusiveMinimum = %v, want none", *got)
+	}
+}
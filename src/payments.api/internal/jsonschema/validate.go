@@ -0,0 +1,233 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/shared"
+)
+
+// Validate checks a JSON document against s, resolving references in the
+// generator's definitions. Every violation is reported, as a
+// *shared.ValidationError whose fields are JSON paths (lineItems[0].quantity)
+// and whose codes are the violated keywords (required, type, minimum, ...).
+func (g *Generator) Validate(s *Schema, data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var errs shared.ValidationError
+	g.validate(s, v, "", &errs)
+	return errs.OrNil()
+}
+
+func (g *Generator) validate(s *Schema, v any, path string, errs *shared.ValidationError) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		def, ok := g.Defs[strings.TrimPrefix(s.Ref, g.RefPrefix)]
+		if !ok {
+			errs.Add(field(path), "$ref", "unknown schema "+s.Ref)
+			return
+		}
+		s = def
+	}
+
+	if len(s.AnyOf) > 0 {
+		g.validateAnyOf(s.AnyOf, v, path, errs)
+	}
+
+	if s.Type != nil && !hasType(s.Type, v) {
+		errs.Add(field(path), "type", fmt.Sprintf("must be %s", typeNames(s.Type)))
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		errs.Add(field(path), "enum", fmt.Sprintf("must be one of %v", s.Enum))
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				errs.Add(join(path, name), "required", "is required")
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if p, ok := s.Properties[name]; ok {
+				g.validate(p, v[name], join(path, name), errs)
+			} else if s.AdditionalProperties != nil {
+				g.validate(s.AdditionalProperties, v[name], join(path, name), errs)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			g.validate(s.Items, item, path+"["+strconv.Itoa(i)+"]", errs)
+		}
+	case string:
+		n := len([]rune(v))
+		if s.MinLength != nil && n < *s.MinLength {
+			errs.Add(field(path), "minLength", fmt.Sprintf("must be at least %d characters long", *s.MinLength))
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			errs.Add(field(path), "maxLength", fmt.Sprintf("must be at most %d characters long", *s.MaxLength))
+		}
+		if !hasFormat(s.Format, v) {
+			errs.Add(field(path), "format", "must be a "+s.Format)
+		}
+	case json.Number:
+		n, _ := v.Float64()
+		switch {
+		case s.Minimum != nil && n < *s.Minimum:
+			errs.Add(field(path), "minimum", fmt.Sprintf("must be at least %v", *s.Minimum))
+		case s.ExclusiveMinimum != nil && n <= *s.ExclusiveMinimum:
+			errs.Add(field(path), "exclusiveMinimum", fmt.Sprintf("must be greater than %v", *s.ExclusiveMinimum))
+		case s.Maximum != nil && n > *s.Maximum:
+			errs.Add(field(path), "maximum", fmt.Sprintf("must be at most %v", *s.Maximum))
+		case s.ExclusiveMaximum != nil && n >= *s.ExclusiveMaximum:
+			errs.Add(field(path), "exclusiveMaximum", fmt.Sprintf("must be less than %v", *s.ExclusiveMaximum))
+		}
+	}
+}
+
+// validateAnyOf accepts v when an alternative does. Otherwise it reports why
+// the alternative of v's type rejects it (a nullable object with an invalid
+// field reports the field), or that no alternative has its type.
+func (g *Generator) validateAnyOf(alternatives []*Schema, v any, path string, errs *shared.ValidationError) {
+	var closest *shared.ValidationError
+	for _, alt := range alternatives {
+		var alternative shared.ValidationError
+		g.validate(alt, v, path, &alternative)
+		if len(alternative.Fields) == 0 {
+			return
+		}
+		if closest == nil && !(alternative.Fields[0].Field == field(path) && alternative.Fields[0].Code == "type") {
+			closest = &alternative
+		}
+	}
+	if closest != nil {
+		errs.Fields = append(errs.Fields, closest.Fields...)
+		return
+	}
+	errs.Add(field(path), "anyOf", "matches none of the allowed schemas")
+}
+
+// hasType reports whether v is of the type, or one of the types, of a schema.
+func hasType(t any, v any) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, v)
+	case []string:
+		for _, name := range t {
+			if isType(name, v) {
+				return true
+			}
+		}
+		return false
+	case []any: // a schema decoded from JSON
+		for _, name := range t {
+			if name, ok := name.(string); ok && isType(name, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, v any) bool {
+	switch name {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	}
+	return true
+}
+
+func typeNames(t any) string {
+	if names, ok := t.([]string); ok {
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		switch e := e.(type) {
+		case float64:
+			if n, ok := v.(json.Number); ok {
+				if f, err := n.Float64(); err == nil && f == e {
+					return true
+				}
+			}
+		default:
+			if e == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasFormat checks the formats the generator emits; others are annotations.
+func hasFormat(format, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "uuid":
+		return uuid.Validate(v) == nil
+	}
+	return true
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// field names the document itself when the violation is at its root.
+func field(path string) string {
+	if path == "" {
+		return "$"
+	}
+	return path
+}
@@ -0,0 +1,73 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/jsonschema"
+	"github.com/smart-health/payments-api/internal/shared"
+)
+
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var invalid *shared.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("error = %v, want a *shared.ValidationError", err)
+	}
+	var out []string
+	for _, f := range invalid.Fields {
+		out = append(out, f.Field+":"+f.Code)
+	}
+	return out
+}
+
+func TestValidate_AcceptsWhatEncodingJSONWrites(t *testing.T) {
+	g := jsonschema.NewGenerator("#/defs/")
+	s := g.Output(result{})
+
+	err := g.Validate(s, []byte(`{"createdAt":"2026-01-02T03:04:05Z","status":"ok","parent":null,"Note":"n"}`))
+	if got := violations(t, err); got != nil {
+		t.Errorf("violations = %v, want none", got)
+	}
+}
+
+func TestValidate_ReportsEveryViolation(t *testing.T) {
+	g := jsonschema.NewGenerator("#/defs/")
+	s := g.Input(request{})
+
+	err := g.Validate(s, []byte(`{
+		"id": "not-a-uuid",
+		"currency": "EURO",
+		"kind": "free",
+		"items": [{"code": "a", "quantity": 1}, {"code": 7, "quantity": 0.5}]
+	}`))
+	want := []string{
+		"currency:maxLength",
+		"id:format",
+		"items[1].code:type",
+		"items[1].quantity:type",
+		"kind:enum",
+	}
+	if got := violations(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+}
+
+func TestValidate_RequiredAndNested(t *testing.T) {
+	g := jsonschema.NewGenerator("#/defs/")
+	s := g.Output(result{})
+
+	err := g.Validate(s, []byte(`{"status":1,"parent":{"createdAt":"yesterday","status":"ok","parent":null}}`))
+	want := []string{"createdAt:required", "parent.createdAt:format", "status:type"}
+	if got := violations(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("violations = %v, want %v", got, want)
+	}
+
+	if err := g.Validate(s, []byte(`{"status":`)); err == nil || errors.As(err, new(*shared.ValidationError)) {
+		t.Errorf("malformed JSON: error = %v, want a decoding error", err)
+	}
+}
@@ -3,11 +3,26 @@ package messaging
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log/slog"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/smart-health/payments-api/internal/shared"
 )
 
+// Headers of dead-lettered messages: why the message was rejected and, for
+// a payload breaking its contract, the violations as a JSON list of
+// {field, code, message}.
+const (
+	HeaderDeadLetterReason     = "dead-letter-reason"
+	HeaderDeadLetterViolations = "dead-letter-violations"
+)
+
+// ErrUnprocessable is wrapped by message handlers to signal an event that
+// redelivery cannot fix, e.g. one the business rules reject. The message is
+// dead-lettered with the error as its reason instead of being requeued.
+var ErrUnprocessable = errors.New("unprocessable message")
+
 // MessageHandler is a function that processes a consumed message.
 type MessageHandler func(ctx context.Context, event AppointmentSlotReservedEvent) error
 
@@ -22,15 +37,25 @@ type Consumer interface {
 // ---------------------------------------------------------------------------
 
 // RabbitMQConsumer consumes messages from a RabbitMQ queue.
+//
+// Architectural Decision: A message is validated against the schema of its
+// contract version before it is handled. One that breaks it, or has a
+// version without a schema, can never succeed: instead of being requeued
+// forever it is moved to the dead-letter queue with the violations in its
+// headers, where it can be inspected and replayed once fixed. So is a
+// message the handler rejects as ErrUnprocessable; other handler errors
+// (a database or provider outage) are requeued.
 type RabbitMQConsumer struct {
-	conn   *amqp.Connection
-	ch     *amqp.Channel
-	queue  string
-	logger *slog.Logger
+	conn            *amqp.Connection
+	ch              *amqp.Channel
+	queue           string
+	deadLetterQueue string
+	logger          *slog.Logger
 }
 
-// NewRabbitMQConsumer creates a new RabbitMQ consumer for the given queue.
-func NewRabbitMQConsumer(url, queue string, logger *slog.Logger) (*RabbitMQConsumer, error) {
+// NewRabbitMQConsumer creates a new RabbitMQ consumer for the given queue,
+// moving messages that cannot be handled to deadLetterQueue.
+func NewRabbitMQConsumer(url, queue, deadLetterQueue string, logger *slog.Logger) (*RabbitMQConsumer, error) {
 	conn, err := amqp.Dial(url)
 	if err != nil {
 		return nil, err
@@ -42,14 +67,16 @@ func NewRabbitMQConsumer(url, queue string, logger *slog.Logger) (*RabbitMQConsu
 		return nil, err
 	}
 
-	// Declare the queue (idempotent)
-	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
-		ch.Close()
-		conn.Close()
-		return nil, err
+	// Declare the queues (idempotent)
+	for _, name := range []string{queue, deadLetterQueue} {
+		if _, err := ch.QueueDeclare(name, true, false, false, false, nil); err != nil {
+			ch.Close()
+			conn.Close()
+			return nil, err
+		}
 	}
 
-	return &RabbitMQConsumer{conn: conn, ch: ch, queue: queue, logger: logger}, nil
+	return &RabbitMQConsumer{conn: conn, ch: ch, queue: queue, deadLetterQueue: deadLetterQueue, logger: logger}, nil
 }
 
 // Start begins consuming messages and dispatches them to the handler.
@@ -76,14 +103,17 @@ func (c *RabbitMQConsumer) Start(ctx context.Context, handler MessageHandler) er
 }
 
 func (c *RabbitMQConsumer) handleMessage(ctx context.Context, msg amqp.Delivery, handler MessageHandler) {
-	var event AppointmentSlotReservedEvent
-	if err := json.Unmarshal(msg.Body, &event); err != nil {
-		c.logger.Error("failed to unmarshal AppointmentSlotReservedEvent", "error", err)
-		_ = msg.Nack(false, false) // dead-letter
+	event, err := DecodeAppointmentSlotReserved(msg.Headers, msg.Body)
+	if err != nil {
+		c.deadLetter(ctx, msg, err)
 		return
 	}
 
 	if err := handler(ctx, event); err != nil {
+		if errors.Is(err, ErrUnprocessable) {
+			c.deadLetter(ctx, msg, err)
+			return
+		}
 		c.logger.Error("failed to handle AppointmentSlotReservedEvent",
 			"appointmentId", event.AppointmentID, "error", err)
 		_ = msg.Nack(false, true) // requeue
@@ -93,6 +123,63 @@ func (c *RabbitMQConsumer) handleMessage(ctx context.Context, msg amqp.Delivery,
 	_ = msg.Ack(false)
 }
 
+// deadLetter moves a message that cannot be handled to the dead-letter
+// queue, with the reason in its headers.
+func (c *RabbitMQConsumer) deadLetter(ctx context.Context, msg amqp.Delivery, reason error) {
+	if err := publishDeadLetter(ctx, c.ch, c.deadLetterQueue, msg, reason); err != nil {
+		c.logger.Error("failed to dead-letter AppointmentSlotReservedEvent", "reason", reason, "error", err)
+		_ = msg.Nack(false, false) // the queue's own dead-lettering, if configured
+		return
+	}
+
+	c.logger.Warn("dead-lettered AppointmentSlotReservedEvent",
+		"queue", c.deadLetterQueue, "reason", reason)
+	_ = msg.Ack(false)
+}
+
+// publishDeadLetter publishes a copy of a message to a dead-letter queue,
+// with the reason – and the violations of a message breaking its
+// contract – in its headers. The original is left to the caller to
+// acknowledge.
+func publishDeadLetter(ctx context.Context, ch Channel, queue string, msg amqp.Delivery, reason error) error {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDeadLetterReason] = reason.Error()
+	var invalid *shared.ValidationError
+	if errors.As(reason, &invalid) {
+		violations, _ := json.Marshal(invalid.Fields)
+		headers[HeaderDeadLetterViolations] = string(violations)
+	}
+
+	return ch.PublishWithContext(ctx, "", queue, false, false, amqp.Publishing{
+		ContentType:   msg.ContentType,
+		MessageId:     msg.MessageId,
+		CorrelationId: msg.CorrelationId,
+		ReplyTo:       msg.ReplyTo,
+		Type:          msg.Type,
+		Timestamp:     msg.Timestamp,
+		Headers:       headers,
+		Body:          msg.Body,
+		DeliveryMode:  amqp.Persistent,
+	})
+}
+
+// DecodeAppointmentSlotReserved validates a message against the schema of
+// its contract version, upcasts it to the current version and decodes it.
+// A message breaking the contract gives a *shared.ValidationError listing
+// every violation.
+func DecodeAppointmentSlotReserved(headers amqp.Table, body []byte) (AppointmentSlotReservedEvent, error) {
+	var event AppointmentSlotReservedEvent
+	version, err := SchemaVersion(headers)
+	if err != nil {
+		return event, err
+	}
+	err = Schemas.Decode("AppointmentSlotReservedEvent", version, body, &event)
+	return event, err
+}
+
 // Close releases the consumer resources.
 func (c *RabbitMQConsumer) Close() error {
 	if err := c.ch.Close(); err != nil {
@@ -1,16 +1,192 @@
 package messaging
 
+import (
+	"fmt"
+	"reflect"
+)
+
+// ---------------------------------------------------------------------------
+// Contract catalog
+//
+// Architectural Decision: Every message this service exchanges over the
+// broker is listed here with the Go type it is encoded from. The catalog
+// is the single place the AsyncAPI document, the outbox and the consumers
+// learn event names from: the outbox refuses to store an event that is
+// not listed, so a new integration event cannot be published without
+// being described.
+//
+// Payloads are validated against the JSON Schemas generated from these
+// types (see Schemas): the binding tags of the fields are the rules. Each
+// contract has a version, carried in the schema-version header of every
+// message; a breaking change to a type is a new version.
+//
+// Evolving a contract: rename the current type (PaymentCompletedIntegrationEventV1),
+// change the type, bump Version and keep the old version as a Revision with
+// an Upcast to the new shape. Messages of the old version still in queues
+// and in the outbox are upcast before they are decoded or published. Give
+// the revision a Downcast as well to keep publishing it, next to the new
+// version, while consumers migrate (LEGACY_EVENT_VERSIONS).
+// ---------------------------------------------------------------------------
+
+// Contract describes a message type.
+type Contract struct {
+	Name     string // type name; the routing key of published events
+	Version  int    // current schema version, carried in the schema-version header
+	Summary  string
+	Payload  any   // zero value of the Go type the JSON body is encoded from
+	Examples []any // valid payloads setting every field between them, published with the schema
+
+	// Superseded versions still read from queues and the outbox, oldest first.
+	Revisions []Revision
+}
+
+// Revision is a superseded version of a contract.
+type Revision struct {
+	Version  int
+	Payload  any // zero value of the Go type of the version
+	Examples []any
+	// Upcast converts a body of this version to the next version.
+	Upcast func(body []byte) ([]byte, error)
+	// Downcast converts a body of the next version to this version; nil
+	// when the version is no longer published.
+	Downcast func(body []byte) ([]byte, error)
+}
+
+// ID names a version of the contract, e.g. "PaymentCompletedIntegrationEvent.v1".
+func (c Contract) ID() string {
+	return fmt.Sprintf("%s.v%d", c.Name, c.Version)
+}
+
+// At returns the contract as it was at a version.
+func (c Contract) At(version int) (Contract, bool) {
+	if version == c.Version {
+		return c, true
+	}
+	for _, r := range c.Revisions {
+		if r.Version == version {
+			return Contract{Name: c.Name, Version: r.Version, Summary: c.Summary, Payload: r.Payload, Examples: r.Examples}, true
+		}
+	}
+	return Contract{}, false
+}
+
+// Versions lists the contract at each of its versions, oldest first.
+func (c Contract) Versions() []Contract {
+	versions := make([]Contract, 0, len(c.Revisions)+1)
+	for _, r := range c.Revisions {
+		v, _ := c.At(r.Version)
+		versions = append(versions, v)
+	}
+	return append(versions, c)
+}
+
+func (c Contract) revision(version int) (Revision, bool) {
+	for _, r := range c.Revisions {
+		if r.Version == version {
+			return r, true
+		}
+	}
+	return Revision{}, false
+}
+
+// Published lists the integration events this service publishes to the
+// outgoing exchange, via the outbox.
+var Published = []Contract{
+	{Name: "PaymentCompletedIntegrationEvent", Version: 2, Summary: "A payment succeeded; the appointment can be confirmed.",
+		Payload: PaymentCompletedIntegrationEvent{},
+		Examples: []any{PaymentCompletedIntegrationEvent{
+			PaymentID: "5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01", AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22",
+			Status: "Completed", Provider: "stripe", TransactionID: "pi_3Nx8", Amount: 107.25, Currency: "usd", TaxAmount: 7.25,
+			TaxLines: []TaxLineInfo{{Name: "CA sales tax", Jurisdiction: "US-CA", ItemCode: "CONSULTATION", Percent: 7.25, Amount: 7.25}},
+		}},
+		Revisions: []Revision{{Version: 1, Payload: PaymentCompletedIntegrationEventV1{},
+			Examples: []any{PaymentCompletedIntegrationEventV1{
+				PaymentID: "5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01", AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22",
+				Status: "Completed", TransactionID: "pi_3Nx8", Amount: 100, Currency: "usd",
+			}},
+			Upcast: upcastPaymentCompletedV1, Downcast: downcastPaymentCompletedV2}}},
+	{Name: "PaymentFailedIntegrationEvent", Version: 2, Summary: "A payment failed; the appointment's slot should be released.",
+		Payload: PaymentFailedIntegrationEvent{},
+		Examples: []any{PaymentFailedIntegrationEvent{
+			PaymentID: "5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01", AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22",
+			FailureCode: "insufficient_funds", Reason: "Your card has insufficient funds. Please use a different card.",
+		}},
+		Revisions: []Revision{{Version: 1, Payload: PaymentFailedIntegrationEventV1{},
+			Examples: []any{PaymentFailedIntegrationEventV1{
+				PaymentID: "5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01", AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22",
+				Reason: "Stripe error: Your card has insufficient funds. code: insufficient_funds",
+			}},
+			Upcast: upcastPaymentFailedV1, Downcast: downcastPaymentFailedV2}}},
+}
+
+// Consumed lists the integration events this service consumes from the incoming queue.
+var Consumed = []Contract{
+	{Name: "AppointmentSlotReservedEvent", Version: 1, Summary: "An appointment slot was reserved; the visit is charged.",
+		Payload: AppointmentSlotReservedEvent{},
+		Examples: []any{AppointmentSlotReservedEvent{
+			AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22", UserID: "user-42", Amount: 100, Currency: "usd",
+			DoctorID: "doctor-7", Specialty: "cardiology", VisitType: "consultation", CouponCode: "WELCOME10",
+			ClinicID: "DOWNTOWN", Jurisdiction: "US-CA-SF", ServiceCategory: "consultation",
+			LineItems: []LineItemInfo{{Code: "CBC", Description: "Complete blood count", Category: "lab", Quantity: 1, UnitPrice: 25}},
+		}}},
+}
+
+// StatusRequest and StatusReply are the request/reply pair of the status queue.
+var (
+	StatusRequest = Contract{Name: "PaymentStatusRequest", Version: 1, Summary: "Asks for the current payment of an appointment.",
+		Payload:  PaymentStatusRequest{},
+		Examples: []any{PaymentStatusRequest{AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22"}}}
+	StatusReply = Contract{Name: "PaymentStatusReply", Version: 1, Summary: "The payment of the appointment, or why it could not be read.",
+		Payload: PaymentStatusReply{},
+		Examples: []any{
+			PaymentStatusReply{Payment: map[string]any{"paymentId": "5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01", "status": "Completed"}},
+			PaymentStatusReply{Error: &ReplyError{Code: "not_found", Message: "payment for appointment 0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22 was not found"}},
+		}}
+)
+
+// PublishedContract returns the catalog entry of a published event value.
+func PublishedContract(event any) (Contract, bool) {
+	t := reflect.TypeOf(event)
+	for _, c := range Published {
+		if reflect.TypeOf(c.Payload) == t {
+			return c, true
+		}
+	}
+	return Contract{}, false
+}
+
 // ---------------------------------------------------------------------------
 // Incoming integration events (consumed by this service)
 // ---------------------------------------------------------------------------
 
 // AppointmentSlotReservedEvent is published by the Appointments service
 // when a time slot is successfully reserved. This triggers payment processing.
+// Amount and Currency are optional: the charge is priced from the fee
+// schedules for the doctor, specialty and visit type.
 type AppointmentSlotReservedEvent struct {
-	AppointmentID string  `json:"appointmentId"`
-	UserID        string  `json:"userId"`
-	Amount        float64 `json:"amount"`
-	Currency      string  `json:"currency"`
+	AppointmentID string  `json:"appointmentId"    binding:"required,uuid"`
+	UserID        string  `json:"userId"           binding:"required"`
+	Amount        float64 `json:"amount,omitempty" binding:"gte=0"`
+	Currency      string  `json:"currency,omitempty"`
+	DoctorID      string  `json:"doctorId,omitempty"`
+	Specialty     string  `json:"specialty,omitempty"`
+	VisitType     string  `json:"visitType,omitempty"`
+	CouponCode    string  `json:"couponCode,omitempty"`
+	ClinicID      string  `json:"clinicId,omitempty"`
+	// Clinic location and service category, used to calculate taxes.
+	Jurisdiction    string `json:"jurisdiction,omitempty"`
+	ServiceCategory string `json:"serviceCategory,omitempty"`
+	// Additional services billed with the visit (lab tests, medicines).
+	LineItems []LineItemInfo `json:"lineItems,omitempty" binding:"dive"`
+}
+
+// LineItemInfo is an additional service billed on a payment.
+type LineItemInfo struct {
+	Code        string  `json:"code"        binding:"required"`
+	Description string  `json:"description"`
+	Category    string  `json:"category,omitempty"`
+	Quantity    int     `json:"quantity"    binding:"required,gt=0"`
+	UnitPrice   float64 `json:"unitPrice"   binding:"required,gt=0"`
 }
 
 // ---------------------------------------------------------------------------
@@ -20,16 +196,60 @@ type AppointmentSlotReservedEvent struct {
 // PaymentCompletedIntegrationEvent is published when payment succeeds.
 // The Appointments service consumes this to confirm the booking.
 type PaymentCompletedIntegrationEvent struct {
-	PaymentID     string `json:"paymentId"`
-	AppointmentID string `json:"appointmentId"`
-	Status        string `json:"status"`
-	TransactionID string `json:"transactionId"`
+	PaymentID     string        `json:"paymentId"     binding:"uuid"`
+	AppointmentID string        `json:"appointmentId" binding:"uuid"`
+	Status        string        `json:"status"        binding:"oneof=Completed"`
+	Provider      string        `json:"provider"      binding:"required"`
+	TransactionID string        `json:"transactionId"`
+	Amount        float64       `json:"amount"        binding:"gte=0"`
+	Currency      string        `json:"currency"      binding:"len=3"`
+	TaxAmount     float64       `json:"taxAmount"     binding:"gte=0"`
+	TaxLines      []TaxLineInfo `json:"taxLines,omitempty"`
+}
+
+// TaxLineInfo is an itemized tax line carried in integration events.
+type TaxLineInfo struct {
+	Name         string  `json:"name"`
+	Jurisdiction string  `json:"jurisdiction"`
+	ItemCode     string  `json:"itemCode,omitempty"`
+	Percent      float64 `json:"percent"`
+	Inclusive    bool    `json:"inclusive"`
+	Amount       float64 `json:"amount"`
 }
 
 // PaymentFailedIntegrationEvent is published when payment fails,
 // enabling compensating transactions in the Appointments service.
+// FailureCode is one of card_declined, insufficient_funds, expired_card,
+// fraud_suspected, provider_unavailable, timeout or cancelled; Reason is the
+// English patient-facing message for it, never the provider's error text.
 type PaymentFailedIntegrationEvent struct {
-	PaymentID     string `json:"paymentId"`
-	AppointmentID string `json:"appointmentId"`
-	Reason        string `json:"reason"`
+	PaymentID     string `json:"paymentId"     binding:"uuid"`
+	AppointmentID string `json:"appointmentId" binding:"uuid"`
+	FailureCode   string `json:"failureCode"   binding:"oneof=card_declined insufficient_funds expired_card fraud_suspected provider_unavailable timeout cancelled"`
+	Reason        string `json:"reason"        binding:"required"`
+}
+
+// ---------------------------------------------------------------------------
+// Request/reply contracts (RPC over the broker)
+// ---------------------------------------------------------------------------
+
+// PaymentStatusRequest is sent by the Appointments saga to ask for the
+// current payment of an appointment. The reply is routed to the AMQP
+// reply_to queue and carries the request's correlation_id.
+type PaymentStatusRequest struct {
+	AppointmentID string `json:"appointmentId" binding:"required,uuid"`
+}
+
+// PaymentStatusReply is the response to a PaymentStatusRequest.
+// Exactly one of Payment or Error is set.
+type PaymentStatusReply struct {
+	Payment any         `json:"payment,omitempty"`
+	Error   *ReplyError `json:"error,omitempty"`
+}
+
+// ReplyError describes why a request could not be answered.
+// Code is one of "bad_request", "not_found", "timeout" or "internal".
+type ReplyError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
@@ -0,0 +1,62 @@
+package messaging_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/messaging"
+)
+
+func TestContracts_AreNamedAfterTheirType(t *testing.T) {
+	all := append(append([]messaging.Contract{}, messaging.Published...), messaging.Consumed...)
+	all = append(all, messaging.StatusRequest, messaging.StatusReply)
+	for _, c := range all {
+		if name := reflect.TypeOf(c.Payload).Name(); c.Name != name {
+			t.Errorf("contract %s describes %s: routing keys are the type names", c.Name, name)
+		}
+	}
+}
+
+func TestPublishedContract(t *testing.T) {
+	c, ok := messaging.PublishedContract(messaging.PaymentFailedIntegrationEvent{PaymentID: "p"})
+	if !ok || c.Name != "PaymentFailedIntegrationEvent" {
+		t.Errorf("PublishedContract = %+v, %v", c, ok)
+	}
+	if _, ok := messaging.PublishedContract(messaging.AppointmentSlotReservedEvent{}); ok {
+		t.Error("consumed events are not published")
+	}
+}
+
+func TestContracts_Versions(t *testing.T) {
+	sent := append(append([]messaging.Contract{}, messaging.Published...), messaging.StatusReply)
+	received := append(append([]messaging.Contract{}, messaging.Consumed...), messaging.StatusRequest)
+	for _, c := range append(sent, received...) {
+		if c.Version < 1 {
+			t.Errorf("%s has no version", c.Name)
+		}
+		previous := 0
+		for _, r := range c.Revisions {
+			if r.Version <= previous || r.Version >= c.Version {
+				t.Errorf("%s: revisions must be older versions, oldest first; got v%d after v%d", c.Name, r.Version, previous)
+			}
+			if r.Upcast == nil {
+				t.Errorf("%s.v%d has no upcaster", c.Name, r.Version)
+			}
+			previous = r.Version
+		}
+
+		// The examples of every version match its schema
+		for _, v := range c.Versions() {
+			if len(v.Examples) == 0 {
+				t.Errorf("%s has no example", v.ID())
+			}
+			for _, example := range v.Examples {
+				body, _ := json.Marshal(example)
+				if _, err := messaging.Schemas.Upcast(v.Name, v.Version, body); err != nil {
+					t.Errorf("example of %s: %v", v.ID(), err)
+				}
+			}
+		}
+	}
+}
@@ -8,8 +8,9 @@ import (
 )
 
 // Publisher defines the contract for publishing integration events to a message broker.
+// version is the contract version of the payload, sent in the schema-version header.
 type Publisher interface {
-	Publish(ctx context.Context, routingKey string, payload []byte) error
+	Publish(ctx context.Context, routingKey string, version int, payload []byte) error
 	Close() error
 }
 
@@ -49,9 +50,11 @@ func NewRabbitMQPublisher(url, exchange string, logger *slog.Logger) (*RabbitMQP
 }
 
 // Publish sends a message to the exchange with the given routing key.
-func (p *RabbitMQPublisher) Publish(ctx context.Context, routingKey string, payload []byte) error {
+func (p *RabbitMQPublisher) Publish(ctx context.Context, routingKey string, version int, payload []byte) error {
 	return p.channel.PublishWithContext(ctx, p.exchange, routingKey, false, false, amqp.Publishing{
 		ContentType:  "application/json",
+		Type:         routingKey,
+		Headers:      amqp.Table{HeaderSchemaVersion: int32(version)},
 		Body:         payload,
 		DeliveryMode: amqp.Persistent,
 	})
@@ -80,8 +83,8 @@ func NewInMemoryPublisher(logger *slog.Logger) *InMemoryPublisher {
 	return &InMemoryPublisher{logger: logger}
 }
 
-func (p *InMemoryPublisher) Publish(_ context.Context, routingKey string, payload []byte) error {
-	p.logger.Info("in-memory publish (no-op)", "routingKey", routingKey, "payload", string(payload))
+func (p *InMemoryPublisher) Publish(_ context.Context, routingKey string, version int, payload []byte) error {
+	p.logger.Info("in-memory publish (no-op)", "routingKey", routingKey, "version", version, "payload", string(payload))
 	return nil
 }
 
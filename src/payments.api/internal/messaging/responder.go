@@ -0,0 +1,261 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+// ErrInvalidRequest is wrapped by request handlers to signal a malformed request.
+// It is reported back to the caller as a "bad_request" reply.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// StatusRequestHandler answers a PaymentStatusRequest with the payment read model.
+type StatusRequestHandler func(ctx context.Context, req PaymentStatusRequest) (any, error)
+
+// Responder defines the contract for answering request/reply messages.
+type Responder interface {
+	Serve(ctx context.Context, handler StatusRequestHandler) error
+	Close() error
+}
+
+// ---------------------------------------------------------------------------
+// RabbitMQ Responder
+// ---------------------------------------------------------------------------
+
+// RabbitMQResponder answers payment status requests from a dedicated queue.
+//
+// Architectural Decision: Request/reply follows the classic AMQP RPC pattern.
+// The caller declares its own (usually exclusive) reply queue, sets reply_to
+// and correlation_id, and the reply is published to the default exchange with
+// reply_to as routing key. Requests are always acknowledged once answered:
+// redelivering a query is pointless after the caller has timed out. A request
+// that cannot be answered (no reply_to, a reply that cannot be sent) is
+// moved to the dead-letter queue with the reason in its headers, as the
+// consumer does with events, so it is not lost without a trace.
+// Requests are answered by a fixed number of workers, and the broker hands
+// out as many unacknowledged requests as there are workers: a slow lookup
+// holds up one worker, not every caller, and the pool bounds the load the
+// queue puts on the database.
+type RabbitMQResponder struct {
+	conn            *amqp.Connection // nil when the channel was handed in
+	ch              Channel
+	queue           string
+	deadLetterQueue string
+	workers         int
+	timeout         time.Duration
+	logger          *slog.Logger
+}
+
+// Channel is the part of an AMQP channel the responder uses; *amqp.Channel
+// implements it.
+type Channel interface {
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// NewRabbitMQResponder creates a new RabbitMQ responder for the given request queue,
+// moving requests that cannot be answered to deadLetterQueue.
+// Up to workers requests are answered at once, each within timeout; slower
+// lookups produce a "timeout" reply.
+func NewRabbitMQResponder(url, queue, deadLetterQueue string, workers int, timeout time.Duration, logger *slog.Logger) (*RabbitMQResponder, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Declare the queues (idempotent)
+	for _, name := range []string{queue, deadLetterQueue} {
+		if _, err := ch.QueueDeclare(name, true, false, false, false, nil); err != nil {
+			ch.Close()
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	r := NewChannelResponder(ch, queue, deadLetterQueue, workers, timeout, logger)
+	r.conn = conn
+	return r, nil
+}
+
+// NewChannelResponder creates a responder on an open channel whose queues
+// already exist. Closing the responder closes the channel.
+func NewChannelResponder(ch Channel, queue, deadLetterQueue string, workers int, timeout time.Duration, logger *slog.Logger) *RabbitMQResponder {
+	if workers < 1 {
+		workers = 1
+	}
+	return &RabbitMQResponder{ch: ch, queue: queue, deadLetterQueue: deadLetterQueue, workers: workers, timeout: timeout, logger: logger}
+}
+
+// Serve begins answering requests and dispatches them to the handler.
+// Blocks until ctx is cancelled and the requests being answered are done.
+func (r *RabbitMQResponder) Serve(ctx context.Context, handler StatusRequestHandler) error {
+	// One unacknowledged request per worker: the rest wait in the queue,
+	// where another replica may pick them up
+	if err := r.ch.Qos(r.workers, 0, false); err != nil {
+		return err
+	}
+	msgs, err := r.ch.Consume(r.queue, "", false, false, false, false, nil)
+	if err != nil {
+		return err
+	}
+
+	r.logger.Info("RabbitMQ responder started", "queue", r.queue, "workers", r.workers)
+
+	var wg sync.WaitGroup
+	for range r.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg, ok := <-msgs:
+					if !ok {
+						return
+					}
+					r.handleRequest(ctx, msg, handler)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (r *RabbitMQResponder) handleRequest(ctx context.Context, msg amqp.Delivery, handler StatusRequestHandler) {
+	if msg.ReplyTo == "" {
+		r.deadLetter(ctx, msg, errNoReplyTo)
+		return
+	}
+
+	payment, err := r.answer(ctx, msg, handler)
+	if err != nil {
+		r.logger.Warn("payment status request failed",
+			"correlationId", msg.CorrelationId, "error", err)
+	}
+	reply := NewStatusReply(payment, err)
+
+	body, err := json.Marshal(reply)
+	if err != nil {
+		r.deadLetter(ctx, msg, fmt.Errorf("marshal reply: %w", err))
+		return
+	}
+
+	if err := r.ch.PublishWithContext(ctx, "", msg.ReplyTo, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: msg.CorrelationId,
+		Headers:       amqp.Table{HeaderSchemaVersion: int32(StatusReply.Version)},
+		Body:          body,
+	}); err != nil {
+		r.deadLetter(ctx, msg, fmt.Errorf("publish reply to %s: %w", msg.ReplyTo, err))
+		return
+	}
+
+	_ = msg.Ack(false)
+}
+
+// errNoReplyTo is the reason a request without reply_to is dead-lettered.
+var errNoReplyTo = errors.New("request has no reply_to")
+
+// deadLetter moves a request that cannot be answered to the dead-letter
+// queue, with the reason in its headers.
+func (r *RabbitMQResponder) deadLetter(ctx context.Context, msg amqp.Delivery, reason error) {
+	if err := publishDeadLetter(ctx, r.ch, r.deadLetterQueue, msg, reason); err != nil {
+		r.logger.Error("failed to dead-letter payment status request",
+			"correlationId", msg.CorrelationId, "reason", reason, "error", err)
+		_ = msg.Nack(false, false) // the queue's own dead-lettering, if configured
+		return
+	}
+
+	r.logger.Warn("dead-lettered payment status request",
+		"queue", r.deadLetterQueue, "correlationId", msg.CorrelationId, "reason", reason)
+	_ = msg.Ack(false)
+}
+
+func (r *RabbitMQResponder) answer(ctx context.Context, msg amqp.Delivery, handler StatusRequestHandler) (any, error) {
+	var req PaymentStatusRequest
+	version, err := SchemaVersion(msg.Headers)
+	if err == nil {
+		err = Schemas.Decode(StatusRequest.Name, version, msg.Body, &req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return handler(ctx, req)
+}
+
+// Close releases the responder resources.
+func (r *RabbitMQResponder) Close() error {
+	if err := r.ch.Close(); err != nil {
+		return err
+	}
+	if r.conn == nil {
+		return nil
+	}
+	return r.conn.Close()
+}
+
+// NewStatusReply builds the reply for a handled request, classifying err
+// into a stable error code the caller can act on.
+func NewStatusReply(payment any, err error) PaymentStatusReply {
+	if err == nil {
+		return PaymentStatusReply{Payment: payment}
+	}
+
+	var notFound *domain.ErrPaymentNotFound
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		return PaymentStatusReply{Error: &ReplyError{Code: "bad_request", Message: err.Error()}}
+	case errors.As(err, &notFound):
+		return PaymentStatusReply{Error: &ReplyError{Code: "not_found", Message: notFound.Error()}}
+	case errors.Is(err, context.DeadlineExceeded):
+		return PaymentStatusReply{Error: &ReplyError{Code: "timeout", Message: "payment status lookup timed out"}}
+	default:
+		// Internal details stay in our logs, not in other services' queues.
+		return PaymentStatusReply{Error: &ReplyError{Code: "internal", Message: "payment status lookup failed"}}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// In-Memory (No-Op) Responder – for local dev and testing
+// ---------------------------------------------------------------------------
+
+// InMemoryResponder is a no-op responder that never receives requests.
+// In development mode, payment status is read via HTTP.
+type InMemoryResponder struct {
+	logger *slog.Logger
+}
+
+// NewInMemoryResponder creates a new in-memory responder.
+func NewInMemoryResponder(logger *slog.Logger) *InMemoryResponder {
+	return &InMemoryResponder{logger: logger}
+}
+
+func (r *InMemoryResponder) Serve(ctx context.Context, _ StatusRequestHandler) error {
+	r.logger.Info("in-memory responder started (no-op) – use HTTP endpoints to read payment status")
+	<-ctx.Done()
+	return nil
+}
+
+func (r *InMemoryResponder) Close() error { return nil }
@@ -0,0 +1,230 @@
+package messaging_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/smart-health/payments-api/internal/messaging"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+func TestNewStatusReply_Success(t *testing.T) {
+	reply := messaging.NewStatusReply("payment", nil)
+	if reply.Error != nil {
+		t.Fatalf("unexpected error reply: %+v", reply.Error)
+	}
+	if reply.Payment != "payment" {
+		t.Errorf("expected payment in reply, got %v", reply.Payment)
+	}
+}
+
+func TestNewStatusReply_ErrorCodes(t *testing.T) {
+	cases := map[string]error{
+		"bad_request": fmt.Errorf("%w: invalid appointmentId", messaging.ErrInvalidRequest),
+		"not_found":   fmt.Errorf("find: %w", &domain.ErrPaymentNotFound{AppointmentID: uuid.New()}),
+		"timeout":     fmt.Errorf("query: %w", context.DeadlineExceeded),
+		"internal":    errors.New("scan payment: connection reset"),
+	}
+	for code, err := range cases {
+		reply := messaging.NewStatusReply(nil, err)
+		if reply.Error == nil {
+			t.Fatalf("%s: expected error reply", code)
+		}
+		if reply.Error.Code != code {
+			t.Errorf("expected code %s, got %s", code, reply.Error.Code)
+		}
+	}
+}
+
+// fakeChannel hands out the deliveries sent to it and records what is
+// published; publishing to a key in failing fails.
+type fakeChannel struct {
+	deliveries chan amqp.Delivery
+	failing    map[string]bool
+
+	mu        sync.Mutex
+	prefetch  int
+	published map[string][]amqp.Publishing
+}
+
+func newFakeChannel() *fakeChannel {
+	return &fakeChannel{deliveries: make(chan amqp.Delivery), failing: map[string]bool{}, published: map[string][]amqp.Publishing{}}
+}
+
+func (c *fakeChannel) Qos(prefetchCount, _ int, _ bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prefetch = prefetchCount
+	return nil
+}
+
+func (c *fakeChannel) Consume(string, string, bool, bool, bool, bool, amqp.Table) (<-chan amqp.Delivery, error) {
+	return c.deliveries, nil
+}
+
+func (c *fakeChannel) PublishWithContext(_ context.Context, _, key string, _, _ bool, msg amqp.Publishing) error {
+	if c.failing[key] {
+		return errors.New("channel closed")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published[key] = append(c.published[key], msg)
+	return nil
+}
+
+func (c *fakeChannel) Close() error { return nil }
+
+func (c *fakeChannel) sent(key string) []amqp.Publishing {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.published[key]
+}
+
+// settlement reports how a delivery was settled: "ack" or "nack".
+type settlement chan string
+
+func (s settlement) Ack(uint64, bool) error        { s <- "ack"; return nil }
+func (s settlement) Nack(uint64, bool, bool) error { s <- "nack"; return nil }
+func (s settlement) Reject(uint64, bool) error     { s <- "nack"; return nil }
+
+func statusRequest(replyTo, correlationID string) (amqp.Delivery, settlement) {
+	settled := make(settlement, 1)
+	body, _ := json.Marshal(messaging.PaymentStatusRequest{AppointmentID: uuid.NewString()})
+	return amqp.Delivery{
+		Acknowledger:  settled,
+		ReplyTo:       replyTo,
+		CorrelationId: correlationID,
+		Headers:       amqp.Table{messaging.HeaderSchemaVersion: int32(1)},
+		Body:          body,
+	}, settled
+}
+
+// serve runs a responder with the given workers on ch until the test ends.
+func serve(t *testing.T, ch *fakeChannel, workers int, handler messaging.StatusRequestHandler) {
+	t.Helper()
+	responder := messaging.NewChannelResponder(ch, "status", "status.dead-letter", workers, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = responder.Serve(ctx, handler)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func found(context.Context, messaging.PaymentStatusRequest) (any, error) {
+	return map[string]string{"status": "Completed"}, nil
+}
+
+func TestRabbitMQResponder_RepliesToTheCaller(t *testing.T) {
+	ch := newFakeChannel()
+	serve(t, ch, 1, found)
+
+	msg, settled := statusRequest("reply-1", "corr-1")
+	ch.deliveries <- msg
+	if s := <-settled; s != "ack" {
+		t.Fatalf("request settled with %s, want ack", s)
+	}
+
+	replies := ch.sent("reply-1")
+	if len(replies) != 1 || replies[0].CorrelationId != "corr-1" {
+		t.Fatalf("replies = %+v, want one correlated with corr-1", replies)
+	}
+	var reply messaging.PaymentStatusReply
+	if err := json.Unmarshal(replies[0].Body, &reply); err != nil || reply.Error != nil || reply.Payment == nil {
+		t.Errorf("reply = %s, %v, want the payment", replies[0].Body, err)
+	}
+}
+
+func TestRabbitMQResponder_DeadLettersUnanswerableRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		replyTo string
+		reason  string
+	}{
+		{"no reply_to", "", "request has no reply_to"},
+		{"reply not published", "gone", "publish reply to gone"},
+	}
+	for _, tt := range tests {
+		ch := newFakeChannel()
+		ch.failing["gone"] = true
+		serve(t, ch, 1, found)
+
+		msg, settled := statusRequest(tt.replyTo, "corr-1")
+		ch.deliveries <- msg
+		if s := <-settled; s != "ack" {
+			t.Errorf("%s: request settled with %s, want ack", tt.name, s)
+		}
+
+		dead := ch.sent("status.dead-letter")
+		if len(dead) != 1 {
+			t.Fatalf("%s: %d requests dead-lettered, want 1", tt.name, len(dead))
+		}
+		reason, _ := dead[0].Headers[messaging.HeaderDeadLetterReason].(string)
+		if !strings.Contains(reason, tt.reason) || dead[0].CorrelationId != "corr-1" {
+			t.Errorf("%s: dead-lettered with reason %q, correlation %q", tt.name, reason, dead[0].CorrelationId)
+		}
+	}
+}
+
+func TestRabbitMQResponder_NacksWhenDeadLetteringFails(t *testing.T) {
+	ch := newFakeChannel()
+	ch.failing["status.dead-letter"] = true
+	serve(t, ch, 1, found)
+
+	msg, settled := statusRequest("", "corr-1")
+	ch.deliveries <- msg
+	if s := <-settled; s != "nack" {
+		t.Errorf("request settled with %s, want nack", s)
+	}
+}
+
+func TestRabbitMQResponder_SlowLookupDoesNotHoldUpOthers(t *testing.T) {
+	ch := newFakeChannel()
+	release := make(chan struct{})
+	defer close(release)
+	calls := make(chan struct{}, 2)
+	var once sync.Once
+	serve(t, ch, 2, func(ctx context.Context, req messaging.PaymentStatusRequest) (any, error) {
+		slow := false
+		once.Do(func() { slow = true })
+		calls <- struct{}{}
+		if slow {
+			<-release
+		}
+		return found(ctx, req)
+	})
+
+	first, _ := statusRequest("reply-1", "slow")
+	ch.deliveries <- first
+	<-calls
+
+	second, settled := statusRequest("reply-2", "fast")
+	ch.deliveries <- second
+	select {
+	case <-settled:
+	case <-time.After(time.Second):
+		t.Fatal("second request not answered while the first was slow")
+	}
+	if len(ch.sent("reply-2")) != 1 {
+		t.Errorf("second caller got no reply")
+	}
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.prefetch != 2 {
+		t.Errorf("prefetch = %d, want one per worker", ch.prefetch)
+	}
+}
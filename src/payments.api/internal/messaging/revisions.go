@@ -0,0 +1,113 @@
+package messaging
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+// ---------------------------------------------------------------------------
+// Superseded versions of the published events
+// ---------------------------------------------------------------------------
+
+// PaymentCompletedIntegrationEventV1 is version 1 of PaymentCompletedIntegrationEvent,
+// published before payments had providers, taxes and a priced amount.
+//
+// Amount and Currency were never part of it: the outbox reads messages
+// stored at version 1 with the amount and currency of their payment, for
+// the upcaster to take over. Downcast bodies leave them out.
+type PaymentCompletedIntegrationEventV1 struct {
+	PaymentID     string  `json:"paymentId"          binding:"uuid"`
+	AppointmentID string  `json:"appointmentId"      binding:"uuid"`
+	Status        string  `json:"status"             binding:"oneof=Completed"`
+	TransactionID string  `json:"transactionId"`
+	Amount        float64 `json:"amount,omitempty"   binding:"gte=0"`
+	Currency      string  `json:"currency,omitempty"`
+}
+
+// PaymentFailedIntegrationEventV1 is version 1 of PaymentFailedIntegrationEvent,
+// whose Reason was the provider's error text rather than a failure code.
+type PaymentFailedIntegrationEventV1 struct {
+	PaymentID     string `json:"paymentId"     binding:"uuid"`
+	AppointmentID string `json:"appointmentId" binding:"uuid"`
+	Reason        string `json:"reason"        binding:"required"`
+}
+
+// defaultProvider charged every payment completed before providers were
+// pluggable.
+const defaultProvider = "stripe"
+
+// upcastPaymentCompletedV1 takes a v1 body to v2: the provider is Stripe,
+// the amount and currency are the payment's and nothing was taxed.
+func upcastPaymentCompletedV1(body []byte) ([]byte, error) {
+	var v1 PaymentCompletedIntegrationEventV1
+	if err := json.Unmarshal(body, &v1); err != nil {
+		return nil, err
+	}
+	return json.Marshal(PaymentCompletedIntegrationEvent{
+		PaymentID:     v1.PaymentID,
+		AppointmentID: v1.AppointmentID,
+		Status:        v1.Status,
+		Provider:      defaultProvider,
+		TransactionID: v1.TransactionID,
+		Amount:        v1.Amount,
+		Currency:      v1.Currency,
+	})
+}
+
+func downcastPaymentCompletedV2(body []byte) ([]byte, error) {
+	var v2 PaymentCompletedIntegrationEvent
+	if err := json.Unmarshal(body, &v2); err != nil {
+		return nil, err
+	}
+	return json.Marshal(PaymentCompletedIntegrationEventV1{
+		PaymentID:     v2.PaymentID,
+		AppointmentID: v2.AppointmentID,
+		Status:        v2.Status,
+		TransactionID: v2.TransactionID,
+	})
+}
+
+// upcastPaymentFailedV1 takes a v1 body to v2: the failure code is the
+// first provider failure reason named in the error text, provider_unavailable
+// if it names none, and the reason becomes the code's patient message.
+func upcastPaymentFailedV1(body []byte) ([]byte, error) {
+	var v1 PaymentFailedIntegrationEventV1
+	if err := json.Unmarshal(body, &v1); err != nil {
+		return nil, err
+	}
+	code := failureCodeOf(v1.Reason)
+	return json.Marshal(PaymentFailedIntegrationEvent{
+		PaymentID:     v1.PaymentID,
+		AppointmentID: v1.AppointmentID,
+		FailureCode:   string(code),
+		Reason:        code.Message(domain.DefaultLanguage),
+	})
+}
+
+func downcastPaymentFailedV2(body []byte) ([]byte, error) {
+	var v2 PaymentFailedIntegrationEvent
+	if err := json.Unmarshal(body, &v2); err != nil {
+		return nil, err
+	}
+	return json.Marshal(PaymentFailedIntegrationEventV1{
+		PaymentID:     v2.PaymentID,
+		AppointmentID: v2.AppointmentID,
+		Reason:        v2.Reason,
+	})
+}
+
+// failureCodeOf finds a provider failure reason ("card_declined") in an
+// error text, such as "Stripe error: ... code: card_declined".
+func failureCodeOf(text string) domain.FailureCode {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return (r < 'a' || r > 'z') && r != '_'
+	})
+	for _, word := range words {
+		if code := domain.FailureCodeFromProvider(word, ""); code != "" {
+			return code
+		}
+	}
+	return domain.FailureProviderUnavailable
+}
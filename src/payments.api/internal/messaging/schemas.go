@@ -0,0 +1,235 @@
+package messaging
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/smart-health/payments-api/internal/jsonschema"
+)
+
+// HeaderSchemaVersion is the AMQP header carrying the contract version of
+// a message body. Messages without it are version 1, the version of every
+// contract before versions were introduced.
+const HeaderSchemaVersion = "schema-version"
+
+// ErrUnknownContract is returned for a message type missing from the catalog.
+type ErrUnknownContract struct {
+	Name string
+}
+
+func (e *ErrUnknownContract) Error() string {
+	return fmt.Sprintf("unknown message contract %q", e.Name)
+}
+
+// ErrUnsupportedVersion is returned for a contract version without a schema.
+type ErrUnsupportedVersion struct {
+	Name    string
+	Version int
+}
+
+func (e *ErrUnsupportedVersion) Error() string {
+	return fmt.Sprintf("unsupported schema version %d of %s", e.Version, e.Name)
+}
+
+// SchemaSet holds the JSON Schema of every contract version of the catalog,
+// and converts bodies between the versions of a contract.
+//
+// Received messages are checked against request-style schemas, which
+// require only the fields tagged binding:"required", so producers may omit
+// optional fields; sent messages against the schemas encoding/json writes,
+// where every field without omitempty is present.
+type SchemaSet struct {
+	generator *jsonschema.Generator
+	contracts map[string]Contract
+	schemas   map[string]map[int]*jsonschema.Schema
+}
+
+// Schemas are the schemas of the catalog.
+var Schemas = NewSchemaSet(
+	append(append([]Contract{}, Published...), StatusReply),
+	append(append([]Contract{}, Consumed...), StatusRequest),
+)
+
+// NewSchemaSet generates the schemas of every version of sent and received
+// contracts.
+func NewSchemaSet(sent, received []Contract) *SchemaSet {
+	set := &SchemaSet{
+		generator: jsonschema.NewGenerator("#/$defs/"),
+		contracts: map[string]Contract{},
+		schemas:   map[string]map[int]*jsonschema.Schema{},
+	}
+	for _, c := range sent {
+		set.add(c, set.generator.Output)
+	}
+	for _, c := range received {
+		set.add(c, set.generator.Input)
+	}
+	return set
+}
+
+func (s *SchemaSet) add(c Contract, schema func(any) *jsonschema.Schema) {
+	s.contracts[c.Name] = c
+	s.schemas[c.Name] = map[int]*jsonschema.Schema{}
+	for _, v := range c.Versions() {
+		s.schemas[c.Name][v.Version] = schema(v.Payload)
+	}
+}
+
+// Validate checks a payload against the schema of a contract version. A
+// payload breaking the contract gives a *shared.ValidationError listing
+// every violation; a contract or version without a schema gives
+// *ErrUnknownContract or *ErrUnsupportedVersion.
+func (s *SchemaSet) Validate(name string, version int, payload []byte) error {
+	versions, ok := s.schemas[name]
+	if !ok {
+		return &ErrUnknownContract{Name: name}
+	}
+	schema, ok := versions[version]
+	if !ok {
+		return &ErrUnsupportedVersion{Name: name, Version: version}
+	}
+	return s.generator.Validate(schema, payload)
+}
+
+// Upcast validates a body of a contract version and converts it, one
+// version at a time, to the current version, which the result is checked
+// against as well.
+func (s *SchemaSet) Upcast(name string, version int, body []byte) ([]byte, error) {
+	if err := s.Validate(name, version, body); err != nil {
+		return nil, err
+	}
+	c := s.contracts[name]
+	for ; version < c.Version; version++ {
+		r, _ := c.revision(version)
+		if r.Upcast == nil {
+			return nil, fmt.Errorf("%s.v%d has no upcaster", name, version)
+		}
+		var err error
+		if body, err = r.Upcast(body); err != nil {
+			return nil, fmt.Errorf("upcast %s.v%d: %w", name, version, err)
+		}
+	}
+	if err := s.Validate(name, c.Version, body); err != nil {
+		return nil, fmt.Errorf("upcast %s to v%d: %w", name, c.Version, err)
+	}
+	return body, nil
+}
+
+// Downcast converts a body of the current version of a contract to an
+// older version, one version at a time.
+func (s *SchemaSet) Downcast(name string, body []byte, version int) ([]byte, error) {
+	c, ok := s.contracts[name]
+	if !ok {
+		return nil, &ErrUnknownContract{Name: name}
+	}
+	if _, ok := c.At(version); !ok {
+		return nil, &ErrUnsupportedVersion{Name: name, Version: version}
+	}
+	for v := c.Version - 1; v >= version; v-- {
+		r, _ := c.revision(v)
+		if r.Downcast == nil {
+			return nil, fmt.Errorf("%s.v%d has no downcaster", name, v)
+		}
+		var err error
+		if body, err = r.Downcast(body); err != nil {
+			return nil, fmt.Errorf("downcast %s to v%d: %w", name, v, err)
+		}
+	}
+	if err := s.Validate(name, version, body); err != nil {
+		return nil, fmt.Errorf("downcast %s to v%d: %w", name, version, err)
+	}
+	return body, nil
+}
+
+// Decode upcasts a body of a contract version and unmarshals it into v, a
+// pointer to the current Go type of the contract.
+func (s *SchemaSet) Decode(name string, version int, body []byte, v any) error {
+	body, err := s.Upcast(name, version, body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// Publication is an event body in one of the versions it is published in.
+type Publication struct {
+	Version int
+	Body    []byte
+}
+
+// Publications converts a stored event body to the versions it is
+// published in: the current version, then each of the legacy versions
+// still published during their migration window.
+func (s *SchemaSet) Publications(name string, version int, body []byte, legacy []int) ([]Publication, error) {
+	current, err := s.Upcast(name, version, body)
+	if err != nil {
+		return nil, err
+	}
+	pubs := []Publication{{Version: s.contracts[name].Version, Body: current}}
+	for _, v := range legacy {
+		old, err := s.Downcast(name, current, v)
+		if err != nil {
+			return nil, err
+		}
+		pubs = append(pubs, Publication{Version: v, Body: old})
+	}
+	return pubs, nil
+}
+
+// LegacyVersions parses contract version IDs ("PaymentCompletedIntegrationEvent.v1")
+// into the legacy versions published per contract, checking that each is a
+// superseded version that can be downcast to.
+func (s *SchemaSet) LegacyVersions(ids []string) (map[string][]int, error) {
+	legacy := map[string][]int{}
+	for _, id := range ids {
+		name, v, ok := strings.Cut(id, ".v")
+		version, err := strconv.Atoi(v)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("invalid contract version %q, want Name.vN", id)
+		}
+		c, ok := s.contracts[name]
+		if !ok {
+			return nil, &ErrUnknownContract{Name: name}
+		}
+		if version >= c.Version {
+			return nil, fmt.Errorf("%s is not a superseded version of %s (v%d)", id, name, c.Version)
+		}
+		if _, ok := c.At(version); !ok {
+			return nil, &ErrUnsupportedVersion{Name: name, Version: version}
+		}
+		for v := version; v < c.Version; v++ {
+			if r, _ := c.revision(v); r.Downcast == nil {
+				return nil, fmt.Errorf("%s cannot be published: %s.v%d has no downcaster", id, name, v)
+			}
+		}
+		legacy[name] = append(legacy[name], version)
+	}
+	return legacy, nil
+}
+
+// SchemaVersion reads the contract version of a message from its headers.
+func SchemaVersion(headers amqp.Table) (int, error) {
+	v, ok := headers[HeaderSchemaVersion]
+	if !ok {
+		return 1, nil
+	}
+	switch v := v.(type) {
+	case int8:
+		return int(v), nil
+	case int16:
+		return int(v), nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil
+	case string:
+		n, err := strconv.Atoi(v)
+		if err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid %s header %v", HeaderSchemaVersion, v)
+}
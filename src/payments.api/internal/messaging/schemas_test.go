@@ -0,0 +1,96 @@
+package messaging_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/smart-health/payments-api/internal/messaging"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/shared"
+)
+
+func TestSchemaVersion(t *testing.T) {
+	cases := []struct {
+		headers amqp.Table
+		want    int
+		wantErr bool
+	}{
+		{headers: nil, want: 1},
+		{headers: amqp.Table{"schema-version": int32(2)}, want: 2},
+		{headers: amqp.Table{"schema-version": int64(3)}, want: 3},
+		{headers: amqp.Table{"schema-version": int8(4)}, want: 4},
+		{headers: amqp.Table{"schema-version": "5"}, want: 5},
+		{headers: amqp.Table{"schema-version": "v5"}, wantErr: true},
+		{headers: amqp.Table{"schema-version": 1.5}, wantErr: true},
+	}
+	for _, tc := range cases {
+		got, err := messaging.SchemaVersion(tc.headers)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("SchemaVersion(%v) = %d, %v; want %d, error %v", tc.headers, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestSchemas_UnknownContractAndVersion(t *testing.T) {
+	var unknown *messaging.ErrUnknownContract
+	if err := messaging.Schemas.Validate("PaymentRefundedIntegrationEvent", 1, []byte(`{}`)); !errors.As(err, &unknown) {
+		t.Errorf("err = %v, want ErrUnknownContract", err)
+	}
+	var unsupported *messaging.ErrUnsupportedVersion
+	if err := messaging.Schemas.Validate("AppointmentSlotReservedEvent", 99, []byte(`{}`)); !errors.As(err, &unsupported) || unsupported.Version != 99 {
+		t.Errorf("err = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestSchemas_AcceptEveryFailureCode(t *testing.T) {
+	codes := []domain.FailureCode{
+		domain.FailureCardDeclined, domain.FailureInsufficientFunds, domain.FailureExpiredCard,
+		domain.FailureFraudSuspected, domain.FailureProviderUnavailable, domain.FailureTimeout, domain.FailureCancelled,
+	}
+	for _, code := range codes {
+		body, _ := json.Marshal(messaging.PaymentFailedIntegrationEvent{
+			PaymentID:     "5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01",
+			AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22",
+			FailureCode:   string(code),
+			Reason:        code.Message("en"),
+		})
+		if err := messaging.Schemas.Validate("PaymentFailedIntegrationEvent", 1, body); err != nil {
+			t.Errorf("failure code %s: %v", code, err)
+		}
+	}
+}
+
+func TestDecodeAppointmentSlotReserved(t *testing.T) {
+	example, _ := json.Marshal(messaging.Consumed[0].Examples[0])
+	event, err := messaging.DecodeAppointmentSlotReserved(amqp.Table{"schema-version": int32(1)}, example)
+	if err != nil || event.AppointmentID != "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22" || len(event.LineItems) != 1 {
+		t.Fatalf("decode example = %+v, %v", event, err)
+	}
+
+	// Producers may omit the optional fields
+	if _, err := messaging.DecodeAppointmentSlotReserved(nil,
+		[]byte(`{"appointmentId":"0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22","userId":"user-42"}`)); err != nil {
+		t.Errorf("minimal event: %v", err)
+	}
+
+	_, err = messaging.DecodeAppointmentSlotReserved(nil,
+		[]byte(`{"appointmentId":"","userId":"user-42","lineItems":[{"code":"CBC","quantity":0,"unitPrice":25}]}`))
+	var invalid *shared.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("err = %v, want a ValidationError", err)
+	}
+	fields := map[string]bool{}
+	for _, f := range invalid.Fields {
+		fields[f.Field] = true
+	}
+	if !fields["appointmentId"] || !fields["lineItems[0].quantity"] {
+		t.Errorf("violations = %+v, want appointmentId and lineItems[0].quantity", invalid.Fields)
+	}
+
+	var unsupported *messaging.ErrUnsupportedVersion
+	if _, err := messaging.DecodeAppointmentSlotReserved(amqp.Table{"schema-version": int32(99)}, example); !errors.As(err, &unsupported) {
+		t.Errorf("err = %v, want ErrUnsupportedVersion", err)
+	}
+}
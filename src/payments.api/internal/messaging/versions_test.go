@@ -0,0 +1,205 @@
+package messaging_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/messaging"
+	"github.com/smart-health/payments-api/internal/shared"
+)
+
+// chargeV1 is the first version of a test contract, amounts in major units.
+type chargeV1 struct {
+	ChargeID string  `json:"chargeId" binding:"required"`
+	Amount   float64 `json:"amount"   binding:"gte=0"`
+}
+
+// charge is its current version: amounts in minor units, with a currency.
+type charge struct {
+	ChargeID    string `json:"chargeId"    binding:"required"`
+	AmountMinor int64  `json:"amountMinor" binding:"gte=0"`
+	Currency    string `json:"currency"    binding:"len=3"`
+}
+
+func upcastCharge(body []byte) ([]byte, error) {
+	var v1 chargeV1
+	if err := json.Unmarshal(body, &v1); err != nil {
+		return nil, err
+	}
+	return json.Marshal(charge{ChargeID: v1.ChargeID, AmountMinor: int64(math.Round(v1.Amount * 100)), Currency: "usd"})
+}
+
+func downcastCharge(body []byte) ([]byte, error) {
+	var v2 charge
+	if err := json.Unmarshal(body, &v2); err != nil {
+		return nil, err
+	}
+	return json.Marshal(chargeV1{ChargeID: v2.ChargeID, Amount: float64(v2.AmountMinor) / 100})
+}
+
+func chargeContract(downcast bool) messaging.Contract {
+	r := messaging.Revision{Version: 1, Payload: chargeV1{}, Upcast: upcastCharge}
+	if downcast {
+		r.Downcast = downcastCharge
+	}
+	return messaging.Contract{Name: "ChargeCaptured", Version: 2, Payload: charge{}, Revisions: []messaging.Revision{r}}
+}
+
+func TestSchemaSet_DecodeUpcastsOldVersions(t *testing.T) {
+	set := messaging.NewSchemaSet(nil, []messaging.Contract{chargeContract(false)})
+
+	var got charge
+	if err := set.Decode("ChargeCaptured", 1, []byte(`{"chargeId":"ch_1","amount":25.5}`), &got); err != nil {
+		t.Fatalf("decode v1: %v", err)
+	}
+	if want := (charge{ChargeID: "ch_1", AmountMinor: 2550, Currency: "usd"}); got != want {
+		t.Errorf("decode v1 = %+v, want %+v", got, want)
+	}
+
+	got = charge{}
+	if err := set.Decode("ChargeCaptured", 2, []byte(`{"chargeId":"ch_2","amountMinor":100,"currency":"eur"}`), &got); err != nil || got.AmountMinor != 100 {
+		t.Errorf("decode v2 = %+v, %v", got, err)
+	}
+
+	// Old versions are checked against their own schema
+	var invalid *shared.ValidationError
+	if err := set.Decode("ChargeCaptured", 1, []byte(`{"amount":-1}`), &got); !errors.As(err, &invalid) || len(invalid.Fields) != 2 {
+		t.Errorf("invalid v1: err = %v", err)
+	}
+	var unsupported *messaging.ErrUnsupportedVersion
+	if err := set.Decode("ChargeCaptured", 3, []byte(`{}`), &got); !errors.As(err, &unsupported) {
+		t.Errorf("v3: err = %v, want ErrUnsupportedVersion", err)
+	}
+}
+
+func TestSchemaSet_DecodeChecksTheUpcastBody(t *testing.T) {
+	c := chargeContract(false)
+	c.Revisions[0].Upcast = func([]byte) ([]byte, error) { return []byte(`{"chargeId":"ch_1","currency":"dollars"}`), nil }
+	set := messaging.NewSchemaSet(nil, []messaging.Contract{c})
+
+	var got charge
+	err := set.Decode("ChargeCaptured", 1, []byte(`{"chargeId":"ch_1","amount":1}`), &got)
+	if err == nil || !strings.Contains(err.Error(), "upcast ChargeCaptured to v2") {
+		t.Errorf("err = %v, want the upcast body rejected", err)
+	}
+}
+
+func TestSchemaSet_Publications(t *testing.T) {
+	set := messaging.NewSchemaSet([]messaging.Contract{chargeContract(true)}, nil)
+
+	// A message stored before the contract changed is published in both versions
+	pubs, err := set.Publications("ChargeCaptured", 1, []byte(`{"chargeId":"ch_1","amount":25.5}`), []int{1})
+	if err != nil {
+		t.Fatalf("publications: %v", err)
+	}
+	if len(pubs) != 2 || pubs[0].Version != 2 || pubs[1].Version != 1 {
+		t.Fatalf("publications = %+v, want v2 then v1", pubs)
+	}
+	var v2 charge
+	var v1 chargeV1
+	_ = json.Unmarshal(pubs[0].Body, &v2)
+	_ = json.Unmarshal(pubs[1].Body, &v1)
+	if v2.AmountMinor != 2550 || v1.Amount != 25.5 {
+		t.Errorf("published v2 = %+v, v1 = %+v", v2, v1)
+	}
+
+	pubs, err = set.Publications("ChargeCaptured", 2, []byte(`{"chargeId":"ch_1","amountMinor":1,"currency":"usd"}`), nil)
+	if err != nil || len(pubs) != 1 || pubs[0].Version != 2 {
+		t.Errorf("without legacy versions = %+v, %v", pubs, err)
+	}
+}
+
+func TestSchemaSet_LegacyVersions(t *testing.T) {
+	set := messaging.NewSchemaSet([]messaging.Contract{chargeContract(true)}, nil)
+
+	legacy, err := set.LegacyVersions([]string{"ChargeCaptured.v1"})
+	if err != nil || !reflect.DeepEqual(legacy, map[string][]int{"ChargeCaptured": {1}}) {
+		t.Errorf("LegacyVersions = %v, %v", legacy, err)
+	}
+	if legacy, err := set.LegacyVersions(nil); err != nil || len(legacy) != 0 {
+		t.Errorf("LegacyVersions(nil) = %v, %v", legacy, err)
+	}
+
+	for _, ids := range [][]string{
+		{"ChargeCaptured"},      // no version
+		{"ChargeCaptured.v2"},   // the current version
+		{"ChargeCaptured.v0"},   // never existed
+		{"ChargeRefunded.v1"},   // unknown contract
+		{"ChargeCaptured.vtwo"}, // not a number
+	} {
+		if _, err := set.LegacyVersions(ids); err == nil {
+			t.Errorf("LegacyVersions(%v) accepted", ids)
+		}
+	}
+
+	withoutDowncast := messaging.NewSchemaSet([]messaging.Contract{chargeContract(false)}, nil)
+	if _, err := withoutDowncast.LegacyVersions([]string{"ChargeCaptured.v1"}); err == nil {
+		t.Error("a version without a downcaster cannot be published")
+	}
+}
+
+func TestPublished_UpcastsVersion1(t *testing.T) {
+	// A completed event stored at v1, read with the amount and currency of its payment
+	body := []byte(`{"paymentId":"5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01","appointmentId":"0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22",` +
+		`"status":"Completed","transactionId":"pi_1","amount":80,"currency":"eur"}`)
+	var completed messaging.PaymentCompletedIntegrationEvent
+	if err := messaging.Schemas.Decode("PaymentCompletedIntegrationEvent", 1, body, &completed); err != nil {
+		t.Fatalf("decode completed v1: %v", err)
+	}
+	if completed.Provider != "stripe" || completed.Amount != 80 || completed.Currency != "eur" || completed.TaxAmount != 0 || completed.TransactionID != "pi_1" {
+		t.Errorf("completed v1 upcast = %+v", completed)
+	}
+
+	for reason, code := range map[string]string{
+		"Stripe error: Your card was declined. code: card_declined": "card_declined",
+		"Stripe error: {\"code\":\"expired_card\"}":                 "expired_card",
+		"Stripe error: connection reset by peer":                    "provider_unavailable",
+	} {
+		body, _ := json.Marshal(messaging.PaymentFailedIntegrationEventV1{
+			PaymentID: "5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01", AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22", Reason: reason,
+		})
+		var failed messaging.PaymentFailedIntegrationEvent
+		if err := messaging.Schemas.Decode("PaymentFailedIntegrationEvent", 1, body, &failed); err != nil {
+			t.Fatalf("decode failed v1 %q: %v", reason, err)
+		}
+		if failed.FailureCode != code || strings.Contains(failed.Reason, "Stripe") {
+			t.Errorf("failed v1 %q upcast = %+v, want code %s and a patient message", reason, failed, code)
+		}
+	}
+}
+
+func TestPublished_PublishesVersion1DuringMigration(t *testing.T) {
+	legacy, err := messaging.Schemas.LegacyVersions([]string{"PaymentCompletedIntegrationEvent.v1", "PaymentFailedIntegrationEvent.v1"})
+	if err != nil {
+		t.Fatalf("LegacyVersions: %v", err)
+	}
+
+	completed, _ := json.Marshal(messaging.PaymentCompletedIntegrationEvent{
+		PaymentID: "5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01", AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22",
+		Status: "Completed", Provider: "adyen", TransactionID: "psp_1", Amount: 107.25, Currency: "usd", TaxAmount: 7.25,
+	})
+	pubs, err := messaging.Schemas.Publications("PaymentCompletedIntegrationEvent", 2, completed, legacy["PaymentCompletedIntegrationEvent"])
+	if err != nil || len(pubs) != 2 || pubs[1].Version != 1 {
+		t.Fatalf("completed publications = %+v, %v", pubs, err)
+	}
+	if want := `{"paymentId":"5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01","appointmentId":"0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22","status":"Completed","transactionId":"psp_1"}`; string(pubs[1].Body) != want {
+		t.Errorf("completed v1 = %s, want the fields of v1 only", pubs[1].Body)
+	}
+
+	failed, _ := json.Marshal(messaging.PaymentFailedIntegrationEvent{
+		PaymentID: "5b0e8f4e-4a4f-4d55-9c5e-3f1a2b7c9d01", AppointmentID: "0c9a7e2d-6b1f-4e8a-a3d2-7f5b9c1e4a22",
+		FailureCode: "timeout", Reason: "The payment was not completed in time. Please try again.",
+	})
+	pubs, err = messaging.Schemas.Publications("PaymentFailedIntegrationEvent", 2, failed, legacy["PaymentFailedIntegrationEvent"])
+	if err != nil || len(pubs) != 2 {
+		t.Fatalf("failed publications = %+v, %v", pubs, err)
+	}
+	var v1 messaging.PaymentFailedIntegrationEventV1
+	if err := json.Unmarshal(pubs[1].Body, &v1); err != nil || v1.Reason != "The payment was not completed in time. Please try again." {
+		t.Errorf("failed v1 = %s, %v", pubs[1].Body, err)
+	}
+}
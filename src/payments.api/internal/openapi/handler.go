@@ -0,0 +1,33 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// Handler serves the document as JSON. It is encoded once: the document
+// does not change while the service runs.
+func Handler(doc *Document) gin.HandlerFunc {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	return func(c *gin.Context) {
+		if err != nil {
+			c.String(http.StatusInternalServerError, "openapi document could not be encoded: %v", err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json", body)
+	}
+}
+
+// DocsHandler serves a self-contained page browsing the document at
+// /openapi.json; it loads nothing from outside the service.
+func DocsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+	}
+}
@@ -0,0 +1,301 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/smart-health/payments-api/internal/jsonschema"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// -----------------------------------------------------------------------
+// OpenAPI document
+//
+// Architectural Decision: Every package that mounts routes declares them
+// next to RegisterRoutes, as Routes(): path, roles, query parameters and
+// the Go types bound from the body and written as the response. The
+// document is built from those declarations and the types' schemas are
+// generated from the structs (see jsonschema), so the contract changes
+// with the code; a test compares the declarations with the routes Gin
+// actually serves and fails when either side has one the other lacks.
+// -----------------------------------------------------------------------
+
+// Version is the OpenAPI version of the documents built here.
+const Version = "3.1.0"
+
+// Route declares an endpoint mounted by RegisterRoutes.
+type Route struct {
+	Method       string // http.MethodGet, ...
+	Path         string // Gin path relative to the group, e.g. "/fee-schedules/:id"
+	Summary      string
+	Description  string
+	Roles        []string // roles admitted, when stricter than the group's
+	Query        []Param
+	Request      any      // value of the type bound from the JSON body; nil for none
+	Response     any      // value of the type written on success; nil for no body
+	Status       int      // success status; http.StatusOK when zero
+	ContentTypes []string // success media types when the response is not JSON
+	Errors       []int    // problem statuses the endpoint may answer with
+}
+
+// Param is a query parameter.
+type Param struct {
+	Name        string
+	Description string
+	Type        string // JSON Schema type; "string" when empty
+	Format      string
+	Required    bool
+}
+
+// Group declares where routes are mounted and who may call them.
+type Group struct {
+	Prefix string   // e.g. "/api/pricing"
+	Tag    string   // groups the operations in the docs
+	Public bool     // no token required
+	Roles  []string // roles admitted; empty admits any authenticated caller
+}
+
+// Info describes the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Tag names a group of operations.
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Components holds the schemas and security schemes operations refer to.
+type Components struct {
+	Schemas         map[string]*jsonschema.Schema `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme     `json:"securitySchemes"`
+}
+
+// SecurityScheme describes how callers authenticate.
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Operation is a method on a path.
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name        string             `json:"name"`
+	In          string             `json:"in"`
+	Description string             `json:"description,omitempty"`
+	Required    bool               `json:"required,omitempty"`
+	Schema      *jsonschema.Schema `json:"schema"`
+}
+
+// RequestBody is the body an operation accepts.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response an operation may answer with.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType carries the schema of a body.
+type MediaType struct {
+	Schema *jsonschema.Schema `json:"schema,omitempty"`
+}
+
+const (
+	refPrefix      = "#/components/schemas/"
+	securityScheme = "bearerAuth"
+)
+
+// Builder assembles a document from route declarations.
+type Builder struct {
+	doc     *Document
+	schema  *jsonschema.Generator
+	problem *jsonschema.Schema
+}
+
+// NewBuilder starts a document.
+func NewBuilder(info Info) *Builder {
+	g := jsonschema.NewGenerator(refPrefix)
+	return &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   map[string]map[string]*Operation{},
+			Components: Components{
+				Schemas: g.Defs,
+				SecuritySchemes: map[string]SecurityScheme{securityScheme: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "A JWT whose roles claim grants access: patient, staff, admin or service",
+				}},
+			},
+		},
+		schema:  g,
+		problem: g.Output(problem.Problem{}),
+	}
+}
+
+// Add declares routes mounted on a group.
+func (b *Builder) Add(group Group, routes ...Route) *Builder {
+	if group.Tag != "" && !b.hasTag(group.Tag) {
+		b.doc.Tags = append(b.doc.Tags, Tag{Name: group.Tag})
+	}
+	for _, r := range routes {
+		path, params := Path(group.Prefix + r.Path)
+		if b.doc.Paths[path] == nil {
+			b.doc.Paths[path] = map[string]*Operation{}
+		}
+		b.doc.Paths[path][strings.ToLower(r.Method)] = b.operation(group, r, path, params)
+	}
+	return b
+}
+
+// Document returns the assembled document.
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+func (b *Builder) hasTag(name string) bool {
+	for _, t := range b.doc.Tags {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Builder) operation(group Group, r Route, path string, pathParams []string) *Operation {
+	op := &Operation{
+		Summary:     r.Summary,
+		Description: r.Description,
+		OperationID: operationID(r.Method, path),
+		Responses:   map[string]*Response{},
+		Security:    []map[string][]string{},
+	}
+	if group.Tag != "" {
+		op.Tags = []string{group.Tag}
+	}
+
+	for _, name := range pathParams {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name: name, In: "path", Required: true, Schema: &jsonschema.Schema{Type: "string"},
+		})
+	}
+	for _, q := range r.Query {
+		typ := q.Type
+		if typ == "" {
+			typ = "string"
+		}
+		op.Parameters = append(op.Parameters, Parameter{
+			Name: q.Name, In: "query", Description: q.Description, Required: q.Required,
+			Schema: &jsonschema.Schema{Type: typ, Format: q.Format},
+		})
+	}
+
+	if r.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: b.schema.Input(r.Request)}},
+		}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	switch {
+	case len(r.ContentTypes) > 0:
+		success.Content = map[string]MediaType{}
+		for _, t := range r.ContentTypes {
+			success.Content[t] = MediaType{}
+		}
+	case r.Response != nil:
+		success.Content = map[string]MediaType{"application/json": {Schema: b.schema.Output(r.Response)}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	failures := append([]int{}, r.Errors...)
+	if !group.Public {
+		// Authenticated routes answer 401 without a valid token and 403 without the role
+		failures = append(failures, http.StatusUnauthorized, http.StatusForbidden)
+		roles := r.Roles
+		if roles == nil {
+			roles = group.Roles
+		}
+		op.Security = []map[string][]string{{securityScheme: {}}}
+		if len(roles) > 0 {
+			op.Description = strings.TrimSpace(op.Description + "\n\nRoles: " + strings.Join(roles, ", ") + ".")
+		}
+	}
+	if r.Request != nil || len(r.Query) > 0 || len(pathParams) > 0 {
+		failures = append(failures, http.StatusBadRequest)
+	}
+	failures = append(failures, http.StatusInternalServerError)
+	sort.Ints(failures)
+	for _, s := range failures {
+		op.Responses[strconv.Itoa(s)] = &Response{
+			Description: http.StatusText(s),
+			Content:     map[string]MediaType{problem.ContentType: {Schema: b.problem}},
+		}
+	}
+	return op
+}
+
+// Path converts a Gin path to an OpenAPI path and lists its parameters:
+// "/api/payments/:id" is "/api/payments/{id}".
+func Path(ginPath string) (string, []string) {
+	var params []string
+	segments := strings.Split(ginPath, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			params = append(params, s[1:])
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives a stable ID: "GET /api/payments/{id}" is "getApiPaymentsById".
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, s := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == '_' || r == '.' }) {
+		if strings.HasPrefix(s, "{") {
+			b.WriteString("By")
+			s = strings.Trim(s, "{}")
+		}
+		b.WriteString(strings.ToUpper(s[:1]) + s[1:])
+	}
+	return b.String()
+}
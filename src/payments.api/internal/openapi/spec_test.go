@@ -0,0 +1,77 @@
+package openapi_test
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/openapi"
+)
+
+type createRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type thing struct {
+	ID string `json:"id"`
+}
+
+func TestPath(t *testing.T) {
+	path, params := openapi.Path("/api/customers/:userId/payment-methods/:pmId")
+	if path != "/api/customers/{userId}/payment-methods/{pmId}" {
+		t.Errorf("path = %q", path)
+	}
+	if !reflect.DeepEqual(params, []string{"userId", "pmId"}) {
+		t.Errorf("params = %v", params)
+	}
+}
+
+func TestBuilder_Operations(t *testing.T) {
+	doc := openapi.NewBuilder(openapi.Info{Title: "test", Version: "1"}).
+		Add(openapi.Group{Prefix: "/api/things", Tag: "Things", Roles: []string{"staff"}},
+			openapi.Route{Method: http.MethodPost, Path: "", Summary: "Create",
+				Request: createRequest{}, Response: thing{}, Status: http.StatusCreated, Errors: []int{http.StatusConflict}},
+			openapi.Route{Method: http.MethodGet, Path: "/:id", Response: thing{}, Roles: []string{"patient"}}).
+		Add(openapi.Group{Public: true},
+			openapi.Route{Method: http.MethodGet, Path: "/health"}).
+		Document()
+
+	create := doc.Paths["/api/things"]["post"]
+	if create == nil {
+		t.Fatalf("paths = %v", doc.Paths)
+	}
+	if create.OperationID != "postApiThings" || !reflect.DeepEqual(create.Tags, []string{"Things"}) {
+		t.Errorf("operationId = %q, tags = %v", create.OperationID, create.Tags)
+	}
+	if create.RequestBody == nil || create.RequestBody.Content["application/json"].Schema.Required[0] != "name" {
+		t.Errorf("request body = %+v", create.RequestBody)
+	}
+	if got := create.Responses["201"].Content["application/json"].Schema.Ref; got != "#/components/schemas/openapi_test.thing" {
+		t.Errorf("201 schema = %q", got)
+	}
+	for _, status := range []string{"400", "401", "403", "409", "500"} {
+		if _, ok := create.Responses[status].Content["application/problem+json"]; !ok {
+			t.Errorf("%s is not a problem response", status)
+		}
+	}
+	if len(create.Security) != 1 || !strings.Contains(create.Description, "Roles: staff") {
+		t.Errorf("security = %v, description = %q", create.Security, create.Description)
+	}
+
+	get := doc.Paths["/api/things/{id}"]["get"]
+	if get.OperationID != "getApiThingsById" || len(get.Parameters) != 1 || get.Parameters[0].In != "path" {
+		t.Errorf("operationId = %q, parameters = %+v", get.OperationID, get.Parameters)
+	}
+	if !strings.Contains(get.Description, "Roles: patient") {
+		t.Errorf("route roles override the group's, description = %q", get.Description)
+	}
+
+	health := doc.Paths["/health"]["get"]
+	if len(health.Security) != 0 || health.Responses["401"] != nil {
+		t.Errorf("public route: security = %v, responses = %v", health.Security, health.Responses)
+	}
+	if health.Responses["200"].Content != nil {
+		t.Errorf("200 without a body, got %v", health.Responses["200"].Content)
+	}
+}
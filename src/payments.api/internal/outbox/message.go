@@ -14,12 +14,13 @@ import (
 // entries and publishes them to the message broker, guaranteeing
 // at-least-once delivery without the dual-write problem.
 type Message struct {
-	ID          uuid.UUID
-	AggregateID uuid.UUID
-	Type        string     // event type name (e.g. "PaymentCompletedIntegrationEvent")
-	Payload     []byte     // JSON-encoded event payload
-	Processed   bool
-	CreatedAt   time.Time
-	ProcessedAt *time.Time
-	RetryCount  int
+	ID            uuid.UUID
+	AggregateID   uuid.UUID
+	Type          string // event type name (e.g. "PaymentCompletedIntegrationEvent")
+	SchemaVersion int    // contract version of the payload
+	Payload       []byte // JSON-encoded event payload
+	Processed     bool
+	CreatedAt     time.Time
+	ProcessedAt   *time.Time
+	RetryCount    int
 }
@@ -48,11 +48,12 @@ func (r *PostgresRepository) SaveEvents(ctx context.Context, tx pgx.Tx, events [
 		}
 
 		_, err = tx.Exec(ctx, `
-			INSERT INTO outbox_messages (id, aggregate_id, type, payload, processed, created_at, retry_count)
-			VALUES ($1, $2, $3, $4, false, $5, 0)`,
+			INSERT INTO outbox_messages (id, aggregate_id, type, schema_version, payload, processed, created_at, retry_count)
+			VALUES ($1, $2, $3, $4, $5, false, $6, 0)`,
 			msg.ID,
 			msg.AggregateID,
 			msg.Type,
+			msg.SchemaVersion,
 			msg.Payload,
 			msg.CreatedAt,
 		)
@@ -64,12 +65,20 @@ func (r *PostgresRepository) SaveEvents(ctx context.Context, tx pgx.Tx, events [
 }
 
 // GetUnprocessed retrieves pending outbox messages for the background publisher.
+// Completed events stored at version 1 did not carry the amount: they are
+// read with the amount and currency of their payment, which the upcaster
+// to version 2 takes over (see messaging.PaymentCompletedIntegrationEventV1).
 func (r *PostgresRepository) GetUnprocessed(ctx context.Context, limit int) ([]*Message, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, aggregate_id, type, payload, processed, created_at, processed_at, retry_count
-		FROM outbox_messages
-		WHERE processed = false AND retry_count < 5
-		ORDER BY created_at ASC
+		SELECT o.id, o.aggregate_id, o.type, o.schema_version,
+		       CASE WHEN o.type = 'PaymentCompletedIntegrationEvent' AND o.schema_version = 1 AND p.id IS NOT NULL
+		            THEN jsonb_build_object('amount', p.amount, 'currency', p.currency) || o.payload
+		            ELSE o.payload END,
+		       o.processed, o.created_at, o.processed_at, o.retry_count
+		FROM outbox_messages o
+		LEFT JOIN payments p ON p.id = o.aggregate_id
+		WHERE o.processed = false AND o.retry_count < 5
+		ORDER BY o.created_at ASC
 		LIMIT $1`, limit)
 	if err != nil {
 		return nil, fmt.Errorf("query outbox messages: %w", err)
@@ -80,7 +89,7 @@ func (r *PostgresRepository) GetUnprocessed(ctx context.Context, limit int) ([]*
 	for rows.Next() {
 		var m Message
 		if err := rows.Scan(
-			&m.ID, &m.AggregateID, &m.Type, &m.Payload,
+			&m.ID, &m.AggregateID, &m.Type, &m.SchemaVersion, &m.Payload,
 			&m.Processed, &m.CreatedAt, &m.ProcessedAt, &m.RetryCount,
 		); err != nil {
 			return nil, fmt.Errorf("scan outbox message: %w", err)
@@ -110,44 +119,67 @@ func (r *PostgresRepository) IncrementRetry(ctx context.Context, id uuid.UUID) e
 func toOutboxMessage(event interface{}) (*Message, error) {
 	switch e := event.(type) {
 	case domain.PaymentCompletedEvent:
+		var taxLines []messaging.TaxLineInfo
+		for _, l := range e.TaxLines {
+			taxLines = append(taxLines, messaging.TaxLineInfo{
+				Name:         l.Name,
+				Jurisdiction: l.Jurisdiction,
+				ItemCode:     l.ItemCode,
+				Percent:      l.Percent,
+				Inclusive:    l.Inclusive,
+				Amount:       l.Amount,
+			})
+		}
 		integrationEvent := messaging.PaymentCompletedIntegrationEvent{
 			PaymentID:     e.PaymentID.String(),
 			AppointmentID: e.AppointmentID.String(),
 			Status:        "Completed",
+			Provider:      e.Provider,
 			TransactionID: e.TransactionID,
+			Amount:        e.Amount,
+			Currency:      e.Currency,
+			TaxAmount:     e.TaxAmount,
+			TaxLines:      taxLines,
 		}
-		payload, err := json.Marshal(integrationEvent)
-		if err != nil {
-			return nil, err
-		}
-		return &Message{
-			ID:          uuid.New(),
-			AggregateID: e.PaymentID,
-			Type:        "PaymentCompletedIntegrationEvent",
-			Payload:     payload,
-			CreatedAt:   time.Now().UTC(),
-		}, nil
+		return newMessage(e.PaymentID, integrationEvent)
 
 	case domain.PaymentFailedEvent:
 		integrationEvent := messaging.PaymentFailedIntegrationEvent{
 			PaymentID:     e.PaymentID.String(),
 			AppointmentID: e.AppointmentID.String(),
-			Reason:        e.Reason,
+			FailureCode:   string(e.Code),
+			Reason:        e.Code.Message(domain.DefaultLanguage),
 		}
-		payload, err := json.Marshal(integrationEvent)
-		if err != nil {
-			return nil, err
-		}
-		return &Message{
-			ID:          uuid.New(),
-			AggregateID: e.PaymentID,
-			Type:        "PaymentFailedIntegrationEvent",
-			Payload:     payload,
-			CreatedAt:   time.Now().UTC(),
-		}, nil
+		return newMessage(e.PaymentID, integrationEvent)
 
 	default:
 		// Not all domain events need to be published externally (e.g. PaymentCreatedEvent)
 		return nil, nil
 	}
 }
+
+// newMessage encodes an integration event listed in the messaging catalog;
+// its catalog name is the message type and routing key. A payload breaking
+// the schema of its contract is refused, failing the transaction: it would
+// break every consumer.
+func newMessage(aggregateID uuid.UUID, event any) (*Message, error) {
+	contract, ok := messaging.PublishedContract(event)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a published integration event: add it to messaging.Published", event)
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	if err := messaging.Schemas.Validate(contract.Name, contract.Version, payload); err != nil {
+		return nil, fmt.Errorf("%s breaks its contract: %w", contract.ID(), err)
+	}
+	return &Message{
+		ID:            uuid.New(),
+		AggregateID:   aggregateID,
+		Type:          contract.Name,
+		SchemaVersion: contract.Version,
+		Payload:       payload,
+		CreatedAt:     time.Now().UTC(),
+	}, nil
+}
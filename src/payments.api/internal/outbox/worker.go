@@ -21,15 +21,23 @@ const (
 // from business operations. Even if the broker is temporarily unavailable,
 // messages accumulate in the DB and are published once the broker recovers.
 // This provides at-least-once delivery semantics.
+//
+// Messages stored at a superseded contract version are upcast to the
+// current version before they are published. During a migration window an
+// event is published once per version consumers still read: the current
+// version, then each legacy version, downcast from it.
 type Worker struct {
 	repo      Repository
 	publisher messaging.Publisher
+	legacy    map[string][]int // legacy versions still published, per event type
 	logger    *slog.Logger
 }
 
-// NewWorker creates a new outbox background worker.
-func NewWorker(repo Repository, publisher messaging.Publisher, logger *slog.Logger) *Worker {
-	return &Worker{repo: repo, publisher: publisher, logger: logger}
+// NewWorker creates a new outbox background worker. legacy lists the
+// superseded versions still published for each event type (see
+// messaging.SchemaSet.LegacyVersions); nil publishes the current versions only.
+func NewWorker(repo Repository, publisher messaging.Publisher, legacy map[string][]int, logger *slog.Logger) *Worker {
+	return &Worker{repo: repo, publisher: publisher, legacy: legacy, logger: logger}
 }
 
 // Run starts the outbox polling loop. It blocks until ctx is cancelled.
@@ -65,7 +73,7 @@ func (w *Worker) processBatch(ctx context.Context) {
 	w.logger.Info("processing outbox messages", "count", len(messages))
 
 	for _, msg := range messages {
-		if err := w.publisher.Publish(ctx, msg.Type, msg.Payload); err != nil {
+		if err := w.publish(ctx, msg); err != nil {
 			w.logger.Error("failed to publish outbox message",
 				"id", msg.ID,
 				"type", msg.Type,
@@ -90,3 +98,19 @@ func (w *Worker) processBatch(ctx context.Context) {
 		}
 	}
 }
+
+// publish sends a message in every version it is published in. A failure
+// retries the whole message, so versions already sent are sent again:
+// delivery stays at-least-once.
+func (w *Worker) publish(ctx context.Context, msg *Message) error {
+	pubs, err := messaging.Schemas.Publications(msg.Type, msg.SchemaVersion, msg.Payload, w.legacy[msg.Type])
+	if err != nil {
+		return err
+	}
+	for _, pub := range pubs {
+		if err := w.publisher.Publish(ctx, msg.Type, pub.Version, pub.Body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
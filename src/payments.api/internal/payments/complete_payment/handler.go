@@ -2,29 +2,35 @@ package completepayment
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway"
 	"github.com/smart-health/payments-api/internal/payments/domain"
 	"github.com/smart-health/payments-api/internal/payments/infrastructure"
-	stripeservice "github.com/smart-health/payments-api/internal/stripe"
 )
 
 // ---------------------------------------------------------------------------
 // Command
 // ---------------------------------------------------------------------------
 
-// Command carries the PaymentID of the payment to process via Stripe.
+// Command carries the PaymentID of the payment to charge with its provider.
 type Command struct {
 	PaymentID uuid.UUID
 }
 
 // Result is returned after processing the payment.
+// While the payment requires action, ClientSecret lets the patient's frontend
+// confirm the transaction (Stripe) and Instructions tell the patient what to
+// do otherwise (e.g. bank transfer details). Neither is persisted.
 type Result struct {
 	PaymentID     string
 	Status        string
 	TransactionID string
+	ClientSecret  string `json:",omitempty"`
+	Instructions  string `json:",omitempty"`
 }
 
 // ---------------------------------------------------------------------------
@@ -35,21 +41,31 @@ type Result struct {
 //
 // Flow:
 //  1. Load Payment aggregate.
-//  2. Call Stripe to create a PaymentIntent.
-//  3. On success: MarkProcessing → MarkCompleted → persist (outbox populated).
-//  4. On Stripe error: MarkFailed → persist (outbox populated).
+//  2. Authorize and capture the amount with the payment's provider (Stripe
+//     confirms off-session when a default card is saved).
+//  3. Transition on the transaction status (see ApplyTransaction): succeeded →
+//     Completed, waiting for the patient → RequiresAction (the client secret
+//     is returned for frontend confirmation), pending → Processing.
+//  4. On a provider error the payment stays Pending and the error is
+//     returned: a temporary one (outage, rate limit, a request still in
+//     flight) is retried later as it was; any other means the provider
+//     refused the request, which is recorded so the next charge is a new
+//     request (see RecordRefusedCharge), until it needs review. Only a
+//     decline fails the payment, and declines are not errors but Failed
+//     transactions, coded by their decline reason.
+//  5. Persist (outbox populated).
 //
 // The PaymentCompletedEvent domain event is translated to
 // PaymentCompletedIntegrationEvent by the outbox repository.
 type Handler struct {
-	repo          infrastructure.PaymentRepository
-	stripeService stripeservice.Service
-	logger        *slog.Logger
+	repo     infrastructure.PaymentRepository
+	gateways gateway.Registry
+	logger   *slog.Logger
 }
 
 // NewHandler creates a new CompletePaymentHandler.
-func NewHandler(repo infrastructure.PaymentRepository, stripe stripeservice.Service, logger *slog.Logger) *Handler {
-	return &Handler{repo: repo, stripeService: stripe, logger: logger}
+func NewHandler(repo infrastructure.PaymentRepository, gateways gateway.Registry, logger *slog.Logger) *Handler {
+	return &Handler{repo: repo, gateways: gateways, logger: logger}
 }
 
 // Handle processes the command.
@@ -62,46 +78,155 @@ func (h *Handler) Handle(ctx context.Context, cmd Command) (*Result, error) {
 		return nil, &domain.ErrPaymentNotFound{ID: cmd.PaymentID}
 	}
 
-	h.logger.Info("processing Stripe payment",
+	provider, err := h.gateways.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.Info("processing payment",
 		"paymentId", payment.ID,
 		"appointmentId", payment.AppointmentID,
+		"provider", payment.Provider,
 		"amount", payment.Amount,
 		"currency", payment.Currency)
 
-	// Call Stripe to create a PaymentIntent
-	intentID, stripeErr := h.stripeService.CreatePaymentIntent(
-		ctx, payment.Amount, payment.Currency, payment.AppointmentID)
-
-	if stripeErr != nil {
-		h.logger.Error("Stripe error, marking payment as failed",
+	lineItems := make([]gateway.LineItem, 0, len(payment.LineItems))
+	for _, item := range payment.LineItems {
+		lineItems = append(lineItems, gateway.LineItem{
+			Code:        item.Code,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			Total:       item.Total,
+		})
+	}
+	tx, providerErr := provider.Authorize(ctx, gateway.AuthorizeRequest{
+		PaymentID:     payment.ID,
+		AppointmentID: payment.AppointmentID,
+		UserID:        payment.UserID,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		LineItems:     lineItems,
+		Capture:       true,
+		Attempt:       payment.ChargeAttempt,
+	})
+
+	if providerErr != nil && gateway.IsTemporary(providerErr) {
+		h.logger.Warn("payment provider unavailable, payment left pending",
 			"paymentId", payment.ID,
-			"error", stripeErr)
-
-		if err := payment.MarkFailed(fmt.Sprintf("Stripe error: %v", stripeErr)); err != nil {
-			return nil, fmt.Errorf("mark payment failed: %w", err)
-		}
-	} else {
-		// Mark processing (intent created), then mark completed (auto-confirm in test mode)
-		if err := payment.MarkProcessing(intentID); err != nil {
-			return nil, fmt.Errorf("mark payment processing: %w", err)
-		}
-		if err := payment.MarkCompleted(); err != nil {
-			return nil, fmt.Errorf("mark payment completed: %w", err)
-		}
+			"provider", payment.Provider,
+			"error", providerErr)
+		return nil, fmt.Errorf("charge payment %s: %w", payment.ID, providerErr)
+	}
+	if providerErr != nil {
+		return nil, h.refused(ctx, payment, providerErr)
+	}
 
-		h.logger.Info("payment completed",
-			"paymentId", payment.ID,
-			"transactionId", intentID)
+	if err := ApplyTransaction(payment, tx); err != nil {
+		return nil, err
+	}
+	var nextAction *gateway.Transaction
+	if payment.Status == domain.PaymentStatusRequiresAction {
+		nextAction = tx
 	}
 
+	h.logger.Info("payment transaction created",
+		"paymentId", payment.ID,
+		"provider", payment.Provider,
+		"transactionId", tx.ID,
+		"transactionStatus", tx.Status,
+		"status", payment.Status)
+
 	// Persist changes – outbox messages are populated by the repository
 	if err := h.repo.Update(ctx, payment); err != nil {
 		return nil, fmt.Errorf("update payment: %w", err)
 	}
 
-	return &Result{
+	result := &Result{
 		PaymentID:     payment.ID.String(),
 		Status:        payment.Status.String(),
-		TransactionID: payment.StripePaymentIntentID,
-	}, nil
+		TransactionID: payment.TransactionID,
+	}
+	if nextAction != nil {
+		result.ClientSecret = nextAction.ClientSecret
+		result.Instructions = nextAction.Instructions
+	}
+	return result, nil
+}
+
+// refused handles a provider error that is not temporary. The payment stays
+// Pending: only a decline fails it, and declines are Failed transactions,
+// not errors. A request the provider answered is recorded as a refused
+// charge, so charging the payment again is a new request rather than a
+// replay of the refused one; a call that ran out of time got no answer and
+// keeps its attempt. After MaxRefusedCharges the retrier stops charging the
+// payment and it is logged for review. The provider error is returned.
+func (h *Handler) refused(ctx context.Context, payment *domain.Payment, providerErr error) error {
+	h.logger.Error("payment provider error, payment left pending",
+		"paymentId", payment.ID,
+		"provider", payment.Provider,
+		"attempt", payment.ChargeAttempt,
+		"error", providerErr)
+
+	if !errors.Is(providerErr, context.DeadlineExceeded) {
+		if err := payment.RecordRefusedCharge(); err != nil {
+			return fmt.Errorf("record refused charge: %w", err)
+		}
+		if err := h.repo.Update(ctx, payment); err != nil {
+			return fmt.Errorf("update payment: %w", err)
+		}
+		if payment.NeedsReview() {
+			h.logger.Error("payment refused too often, left for review",
+				"paymentId", payment.ID,
+				"provider", payment.Provider,
+				"refusedCharges", payment.ChargeAttempt)
+		}
+	}
+	return fmt.Errorf("charge payment %s: %w", payment.ID, providerErr)
+}
+
+// ApplyTransaction transitions the payment to match the state of its provider
+// transaction. Statuses the payment is already in are left unchanged, so it
+// is safe to apply the same transaction state twice.
+func ApplyTransaction(payment *domain.Payment, tx *gateway.Transaction) error {
+	switch tx.Status {
+	case gateway.StatusSucceeded:
+		if payment.Status != domain.PaymentStatusProcessing {
+			if err := payment.MarkProcessing(tx.ID); err != nil {
+				return fmt.Errorf("mark payment processing: %w", err)
+			}
+		}
+		if err := payment.MarkCompleted(); err != nil {
+			return fmt.Errorf("mark payment completed: %w", err)
+		}
+
+	case gateway.StatusPending, gateway.StatusAuthorized:
+		if payment.Status != domain.PaymentStatusProcessing {
+			if err := payment.MarkProcessing(tx.ID); err != nil {
+				return fmt.Errorf("mark payment processing: %w", err)
+			}
+		}
+
+	case gateway.StatusRequiresAction:
+		if payment.Status != domain.PaymentStatusRequiresAction {
+			if err := payment.MarkRequiresAction(tx.ID); err != nil {
+				return fmt.Errorf("mark payment requires action: %w", err)
+			}
+		}
+
+	case gateway.StatusFailed:
+		code := domain.FailureCodeFromProvider(tx.FailureCode, domain.FailureCardDeclined)
+		if err := payment.MarkFailed(code, fmt.Sprintf("%s error: %s", payment.Provider, tx.FailureReason)); err != nil {
+			return fmt.Errorf("mark payment failed: %w", err)
+		}
+
+	case gateway.StatusCanceled:
+		code := domain.FailureCodeFromProvider(tx.FailureCode, domain.FailureCancelled)
+		if err := payment.MarkFailed(code, "payment transaction was canceled"); err != nil {
+			return fmt.Errorf("mark payment failed: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unexpected transaction status %q", tx.Status)
+	}
+	return nil
 }
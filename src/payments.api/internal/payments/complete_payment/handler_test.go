@@ -0,0 +1,229 @@
+package completepayment_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway"
+	completepayment "github.com/smart-health/payments-api/internal/payments/complete_payment"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+)
+
+type fakeRepo struct {
+	infrastructure.PaymentRepository
+	payment *domain.Payment
+	updates int
+	err     error // returned by Update
+}
+
+func (r *fakeRepo) FindByID(_ context.Context, id uuid.UUID) (*domain.Payment, error) {
+	if r.payment == nil || r.payment.ID != id {
+		return nil, nil
+	}
+	return r.payment, nil
+}
+
+func (r *fakeRepo) Update(context.Context, *domain.Payment) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.updates++
+	return nil
+}
+
+// fakeGateway answers each Authorize with the next of its errors, then
+// with a succeeded transaction.
+type fakeGateway struct {
+	gateway.PaymentGateway
+	errs     []error
+	requests []gateway.AuthorizeRequest
+}
+
+func (g *fakeGateway) Get(string) (gateway.PaymentGateway, error) { return g, nil }
+
+func (g *fakeGateway) Authorize(_ context.Context, req gateway.AuthorizeRequest) (*gateway.Transaction, error) {
+	g.requests = append(g.requests, req)
+	if len(g.errs) > 0 {
+		err := g.errs[0]
+		g.errs = g.errs[1:]
+		return nil, err
+	}
+	return &gateway.Transaction{ID: "tx_1", Status: gateway.StatusSucceeded}, nil
+}
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "provider unavailable" }
+func (temporaryError) Temporary() bool { return true }
+
+func newHandler(t *testing.T, provider *fakeGateway) (*completepayment.Handler, *fakeRepo) {
+	t.Helper()
+	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "eur")
+	repo := &fakeRepo{payment: p}
+	return completepayment.NewHandler(repo, provider, slog.New(slog.NewTextHandler(io.Discard, nil))), repo
+}
+
+func TestHandle_RefusedChargeLeavesPaymentPending(t *testing.T) {
+	refusal := errors.New("invalid request (parameter_invalid)")
+	provider := &fakeGateway{errs: []error{refusal}}
+	h, repo := newHandler(t, provider)
+	cmd := completepayment.Command{PaymentID: repo.payment.ID}
+
+	if _, err := h.Handle(context.Background(), cmd); !errors.Is(err, refusal) {
+		t.Fatalf("expected the provider error, got %v", err)
+	}
+	if repo.payment.Status != domain.PaymentStatusPending || repo.payment.ChargeAttempt != 1 || repo.updates != 1 {
+		t.Errorf("payment %s at attempt %d after %d updates, want Pending at attempt 1 saved",
+			repo.payment.Status, repo.payment.ChargeAttempt, repo.updates)
+	}
+
+	// Charged again, the payment is a new request to the provider
+	result, err := h.Handle(context.Background(), cmd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != domain.PaymentStatusCompleted.String() {
+		t.Errorf("status = %s, want Completed", result.Status)
+	}
+	if provider.requests[0].Attempt != 0 || provider.requests[1].Attempt != 1 {
+		t.Errorf("attempts = %d, %d, want 0, 1", provider.requests[0].Attempt, provider.requests[1].Attempt)
+	}
+}
+
+func TestHandle_RefusedTooOftenNeedsReview(t *testing.T) {
+	refusal := errors.New("invalid request (parameter_invalid)")
+	provider := &fakeGateway{}
+	h, repo := newHandler(t, provider)
+
+	for i := 1; i <= domain.MaxRefusedCharges; i++ {
+		if repo.payment.NeedsReview() {
+			t.Fatalf("payment needs review after %d refused charges", i-1)
+		}
+		provider.errs = []error{refusal}
+		if _, err := h.Handle(context.Background(), completepayment.Command{PaymentID: repo.payment.ID}); !errors.Is(err, refusal) {
+			t.Fatalf("charge %d: expected the provider error, got %v", i, err)
+		}
+	}
+	if !repo.payment.NeedsReview() || repo.payment.Status != domain.PaymentStatusPending {
+		t.Errorf("payment %s after %d refused charges, want Pending and needing review",
+			repo.payment.Status, repo.payment.ChargeAttempt)
+	}
+}
+
+func TestHandle_UnansweredChargeKeepsItsAttempt(t *testing.T) {
+	for _, providerErr := range []error{temporaryError{}, fmt.Errorf("charge: %w", context.DeadlineExceeded)} {
+		h, repo := newHandler(t, &fakeGateway{errs: []error{providerErr}})
+		if _, err := h.Handle(context.Background(), completepayment.Command{PaymentID: repo.payment.ID}); err == nil {
+			t.Errorf("%v: expected the provider error", providerErr)
+		}
+		// The provider may have made the charge: the retry must find it again
+		if repo.payment.Status != domain.PaymentStatusPending || repo.payment.ChargeAttempt != 0 || repo.updates != 0 {
+			t.Errorf("%v: payment %s at attempt %d after %d updates, want Pending at attempt 0 untouched",
+				providerErr, repo.payment.Status, repo.payment.ChargeAttempt, repo.updates)
+		}
+	}
+}
+
+func TestHandle_ConcurrentUpdateIsReturned(t *testing.T) {
+	h, repo := newHandler(t, &fakeGateway{})
+	repo.err = &domain.ErrConcurrentUpdate{ID: repo.payment.ID}
+
+	// e.g. a webhook completed the payment while it was being charged
+	_, err := h.Handle(context.Background(), completepayment.Command{PaymentID: repo.payment.ID})
+	var concurrent *domain.ErrConcurrentUpdate
+	if !errors.As(err, &concurrent) {
+		t.Errorf("expected ErrConcurrentUpdate, got %v", err)
+	}
+}
+
+func TestApplyTransaction(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    string
+		wantState domain.PaymentStatus
+	}{
+		{"succeeded", gateway.StatusSucceeded, domain.PaymentStatusCompleted},
+		{"pending", gateway.StatusPending, domain.PaymentStatusProcessing},
+		{"authorized", gateway.StatusAuthorized, domain.PaymentStatusProcessing},
+		{"requires action", gateway.StatusRequiresAction, domain.PaymentStatusRequiresAction},
+		{"declined", gateway.StatusFailed, domain.PaymentStatusFailed},
+		{"canceled", gateway.StatusCanceled, domain.PaymentStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "eur")
+			tx := &gateway.Transaction{ID: "pi_123", Status: tt.status}
+			if err := completepayment.ApplyTransaction(p, tx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.Status != tt.wantState {
+				t.Errorf("expected %v, got %v", tt.wantState, p.Status)
+			}
+		})
+	}
+}
+
+func TestApplyTransaction_ConfirmedAfterAction(t *testing.T) {
+	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "eur")
+	tx := &gateway.Transaction{ID: "pi_123", Status: gateway.StatusRequiresAction}
+	if err := completepayment.ApplyTransaction(p, tx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Applying the same state again is a no-op
+	if err := completepayment.ApplyTransaction(p, tx); err != nil {
+		t.Fatalf("unexpected error re-applying transaction: %v", err)
+	}
+
+	tx.Status = gateway.StatusSucceeded
+	if err := completepayment.ApplyTransaction(p, tx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Status != domain.PaymentStatusCompleted || p.TransactionID != "pi_123" {
+		t.Errorf("expected Completed with transaction pi_123, got %v / %v", p.Status, p.TransactionID)
+	}
+}
+
+func TestApplyTransaction_UnknownStatus(t *testing.T) {
+	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "eur")
+	if err := completepayment.ApplyTransaction(p, &gateway.Transaction{ID: "x", Status: "mystery"}); err == nil {
+		t.Fatal("expected an error for an unknown transaction status")
+	}
+}
+
+func TestApplyTransaction_FailureCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   gateway.Transaction
+		want domain.FailureCode
+	}{
+		{"insufficient funds", gateway.Transaction{Status: gateway.StatusFailed, FailureCode: "insufficient_funds", FailureReason: "Your card has insufficient funds."}, domain.FailureInsufficientFunds},
+		{"unknown decline", gateway.Transaction{Status: gateway.StatusFailed, FailureCode: "do_not_try_again"}, domain.FailureCardDeclined},
+		{"canceled", gateway.Transaction{Status: gateway.StatusCanceled}, domain.FailureCancelled},
+		{"abandoned", gateway.Transaction{Status: gateway.StatusCanceled, FailureCode: "abandoned"}, domain.FailureTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "eur")
+			tx := tt.tx
+			tx.ID = "pi_123"
+			if err := completepayment.ApplyTransaction(p, &tx); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if p.FailureCode != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, p.FailureCode)
+			}
+			if p.FailureDetail == "" {
+				t.Error("expected the provider's detail to be kept")
+			}
+		})
+	}
+}
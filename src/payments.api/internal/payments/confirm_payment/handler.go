@@ -0,0 +1,193 @@
+package confirmpayment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway"
+	completepayment "github.com/smart-health/payments-api/internal/payments/complete_payment"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+)
+
+// ErrNotPaymentOwner is returned when a user acts on another user's payment.
+var ErrNotPaymentOwner = errors.New("payment belongs to another user")
+
+// ErrNoActionRequired is returned when a client secret is requested for a
+// payment that is not waiting for the patient.
+var ErrNoActionRequired = errors.New("payment does not require action")
+
+// ---------------------------------------------------------------------------
+// Command / Query
+// ---------------------------------------------------------------------------
+
+// Command is sent by the frontend after it has confirmed the PaymentIntent
+// with Stripe.js (including any 3-D Secure challenge).
+type Command struct {
+	PaymentID uuid.UUID
+	UserID    string // authenticated caller; must own the payment
+}
+
+// SyncCommand is sent by the service itself when a provider reports a change
+// outside the frontend, e.g. a bank transfer was received. It has no owner check.
+type SyncCommand struct {
+	PaymentID uuid.UUID
+}
+
+// ClientSecretQuery returns the client secret (or payment instructions) of a
+// payment that requires action. Used when the payment was created from an
+// event, with no caller to return it to.
+type ClientSecretQuery struct {
+	PaymentID uuid.UUID
+	UserID    string // authenticated caller; must own the payment
+}
+
+// Result is the payment state after re-fetching its provider transaction.
+type Result struct {
+	PaymentID     string
+	Status        string
+	TransactionID string
+	ClientSecret  string `json:",omitempty"`
+	Instructions  string `json:",omitempty"`
+}
+
+// ---------------------------------------------------------------------------
+// Handler
+// ---------------------------------------------------------------------------
+
+// Handler handles the ConfirmPaymentCommand, SyncCommand and ClientSecretQuery.
+//
+// Flow (confirm):
+//  1. Load Payment aggregate and check the caller owns it.
+//  2. Re-fetch the transaction from the provider – the frontend's word is not trusted.
+//  3. Transition the aggregate to match it (completepayment.ApplyTransaction).
+//  4. Persist if the status changed (outbox, coupon settlement, invoice).
+type Handler struct {
+	repo     infrastructure.PaymentRepository
+	gateways gateway.Registry
+	logger   *slog.Logger
+}
+
+// NewHandler creates a new ConfirmPaymentHandler.
+func NewHandler(repo infrastructure.PaymentRepository, gateways gateway.Registry, logger *slog.Logger) *Handler {
+	return &Handler{repo: repo, gateways: gateways, logger: logger}
+}
+
+// Handle processes the command.
+func (h *Handler) Handle(ctx context.Context, cmd Command) (*Result, error) {
+	payment, err := h.load(ctx, cmd.PaymentID, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return h.sync(ctx, payment)
+}
+
+// HandleSync processes the system command.
+func (h *Handler) HandleSync(ctx context.Context, cmd SyncCommand) (*Result, error) {
+	payment, err := h.repo.FindByID(ctx, cmd.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("find payment: %w", err)
+	}
+	if payment == nil {
+		return nil, &domain.ErrPaymentNotFound{ID: cmd.PaymentID}
+	}
+	return h.sync(ctx, payment)
+}
+
+// HandleClientSecret processes the query.
+func (h *Handler) HandleClientSecret(ctx context.Context, q ClientSecretQuery) (*Result, error) {
+	payment, err := h.load(ctx, q.PaymentID, q.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Status != domain.PaymentStatusRequiresAction {
+		return nil, ErrNoActionRequired
+	}
+
+	tx, err := h.fetch(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+	return toResult(payment, tx), nil
+}
+
+// sync applies the provider's view of the transaction to the payment.
+func (h *Handler) sync(ctx context.Context, payment *domain.Payment) (*Result, error) {
+	switch payment.Status {
+	case domain.PaymentStatusCompleted, domain.PaymentStatusFailed:
+		// Already settled – confirming again is a no-op
+		return toResult(payment, nil), nil
+	case domain.PaymentStatusRequiresAction, domain.PaymentStatusProcessing:
+	default:
+		return nil, &domain.ErrInvalidTransition{
+			From:    payment.Status,
+			Allowed: []domain.PaymentStatus{domain.PaymentStatusRequiresAction, domain.PaymentStatusProcessing},
+		}
+	}
+
+	tx, err := h.fetch(ctx, payment)
+	if err != nil {
+		return nil, err
+	}
+
+	before := payment.Status
+	if err := completepayment.ApplyTransaction(payment, tx); err != nil {
+		return nil, err
+	}
+
+	if payment.Status != before {
+		if err := h.repo.Update(ctx, payment); err != nil {
+			return nil, fmt.Errorf("update payment: %w", err)
+		}
+		h.logger.Info("payment confirmed",
+			"paymentId", payment.ID,
+			"provider", payment.Provider,
+			"transactionStatus", tx.Status,
+			"from", before,
+			"to", payment.Status)
+	}
+	return toResult(payment, tx), nil
+}
+
+func (h *Handler) fetch(ctx context.Context, payment *domain.Payment) (*gateway.Transaction, error) {
+	provider, err := h.gateways.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := provider.Status(ctx, payment.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("fetch transaction: %w", err)
+	}
+	return tx, nil
+}
+
+func (h *Handler) load(ctx context.Context, paymentID uuid.UUID, userID string) (*domain.Payment, error) {
+	payment, err := h.repo.FindByID(ctx, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("find payment: %w", err)
+	}
+	if payment == nil {
+		return nil, &domain.ErrPaymentNotFound{ID: paymentID}
+	}
+	if payment.UserID != userID {
+		return nil, ErrNotPaymentOwner
+	}
+	return payment, nil
+}
+
+// toResult includes the next action only while the payment waits for the patient.
+func toResult(payment *domain.Payment, tx *gateway.Transaction) *Result {
+	result := &Result{
+		PaymentID:     payment.ID.String(),
+		Status:        payment.Status.String(),
+		TransactionID: payment.TransactionID,
+	}
+	if tx != nil && payment.Status == domain.PaymentStatusRequiresAction {
+		result.ClientSecret = tx.ClientSecret
+		result.Instructions = tx.Instructions
+	}
+	return result
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/coupons"
+	"github.com/smart-health/payments-api/internal/gateway"
 	completepayment "github.com/smart-health/payments-api/internal/payments/complete_payment"
 	"github.com/smart-health/payments-api/internal/payments/domain"
 	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+	"github.com/smart-health/payments-api/internal/pricing"
 	"github.com/smart-health/payments-api/internal/shared"
+	"github.com/smart-health/payments-api/internal/tax"
 )
 
 // ---------------------------------------------------------------------------
@@ -18,38 +23,95 @@ import (
 
 // Command carries the data needed to create a new payment.
 // Triggered by consuming the AppointmentSlotReservedEvent from the message broker.
+//
+// Amount is optional: when zero, the consultation is priced from the fee
+// schedules for DoctorID, Specialty and VisitType; otherwise it is verified
+// against them. LineItems bill additional services (lab tests, medicines) on
+// top of the consultation, or on their own when there is no consultation fee.
 type Command struct {
 	AppointmentID uuid.UUID
 	UserID        string
 	Amount        float64
 	Currency      string
+	DoctorID      string
+	Specialty     string
+	VisitType     string
+	CouponCode    string // optional discount code
+	ClinicID      string // billing clinic; selects the invoice number series
+
+	// Tax inputs: clinic location and service category (both optional).
+	Jurisdiction    string
+	ServiceCategory string
+
+	LineItems []LineItem
+}
+
+// LineItem is an additional service billed on the payment.
+type LineItem struct {
+	Code        string
+	Description string
+	Category    string // tax service category; defaults to the command's ServiceCategory
+	Quantity    int
+	UnitPrice   float64
 }
 
+// ConsultationCode is the line item code of the fee-schedule-priced consultation.
+const ConsultationCode = "CONSULTATION"
+
 // Result is returned after successfully creating (or idempotently finding) a payment.
+// ClientSecret is set when the patient must confirm the payment in the frontend,
+// Instructions when the patient must act elsewhere (e.g. send a bank transfer).
 type Result struct {
-	PaymentID string
-	Status    string
+	PaymentID    string
+	Status       string
+	ClientSecret string `json:",omitempty"`
+	Instructions string `json:",omitempty"`
 }
 
 // ---------------------------------------------------------------------------
 // Validation
 // ---------------------------------------------------------------------------
 
-// Validate checks that the command fields satisfy business rules.
+// Validate checks that the command fields satisfy business rules and
+// reports every violated rule as a *shared.ValidationError.
 func (c Command) Validate() error {
+	var errs shared.ValidationError
 	if c.AppointmentID == uuid.Nil {
-		return fmt.Errorf("appointmentId is required")
+		errs.Add("appointmentId", "required", "appointmentId is required")
 	}
 	if c.UserID == "" {
-		return fmt.Errorf("userId is required")
+		errs.Add("userId", "required", "userId is required")
 	}
-	if c.Amount <= 0 {
-		return fmt.Errorf("amount must be greater than zero")
+	if c.Amount < 0 || (c.Amount == 0 && !c.priceable() && len(c.LineItems) == 0) {
+		errs.Add("amount", "gt", "amount must be greater than 0")
+	}
+	for i, item := range c.LineItems {
+		field := fmt.Sprintf("lineItems[%d]", i)
+		if item.Code == "" {
+			errs.Add(field+".code", "required", field+".code is required")
+		}
+		if item.Quantity <= 0 {
+			errs.Add(field+".quantity", "gt", field+".quantity must be greater than 0")
+		}
+		if item.UnitPrice <= 0 {
+			errs.Add(field+".unitPrice", "gt", field+".unitPrice must be greater than 0")
+		}
 	}
 	if len(c.Currency) != 3 {
-		return fmt.Errorf("currency must be a 3-letter ISO code")
+		errs.Add("currency", "len", "currency must be a 3-letter ISO code")
 	}
-	return nil
+	return errs.OrNil()
+}
+
+// priceable reports whether the command identifies the visit well enough to
+// price it from the fee schedules.
+func (c Command) priceable() bool {
+	return c.DoctorID != "" || c.Specialty != "" || c.VisitType != ""
+}
+
+// billsConsultation reports whether the payment includes a consultation fee.
+func (c Command) billsConsultation() bool {
+	return c.Amount > 0 || c.priceable()
 }
 
 // ---------------------------------------------------------------------------
@@ -60,19 +122,30 @@ func (c Command) Validate() error {
 //
 // Flow:
 //  1. Validate command.
-//  2. Idempotency check – return existing payment if one already exists for this appointment.
-//  3. Create Payment aggregate.
-//  4. Persist payment (and PaymentCreatedEvent to outbox).
-//  5. Dispatch CompletePaymentCommand to process the Stripe charge.
+//  2. Idempotency check – return existing payment if one already exists for
+//     this appointment; charge it again if it is still Pending.
+//  3. Price the consultation and each line item from the fee schedules (or
+//     verify the supplied amounts against them).
+//  4. Create Payment aggregate from the line items and route it to a payment
+//     provider by currency, country and clinic.
+//  5. Apply the coupon discount, if any.
+//  6. Calculate taxes per line item on the discounted price.
+//  7. Persist payment (PaymentCreatedEvent to outbox, coupon redemption reserved).
+//  8. Dispatch CompletePaymentCommand to charge the payment with its provider;
+//     an outage leaves it Pending, any other error is returned.
 type Handler struct {
-	repo     infrastructure.PaymentRepository
-	mediator *shared.Mediator
-	logger   *slog.Logger
+	repo      infrastructure.PaymentRepository
+	pricing   pricing.Service
+	coupons   coupons.Service
+	tax       tax.Service
+	providers gateway.Selector
+	mediator  *shared.Mediator
+	logger    *slog.Logger
 }
 
 // NewHandler creates a new CreatePaymentHandler.
-func NewHandler(repo infrastructure.PaymentRepository, mediator *shared.Mediator, logger *slog.Logger) *Handler {
-	return &Handler{repo: repo, mediator: mediator, logger: logger}
+func NewHandler(repo infrastructure.PaymentRepository, pricing pricing.Service, coupons coupons.Service, tax tax.Service, providers gateway.Selector, mediator *shared.Mediator, logger *slog.Logger) *Handler {
+	return &Handler{repo: repo, pricing: pricing, coupons: coupons, tax: tax, providers: providers, mediator: mediator, logger: logger}
 }
 
 // Handle processes the command and returns the result.
@@ -88,17 +161,117 @@ func (h *Handler) Handle(ctx context.Context, cmd Command) (*Result, error) {
 		return nil, fmt.Errorf("check existing payment: %w", err)
 	}
 	if existing != nil {
+		if existing.Status == domain.PaymentStatusPending {
+			// An earlier charge hit a provider outage – retry it
+			h.logger.Info("payment exists but was not charged yet, retrying",
+				"appointmentId", cmd.AppointmentID,
+				"paymentId", existing.ID)
+			return h.charge(ctx, existing)
+		}
 		h.logger.Info("payment already exists for appointment, skipping",
 			"appointmentId", cmd.AppointmentID,
 			"paymentId", existing.ID)
 		return &Result{PaymentID: existing.ID.String(), Status: existing.Status.String()}, nil
 	}
 
-	// Create new Payment aggregate
-	payment, err := domain.NewPayment(cmd.AppointmentID, cmd.UserID, cmd.Amount, cmd.Currency)
+	// Price the consultation – the fee schedule, not the event, decides what is charged
+	now := time.Now().UTC()
+	var feeScheduleID uuid.UUID
+	var items []domain.LineItem
+	if cmd.billsConsultation() {
+		quote, err := h.pricing.Resolve(ctx, pricing.Key{
+			DoctorID:  cmd.DoctorID,
+			Specialty: cmd.Specialty,
+			VisitType: cmd.VisitType,
+			Currency:  cmd.Currency,
+		}, cmd.Amount, now)
+		if err != nil {
+			return nil, fmt.Errorf("price payment: %w", err)
+		}
+		feeScheduleID = quote.FeeScheduleID
+		items = append(items, domain.LineItem{
+			Code:        ConsultationCode,
+			Description: consultationDescription(cmd),
+			Category:    cmd.ServiceCategory,
+			Quantity:    1,
+			UnitPrice:   quote.Amount,
+		})
+	}
+	// Line items are priced the same way, by their code: the supplied unit
+	// price is only charged if it agrees with the item's fee schedule
+	for _, item := range cmd.LineItems {
+		quote, err := h.pricing.Resolve(ctx, pricing.Key{
+			DoctorID:  cmd.DoctorID,
+			Specialty: cmd.Specialty,
+			VisitType: cmd.VisitType,
+			Currency:  cmd.Currency,
+			ItemCode:  item.Code,
+		}, item.UnitPrice, now)
+		if err != nil {
+			return nil, fmt.Errorf("price line item %s: %w", item.Code, err)
+		}
+		category := item.Category
+		if category == "" {
+			category = cmd.ServiceCategory
+		}
+		items = append(items, domain.LineItem{
+			Code:        item.Code,
+			Description: item.Description,
+			Category:    category,
+			Quantity:    item.Quantity,
+			UnitPrice:   quote.Amount,
+		})
+	}
+
+	// Create new Payment aggregate – the subtotal is derived from the line items
+	payment, err := domain.NewPaymentWithLineItems(cmd.AppointmentID, cmd.UserID, items, cmd.Currency)
 	if err != nil {
 		return nil, fmt.Errorf("create payment aggregate: %w", err)
 	}
+	if cmd.ClinicID != "" {
+		if err := payment.AssignClinic(cmd.ClinicID); err != nil {
+			return nil, fmt.Errorf("assign clinic: %w", err)
+		}
+	}
+
+	provider, err := h.providers.Select(ctx, gateway.Criteria{
+		Currency: payment.Currency,
+		Country:  gateway.CountryOf(cmd.Jurisdiction),
+		ClinicID: cmd.ClinicID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("route payment: %w", err)
+	}
+	if err := payment.AssignProvider(provider); err != nil {
+		return nil, fmt.Errorf("assign provider: %w", err)
+	}
+
+	if cmd.CouponCode != "" {
+		discount, err := h.coupons.Apply(ctx, cmd.CouponCode, cmd.UserID, payment.Subtotal, payment.Currency, now)
+		if err != nil {
+			return nil, fmt.Errorf("apply coupon: %w", err)
+		}
+		if err := payment.ApplyDiscount(*discount); err != nil {
+			return nil, fmt.Errorf("apply coupon: %w", err)
+		}
+	}
+
+	// Taxes are computed per line item on its discounted price, so lab tests and
+	// medicines can be taxed differently from the consultation
+	var taxLines []domain.TaxLine
+	for _, item := range payment.LineItems {
+		calc, err := h.tax.Calculate(ctx, cmd.Jurisdiction, item.Category, item.Net())
+		if err != nil {
+			return nil, fmt.Errorf("calculate tax: %w", err)
+		}
+		for _, l := range calc.Lines {
+			l.ItemCode = item.Code
+			taxLines = append(taxLines, l)
+		}
+	}
+	if err := payment.ApplyTax(taxLines); err != nil {
+		return nil, fmt.Errorf("apply tax: %w", err)
+	}
 
 	// Persist (includes domain events in outbox)
 	if err := h.repo.Create(ctx, payment); err != nil {
@@ -107,22 +280,55 @@ func (h *Handler) Handle(ctx context.Context, cmd Command) (*Result, error) {
 
 	h.logger.Info("payment created",
 		"paymentId", payment.ID,
-		"appointmentId", payment.AppointmentID)
+		"appointmentId", payment.AppointmentID,
+		"amount", payment.Amount,
+		"lineItems", len(payment.LineItems),
+		"provider", payment.Provider,
+		"feeScheduleId", feeScheduleID)
+
+	return h.charge(ctx, payment)
+}
 
-	// Dispatch CompletePaymentCommand – processes the Stripe charge
+// charge dispatches CompletePaymentCommand, which charges the payment with its
+// provider. When the provider is temporarily unavailable (or its circuit
+// breaker open) the payment stays Pending for the pending-payment retrier;
+// the event is not redelivered, which would only hammer the provider. Any
+// other error – the provider refused the request, the payment could not be
+// saved – is returned: the payment exists and stays Pending, but the caller
+// is told it was not charged.
+func (h *Handler) charge(ctx context.Context, payment *domain.Payment) (*Result, error) {
 	completeCmd := completepayment.Command{PaymentID: payment.ID}
 	resp, err := h.mediator.Send(ctx, completeCmd)
 	if err != nil {
-		// Log but don't fail – the outbox will eventually retry
-		h.logger.Error("complete payment command failed",
+		if !gateway.IsTemporary(err) {
+			return nil, fmt.Errorf("complete payment %s: %w", payment.ID, err)
+		}
+		h.logger.Warn("payment provider unavailable, payment will be retried later",
 			"paymentId", payment.ID,
 			"error", err)
 		return &Result{PaymentID: payment.ID.String(), Status: payment.Status.String()}, nil
 	}
 
 	if result, ok := resp.(*completepayment.Result); ok {
-		return &Result{PaymentID: result.PaymentID, Status: result.Status}, nil
+		return &Result{
+			PaymentID:    result.PaymentID,
+			Status:       result.Status,
+			ClientSecret: result.ClientSecret,
+			Instructions: result.Instructions,
+		}, nil
 	}
 
 	return &Result{PaymentID: payment.ID.String(), Status: payment.Status.String()}, nil
 }
+
+// consultationDescription describes the consultation line, e.g. "Consultation – cardiology (follow_up)".
+func consultationDescription(cmd Command) string {
+	description := "Consultation"
+	if cmd.Specialty != "" {
+		description += " – " + cmd.Specialty
+	}
+	if cmd.VisitType != "" {
+		description += " (" + cmd.VisitType + ")"
+	}
+	return description
+}
@@ -1,10 +1,23 @@
 package createpayment_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway"
+	completepayment "github.com/smart-health/payments-api/internal/payments/complete_payment"
 	createpayment "github.com/smart-health/payments-api/internal/payments/create_payment"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+	"github.com/smart-health/payments-api/internal/pricing"
+	"github.com/smart-health/payments-api/internal/shared"
+	"github.com/smart-health/payments-api/internal/tax"
 )
 
 func TestCommand_Validate_Valid(t *testing.T) {
@@ -53,3 +66,205 @@ func TestCommand_Validate_InvalidCurrency(t *testing.T) {
 		t.Error("expected validation error for invalid currency")
 	}
 }
+
+func TestCommand_Validate_ZeroAmountPricedFromSchedule(t *testing.T) {
+	cmd := createpayment.Command{
+		AppointmentID: uuid.New(),
+		UserID:        "user-1",
+		Currency:      "usd",
+		DoctorID:      "doctor-1",
+	}
+	if err := cmd.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestCommand_Validate_NegativeAmount(t *testing.T) {
+	cmd := createpayment.Command{
+		AppointmentID: uuid.New(),
+		UserID:        "user-1",
+		Amount:        -10,
+		Currency:      "usd",
+		DoctorID:      "doctor-1",
+	}
+	if err := cmd.Validate(); err == nil {
+		t.Error("expected validation error for negative amount")
+	}
+}
+
+func TestCommand_Validate_LineItemsOnly(t *testing.T) {
+	cmd := createpayment.Command{
+		AppointmentID: uuid.New(),
+		UserID:        "user-1",
+		Currency:      "usd",
+		LineItems: []createpayment.LineItem{
+			{Code: "CBC", Description: "Complete blood count", Quantity: 1, UnitPrice: 25},
+		},
+	}
+	if err := cmd.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+}
+
+func TestCommand_Validate_InvalidLineItem(t *testing.T) {
+	cmd := createpayment.Command{
+		AppointmentID: uuid.New(),
+		UserID:        "user-1",
+		Amount:        100.0,
+		Currency:      "usd",
+		LineItems:     []createpayment.LineItem{{Code: "CBC", Quantity: 0, UnitPrice: 25}},
+	}
+	if err := cmd.Validate(); err == nil {
+		t.Error("expected validation error for zero quantity")
+	}
+}
+
+func TestCommand_Validate_ReportsEveryField(t *testing.T) {
+	cmd := createpayment.Command{
+		UserID:    "user-1",
+		Currency:  "euro",
+		LineItems: []createpayment.LineItem{{Code: "CBC", Quantity: 1, UnitPrice: 25}, {Quantity: 2}},
+	}
+	var invalid *shared.ValidationError
+	if err := cmd.Validate(); !errors.As(err, &invalid) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+
+	want := []string{"appointmentId", "lineItems[1].code", "lineItems[1].unitPrice", "currency"}
+	if len(invalid.Fields) != len(want) {
+		t.Fatalf("expected fields %v, got %+v", want, invalid.Fields)
+	}
+	for i, field := range want {
+		if invalid.Fields[i].Field != field {
+			t.Errorf("expected field %d to be %s, got %s", i, field, invalid.Fields[i].Field)
+		}
+	}
+}
+
+type fakeRepo struct {
+	infrastructure.PaymentRepository
+	payment *domain.Payment
+}
+
+func (r *fakeRepo) Create(_ context.Context, p *domain.Payment) error {
+	r.payment = p
+	return nil
+}
+
+func (r *fakeRepo) FindByAppointmentID(_ context.Context, appointmentID uuid.UUID) (*domain.Payment, error) {
+	if r.payment == nil || r.payment.AppointmentID != appointmentID {
+		return nil, nil
+	}
+	return r.payment, nil
+}
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "provider unavailable" }
+func (temporaryError) Temporary() bool { return true }
+
+// chargeFailing creates a handler whose pending payment fails to be charged with err.
+func chargeFailing(err error) (*createpayment.Handler, createpayment.Command) {
+	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "eur")
+	mediator := shared.NewMediator()
+	mediator.Register(fmt.Sprintf("%T", completepayment.Command{}), func(context.Context, shared.Request) (shared.Response, error) {
+		return nil, err
+	})
+	h := createpayment.NewHandler(&fakeRepo{payment: p}, nil, nil, nil, nil, mediator, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return h, createpayment.Command{AppointmentID: p.AppointmentID, UserID: "user-1", Amount: 100, Currency: "eur"}
+}
+
+func TestHandle_OutageLeavesPaymentPending(t *testing.T) {
+	h, cmd := chargeFailing(temporaryError{})
+
+	result, err := h.Handle(context.Background(), cmd)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != domain.PaymentStatusPending.String() {
+		t.Errorf("status = %s, want Pending", result.Status)
+	}
+}
+
+func TestHandle_RefusedChargeIsReturned(t *testing.T) {
+	refusal := errors.New("invalid request (parameter_invalid)")
+	h, cmd := chargeFailing(refusal)
+
+	if _, err := h.Handle(context.Background(), cmd); !errors.Is(err, refusal) {
+		t.Errorf("expected the provider error, got %v", err)
+	}
+}
+
+type fakeSchedules struct {
+	pricing.Repository
+	schedules []*pricing.FeeSchedule
+}
+
+func (r *fakeSchedules) FindApplicable(context.Context, pricing.Key, time.Time) ([]*pricing.FeeSchedule, error) {
+	return r.schedules, nil
+}
+
+type fakeSelector struct{}
+
+func (fakeSelector) Select(context.Context, gateway.Criteria) (string, error) { return "stripe", nil }
+
+type fakeTax struct{}
+
+func (fakeTax) Calculate(context.Context, string, string, float64) (*tax.Calculation, error) {
+	return &tax.Calculation{}, nil
+}
+
+// lineItemHandler creates a handler whose fee schedules price a blood test at 25 usd.
+func lineItemHandler(t *testing.T) (*createpayment.Handler, *fakeRepo) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	bloodTest, err := pricing.NewFeeSchedule(pricing.Key{Currency: "usd", ItemCode: "LAB-CBC"}, 25, time.Now().Add(-time.Hour), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prices := pricing.NewPricingService(&fakeSchedules{schedules: []*pricing.FeeSchedule{bloodTest}}, 0.01, true, logger)
+
+	mediator := shared.NewMediator()
+	mediator.Register(fmt.Sprintf("%T", completepayment.Command{}), func(_ context.Context, req shared.Request) (shared.Response, error) {
+		id := req.(completepayment.Command).PaymentID
+		return &completepayment.Result{PaymentID: id.String(), Status: domain.PaymentStatusCompleted.String()}, nil
+	})
+	repo := &fakeRepo{}
+	return createpayment.NewHandler(repo, prices, nil, fakeTax{}, fakeSelector{}, mediator, logger), repo
+}
+
+func lineItemCommand(items ...createpayment.LineItem) createpayment.Command {
+	return createpayment.Command{AppointmentID: uuid.New(), UserID: "user-1", Currency: "usd", LineItems: items}
+}
+
+func TestHandle_LineItemsArePricedByTheirSchedule(t *testing.T) {
+	h, repo := lineItemHandler(t)
+
+	cmd := lineItemCommand(createpayment.LineItem{Code: "LAB-CBC", Quantity: 2, UnitPrice: 25.01})
+	if _, err := h.Handle(context.Background(), cmd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if item := repo.payment.LineItems[0]; item.UnitPrice != 25 || repo.payment.Amount != 50 {
+		t.Errorf("unit price %.2f, amount %.2f, want the scheduled 25 and 50", item.UnitPrice, repo.payment.Amount)
+	}
+}
+
+func TestHandle_RejectsUnscheduledLineItemPrices(t *testing.T) {
+	h, repo := lineItemHandler(t)
+
+	var mismatch *pricing.ErrAmountMismatch
+	cmd := lineItemCommand(createpayment.LineItem{Code: "LAB-CBC", Quantity: 1, UnitPrice: 1})
+	if _, err := h.Handle(context.Background(), cmd); !errors.As(err, &mismatch) {
+		t.Errorf("underpriced item: expected ErrAmountMismatch, got %v", err)
+	}
+
+	var noSchedule *pricing.ErrNoFeeSchedule
+	cmd = lineItemCommand(createpayment.LineItem{Code: "MED-IBU", Quantity: 1, UnitPrice: 5})
+	if _, err := h.Handle(context.Background(), cmd); !errors.As(err, &noSchedule) || noSchedule.Key.ItemCode != "MED-IBU" {
+		t.Errorf("unknown item: expected ErrNoFeeSchedule for MED-IBU, got %v", err)
+	}
+
+	if repo.payment != nil {
+		t.Errorf("payment created for rejected line items: %+v", repo.payment)
+	}
+}
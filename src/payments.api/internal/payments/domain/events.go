@@ -19,12 +19,18 @@ type PaymentCreatedEvent struct {
 type PaymentCompletedEvent struct {
 	PaymentID     uuid.UUID
 	AppointmentID uuid.UUID
-	TransactionID string // Stripe PaymentIntent ID
+	Provider      string
+	TransactionID string // the provider's reference, e.g. the Stripe PaymentIntent ID
+	Amount        float64
+	Currency      string
+	TaxAmount     float64
+	TaxLines      []TaxLine
 }
 
-// PaymentFailedEvent is raised when a payment fails.
+// PaymentFailedEvent is raised when a payment fails. It carries the code
+// only; the payment's FailureDetail is internal.
 type PaymentFailedEvent struct {
 	PaymentID     uuid.UUID
 	AppointmentID uuid.UUID
-	Reason        string
+	Code          FailureCode
 }
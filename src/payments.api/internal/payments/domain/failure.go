@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Failure codes
+//
+// Architectural Decision: Why a payment failed is recorded twice. The
+// FailureCode is a closed set, safe to publish to other services and to map
+// to a message for the patient; FailureDetail is the provider's own wording
+// (error dumps, decline texts) and stays internal – it is persisted for
+// support staff but never published or shown to patients. Patient messages
+// are looked up by code and language, so wording changes need no migration.
+// ---------------------------------------------------------------------------
+
+// FailureCode classifies why a payment failed.
+type FailureCode string
+
+const (
+	FailureCardDeclined        FailureCode = "card_declined"
+	FailureInsufficientFunds   FailureCode = "insufficient_funds"
+	FailureExpiredCard         FailureCode = "expired_card"
+	FailureFraudSuspected      FailureCode = "fraud_suspected"
+	FailureProviderUnavailable FailureCode = "provider_unavailable"
+	FailureTimeout             FailureCode = "timeout"
+	FailureCancelled           FailureCode = "cancelled"
+)
+
+// providerFailureCodes maps the reasons providers report (Stripe's decline
+// codes and cancellation reasons, which other adapters reuse) to failure codes.
+var providerFailureCodes = map[string]FailureCode{
+	"card_declined":          FailureCardDeclined,
+	"generic_decline":        FailureCardDeclined,
+	"do_not_honor":           FailureCardDeclined,
+	"insufficient_funds":     FailureInsufficientFunds,
+	"card_velocity_exceeded": FailureInsufficientFunds,
+	"expired_card":           FailureExpiredCard,
+	"fraudulent":             FailureFraudSuspected,
+	"lost_card":              FailureFraudSuspected,
+	"stolen_card":            FailureFraudSuspected,
+	"pickup_card":            FailureFraudSuspected,
+	"merchant_blacklist":     FailureFraudSuspected,
+	"security_violation":     FailureFraudSuspected,
+	"processing_error":       FailureProviderUnavailable,
+	"issuer_not_available":   FailureProviderUnavailable,
+	"try_again_later":        FailureProviderUnavailable,
+	"abandoned":              FailureTimeout, // the patient never completed the payment
+	"automatic":              FailureTimeout, // the authorization expired before capture
+	"requested_by_customer":  FailureCancelled,
+}
+
+// FailureCodeFromProvider maps a provider's failure reason to a failure code,
+// returning fallback for reasons without a more specific code.
+func FailureCodeFromProvider(reason string, fallback FailureCode) FailureCode {
+	if code, ok := providerFailureCodes[strings.ToLower(reason)]; ok {
+		return code
+	}
+	return fallback
+}
+
+// DefaultLanguage is used when none of the caller's languages is supported.
+const DefaultLanguage = "en"
+
+// failureMessages are the patient-facing messages per language. The empty
+// code covers payments that failed before failure codes were recorded.
+// Fraud suspicions are deliberately worded like an ordinary decline.
+var failureMessages = map[string]map[FailureCode]string{
+	"en": {
+		FailureCardDeclined:        "Your card was declined. Please use a different card or contact your bank.",
+		FailureInsufficientFunds:   "Your card has insufficient funds. Please use a different card.",
+		FailureExpiredCard:         "Your card has expired. Please use a different card.",
+		FailureFraudSuspected:      "Your payment could not be processed. Please use a different card or contact your bank.",
+		FailureProviderUnavailable: "The payment service is temporarily unavailable. Please try again later.",
+		FailureTimeout:             "The payment was not completed in time. Please try again.",
+		FailureCancelled:           "The payment was cancelled.",
+		"":                         "The payment could not be completed.",
+	},
+	"de": {
+		FailureCardDeclined:        "Ihre Karte wurde abgelehnt. Bitte verwenden Sie eine andere Karte oder wenden Sie sich an Ihre Bank.",
+		FailureInsufficientFunds:   "Ihre Karte ist nicht ausreichend gedeckt. Bitte verwenden Sie eine andere Karte.",
+		FailureExpiredCard:         "Ihre Karte ist abgelaufen. Bitte verwenden Sie eine andere Karte.",
+		FailureFraudSuspected:      "Ihre Zahlung konnte nicht verarbeitet werden. Bitte verwenden Sie eine andere Karte oder wenden Sie sich an Ihre Bank.",
+		FailureProviderUnavailable: "Der Zahlungsdienst ist vorübergehend nicht erreichbar. Bitte versuchen Sie es später erneut.",
+		FailureTimeout:             "Die Zahlung wurde nicht rechtzeitig abgeschlossen. Bitte versuchen Sie es erneut.",
+		FailureCancelled:           "Die Zahlung wurde storniert.",
+		"":                         "Die Zahlung konnte nicht abgeschlossen werden.",
+	},
+	"fr": {
+		FailureCardDeclined:        "Votre carte a été refusée. Veuillez utiliser une autre carte ou contacter votre banque.",
+		FailureInsufficientFunds:   "Le solde de votre carte est insuffisant. Veuillez utiliser une autre carte.",
+		FailureExpiredCard:         "Votre carte a expiré. Veuillez utiliser une autre carte.",
+		FailureFraudSuspected:      "Votre paiement n'a pas pu être traité. Veuillez utiliser une autre carte ou contacter votre banque.",
+		FailureProviderUnavailable: "Le service de paiement est temporairement indisponible. Veuillez réessayer plus tard.",
+		FailureTimeout:             "Le paiement n'a pas été finalisé à temps. Veuillez réessayer.",
+		FailureCancelled:           "Le paiement a été annulé.",
+		"":                         "Le paiement n'a pas pu être finalisé.",
+	},
+	"es": {
+		FailureCardDeclined:        "Su tarjeta fue rechazada. Utilice otra tarjeta o póngase en contacto con su banco.",
+		FailureInsufficientFunds:   "Su tarjeta no tiene fondos suficientes. Utilice otra tarjeta.",
+		FailureExpiredCard:         "Su tarjeta ha caducado. Utilice otra tarjeta.",
+		FailureFraudSuspected:      "No se pudo procesar su pago. Utilice otra tarjeta o póngase en contacto con su banco.",
+		FailureProviderUnavailable: "El servicio de pago no está disponible temporalmente. Vuelva a intentarlo más tarde.",
+		FailureTimeout:             "El pago no se completó a tiempo. Vuelva a intentarlo.",
+		FailureCancelled:           "El pago fue cancelado.",
+		"":                         "No se pudo completar el pago.",
+	},
+}
+
+// Message returns the patient-facing message for the code in a supported
+// language (see NegotiateLanguage), falling back to English.
+func (c FailureCode) Message(language string) string {
+	messages, ok := failureMessages[language]
+	if !ok {
+		messages = failureMessages[DefaultLanguage]
+	}
+	if m, ok := messages[c]; ok {
+		return m
+	}
+	return messages[""]
+}
+
+// NegotiateLanguage picks the supported language the caller prefers most from
+// an Accept-Language header value ("de-CH, de;q=0.9, en;q=0.8"). Regional
+// variants match their base language.
+func NegotiateLanguage(acceptLanguage string) string {
+	type candidate struct {
+		language string
+		q        float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := failureMessages[base]; ok && q > 0 {
+			candidates = append(candidates, candidate{base, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].language
+}
@@ -0,0 +1,73 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+func TestFailureCodeFromProvider(t *testing.T) {
+	cases := []struct {
+		reason   string
+		fallback domain.FailureCode
+		want     domain.FailureCode
+	}{
+		{"insufficient_funds", domain.FailureCardDeclined, domain.FailureInsufficientFunds},
+		{"expired_card", domain.FailureCardDeclined, domain.FailureExpiredCard},
+		{"stolen_card", domain.FailureCardDeclined, domain.FailureFraudSuspected},
+		{"issuer_not_available", domain.FailureCardDeclined, domain.FailureProviderUnavailable},
+		{"abandoned", domain.FailureCancelled, domain.FailureTimeout},
+		{"some_new_decline_code", domain.FailureCardDeclined, domain.FailureCardDeclined},
+		{"", domain.FailureCancelled, domain.FailureCancelled},
+	}
+	for _, tc := range cases {
+		if got := domain.FailureCodeFromProvider(tc.reason, tc.fallback); got != tc.want {
+			t.Errorf("FailureCodeFromProvider(%q): expected %q, got %q", tc.reason, tc.want, got)
+		}
+	}
+}
+
+func TestFailureCode_Message(t *testing.T) {
+	codes := []domain.FailureCode{
+		domain.FailureCardDeclined, domain.FailureInsufficientFunds, domain.FailureExpiredCard,
+		domain.FailureFraudSuspected, domain.FailureProviderUnavailable, domain.FailureTimeout,
+		domain.FailureCancelled,
+	}
+	for _, language := range []string{"en", "de", "fr", "es"} {
+		generic := domain.FailureCode("").Message(language)
+		for _, code := range codes {
+			if m := code.Message(language); m == "" || m == generic {
+				t.Errorf("expected a specific %s message for %q, got %q", language, code, m)
+			}
+		}
+	}
+
+	if got, want := domain.FailureExpiredCard.Message("de"), "Ihre Karte ist abgelaufen. Bitte verwenden Sie eine andere Karte."; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+	// Unsupported languages fall back to English, unknown codes to the generic message
+	if got := domain.FailureCancelled.Message("ja"); got != domain.FailureCancelled.Message("en") {
+		t.Errorf("expected the English message, got %q", got)
+	}
+	if got := domain.FailureCode("bogus").Message("en"); got != "The payment could not be completed." {
+		t.Errorf("expected the generic message, got %q", got)
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                          "en",
+		"de":                        "de",
+		"de-CH, de;q=0.9, en;q=0.8": "de",
+		"ja, fr;q=0.5, en;q=0.4":    "fr",
+		"en;q=0.2, es-MX;q=0.7":     "es",
+		"it, *;q=0.1":               "en",
+		"fr;q=0, de;q=0.1":          "de",
+		"es;q=abc, FR-ca":           "fr",
+	}
+	for header, want := range cases {
+		if got := domain.NegotiateLanguage(header); got != want {
+			t.Errorf("NegotiateLanguage(%q): expected %q, got %q", header, want, got)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +15,19 @@ var ErrInvalidAmount = errors.New("payment amount must be greater than zero")
 // ErrInvalidCurrency is returned when currency is empty.
 var ErrInvalidCurrency = errors.New("currency must be specified")
 
+// ErrDiscountExceedsAmount is returned when a discount would leave nothing to charge.
+var ErrDiscountExceedsAmount = errors.New("discount must be less than the payment amount")
+
+// ErrDiscountAlreadyApplied is returned when a second discount is applied to a payment.
+var ErrDiscountAlreadyApplied = errors.New("a discount has already been applied to this payment")
+
+// ErrTaxAlreadyApplied is returned when taxes are calculated twice, or a
+// discount is applied after taxes (taxes are computed on the discounted price).
+var ErrTaxAlreadyApplied = errors.New("taxes have already been applied to this payment")
+
+// ErrInvalidLineItem is returned when a line item violates its invariants.
+var ErrInvalidLineItem = errors.New("invalid line item")
+
 // ErrInvalidTransition is returned when a state transition is not allowed.
 type ErrInvalidTransition struct {
 	From    PaymentStatus
@@ -25,11 +39,16 @@ func (e *ErrInvalidTransition) Error() string {
 }
 
 // ErrPaymentNotFound is returned when a payment record cannot be found.
+// Either ID or AppointmentID is set, depending on how the payment was looked up.
 type ErrPaymentNotFound struct {
-	ID uuid.UUID
+	ID            uuid.UUID
+	AppointmentID uuid.UUID
 }
 
 func (e *ErrPaymentNotFound) Error() string {
+	if e.ID == uuid.Nil && e.AppointmentID != uuid.Nil {
+		return fmt.Sprintf("payment for appointment %s was not found", e.AppointmentID)
+	}
 	return fmt.Sprintf("payment %s was not found", e.ID)
 }
 
@@ -42,6 +61,17 @@ func (e *ErrDuplicatePayment) Error() string {
 	return fmt.Sprintf("payment for appointment %s already exists", e.AppointmentID)
 }
 
+// ErrConcurrentUpdate is returned when a payment is saved over changes made
+// since it was loaded, e.g. by a webhook while it was being charged. Loading
+// it again and redoing the work applies both.
+type ErrConcurrentUpdate struct {
+	ID uuid.UUID
+}
+
+func (e *ErrConcurrentUpdate) Error() string {
+	return fmt.Sprintf("payment %s was changed concurrently", e.ID)
+}
+
 // -----------------------------------------------------------------------
 // Payment aggregate root
 //
@@ -52,18 +82,72 @@ func (e *ErrDuplicatePayment) Error() string {
 // to populate the outbox table in the same database transaction.
 // -----------------------------------------------------------------------
 
+// Discount is a price reduction applied to a payment by a coupon.
+type Discount struct {
+	CouponID uuid.UUID
+	Code     string
+	Kind     string  // "percentage" or "fixed"
+	Value    float64 // percentage or fixed amount, as defined by the coupon
+	Amount   float64 // amount deducted from the subtotal
+}
+
+// LineItem is one billed service on a payment (consultation, lab test,
+// prescribed medicine). Discount, Tax and Total are derived by the aggregate.
+type LineItem struct {
+	Code        string // unique within the payment, e.g. "CONSULTATION" or a lab test code
+	Description string
+	Category    string // service category, used to select tax rates
+	Quantity    int
+	UnitPrice   float64
+	Discount    float64 // share of the coupon discount allocated to this line
+	Tax         float64 // taxes on this line, inclusive and exclusive
+	Total       float64 // amount charged for this line: Gross - Discount + exclusive taxes
+}
+
+// Gross returns the line price before discounts and taxes.
+func (l LineItem) Gross() float64 {
+	return roundCents(float64(l.Quantity) * l.UnitPrice)
+}
+
+// Net returns the line price after its share of the discount, before taxes.
+func (l LineItem) Net() float64 {
+	return roundCents(l.Gross() - l.Discount)
+}
+
+// TaxLine is one itemized tax charged on a payment.
+type TaxLine struct {
+	Name          string
+	Jurisdiction  string
+	Category      string
+	ItemCode      string // line item the tax was computed on; empty for payment-level taxes
+	Percent       float64
+	Inclusive     bool    // true: already contained in the price, not added on top
+	TaxableAmount float64 // net base the tax was computed on
+	Amount        float64
+}
+
 // Payment is the aggregate root for the payments bounded context.
 type Payment struct {
-	ID                    uuid.UUID
-	AppointmentID         uuid.UUID
-	UserID                string
-	Amount                float64
-	Currency              string
-	Status                PaymentStatus
-	StripePaymentIntentID string
-	FailureReason         string
-	CreatedAt             time.Time
-	UpdatedAt             *time.Time
+	ID            uuid.UUID
+	AppointmentID uuid.UUID
+	UserID        string
+	ClinicID      string     // clinic the visit is billed by; selects the invoice number series
+	LineItems     []LineItem // empty for single-amount payments
+	Subtotal      float64    // price before discounts; the sum of line item gross prices
+	Discount      *Discount  // nil when no coupon was applied
+	TaxLines      []TaxLine
+	TaxAmount     float64 // sum of all tax lines, inclusive and exclusive
+	Amount        float64 // amount charged: Subtotal - Discount.Amount + exclusive taxes
+	Currency      string
+	Status        PaymentStatus
+	Provider      string      // payment gateway charging the payment, e.g. "stripe"
+	TransactionID string      // the provider's reference, e.g. the Stripe PaymentIntent ID
+	FailureCode   FailureCode // why the payment failed; empty unless Failed
+	FailureDetail string      // the provider's explanation; internal, never shown to patients
+	ChargeAttempt int         // charges the provider refused so far (see RecordRefusedCharge)
+	Version       int         // changes saved so far; a save expects the version it loaded
+	CreatedAt     time.Time
+	UpdatedAt     *time.Time
 
 	// Domain events collected during this operation (not persisted directly).
 	// Processed by the repository layer to populate the outbox.
@@ -83,6 +167,7 @@ func NewPayment(appointmentID uuid.UUID, userID string, amount float64, currency
 		ID:            uuid.New(),
 		AppointmentID: appointmentID,
 		UserID:        userID,
+		Subtotal:      amount,
 		Amount:        amount,
 		Currency:      lowercase(currency),
 		Status:        PaymentStatusPending,
@@ -100,12 +185,163 @@ func NewPayment(appointmentID uuid.UUID, userID string, amount float64, currency
 	return p, nil
 }
 
-// MarkProcessing transitions the payment to Processing after Stripe intent is created.
-func (p *Payment) MarkProcessing(stripeIntentID string) error {
+// NewPaymentWithLineItems creates a payment whose subtotal is derived from
+// its line items. Item codes must be unique so taxes can be attributed to them.
+func NewPaymentWithLineItems(appointmentID uuid.UUID, userID string, items []LineItem, currency string) (*Payment, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: at least one line item is required", ErrInvalidLineItem)
+	}
+
+	lines := make([]LineItem, len(items))
+	seen := make(map[string]bool, len(items))
+	var subtotal float64
+	for i, item := range items {
+		if item.Code == "" {
+			return nil, fmt.Errorf("%w: code is required", ErrInvalidLineItem)
+		}
+		if seen[item.Code] {
+			return nil, fmt.Errorf("%w: duplicate code %q", ErrInvalidLineItem, item.Code)
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity of %q must be greater than zero", ErrInvalidLineItem, item.Code)
+		}
+		if item.UnitPrice <= 0 {
+			return nil, fmt.Errorf("%w: unit price of %q must be greater than zero", ErrInvalidLineItem, item.Code)
+		}
+		seen[item.Code] = true
+
+		item.Discount, item.Tax = 0, 0
+		item.Total = item.Gross()
+		lines[i] = item
+		subtotal += item.Total
+	}
+
+	p, err := NewPayment(appointmentID, userID, roundCents(subtotal), currency)
+	if err != nil {
+		return nil, err
+	}
+	p.LineItems = lines
+	return p, nil
+}
+
+// AssignClinic records the clinic billing the visit. Only pending payments
+// can be reassigned, since completion issues the invoice in the clinic's series.
+func (p *Payment) AssignClinic(clinicID string) error {
+	if err := p.ensureStatus(PaymentStatusPending); err != nil {
+		return err
+	}
+	p.ClinicID = clinicID
+	return nil
+}
+
+// AssignProvider records the payment gateway that charges the payment.
+// Only pending payments can be routed: once charged, the transaction lives
+// at the provider.
+func (p *Payment) AssignProvider(provider string) error {
+	if err := p.ensureStatus(PaymentStatusPending); err != nil {
+		return err
+	}
+	p.Provider = provider
+	return nil
+}
+
+// ApplyDiscount deducts a coupon discount from the subtotal. On payments with
+// line items the discount is allocated to the lines pro rata to their gross
+// price, with the rounding residual on the last line.
+// Only pending payments can be discounted, and only once.
+func (p *Payment) ApplyDiscount(d Discount) error {
 	if err := p.ensureStatus(PaymentStatusPending); err != nil {
 		return err
 	}
-	p.StripePaymentIntentID = stripeIntentID
+	if p.Discount != nil {
+		return ErrDiscountAlreadyApplied
+	}
+	if p.TaxLines != nil {
+		return ErrTaxAlreadyApplied
+	}
+	if d.Amount <= 0 || d.Amount >= p.Subtotal {
+		return ErrDiscountExceedsAmount
+	}
+	p.Discount = &d
+	p.Amount = roundCents(p.Subtotal - d.Amount)
+
+	remaining := d.Amount
+	for i := range p.LineItems {
+		line := &p.LineItems[i]
+		if i == len(p.LineItems)-1 {
+			line.Discount = roundCents(remaining)
+		} else {
+			line.Discount = roundCents(d.Amount * line.Gross() / p.Subtotal)
+			remaining -= line.Discount
+		}
+		line.Total = line.Net()
+	}
+	return nil
+}
+
+// ApplyTax records the tax lines for the (discounted) price and adds
+// exclusive taxes to the amount charged. Tax lines carrying an ItemCode are
+// also added to that line item's Tax and Total.
+// Only pending payments can be taxed, and only once.
+func (p *Payment) ApplyTax(lines []TaxLine) error {
+	if err := p.ensureStatus(PaymentStatusPending); err != nil {
+		return err
+	}
+	if p.TaxLines != nil {
+		return ErrTaxAlreadyApplied
+	}
+
+	items := make(map[string]*LineItem, len(p.LineItems))
+	for i := range p.LineItems {
+		items[p.LineItems[i].Code] = &p.LineItems[i]
+	}
+	for _, l := range lines {
+		if l.ItemCode != "" && items[l.ItemCode] == nil {
+			return fmt.Errorf("%w: tax line %q references unknown item %q", ErrInvalidLineItem, l.Name, l.ItemCode)
+		}
+	}
+
+	p.TaxLines = make([]TaxLine, len(lines))
+	copy(p.TaxLines, lines)
+
+	var total, exclusive float64
+	for _, l := range lines {
+		total += l.Amount
+		if !l.Inclusive {
+			exclusive += l.Amount
+		}
+		if item := items[l.ItemCode]; item != nil {
+			item.Tax = roundCents(item.Tax + l.Amount)
+			if !l.Inclusive {
+				item.Total = roundCents(item.Total + l.Amount)
+			}
+		}
+	}
+	p.TaxAmount = roundCents(total)
+	p.Amount = roundCents(p.Amount + exclusive)
+	return nil
+}
+
+// MarkRequiresAction records a provider transaction that waits for the patient
+// (3-D Secure, entering a card, or sending a bank transfer) before it can be charged.
+func (p *Payment) MarkRequiresAction(transactionID string) error {
+	if err := p.ensureStatus(PaymentStatusPending); err != nil {
+		return err
+	}
+	p.TransactionID = transactionID
+	p.Status = PaymentStatusRequiresAction
+	now := time.Now().UTC()
+	p.UpdatedAt = &now
+	return nil
+}
+
+// MarkProcessing transitions the payment to Processing after the provider
+// transaction is created, or after the patient has completed a required action.
+func (p *Payment) MarkProcessing(transactionID string) error {
+	if err := p.ensureStatus(PaymentStatusPending, PaymentStatusRequiresAction); err != nil {
+		return err
+	}
+	p.TransactionID = transactionID
 	p.Status = PaymentStatusProcessing
 	now := time.Now().UTC()
 	p.UpdatedAt = &now
@@ -125,17 +361,51 @@ func (p *Payment) MarkCompleted() error {
 	p.addEvent(PaymentCompletedEvent{
 		PaymentID:     p.ID,
 		AppointmentID: p.AppointmentID,
-		TransactionID: p.StripePaymentIntentID,
+		Provider:      p.Provider,
+		TransactionID: p.TransactionID,
+		Amount:        p.Amount,
+		Currency:      p.Currency,
+		TaxAmount:     p.TaxAmount,
+		TaxLines:      p.TaxLines,
 	})
 	return nil
 }
 
+// MaxRefusedCharges is how many refused charges a payment may collect before
+// it is left for review: the pending-payment retrier stops charging it, as
+// a request refused this often is wrong in a way another attempt won't fix.
+const MaxRefusedCharges = 3
+
+// RecordRefusedCharge counts a charge the provider refused with an answer
+// that sending the same request again would only repeat, such as an invalid
+// request. Providers deduplicate charges by payment and attempt, so the
+// next charge is a new request instead of a replay of the refused one;
+// errors without an answer (timeouts, outages) keep the attempt, and a
+// charge the provider did make is found again when retried.
+func (p *Payment) RecordRefusedCharge() error {
+	if err := p.ensureStatus(PaymentStatusPending); err != nil {
+		return err
+	}
+	p.ChargeAttempt++
+	now := time.Now().UTC()
+	p.UpdatedAt = &now
+	return nil
+}
+
+// NeedsReview reports whether the payment is Pending with as many refused
+// charges as MaxRefusedCharges allows; it is no longer retried automatically.
+func (p *Payment) NeedsReview() bool {
+	return p.Status == PaymentStatusPending && p.ChargeAttempt >= MaxRefusedCharges
+}
+
 // MarkFailed transitions the payment to Failed and raises PaymentFailedEvent.
-func (p *Payment) MarkFailed(reason string) error {
-	if err := p.ensureStatus(PaymentStatusProcessing, PaymentStatusPending); err != nil {
+// The detail is kept for support staff and is not published.
+func (p *Payment) MarkFailed(code FailureCode, detail string) error {
+	if err := p.ensureStatus(PaymentStatusProcessing, PaymentStatusPending, PaymentStatusRequiresAction); err != nil {
 		return err
 	}
-	p.FailureReason = reason
+	p.FailureCode = code
+	p.FailureDetail = detail
 	p.Status = PaymentStatusFailed
 	now := time.Now().UTC()
 	p.UpdatedAt = &now
@@ -143,7 +413,7 @@ func (p *Payment) MarkFailed(reason string) error {
 	p.addEvent(PaymentFailedEvent{
 		PaymentID:     p.ID,
 		AppointmentID: p.AppointmentID,
-		Reason:        reason,
+		Code:          code,
 	})
 	return nil
 }
@@ -173,6 +443,10 @@ func (p *Payment) ensureStatus(allowed ...PaymentStatus) error {
 	return &ErrInvalidTransition{From: p.Status, Allowed: allowed}
 }
 
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
 func lowercase(s string) string {
 	result := make([]byte, len(s))
 	for i := range s {
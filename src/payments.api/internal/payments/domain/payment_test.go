@@ -1,6 +1,7 @@
 package domain_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/google/uuid"
@@ -49,8 +50,8 @@ func TestPayment_MarkProcessing(t *testing.T) {
 	if p.Status != domain.PaymentStatusProcessing {
 		t.Errorf("expected Processing, got %v", p.Status)
 	}
-	if p.StripePaymentIntentID != "pi_test_123" {
-		t.Errorf("expected pi_test_123, got %v", p.StripePaymentIntentID)
+	if p.TransactionID != "pi_test_123" {
+		t.Errorf("expected pi_test_123, got %v", p.TransactionID)
 	}
 }
 
@@ -75,20 +76,26 @@ func TestPayment_MarkCompleted(t *testing.T) {
 
 func TestPayment_MarkFailed(t *testing.T) {
 	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "usd")
-	if err := p.MarkFailed("card declined"); err != nil {
+	if err := p.MarkFailed(domain.FailureInsufficientFunds, "stripe error: Your card has insufficient funds."); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 	if p.Status != domain.PaymentStatusFailed {
 		t.Errorf("expected Failed, got %v", p.Status)
 	}
-	if p.FailureReason != "card declined" {
-		t.Errorf("unexpected failure reason: %v", p.FailureReason)
+	if p.FailureCode != domain.FailureInsufficientFunds || p.FailureDetail == "" {
+		t.Errorf("unexpected failure: %q / %q", p.FailureCode, p.FailureDetail)
+	}
+	// The event carries the code, never the provider's detail
+	events := p.DomainEvents()
+	failed, ok := events[len(events)-1].(domain.PaymentFailedEvent)
+	if !ok || failed.Code != domain.FailureInsufficientFunds {
+		t.Errorf("expected PaymentFailedEvent with insufficient_funds, got %+v", events[len(events)-1])
 	}
 }
 
 func TestPayment_InvalidTransition(t *testing.T) {
 	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "usd")
-	_ = p.MarkFailed("error")
+	_ = p.MarkFailed(domain.FailureProviderUnavailable, "error")
 	if err := p.MarkCompleted(); err == nil {
 		t.Fatal("expected error transitioning from Failed to Completed")
 	}
@@ -101,3 +108,132 @@ func TestPayment_ClearDomainEvents(t *testing.T) {
 		t.Errorf("expected 0 domain events after clear, got %d", len(p.DomainEvents()))
 	}
 }
+
+func TestPayment_ApplyDiscount(t *testing.T) {
+	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "usd")
+	if err := p.ApplyDiscount(domain.Discount{Code: "WELCOME10", Kind: "percentage", Value: 10, Amount: 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Amount != 90 || p.Subtotal != 100 {
+		t.Errorf("expected amount 90 of subtotal 100, got %v of %v", p.Amount, p.Subtotal)
+	}
+	if err := p.ApplyDiscount(domain.Discount{Code: "AGAIN", Amount: 5}); err == nil {
+		t.Fatal("expected error applying a second discount")
+	}
+}
+
+func TestPayment_ApplyDiscount_ExceedsAmount(t *testing.T) {
+	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "usd")
+	if err := p.ApplyDiscount(domain.Discount{Code: "FREE", Amount: 100}); err == nil {
+		t.Fatal("expected error for discount covering the whole amount")
+	}
+}
+
+func TestPayment_ApplyTax_AddsExclusiveTaxOnly(t *testing.T) {
+	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "usd")
+	err := p.ApplyTax([]domain.TaxLine{
+		{Name: "VAT", Percent: 10, Inclusive: true, Amount: 9.09},
+		{Name: "City tax", Percent: 2, Inclusive: false, Amount: 1.82},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Amount != 101.82 {
+		t.Errorf("expected amount 101.82, got %v", p.Amount)
+	}
+	if p.TaxAmount != 10.91 {
+		t.Errorf("expected tax amount 10.91, got %v", p.TaxAmount)
+	}
+	if err := p.ApplyDiscount(domain.Discount{Code: "LATE", Amount: 5}); err == nil {
+		t.Error("expected error applying a discount after tax")
+	}
+}
+
+func TestPayment_LineItems_DeriveTotals(t *testing.T) {
+	p, err := domain.NewPaymentWithLineItems(uuid.New(), "user-1", []domain.LineItem{
+		{Code: "CONSULTATION", Description: "Consultation", Quantity: 1, UnitPrice: 60},
+		{Code: "CBC", Description: "Complete blood count", Quantity: 2, UnitPrice: 20},
+	}, "usd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Subtotal != 100 || p.Amount != 100 {
+		t.Fatalf("expected subtotal and amount 100, got %v and %v", p.Subtotal, p.Amount)
+	}
+
+	if err := p.ApplyDiscount(domain.Discount{Code: "TEN", Amount: 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.LineItems[0].Discount != 6 || p.LineItems[1].Discount != 4 {
+		t.Errorf("expected discount allocated 6/4, got %v/%v", p.LineItems[0].Discount, p.LineItems[1].Discount)
+	}
+
+	err = p.ApplyTax([]domain.TaxLine{
+		{Name: "Lab tax", ItemCode: "CBC", Percent: 5, Amount: 1.8},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.LineItems[1].Total != 37.8 || p.LineItems[0].Total != 54 {
+		t.Errorf("unexpected line totals: %v, %v", p.LineItems[0].Total, p.LineItems[1].Total)
+	}
+	if p.Amount != 91.8 {
+		t.Errorf("expected amount 91.8, got %v", p.Amount)
+	}
+}
+
+func TestPayment_LineItems_RejectDuplicateCodes(t *testing.T) {
+	_, err := domain.NewPaymentWithLineItems(uuid.New(), "user-1", []domain.LineItem{
+		{Code: "CBC", Quantity: 1, UnitPrice: 20},
+		{Code: "CBC", Quantity: 1, UnitPrice: 20},
+	}, "usd")
+	if !errors.Is(err, domain.ErrInvalidLineItem) {
+		t.Fatalf("expected ErrInvalidLineItem, got %v", err)
+	}
+}
+
+func TestPayment_RequiresAction_ThenCompleted(t *testing.T) {
+	p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "eur")
+	if err := p.MarkRequiresAction("pi_3ds"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Status != domain.PaymentStatusRequiresAction || p.Status.String() != "RequiresAction" {
+		t.Errorf("expected RequiresAction, got %v", p.Status)
+	}
+	if err := p.MarkCompleted(); err == nil {
+		t.Error("expected error completing before the intent is confirmed")
+	}
+	if err := p.MarkProcessing("pi_3ds"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := p.MarkCompleted(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPaymentStatus_StageGrowsWithEveryTransition(t *testing.T) {
+	transitions := map[string]func(p *domain.Payment) error{
+		"requires action": func(p *domain.Payment) error { return p.MarkRequiresAction("pi_1") },
+		"processing":      func(p *domain.Payment) error { return p.MarkProcessing("pi_1") },
+		"completed":       func(p *domain.Payment) error { return p.MarkCompleted() },
+		"failed":          func(p *domain.Payment) error { return p.MarkFailed(domain.FailureCardDeclined, "") },
+	}
+	for _, from := range []domain.PaymentStatus{
+		domain.PaymentStatusPending, domain.PaymentStatusRequiresAction, domain.PaymentStatusProcessing,
+		domain.PaymentStatusCompleted, domain.PaymentStatusFailed,
+	} {
+		for name, transition := range transitions {
+			p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "usd")
+			p.Status = from
+			if err := transition(p); err != nil {
+				continue // not allowed from this status
+			}
+			if p.Status.Stage() <= from.Stage() {
+				t.Errorf("%s → %s (%s): stage %d → %d, want it to grow", from, p.Status, name, from.Stage(), p.Status.Stage())
+			}
+			if from.IsFinal() {
+				t.Errorf("%s is final but allows %s", from, name)
+			}
+		}
+	}
+}
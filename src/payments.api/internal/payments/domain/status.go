@@ -1,14 +1,19 @@
 package domain
 
+import "strings"
+
 // PaymentStatus represents the lifecycle states of a payment.
-// Transitions: Pending → Processing → Completed | Failed
+// Transitions: Pending → [RequiresAction →] Processing → Completed | Failed
+// RequiresAction means the patient must confirm the payment in the frontend
+// (e.g. 3-D Secure) before Stripe can charge it.
 type PaymentStatus int
 
 const (
-	PaymentStatusPending    PaymentStatus = 0
-	PaymentStatusProcessing PaymentStatus = 1
-	PaymentStatusCompleted  PaymentStatus = 2
-	PaymentStatusFailed     PaymentStatus = 3
+	PaymentStatusPending        PaymentStatus = 0
+	PaymentStatusProcessing     PaymentStatus = 1
+	PaymentStatusCompleted      PaymentStatus = 2
+	PaymentStatusFailed         PaymentStatus = 3
+	PaymentStatusRequiresAction PaymentStatus = 4
 )
 
 // String returns the string representation of the status.
@@ -22,7 +27,48 @@ func (s PaymentStatus) String() string {
 		return "Completed"
 	case PaymentStatusFailed:
 		return "Failed"
+	case PaymentStatusRequiresAction:
+		return "RequiresAction"
 	default:
 		return "Unknown"
 	}
 }
+
+// Stage orders the statuses along the lifecycle: every transition moves a
+// payment to a later stage, so the stage tells how far a payment has come
+// (Pending 1, RequiresAction 2, Processing 3, Completed and Failed 4).
+func (s PaymentStatus) Stage() int {
+	switch s {
+	case PaymentStatusPending:
+		return 1
+	case PaymentStatusRequiresAction:
+		return 2
+	case PaymentStatusProcessing:
+		return 3
+	case PaymentStatusCompleted, PaymentStatusFailed:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// IsFinal reports whether the payment can no longer change.
+func (s PaymentStatus) IsFinal() bool {
+	return s == PaymentStatusCompleted || s == PaymentStatusFailed
+}
+
+// ParsePaymentStatus returns the status with the name, ignoring case.
+func ParsePaymentStatus(name string) (PaymentStatus, bool) {
+	for _, s := range []PaymentStatus{
+		PaymentStatusPending,
+		PaymentStatusProcessing,
+		PaymentStatusCompleted,
+		PaymentStatusFailed,
+		PaymentStatusRequiresAction,
+	} {
+		if strings.EqualFold(s.String(), name) {
+			return s, true
+		}
+	}
+	return 0, false
+}
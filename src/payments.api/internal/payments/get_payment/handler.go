@@ -8,29 +8,80 @@ import (
 	"github.com/google/uuid"
 	"github.com/smart-health/payments-api/internal/payments/domain"
 	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
 )
 
 // ---------------------------------------------------------------------------
 // Query
 // ---------------------------------------------------------------------------
 
-// Query is the read-side request for payment details.
+// Query is the read-side request for payment details. Language selects the
+// language of the failure message (see domain.NegotiateLanguage); empty means English.
 type Query struct {
 	PaymentID uuid.UUID
+	Language  string
+}
+
+// ByAppointmentQuery looks up the payment raised for an appointment.
+// Used by the Appointments saga, which knows the appointment but not the payment ID.
+type ByAppointmentQuery struct {
+	AppointmentID uuid.UUID
+	Language      string // as in Query
 }
 
 // Result is the read model returned to the caller.
 type Result struct {
-	PaymentID             string     `json:"paymentId"`
-	AppointmentID         string     `json:"appointmentId"`
-	UserID                string     `json:"userId"`
-	Amount                float64    `json:"amount"`
-	Currency              string     `json:"currency"`
-	Status                string     `json:"status"`
-	StripePaymentIntentID string     `json:"stripePaymentIntentId,omitempty"`
-	FailureReason         string     `json:"failureReason,omitempty"`
-	CreatedAt             time.Time  `json:"createdAt"`
-	UpdatedAt             *time.Time `json:"updatedAt,omitempty"`
+	PaymentID             string           `json:"paymentId"`
+	AppointmentID         string           `json:"appointmentId"`
+	UserID                string           `json:"userId"`
+	LineItems             []LineItemResult `json:"lineItems,omitempty"`
+	Subtotal              float64          `json:"subtotal"`
+	Discount              *DiscountResult  `json:"discount,omitempty"`
+	TaxAmount             float64          `json:"taxAmount"`
+	TaxLines              []TaxLineResult  `json:"taxLines,omitempty"`
+	Amount                float64          `json:"amount"`
+	Currency              string           `json:"currency"`
+	Status                string           `json:"status"`
+	Provider              string           `json:"provider"`
+	TransactionID         string           `json:"transactionId,omitempty"`
+	StripePaymentIntentID string           `json:"stripePaymentIntentId,omitempty"` // deprecated: use transactionId
+	FailureCode           string           `json:"failureCode,omitempty"`
+	FailureMessage        string           `json:"failureMessage,omitempty"` // patient-facing, localized
+	FailureReason         string           `json:"failureReason,omitempty"`  // deprecated: use failureMessage
+	CreatedAt             time.Time        `json:"createdAt"`
+	UpdatedAt             *time.Time       `json:"updatedAt,omitempty"`
+}
+
+// DiscountResult is the coupon discount breakdown of a payment.
+type DiscountResult struct {
+	Code   string  `json:"code"`
+	Kind   string  `json:"kind"`
+	Value  float64 `json:"value"`
+	Amount float64 `json:"amount"`
+}
+
+// LineItemResult is a billed service of a payment.
+type LineItemResult struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Category    string  `json:"category,omitempty"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unitPrice"`
+	Discount    float64 `json:"discount"`
+	Tax         float64 `json:"tax"`
+	Total       float64 `json:"total"`
+}
+
+// TaxLineResult is an itemized tax line of a payment.
+type TaxLineResult struct {
+	Name          string  `json:"name"`
+	Jurisdiction  string  `json:"jurisdiction"`
+	Category      string  `json:"category,omitempty"`
+	ItemCode      string  `json:"itemCode,omitempty"`
+	Percent       float64 `json:"percent"`
+	Inclusive     bool    `json:"inclusive"`
+	TaxableAmount float64 `json:"taxableAmount"`
+	Amount        float64 `json:"amount"`
 }
 
 // ---------------------------------------------------------------------------
@@ -57,16 +108,86 @@ func (h *Handler) Handle(ctx context.Context, q Query) (*Result, error) {
 		return nil, &domain.ErrPaymentNotFound{ID: q.PaymentID}
 	}
 
+	return toResult(payment, q.Language), nil
+}
+
+// HandleByAppointment processes the query and returns the payment read model.
+func (h *Handler) HandleByAppointment(ctx context.Context, q ByAppointmentQuery) (*Result, error) {
+	payment, err := h.repo.FindByAppointmentID(ctx, q.AppointmentID)
+	if err != nil {
+		return nil, fmt.Errorf("find payment by appointment: %w", err)
+	}
+	if payment == nil {
+		return nil, &domain.ErrPaymentNotFound{AppointmentID: q.AppointmentID}
+	}
+
+	return toResult(payment, q.Language), nil
+}
+
+// toResult builds the read model. The failure detail is internal and left out.
+func toResult(payment *domain.Payment, language string) *Result {
+	var discount *DiscountResult
+	if d := payment.Discount; d != nil {
+		discount = &DiscountResult{Code: d.Code, Kind: d.Kind, Value: d.Value, Amount: d.Amount}
+	}
+
+	var lineItems []LineItemResult
+	for _, item := range payment.LineItems {
+		lineItems = append(lineItems, LineItemResult{
+			Code:        item.Code,
+			Description: item.Description,
+			Category:    item.Category,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Discount:    item.Discount,
+			Tax:         item.Tax,
+			Total:       item.Total,
+		})
+	}
+
+	var taxLines []TaxLineResult
+	for _, l := range payment.TaxLines {
+		taxLines = append(taxLines, TaxLineResult{
+			Name:          l.Name,
+			Jurisdiction:  l.Jurisdiction,
+			Category:      l.Category,
+			ItemCode:      l.ItemCode,
+			Percent:       l.Percent,
+			Inclusive:     l.Inclusive,
+			TaxableAmount: l.TaxableAmount,
+			Amount:        l.Amount,
+		})
+	}
+
+	var failureMessage string
+	if payment.Status == domain.PaymentStatusFailed {
+		failureMessage = payment.FailureCode.Message(language)
+	}
+
+	var stripeIntentID string
+	if payment.Provider == stripeservice.ProviderName {
+		stripeIntentID = payment.TransactionID
+	}
+
 	return &Result{
 		PaymentID:             payment.ID.String(),
 		AppointmentID:         payment.AppointmentID.String(),
 		UserID:                payment.UserID,
+		LineItems:             lineItems,
+		Subtotal:              payment.Subtotal,
+		Discount:              discount,
+		TaxAmount:             payment.TaxAmount,
+		TaxLines:              taxLines,
 		Amount:                payment.Amount,
 		Currency:              payment.Currency,
 		Status:                payment.Status.String(),
-		StripePaymentIntentID: payment.StripePaymentIntentID,
-		FailureReason:         payment.FailureReason,
+		Provider:              payment.Provider,
+		TransactionID:         payment.TransactionID,
+		StripePaymentIntentID: stripeIntentID,
+		FailureCode:           string(payment.FailureCode),
+		FailureMessage:        failureMessage,
+		FailureReason:         failureMessage,
 		CreatedAt:             payment.CreatedAt,
 		UpdatedAt:             payment.UpdatedAt,
-	}, nil
+	}
 }
@@ -0,0 +1,44 @@
+package infrastructure
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+// PaymentSort orders a payment search. The value is the API's sort parameter:
+// a field name, descending when prefixed with "-".
+type PaymentSort string
+
+const (
+	SortCreatedDesc PaymentSort = "-createdAt" // newest first (default)
+	SortCreatedAsc  PaymentSort = "createdAt"
+	SortAmountDesc  PaymentSort = "-amount"
+	SortAmountAsc   PaymentSort = "amount"
+)
+
+// PaymentFilter selects payments for Search. Zero-valued fields match every payment.
+type PaymentFilter struct {
+	UserID        string
+	AppointmentID uuid.UUID
+	Statuses      []domain.PaymentStatus // any of
+	Currency      string
+	MinAmount     *float64 // inclusive
+	MaxAmount     *float64 // inclusive
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time // exclusive
+	TransactionID string     // provider reference, e.g. the Stripe PaymentIntent ID
+
+	Sort  PaymentSort
+	After *PaymentCursor // nil for the first page
+	Limit int
+}
+
+// PaymentCursor is the position after which the next page starts: the sort
+// key of the last payment returned, with its ID to break ties.
+type PaymentCursor struct {
+	CreatedAt time.Time
+	Amount    float64
+	ID        uuid.UUID
+}
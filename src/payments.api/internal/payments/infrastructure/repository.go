@@ -3,11 +3,14 @@ package infrastructure
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/smart-health/payments-api/internal/coupons"
+	"github.com/smart-health/payments-api/internal/invoicing"
 	"github.com/smart-health/payments-api/internal/outbox"
 	"github.com/smart-health/payments-api/internal/payments/domain"
 )
@@ -18,43 +21,141 @@ type PaymentRepository interface {
 	Create(ctx context.Context, payment *domain.Payment) error
 	FindByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error)
 	FindByAppointmentID(ctx context.Context, appointmentID uuid.UUID) (*domain.Payment, error)
+	// FindPending returns up to limit payments created before the time that are
+	// still Pending, i.e. not yet charged by their provider, oldest first,
+	// leaving out payments that need review.
+	FindPending(ctx context.Context, createdBefore time.Time, limit int) ([]*domain.Payment, error)
+	// Search returns the payments matching the filter in the filter's order,
+	// without their line items and tax lines.
+	Search(ctx context.Context, filter PaymentFilter) ([]*domain.Payment, error)
 	Update(ctx context.Context, payment *domain.Payment) error
 }
 
+// StatusListener is told about every payment the repository has saved,
+// once the transaction is committed – e.g. to push status changes to the
+// clients following the payment.
+type StatusListener interface {
+	PaymentSaved(payment *domain.Payment)
+}
+
 // PostgresPaymentRepository implements PaymentRepository using PostgreSQL.
 // It uses a pgxpool for connection pooling and handles transactions internally.
 type PostgresPaymentRepository struct {
-	pool       *pgxpool.Pool
-	outboxRepo outbox.Repository
+	pool        *pgxpool.Pool
+	outboxRepo  outbox.Repository
+	redemptions coupons.RedemptionStore
+	invoices    invoicing.Issuer
+	listener    StatusListener
 }
 
 // NewPostgresPaymentRepository creates a new PostgreSQL-backed payment repository.
-func NewPostgresPaymentRepository(pool *pgxpool.Pool, outboxRepo outbox.Repository) *PostgresPaymentRepository {
-	return &PostgresPaymentRepository{pool: pool, outboxRepo: outboxRepo}
+// The listener may be nil.
+func NewPostgresPaymentRepository(pool *pgxpool.Pool, outboxRepo outbox.Repository, redemptions coupons.RedemptionStore, invoices invoicing.Issuer, listener StatusListener) *PostgresPaymentRepository {
+	return &PostgresPaymentRepository{pool: pool, outboxRepo: outboxRepo, redemptions: redemptions, invoices: invoices, listener: listener}
 }
 
+// stripe_payment_intent_id predates pluggable providers and holds the
+// transaction reference of whichever provider charged the payment;
+// failure_reason predates failure codes and holds the internal FailureDetail.
+const paymentColumns = `id, appointment_id, user_id, COALESCE(clinic_id, ''), COALESCE(subtotal, amount), COALESCE(tax_amount, 0), amount, currency, status,
+	COALESCE(provider, 'stripe'), COALESCE(stripe_payment_intent_id, ''), COALESCE(failure_code, ''), COALESCE(failure_reason, ''), created_at, updated_at,
+	discount_coupon_id, COALESCE(discount_code, ''), COALESCE(discount_kind, ''),
+	COALESCE(discount_value, 0), COALESCE(discount_amount, 0), charge_attempt, version`
+
 // Create persists a new Payment aggregate in a transaction that also
-// writes any domain events to the outbox table (transactional outbox pattern).
+// writes any domain events to the outbox table (transactional outbox pattern)
+// and reserves the coupon redemption of a discounted payment.
 func (r *PostgresPaymentRepository) Create(ctx context.Context, payment *domain.Payment) error {
-	return r.withTransaction(ctx, func(tx pgx.Tx) error {
+	return r.save(ctx, payment, func(tx pgx.Tx) error {
+		var discount domain.Discount
+		if payment.Discount != nil {
+			discount = *payment.Discount
+		}
+
 		_, err := tx.Exec(ctx, `
-			INSERT INTO payments (id, appointment_id, user_id, amount, currency, status, stripe_payment_intent_id, failure_reason, created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			INSERT INTO payments (id, appointment_id, user_id, clinic_id, subtotal, tax_amount, amount, currency, status, provider, stripe_payment_intent_id, failure_code, failure_reason, created_at, updated_at,
+			                      discount_coupon_id, discount_code, discount_kind, discount_value, discount_amount)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)`,
 			payment.ID,
 			payment.AppointmentID,
 			payment.UserID,
+			nilIfEmpty(payment.ClinicID),
+			payment.Subtotal,
+			payment.TaxAmount,
 			payment.Amount,
 			payment.Currency,
 			int(payment.Status),
-			nilIfEmpty(payment.StripePaymentIntentID),
-			nilIfEmpty(payment.FailureReason),
+			nilIfEmpty(payment.Provider),
+			nilIfEmpty(payment.TransactionID),
+			nilIfEmpty(string(payment.FailureCode)),
+			nilIfEmpty(payment.FailureDetail),
 			payment.CreatedAt,
 			payment.UpdatedAt,
+			nilIfNilUUID(discount.CouponID),
+			nilIfEmpty(discount.Code),
+			nilIfEmpty(discount.Kind),
+			discount.Value,
+			discount.Amount,
 		)
 		if err != nil {
 			return fmt.Errorf("insert payment: %w", err)
 		}
 
+		for i, item := range payment.LineItems {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO payment_line_items (payment_id, position, code, description, category, quantity, unit_price, discount_amount, tax_amount, total)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+				payment.ID,
+				i,
+				item.Code,
+				item.Description,
+				nilIfEmpty(item.Category),
+				item.Quantity,
+				item.UnitPrice,
+				item.Discount,
+				item.Tax,
+				item.Total,
+			)
+			if err != nil {
+				return fmt.Errorf("insert line item: %w", err)
+			}
+		}
+
+		for i, line := range payment.TaxLines {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO payment_tax_lines (payment_id, position, name, jurisdiction, category, item_code, percent, inclusive, taxable_amount, amount)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+				payment.ID,
+				i,
+				line.Name,
+				line.Jurisdiction,
+				nilIfEmpty(line.Category),
+				nilIfEmpty(line.ItemCode),
+				line.Percent,
+				line.Inclusive,
+				line.TaxableAmount,
+				line.Amount,
+			)
+			if err != nil {
+				return fmt.Errorf("insert tax line: %w", err)
+			}
+		}
+
+		// Reserve the coupon use in the same transaction – limits are re-checked under lock
+		if payment.Discount != nil {
+			if err := r.redemptions.Reserve(ctx, tx, &coupons.Redemption{
+				ID:             uuid.New(),
+				CouponID:       discount.CouponID,
+				PaymentID:      payment.ID,
+				UserID:         payment.UserID,
+				DiscountAmount: discount.Amount,
+				Currency:       payment.Currency,
+				CreatedAt:      payment.CreatedAt,
+			}); err != nil {
+				return fmt.Errorf("reserve coupon redemption: %w", err)
+			}
+		}
+
 		// Write domain events to outbox in the same transaction
 		if err := r.outboxRepo.SaveEvents(ctx, tx, payment.DomainEvents()); err != nil {
 			return fmt.Errorf("save outbox events: %w", err)
@@ -67,47 +168,252 @@ func (r *PostgresPaymentRepository) Create(ctx context.Context, payment *domain.
 
 // FindByID retrieves a payment by its primary key.
 func (r *PostgresPaymentRepository) FindByID(ctx context.Context, id uuid.UUID) (*domain.Payment, error) {
-	row := r.pool.QueryRow(ctx, `
-		SELECT id, appointment_id, user_id, amount, currency, status,
-		       COALESCE(stripe_payment_intent_id, ''), COALESCE(failure_reason, ''), created_at, updated_at
-		FROM payments WHERE id = $1`, id)
-
-	return scanPayment(row)
+	return r.findOne(ctx, `SELECT `+paymentColumns+` FROM payments WHERE id = $1`, id)
 }
 
 // FindByAppointmentID retrieves a payment by its appointment ID (for idempotency checks).
 func (r *PostgresPaymentRepository) FindByAppointmentID(ctx context.Context, appointmentID uuid.UUID) (*domain.Payment, error) {
-	row := r.pool.QueryRow(ctx, `
-		SELECT id, appointment_id, user_id, amount, currency, status,
-		       COALESCE(stripe_payment_intent_id, ''), COALESCE(failure_reason, ''), created_at, updated_at
-		FROM payments WHERE appointment_id = $1`, appointmentID)
+	return r.findOne(ctx, `SELECT `+paymentColumns+` FROM payments WHERE appointment_id = $1`, appointmentID)
+}
+
+// FindPending retrieves Pending payments created before a time, oldest first.
+// Payments that need review (see Payment.NeedsReview) are left out.
+func (r *PostgresPaymentRepository) FindPending(ctx context.Context, createdBefore time.Time, limit int) ([]*domain.Payment, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id FROM payments
+		WHERE status = $1 AND created_at < $2 AND charge_attempt < $3
+		ORDER BY created_at
+		LIMIT $4`, int(domain.PaymentStatusPending), createdBefore, domain.MaxRefusedCharges, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query pending payments: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return nil, fmt.Errorf("scan pending payments: %w", err)
+	}
+
+	payments := make([]*domain.Payment, 0, len(ids))
+	for _, id := range ids {
+		p, err := r.FindByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			payments = append(payments, p)
+		}
+	}
+	return payments, nil
+}
+
+// Search retrieves a page of payments matching the filter. Pages are
+// delimited by the sort key and ID of the last payment of the previous page
+// (keyset pagination), so paging stays stable while payments are created.
+func (r *PostgresPaymentRepository) Search(ctx context.Context, filter PaymentFilter) ([]*domain.Payment, error) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
 
-	p, err := scanPayment(row)
+	if filter.UserID != "" {
+		where = append(where, "user_id = "+arg(filter.UserID))
+	}
+	if filter.AppointmentID != uuid.Nil {
+		where = append(where, "appointment_id = "+arg(filter.AppointmentID))
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]int, len(filter.Statuses))
+		for i, s := range filter.Statuses {
+			statuses[i] = int(s)
+		}
+		where = append(where, "status = ANY("+arg(statuses)+")")
+	}
+	if filter.Currency != "" {
+		where = append(where, "currency = "+arg(strings.ToLower(filter.Currency)))
+	}
+	if filter.MinAmount != nil {
+		where = append(where, "amount >= "+arg(*filter.MinAmount))
+	}
+	if filter.MaxAmount != nil {
+		where = append(where, "amount <= "+arg(*filter.MaxAmount))
+	}
+	if filter.CreatedFrom != nil {
+		where = append(where, "created_at >= "+arg(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		where = append(where, "created_at < "+arg(*filter.CreatedTo))
+	}
+	if filter.TransactionID != "" {
+		where = append(where, "stripe_payment_intent_id = "+arg(filter.TransactionID))
+	}
+
+	column, direction := "created_at", "DESC"
+	switch filter.Sort {
+	case SortCreatedAsc:
+		direction = "ASC"
+	case SortAmountDesc:
+		column = "amount"
+	case SortAmountAsc:
+		column, direction = "amount", "ASC"
+	}
+	if c := filter.After; c != nil {
+		op := "<"
+		if direction == "ASC" {
+			op = ">"
+		}
+		var key any = c.CreatedAt
+		if column == "amount" {
+			key = c.Amount
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s, %s)", column, op, arg(key), arg(c.ID)))
+	}
+
+	query := `SELECT ` + paymentColumns + ` FROM payments`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s LIMIT %[3]s", column, direction, arg(filter.Limit))
+
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
+		return nil, fmt.Errorf("query payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []*domain.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query payments: %w", err)
+	}
+	return payments, nil
+}
+
+// findOne scans a single payment and loads its child rows.
+func (r *PostgresPaymentRepository) findOne(ctx context.Context, query string, arg any) (*domain.Payment, error) {
+	p, err := scanPayment(r.pool.QueryRow(ctx, query, arg))
+	if err != nil || p == nil {
+		return p, err
+	}
+
+	if p.LineItems, err = r.loadLineItems(ctx, p.ID); err != nil {
+		return nil, err
+	}
+	if p.TaxLines, err = r.loadTaxLines(ctx, p.ID); err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
+func (r *PostgresPaymentRepository) loadLineItems(ctx context.Context, paymentID uuid.UUID) ([]domain.LineItem, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT code, description, COALESCE(category, ''), quantity, unit_price, discount_amount, tax_amount, total
+		FROM payment_line_items
+		WHERE payment_id = $1
+		ORDER BY position`, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("query line items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []domain.LineItem
+	for rows.Next() {
+		var item domain.LineItem
+		if err := rows.Scan(
+			&item.Code, &item.Description, &item.Category, &item.Quantity,
+			&item.UnitPrice, &item.Discount, &item.Tax, &item.Total,
+		); err != nil {
+			return nil, fmt.Errorf("scan line item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *PostgresPaymentRepository) loadTaxLines(ctx context.Context, paymentID uuid.UUID) ([]domain.TaxLine, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT name, jurisdiction, COALESCE(category, ''), COALESCE(item_code, ''), percent, inclusive, taxable_amount, amount
+		FROM payment_tax_lines
+		WHERE payment_id = $1
+		ORDER BY position`, paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("query tax lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []domain.TaxLine
+	for rows.Next() {
+		var l domain.TaxLine
+		if err := rows.Scan(
+			&l.Name, &l.Jurisdiction, &l.Category, &l.ItemCode, &l.Percent,
+			&l.Inclusive, &l.TaxableAmount, &l.Amount,
+		); err != nil {
+			return nil, fmt.Errorf("scan tax line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
 // Update persists state changes to an existing payment and writes domain events to outbox.
+// Completing a payment also settles its coupon redemption and issues its invoice.
+//
+// Payments are written by the pending-payment retrier, redelivered events,
+// provider webhooks and patients confirming at once. Update saves only over
+// the version the payment was loaded at and returns *domain.ErrConcurrentUpdate
+// otherwise, so a writer holding a stale payment never reverts a newer
+// status or publishes its events twice.
 func (r *PostgresPaymentRepository) Update(ctx context.Context, payment *domain.Payment) error {
-	return r.withTransaction(ctx, func(tx pgx.Tx) error {
-		_, err := tx.Exec(ctx, `
+	err := r.save(ctx, payment, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
 			UPDATE payments SET
 				status = $2,
 				stripe_payment_intent_id = $3,
-				failure_reason = $4,
-				updated_at = $5
-			WHERE id = $1`,
+				failure_code = $4,
+				failure_reason = $5,
+				charge_attempt = $6,
+				updated_at = $7,
+				version = version + 1
+			WHERE id = $1 AND version = $8`,
 			payment.ID,
 			int(payment.Status),
-			nilIfEmpty(payment.StripePaymentIntentID),
-			nilIfEmpty(payment.FailureReason),
+			nilIfEmpty(payment.TransactionID),
+			nilIfEmpty(string(payment.FailureCode)),
+			nilIfEmpty(payment.FailureDetail),
+			payment.ChargeAttempt,
 			payment.UpdatedAt,
+			payment.Version,
 		)
 		if err != nil {
 			return fmt.Errorf("update payment: %w", err)
 		}
+		if tag.RowsAffected() == 0 {
+			return &domain.ErrConcurrentUpdate{ID: payment.ID}
+		}
+
+		// Settle the coupon use: a failed payment gives it back
+		switch payment.Status {
+		case domain.PaymentStatusCompleted:
+			err = r.redemptions.Settle(ctx, tx, payment.ID, coupons.RedemptionRedeemed)
+		case domain.PaymentStatusFailed:
+			err = r.redemptions.Settle(ctx, tx, payment.ID, coupons.RedemptionReleased)
+		}
+		if err != nil {
+			return fmt.Errorf("settle coupon redemption: %w", err)
+		}
+
+		// Issue the invoice in the same transaction: a completed payment always has one
+		if payment.Status == domain.PaymentStatusCompleted {
+			if err := r.invoices.Issue(ctx, tx, payment); err != nil {
+				return fmt.Errorf("issue invoice: %w", err)
+			}
+		}
 
 		// Write domain events to outbox in the same transaction
 		if err := r.outboxRepo.SaveEvents(ctx, tx, payment.DomainEvents()); err != nil {
@@ -117,6 +423,22 @@ func (r *PostgresPaymentRepository) Update(ctx context.Context, payment *domain.
 		payment.ClearDomainEvents()
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	payment.Version++
+	return nil
+}
+
+// save runs fn in a transaction and tells the listener once it is committed.
+func (r *PostgresPaymentRepository) save(ctx context.Context, payment *domain.Payment, fn func(pgx.Tx) error) error {
+	if err := r.withTransaction(ctx, fn); err != nil {
+		return err
+	}
+	if r.listener != nil {
+		r.listener.PaymentSaved(payment)
+	}
+	return nil
 }
 
 func (r *PostgresPaymentRepository) withTransaction(ctx context.Context, fn func(pgx.Tx) error) error {
@@ -137,18 +459,32 @@ func scanPayment(row pgx.Row) (*domain.Payment, error) {
 	var p domain.Payment
 	var updatedAt *time.Time
 	var status int
+	var couponID *uuid.UUID
+	var discount domain.Discount
 
 	err := row.Scan(
 		&p.ID,
 		&p.AppointmentID,
 		&p.UserID,
+		&p.ClinicID,
+		&p.Subtotal,
+		&p.TaxAmount,
 		&p.Amount,
 		&p.Currency,
 		&status,
-		&p.StripePaymentIntentID,
-		&p.FailureReason,
+		&p.Provider,
+		&p.TransactionID,
+		&p.FailureCode,
+		&p.FailureDetail,
 		&p.CreatedAt,
 		&updatedAt,
+		&couponID,
+		&discount.Code,
+		&discount.Kind,
+		&discount.Value,
+		&discount.Amount,
+		&p.ChargeAttempt,
+		&p.Version,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -159,6 +495,10 @@ func scanPayment(row pgx.Row) (*domain.Payment, error) {
 
 	p.Status = domain.PaymentStatus(status)
 	p.UpdatedAt = updatedAt
+	if couponID != nil {
+		discount.CouponID = *couponID
+		p.Discount = &discount
+	}
 	return &p, nil
 }
 
@@ -168,3 +508,10 @@ func nilIfEmpty(s string) *string {
 	}
 	return &s
 }
+
+func nilIfNilUUID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,278 @@
+package listpayments
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+)
+
+// Page sizes
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+// ---------------------------------------------------------------------------
+// Query
+// ---------------------------------------------------------------------------
+
+// Query searches payments for support staff. Zero-valued filters match every
+// payment; Cursor is the NextCursor of the previous page.
+type Query struct {
+	UserID        string
+	AppointmentID uuid.UUID
+	Statuses      []domain.PaymentStatus
+	Currency      string
+	MinAmount     *float64
+	MaxAmount     *float64
+	CreatedFrom   *time.Time
+	CreatedTo     *time.Time // exclusive
+	TransactionID string     // provider reference, e.g. the Stripe PaymentIntent ID
+
+	Sort   infrastructure.PaymentSort // default: newest first
+	Limit  int                        // default DefaultLimit, at most MaxLimit
+	Cursor string
+}
+
+// Result is a page of payments.
+type Result struct {
+	Payments   []Summary `json:"payments"`
+	NextCursor string    `json:"nextCursor,omitempty"` // empty on the last page
+}
+
+// Summary is the list read model of a payment; GET /api/payments/:id has the details.
+type Summary struct {
+	PaymentID     string     `json:"paymentId"`
+	AppointmentID string     `json:"appointmentId"`
+	UserID        string     `json:"userId"`
+	ClinicID      string     `json:"clinicId,omitempty"`
+	Amount        float64    `json:"amount"`
+	Currency      string     `json:"currency"`
+	Status        string     `json:"status"`
+	Provider      string     `json:"provider"`
+	TransactionID string     `json:"transactionId,omitempty"`
+	FailureCode   string     `json:"failureCode,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+}
+
+// ErrInvalidQuery is returned for a malformed filter, sort or cursor.
+type ErrInvalidQuery struct {
+	Reason string
+}
+
+func (e *ErrInvalidQuery) Error() string {
+	return "invalid payment query: " + e.Reason
+}
+
+// ParseQuery reads a Query from the parameters of GET /api/payments.
+// Statuses may be repeated or comma-separated; times are RFC 3339.
+func ParseQuery(params url.Values) (Query, error) {
+	q := Query{
+		UserID:        params.Get("userId"),
+		Currency:      params.Get("currency"),
+		TransactionID: params.Get("transactionId"),
+		Sort:          infrastructure.PaymentSort(params.Get("sort")),
+		Cursor:        params.Get("cursor"),
+	}
+
+	if v := params.Get("appointmentId"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return Query{}, &ErrInvalidQuery{Reason: "appointmentId must be a UUID"}
+		}
+		q.AppointmentID = id
+	}
+
+	for _, v := range params["status"] {
+		for _, name := range strings.Split(v, ",") {
+			status, ok := domain.ParsePaymentStatus(strings.TrimSpace(name))
+			if !ok {
+				return Query{}, &ErrInvalidQuery{Reason: fmt.Sprintf("unknown status %q", name)}
+			}
+			q.Statuses = append(q.Statuses, status)
+		}
+	}
+
+	for name, dst := range map[string]**float64{"minAmount": &q.MinAmount, "maxAmount": &q.MaxAmount} {
+		if v := params.Get(name); v != "" {
+			amount, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return Query{}, &ErrInvalidQuery{Reason: name + " must be a number"}
+			}
+			*dst = &amount
+		}
+	}
+
+	for name, dst := range map[string]**time.Time{"createdFrom": &q.CreatedFrom, "createdTo": &q.CreatedTo} {
+		if v := params.Get(name); v != "" {
+			at, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return Query{}, &ErrInvalidQuery{Reason: name + " must be an RFC 3339 timestamp"}
+			}
+			*dst = &at
+		}
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return Query{}, &ErrInvalidQuery{Reason: "limit must be a positive integer"}
+		}
+		q.Limit = limit
+	}
+	return q, nil
+}
+
+// ---------------------------------------------------------------------------
+// Handler
+// ---------------------------------------------------------------------------
+
+// Handler handles the ListPaymentsQuery.
+//
+// Pagination is keyset-based: the cursor encodes the sort order and the sort
+// key and ID of the page's last payment, so a page never repeats or skips
+// payments created while paging, and deep pages cost the same as the first.
+type Handler struct {
+	repo infrastructure.PaymentRepository
+}
+
+// NewHandler creates a new ListPaymentsHandler.
+func NewHandler(repo infrastructure.PaymentRepository) *Handler {
+	return &Handler{repo: repo}
+}
+
+// Handle processes the query and returns a page of payments.
+func (h *Handler) Handle(ctx context.Context, q Query) (*Result, error) {
+	filter, err := toFilter(q)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra payment tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	payments, err := h.repo.Search(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("search payments: %w", err)
+	}
+
+	result := &Result{Payments: make([]Summary, 0, min(len(payments), limit))}
+	if len(payments) > limit {
+		payments = payments[:limit]
+		last := payments[limit-1]
+		result.NextCursor = encodeCursor(filter.Sort, infrastructure.PaymentCursor{
+			CreatedAt: last.CreatedAt,
+			Amount:    last.Amount,
+			ID:        last.ID,
+		})
+	}
+	for _, p := range payments {
+		result.Payments = append(result.Payments, toSummary(p))
+	}
+	return result, nil
+}
+
+// toFilter validates the query and applies the defaults.
+func toFilter(q Query) (infrastructure.PaymentFilter, error) {
+	filter := infrastructure.PaymentFilter{
+		UserID:        q.UserID,
+		AppointmentID: q.AppointmentID,
+		Statuses:      q.Statuses,
+		Currency:      q.Currency,
+		MinAmount:     q.MinAmount,
+		MaxAmount:     q.MaxAmount,
+		CreatedFrom:   q.CreatedFrom,
+		CreatedTo:     q.CreatedTo,
+		TransactionID: q.TransactionID,
+		Sort:          q.Sort,
+		Limit:         q.Limit,
+	}
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = infrastructure.SortCreatedDesc
+	case infrastructure.SortCreatedDesc, infrastructure.SortCreatedAsc,
+		infrastructure.SortAmountDesc, infrastructure.SortAmountAsc:
+	default:
+		return filter, &ErrInvalidQuery{Reason: fmt.Sprintf("unknown sort %q (use createdAt, -createdAt, amount or -amount)", q.Sort)}
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLimit
+	}
+	filter.Limit = min(filter.Limit, MaxLimit)
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return filter, &ErrInvalidQuery{Reason: "minAmount is greater than maxAmount"}
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return filter, &ErrInvalidQuery{Reason: "createdFrom must be before createdTo"}
+	}
+
+	if q.Cursor != "" {
+		after, err := decodeCursor(q.Cursor, filter.Sort)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = after
+	}
+	return filter, nil
+}
+
+func toSummary(p *domain.Payment) Summary {
+	return Summary{
+		PaymentID:     p.ID.String(),
+		AppointmentID: p.AppointmentID.String(),
+		UserID:        p.UserID,
+		ClinicID:      p.ClinicID,
+		Amount:        p.Amount,
+		Currency:      p.Currency,
+		Status:        p.Status.String(),
+		Provider:      p.Provider,
+		TransactionID: p.TransactionID,
+		FailureCode:   string(p.FailureCode),
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Cursor
+// ---------------------------------------------------------------------------
+
+// cursor is the opaque pagination token, URL-safe base64 JSON.
+type cursor struct {
+	Sort      infrastructure.PaymentSort `json:"s"`
+	CreatedAt time.Time                  `json:"c"`
+	Amount    float64                    `json:"a"`
+	ID        uuid.UUID                  `json:"i"`
+}
+
+func encodeCursor(sort infrastructure.PaymentSort, pos infrastructure.PaymentCursor) string {
+	data, _ := json.Marshal(cursor{Sort: sort, CreatedAt: pos.CreatedAt, Amount: pos.Amount, ID: pos.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string, sort infrastructure.PaymentSort) (*infrastructure.PaymentCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, &ErrInvalidQuery{Reason: "malformed cursor"}
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, &ErrInvalidQuery{Reason: "malformed cursor"}
+	}
+	// A position in one order means nothing in another
+	if c.Sort != sort {
+		return nil, &ErrInvalidQuery{Reason: "cursor belongs to a different sort order"}
+	}
+	return &infrastructure.PaymentCursor{CreatedAt: c.CreatedAt, Amount: c.Amount, ID: c.ID}, nil
+}
@@ -0,0 +1,171 @@
+package listpayments_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+	listpayments "github.com/smart-health/payments-api/internal/payments/list_payments"
+)
+
+// fakeRepo serves Search from a slice sorted newest first and records the filters.
+type fakeRepo struct {
+	infrastructure.PaymentRepository
+	payments []*domain.Payment
+	filters  []infrastructure.PaymentFilter
+}
+
+func (r *fakeRepo) Search(_ context.Context, filter infrastructure.PaymentFilter) ([]*domain.Payment, error) {
+	r.filters = append(r.filters, filter)
+	var page []*domain.Payment
+	for _, p := range r.payments {
+		if filter.After != nil && !p.CreatedAt.Before(filter.After.CreatedAt) {
+			continue
+		}
+		if len(page) == filter.Limit {
+			break
+		}
+		page = append(page, p)
+	}
+	return page, nil
+}
+
+func newRepo(n int) *fakeRepo {
+	repo := &fakeRepo{}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		p, _ := domain.NewPayment(uuid.New(), "user-1", 100.0, "eur")
+		p.CreatedAt = start.Add(-time.Duration(i) * time.Minute)
+		repo.payments = append(repo.payments, p)
+	}
+	return repo
+}
+
+func TestHandle_PagesThroughAllPayments(t *testing.T) {
+	repo := newRepo(5)
+	h := listpayments.NewHandler(repo)
+
+	var seen []string
+	q := listpayments.Query{Limit: 2}
+	for page := 0; page < 5; page++ {
+		result, err := h.Handle(context.Background(), q)
+		if err != nil {
+			t.Fatalf("page %d: %v", page, err)
+		}
+		for _, p := range result.Payments {
+			seen = append(seen, p.PaymentID)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		q.Cursor = result.NextCursor
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("expected 5 payments over all pages, got %d", len(seen))
+	}
+	for i, p := range repo.payments {
+		if seen[i] != p.ID.String() {
+			t.Errorf("payment %d: expected %s, got %s", i, p.ID, seen[i])
+		}
+	}
+	if repo.filters[0].Limit != 3 || repo.filters[0].Sort != infrastructure.SortCreatedDesc {
+		t.Errorf("expected one extra payment newest first, got limit %d sort %q", repo.filters[0].Limit, repo.filters[0].Sort)
+	}
+}
+
+func TestHandle_LastPageHasNoCursor(t *testing.T) {
+	h := listpayments.NewHandler(newRepo(2))
+	result, err := h.Handle(context.Background(), listpayments.Query{Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Payments) != 2 || result.NextCursor != "" {
+		t.Errorf("expected 2 payments and no cursor, got %d and %q", len(result.Payments), result.NextCursor)
+	}
+}
+
+func TestHandle_InvalidQueries(t *testing.T) {
+	repo := newRepo(3)
+	h := listpayments.NewHandler(repo)
+	first, err := h.Handle(context.Background(), listpayments.Query{Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	low, high := 50.0, 10.0
+	tests := []struct {
+		name string
+		q    listpayments.Query
+	}{
+		{"unknown sort", listpayments.Query{Sort: "status"}},
+		{"malformed cursor", listpayments.Query{Cursor: "not a cursor"}},
+		{"cursor of another sort", listpayments.Query{Cursor: first.NextCursor, Sort: infrastructure.SortAmountAsc}},
+		{"amount range", listpayments.Query{MinAmount: &low, MaxAmount: &high}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := h.Handle(context.Background(), tt.q)
+			var invalid *listpayments.ErrInvalidQuery
+			if !errors.As(err, &invalid) {
+				t.Fatalf("expected ErrInvalidQuery, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	appointmentID := uuid.New()
+	q, err := listpayments.ParseQuery(url.Values{
+		"userId":        {"user-1"},
+		"appointmentId": {appointmentID.String()},
+		"status":        {"failed,pending", "requiresaction"},
+		"minAmount":     {"10.5"},
+		"createdFrom":   {"2026-01-01T00:00:00Z"},
+		"transactionId": {"pi_123"},
+		"sort":          {"-amount"},
+		"limit":         {"20"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantStatuses := []domain.PaymentStatus{domain.PaymentStatusFailed, domain.PaymentStatusPending, domain.PaymentStatusRequiresAction}
+	if len(q.Statuses) != len(wantStatuses) {
+		t.Fatalf("expected statuses %v, got %v", wantStatuses, q.Statuses)
+	}
+	for i, s := range wantStatuses {
+		if q.Statuses[i] != s {
+			t.Errorf("status %d: expected %v, got %v", i, s, q.Statuses[i])
+		}
+	}
+	if q.UserID != "user-1" || q.AppointmentID != appointmentID || q.TransactionID != "pi_123" {
+		t.Errorf("unexpected identifiers: %+v", q)
+	}
+	if q.MinAmount == nil || *q.MinAmount != 10.5 || q.MaxAmount != nil {
+		t.Errorf("unexpected amount range: %v – %v", q.MinAmount, q.MaxAmount)
+	}
+	if q.CreatedFrom == nil || q.CreatedFrom.Year() != 2026 || q.CreatedTo != nil {
+		t.Errorf("unexpected created range: %v – %v", q.CreatedFrom, q.CreatedTo)
+	}
+	if q.Sort != infrastructure.SortAmountDesc || q.Limit != 20 {
+		t.Errorf("expected sort -amount limit 20, got %q %d", q.Sort, q.Limit)
+	}
+
+	for _, bad := range []url.Values{
+		{"status": {"Refunded"}},
+		{"appointmentId": {"42"}},
+		{"maxAmount": {"lots"}},
+		{"createdTo": {"yesterday"}},
+		{"limit": {"0"}},
+	} {
+		if _, err := listpayments.ParseQuery(bad); err == nil {
+			t.Errorf("expected an error for %v", bad)
+		}
+	}
+}
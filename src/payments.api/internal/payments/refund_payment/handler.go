@@ -0,0 +1,143 @@
+package refundpayment
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway"
+	"github.com/smart-health/payments-api/internal/invoicing"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+)
+
+// ---------------------------------------------------------------------------
+// Command
+// ---------------------------------------------------------------------------
+
+// Command refunds part or all of a completed payment.
+type Command struct {
+	PaymentID uuid.UUID
+	Amount    float64 // in the major currency unit; zero refunds what has not been refunded yet
+	Reason    string  // printed on the credit note
+}
+
+// Result describes the refund issued with the provider and the credit note
+// recording it.
+type Result struct {
+	RefundID         string // provider reference of the refund
+	PaymentID        string
+	Amount           float64
+	Status           string // provider refund status, e.g. "succeeded" or "pending"
+	CreditNoteID     string
+	CreditNoteNumber string
+}
+
+// ---------------------------------------------------------------------------
+// Handler
+// ---------------------------------------------------------------------------
+
+// Handler handles the RefundPaymentCommand.
+//
+// Flow:
+//  1. Load the Payment aggregate; only completed payments are refunded.
+//  2. Load its invoice and the credit notes issued against it, and check the
+//     credit note the refund needs could be issued (amount, reason).
+//  3. Refund the amount with the payment's provider.
+//  4. Issue the credit note.
+//
+// The provider refund comes first: a credit note cannot be withdrawn, a
+// refund that failed leaves nothing to undo. The refund's reference is the
+// payment ID and the position of its credit note, so a command retried
+// after step 3 succeeded but step 4 failed replays the same provider refund
+// (see gateway.PaymentGateway.Refund) and issues the missing credit note.
+type Handler struct {
+	repo     infrastructure.PaymentRepository
+	gateways gateway.Registry
+	invoices invoicing.Repository
+	logger   *slog.Logger
+}
+
+// NewHandler creates a new RefundPaymentHandler.
+func NewHandler(repo infrastructure.PaymentRepository, gateways gateway.Registry, invoices invoicing.Repository, logger *slog.Logger) *Handler {
+	return &Handler{repo: repo, gateways: gateways, invoices: invoices, logger: logger}
+}
+
+// Handle processes the command.
+func (h *Handler) Handle(ctx context.Context, cmd Command) (*Result, error) {
+	payment, err := h.repo.FindByID(ctx, cmd.PaymentID)
+	if err != nil {
+		return nil, fmt.Errorf("find payment: %w", err)
+	}
+	if payment == nil {
+		return nil, &domain.ErrPaymentNotFound{ID: cmd.PaymentID}
+	}
+	if payment.Status != domain.PaymentStatusCompleted {
+		return nil, &domain.ErrInvalidTransition{From: payment.Status, Allowed: []domain.PaymentStatus{domain.PaymentStatusCompleted}}
+	}
+
+	invoice, err := h.invoices.FindByPaymentID(ctx, payment.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find invoice: %w", err)
+	}
+	if invoice == nil {
+		return nil, &invoicing.ErrInvoiceNotFound{PaymentID: payment.ID}
+	}
+	notes, err := h.invoices.CreditNotes(ctx, invoice.ID)
+	if err != nil {
+		return nil, fmt.Errorf("find credit notes: %w", err)
+	}
+	var credited float64
+	for _, n := range notes {
+		credited += n.Total
+	}
+
+	amount := cmd.Amount
+	if amount == 0 {
+		amount = math.Round((invoice.Total-credited)*100) / 100
+	}
+	// Refused here rather than after the money has left
+	if _, err := invoicing.NewCreditNote(invoice, credited, amount, cmd.Reason, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	provider, err := h.gateways.Get(payment.Provider)
+	if err != nil {
+		return nil, err
+	}
+	reference := fmt.Sprintf("%s-%d", payment.ID, len(notes)+1)
+	refund, err := provider.Refund(ctx, payment.TransactionID, amount, cmd.Reason, reference)
+	if err != nil {
+		return nil, fmt.Errorf("refund payment: %w", err)
+	}
+
+	note, err := h.invoices.IssueCreditNote(ctx, invoice.ID, amount, cmd.Reason)
+	if err != nil {
+		// Retrying the command replays the refund and issues the credit note
+		h.logger.Error("refund issued without a credit note",
+			"paymentId", payment.ID,
+			"refundId", refund.ID,
+			"amount", amount,
+			"error", err)
+		return nil, fmt.Errorf("issue credit note: %w", err)
+	}
+
+	h.logger.Info("payment refunded",
+		"paymentId", payment.ID,
+		"provider", payment.Provider,
+		"refundId", refund.ID,
+		"amount", amount,
+		"creditNote", note.Number)
+
+	return &Result{
+		RefundID:         refund.ID,
+		PaymentID:        payment.ID.String(),
+		Amount:           amount,
+		Status:           refund.Status,
+		CreditNoteID:     note.ID.String(),
+		CreditNoteNumber: note.Number,
+	}, nil
+}
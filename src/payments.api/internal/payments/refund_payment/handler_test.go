@@ -0,0 +1,203 @@
+package refundpayment_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway"
+	"github.com/smart-health/payments-api/internal/invoicing"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+	refundpayment "github.com/smart-health/payments-api/internal/payments/refund_payment"
+)
+
+type fakeRepo struct {
+	infrastructure.PaymentRepository
+	payment *domain.Payment
+}
+
+func (r *fakeRepo) FindByID(_ context.Context, id uuid.UUID) (*domain.Payment, error) {
+	if r.payment == nil || r.payment.ID != id {
+		return nil, nil
+	}
+	return r.payment, nil
+}
+
+// fakeGateway records refunds by reference; a reference refunded before is replayed.
+type fakeGateway struct {
+	gateway.PaymentGateway
+	refunds    map[string]*gateway.Refund
+	references []string
+}
+
+func (g *fakeGateway) Get(string) (gateway.PaymentGateway, error) { return g, nil }
+
+func (g *fakeGateway) Refund(_ context.Context, transactionID string, amount float64, _, reference string) (*gateway.Refund, error) {
+	g.references = append(g.references, reference)
+	if r, ok := g.refunds[reference]; ok {
+		return r, nil
+	}
+	r := &gateway.Refund{ID: "re_" + reference, TransactionID: transactionID, Amount: amount, Status: "succeeded"}
+	g.refunds[reference] = r
+	return r, nil
+}
+
+// fakeInvoices holds the invoice of the payment and the credit notes issued against it.
+type fakeInvoices struct {
+	invoicing.Repository
+	invoice *invoicing.Invoice
+	notes   []*invoicing.Invoice
+	fail    error // returned by the next IssueCreditNote
+}
+
+func (r *fakeInvoices) FindByPaymentID(_ context.Context, paymentID uuid.UUID) (*invoicing.Invoice, error) {
+	if r.invoice == nil || r.invoice.PaymentID != paymentID {
+		return nil, nil
+	}
+	return r.invoice, nil
+}
+
+func (r *fakeInvoices) CreditNotes(context.Context, uuid.UUID) ([]*invoicing.Invoice, error) {
+	return r.notes, nil
+}
+
+func (r *fakeInvoices) IssueCreditNote(_ context.Context, _ uuid.UUID, amount float64, reason string) (*invoicing.Invoice, error) {
+	if err := r.fail; err != nil {
+		r.fail = nil
+		return nil, err
+	}
+	var credited float64
+	for _, n := range r.notes {
+		credited += n.Total
+	}
+	note, err := invoicing.NewCreditNote(r.invoice, credited, amount, reason, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	note.Number = invoicing.FormatNumber(note.Kind, note.ClinicID, int64(len(r.notes)+1))
+	r.notes = append(r.notes, note)
+	return note, nil
+}
+
+type fixture struct {
+	payment  *domain.Payment
+	gateway  *fakeGateway
+	invoices *fakeInvoices
+	handler  *refundpayment.Handler
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	p, _ := domain.NewPayment(uuid.New(), "user-1", 120.0, "eur")
+	if err := p.MarkProcessing("pi_123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.MarkCompleted(); err != nil {
+		t.Fatal(err)
+	}
+	invoice := invoicing.NewInvoice(p, "DOWNTOWN", time.Now())
+	invoice.Number = "INV-DOWNTOWN-000001"
+
+	f := &fixture{
+		payment:  p,
+		gateway:  &fakeGateway{refunds: map[string]*gateway.Refund{}},
+		invoices: &fakeInvoices{invoice: invoice},
+	}
+	f.handler = refundpayment.NewHandler(&fakeRepo{payment: p}, f.gateway, f.invoices, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return f
+}
+
+func TestHandle_RefundsAndIssuesCreditNotes(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	first, err := f.handler.Handle(ctx, refundpayment.Command{PaymentID: f.payment.ID, Amount: 20, Reason: "shorter visit"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.Amount != 20 || first.Status != "succeeded" || first.CreditNoteNumber != "CN-DOWNTOWN-000001" {
+		t.Errorf("first refund = %+v", first)
+	}
+
+	// Zero refunds the rest
+	rest, err := f.handler.Handle(ctx, refundpayment.Command{PaymentID: f.payment.ID, Reason: "appointment cancelled"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rest.Amount != 100 || len(f.invoices.notes) != 2 {
+		t.Errorf("second refund = %+v with %d credit notes, want the remaining 100", rest, len(f.invoices.notes))
+	}
+	want := []string{f.payment.ID.String() + "-1", f.payment.ID.String() + "-2"}
+	if len(f.gateway.references) != 2 || f.gateway.references[0] != want[0] || f.gateway.references[1] != want[1] {
+		t.Errorf("refund references = %v, want %v", f.gateway.references, want)
+	}
+
+	// Nothing is left to refund
+	_, err = f.handler.Handle(ctx, refundpayment.Command{PaymentID: f.payment.ID, Reason: "again"})
+	if !errors.Is(err, invoicing.ErrInvalidCreditNote) {
+		t.Errorf("expected ErrInvalidCreditNote for a fully refunded payment, got %v", err)
+	}
+}
+
+func TestHandle_RejectsBeforeRefunding(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		cmd  refundpayment.Command
+	}{
+		{"more than paid", refundpayment.Command{PaymentID: f.payment.ID, Amount: 120.01, Reason: "too much"}},
+		{"no reason", refundpayment.Command{PaymentID: f.payment.ID, Amount: 10}},
+		{"negative amount", refundpayment.Command{PaymentID: f.payment.ID, Amount: -5, Reason: "negative"}},
+	}
+	for _, tt := range tests {
+		if _, err := f.handler.Handle(ctx, tt.cmd); !errors.Is(err, invoicing.ErrInvalidCreditNote) {
+			t.Errorf("%s: expected ErrInvalidCreditNote, got %v", tt.name, err)
+		}
+	}
+
+	var notFound *domain.ErrPaymentNotFound
+	if _, err := f.handler.Handle(ctx, refundpayment.Command{PaymentID: uuid.New(), Reason: "unknown"}); !errors.As(err, &notFound) {
+		t.Errorf("expected ErrPaymentNotFound, got %v", err)
+	}
+
+	pending, _ := domain.NewPayment(uuid.New(), "user-1", 50.0, "eur")
+	h := refundpayment.NewHandler(&fakeRepo{payment: pending}, f.gateway, f.invoices, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	var transition *domain.ErrInvalidTransition
+	if _, err := h.Handle(ctx, refundpayment.Command{PaymentID: pending.ID, Reason: "not paid"}); !errors.As(err, &transition) {
+		t.Errorf("expected ErrInvalidTransition for a pending payment, got %v", err)
+	}
+
+	if len(f.gateway.references) != 0 {
+		t.Errorf("expected no provider refund, got %v", f.gateway.references)
+	}
+}
+
+func TestHandle_RetryIssuesTheMissingCreditNote(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	cmd := refundpayment.Command{PaymentID: f.payment.ID, Amount: 30, Reason: "shorter visit"}
+
+	f.invoices.fail = errors.New("connection reset")
+	if _, err := f.handler.Handle(ctx, cmd); err == nil {
+		t.Fatal("expected the credit note failure to be returned")
+	}
+
+	result, err := f.handler.Handle(ctx, cmd)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	// The provider refund of the first attempt is replayed, not issued again
+	if len(f.gateway.refunds) != 1 || result.RefundID != "re_"+f.payment.ID.String()+"-1" {
+		t.Errorf("refunds = %v, result = %+v, want one refund replayed", f.gateway.refunds, result)
+	}
+	if len(f.invoices.notes) != 1 || f.invoices.notes[0].Total != 30 {
+		t.Errorf("credit notes = %d, want one of 30", len(f.invoices.notes))
+	}
+}
@@ -0,0 +1,144 @@
+package retrypending
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/gateway"
+	completepayment "github.com/smart-health/payments-api/internal/payments/complete_payment"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/infrastructure"
+	"github.com/smart-health/payments-api/internal/shared"
+)
+
+// ---------------------------------------------------------------------------
+// Command
+// ---------------------------------------------------------------------------
+
+// Command charges payments left Pending because their provider was
+// unavailable (outage, open circuit breaker, full bulkhead).
+type Command struct {
+	CreatedBefore time.Time // younger payments may still be being charged
+	Limit         int
+}
+
+// Result counts what happened to the payments found.
+type Result struct {
+	Found        int
+	Charged      int // no longer Pending: completed, failed or waiting for the patient
+	StillPending int // the provider is still unavailable
+}
+
+// ---------------------------------------------------------------------------
+// Handler
+// ---------------------------------------------------------------------------
+
+// Handler handles the RetryPendingCommand.
+//
+// Flow:
+//  1. Load the oldest Pending payments.
+//  2. Dispatch CompletePaymentCommand for each; the provider's idempotency
+//     (e.g. the PaymentIntent idempotency key) makes a repeated charge safe.
+//  3. After a temporary error the payment's provider is skipped for the rest
+//     of the batch – it is still down, and its breaker is likely open.
+type Handler struct {
+	repo     infrastructure.PaymentRepository
+	mediator *shared.Mediator
+	logger   *slog.Logger
+}
+
+// NewHandler creates a new RetryPendingHandler.
+func NewHandler(repo infrastructure.PaymentRepository, mediator *shared.Mediator, logger *slog.Logger) *Handler {
+	return &Handler{repo: repo, mediator: mediator, logger: logger}
+}
+
+// Handle processes the command.
+func (h *Handler) Handle(ctx context.Context, cmd Command) (*Result, error) {
+	payments, err := h.repo.FindPending(ctx, cmd.CreatedBefore, cmd.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("find pending payments: %w", err)
+	}
+
+	result := &Result{Found: len(payments)}
+	unavailable := make(map[string]bool)
+	for _, payment := range payments {
+		if unavailable[payment.Provider] {
+			result.StillPending++
+			continue
+		}
+
+		resp, err := h.mediator.Send(ctx, completepayment.Command{PaymentID: payment.ID})
+		switch {
+		case err != nil && gateway.IsTemporary(err):
+			unavailable[payment.Provider] = true
+			result.StillPending++
+			h.logger.Warn("payment provider still unavailable, payments stay pending",
+				"provider", payment.Provider,
+				"paymentId", payment.ID,
+				"error", err)
+		case err != nil:
+			h.logger.Error("retrying pending payment failed",
+				"paymentId", payment.ID,
+				"error", err)
+		default:
+			if r, ok := resp.(*completepayment.Result); ok && r.Status == domain.PaymentStatusPending.String() {
+				result.StillPending++
+				continue
+			}
+			result.Charged++
+		}
+	}
+	return result, nil
+}
+
+// ---------------------------------------------------------------------------
+// Worker
+// ---------------------------------------------------------------------------
+
+// Worker runs the Handler periodically.
+type Worker struct {
+	handler   *Handler
+	interval  time.Duration
+	batchSize int
+	logger    *slog.Logger
+}
+
+// NewWorker creates a worker retrying up to batchSize payments every interval.
+// Only payments older than one interval are retried.
+func NewWorker(handler *Handler, interval time.Duration, batchSize int, logger *slog.Logger) *Worker {
+	return &Worker{handler: handler, interval: interval, batchSize: batchSize, logger: logger}
+}
+
+// Run starts the retry loop. It blocks until ctx is cancelled.
+// Designed to be run as a goroutine.
+func (w *Worker) Run(ctx context.Context) {
+	w.logger.Info("pending payment retrier starting", "interval", w.interval)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.logger.Info("pending payment retrier stopped")
+			return
+		case <-ticker.C:
+			result, err := w.handler.Handle(ctx, Command{
+				CreatedBefore: time.Now().UTC().Add(-w.interval),
+				Limit:         w.batchSize,
+			})
+			if err != nil {
+				w.logger.Error("failed to retry pending payments", "error", err)
+				continue
+			}
+			if result.Found > 0 {
+				w.logger.Info("retried pending payments",
+					"found", result.Found,
+					"charged", result.Charged,
+					"stillPending", result.StillPending)
+			}
+		}
+	}
+}
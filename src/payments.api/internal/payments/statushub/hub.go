@@ -0,0 +1,214 @@
+package statushub
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+// ---------------------------------------------------------------------------
+// Payment status hub
+//
+// Architectural Decision: The patient frontend follows a payment over a
+// Server-Sent Events stream instead of polling GET /api/payments/:id. The
+// repository tells the hub about every payment it has saved, after the
+// commit, and the hub pushes the status to the streams following that
+// payment. With several replicas, a PostgresRelay forwards the changes
+// through Postgres LISTEN/NOTIFY, so a stream sees the payments charged by
+// any replica.
+//
+// A payment's status only moves forward (see domain.PaymentStatus.Stage),
+// so the status name is the SSE event ID: a client resuming with
+// Last-Event-ID gets the statuses after it, whichever replica serves it,
+// and a change that arrives late or twice is recognized and dropped.
+// ---------------------------------------------------------------------------
+
+// Change is the status of a payment after a committed save.
+type Change struct {
+	PaymentID   uuid.UUID            `json:"paymentId"`
+	UserID      string               `json:"userId"`
+	Status      domain.PaymentStatus `json:"status"`
+	FailureCode domain.FailureCode   `json:"failureCode,omitempty"`
+	At          time.Time            `json:"at"`
+}
+
+// ID is the SSE event ID of the change.
+func (c Change) ID() string {
+	return c.Status.String()
+}
+
+// subscriberBuffer holds every change a subscription can receive: one per
+// stage of the payment, so delivering never blocks the publisher.
+const subscriberBuffer = 4
+
+// Hub delivers the status changes of payments to their subscribers and
+// keeps the changes of recently saved payments for resuming streams.
+type Hub struct {
+	retention time.Duration
+
+	mu     sync.Mutex
+	topics map[uuid.UUID]*topic
+	pruned time.Time
+	closed bool
+	relay  func(Change) // forwards local changes to the other replicas
+}
+
+type topic struct {
+	history []Change // by stage
+	subs    map[*Subscription]struct{}
+	updated time.Time
+}
+
+// Subscription receives the changes of a payment on C, which is closed
+// when the hub is closed.
+type Subscription struct {
+	C <-chan Change
+
+	c         chan Change
+	hub       *Hub
+	paymentID uuid.UUID
+}
+
+// New creates a hub keeping the changes of payments for retention after
+// their last change.
+func New(retention time.Duration) *Hub {
+	return &Hub{retention: retention, topics: map[uuid.UUID]*topic{}, pruned: time.Now()}
+}
+
+// PaymentSaved publishes the status of a saved payment; it implements
+// infrastructure.StatusListener.
+func (h *Hub) PaymentSaved(p *domain.Payment) {
+	at := p.CreatedAt
+	if p.UpdatedAt != nil {
+		at = *p.UpdatedAt
+	}
+	h.Publish(Change{PaymentID: p.ID, UserID: p.UserID, Status: p.Status, FailureCode: p.FailureCode, At: at})
+}
+
+// Publish delivers a change made by this replica, and forwards it to the
+// other replicas when a relay is attached.
+func (h *Hub) Publish(c Change) {
+	if h.deliver(c) && h.relay != nil {
+		h.relay(c)
+	}
+}
+
+// deliver records a change and sends it to the payment's subscribers. It
+// reports whether the change advanced the payment; saves that leave the
+// status unchanged, and changes already delivered, are dropped.
+func (h *Hub) deliver(c Change) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	h.prune(now)
+
+	t := h.topics[c.PaymentID]
+	if t == nil {
+		t = &topic{subs: map[*Subscription]struct{}{}}
+		h.topics[c.PaymentID] = t
+	}
+	if n := len(t.history); n > 0 && t.history[n-1].Status.Stage() >= c.Status.Stage() {
+		return false
+	}
+	t.history = append(t.history, c)
+	t.updated = now
+
+	for s := range t.subs {
+		select {
+		case s.c <- c:
+		default: // cannot happen (see subscriberBuffer); never block the publisher
+			delete(t.subs, s)
+			close(s.c)
+		}
+	}
+	return true
+}
+
+// Subscribe follows the changes of a payment. It returns the changes kept
+// for the payment, oldest first, to replay before the ones to come.
+func (h *Hub) Subscribe(paymentID uuid.UUID) (*Subscription, []Change) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topics[paymentID]
+	if t == nil {
+		t = &topic{subs: map[*Subscription]struct{}{}, updated: time.Now()}
+		h.topics[paymentID] = t
+	}
+	c := make(chan Change, subscriberBuffer)
+	s := &Subscription{C: c, c: c, hub: h, paymentID: paymentID}
+	if h.closed {
+		close(c)
+	} else {
+		t.subs[s] = struct{}{}
+	}
+	return s, append([]Change(nil), t.history...)
+}
+
+// Current loads the current status of a payment.
+type Current func(ctx context.Context, paymentID uuid.UUID) (Change, error)
+
+// Follow subscribes to a payment and returns, oldest first, the changes to
+// send before the ones to come: those kept by the hub, and the current
+// status when the hub has not seen it – another replica made the change and
+// its notification was lost, or this replica never saw the payment.
+func (h *Hub) Follow(ctx context.Context, paymentID uuid.UUID, current Current) (*Subscription, []Change, error) {
+	// Subscribe before reading the payment, so no change falls in between
+	sub, backlog := h.Subscribe(paymentID)
+	now, err := current(ctx, paymentID)
+	if err != nil {
+		sub.Close()
+		return nil, nil, err
+	}
+	if n := len(backlog); n == 0 || backlog[n-1].Status.Stage() < now.Status.Stage() {
+		backlog = append(backlog, now)
+	}
+	return sub, backlog, nil
+}
+
+// Close ends every subscription, so that streams finish when the server
+// shuts down; later subscriptions end at once.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, t := range h.topics {
+		for s := range t.subs {
+			delete(t.subs, s)
+			close(s.c)
+		}
+	}
+}
+
+// Close stops the subscription.
+func (s *Subscription) Close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if t := h.topics[s.paymentID]; t != nil {
+		if _, ok := t.subs[s]; ok {
+			delete(t.subs, s)
+			close(s.c)
+		}
+	}
+}
+
+// prune forgets the payments without subscribers that have not changed
+// for the retention period; it scans at most twice per period.
+func (h *Hub) prune(now time.Time) {
+	if now.Sub(h.pruned) < h.retention/2 {
+		return
+	}
+	h.pruned = now
+	for id, t := range h.topics {
+		if len(t.subs) == 0 && now.Sub(t.updated) > h.retention {
+			delete(h.topics, id)
+		}
+	}
+}
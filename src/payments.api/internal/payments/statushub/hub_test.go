@@ -0,0 +1,85 @@
+package statushub_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/statushub"
+)
+
+func change(id uuid.UUID, status domain.PaymentStatus) statushub.Change {
+	return statushub.Change{PaymentID: id, UserID: "user-1", Status: status, At: time.Now()}
+}
+
+func TestHub_DeliversChangesThatAdvanceThePayment(t *testing.T) {
+	hub := statushub.New(time.Minute)
+	id := uuid.New()
+	hub.Publish(change(id, domain.PaymentStatusPending))
+
+	sub, history := hub.Subscribe(id)
+	defer sub.Close()
+	if len(history) != 1 || history[0].Status != domain.PaymentStatusPending {
+		t.Fatalf("history = %+v, want Pending", history)
+	}
+
+	hub.Publish(change(id, domain.PaymentStatusProcessing))
+	hub.Publish(change(id, domain.PaymentStatusProcessing))     // saved again
+	hub.Publish(change(id, domain.PaymentStatusRequiresAction)) // arrives late
+	hub.Publish(change(id, domain.PaymentStatusCompleted))
+	hub.Publish(change(uuid.New(), domain.PaymentStatusFailed)) // another payment
+
+	for _, want := range []domain.PaymentStatus{domain.PaymentStatusProcessing, domain.PaymentStatusCompleted} {
+		select {
+		case got := <-sub.C:
+			if got.Status != want {
+				t.Errorf("change = %s, want %s", got.Status, want)
+			}
+		default:
+			t.Fatalf("no change, want %s", want)
+		}
+	}
+	select {
+	case got := <-sub.C:
+		t.Errorf("unexpected change %+v", got)
+	default:
+	}
+}
+
+func TestHub_PaymentSaved(t *testing.T) {
+	hub := statushub.New(time.Minute)
+	p, err := domain.NewPayment(uuid.New(), "user-1", 100, "usd")
+	if err != nil {
+		t.Fatalf("new payment: %v", err)
+	}
+	sub, _ := hub.Subscribe(p.ID)
+	defer sub.Close()
+
+	hub.PaymentSaved(p)
+	_ = p.MarkFailed(domain.FailureCardDeclined, "do_not_honor")
+	hub.PaymentSaved(p)
+
+	if got := <-sub.C; got.Status != domain.PaymentStatusPending || got.UserID != "user-1" {
+		t.Errorf("first change = %+v", got)
+	}
+	if got := <-sub.C; got.Status != domain.PaymentStatusFailed || got.FailureCode != domain.FailureCardDeclined || !got.At.Equal(*p.UpdatedAt) {
+		t.Errorf("second change = %+v", got)
+	}
+}
+
+func TestHub_CloseEndsSubscriptions(t *testing.T) {
+	hub := statushub.New(time.Minute)
+	sub, _ := hub.Subscribe(uuid.New())
+	defer sub.Close()
+
+	hub.Close()
+	if _, ok := <-sub.C; ok {
+		t.Error("subscriptions end when the hub is closed")
+	}
+	late, _ := hub.Subscribe(uuid.New())
+	defer late.Close()
+	if _, ok := <-late.C; ok {
+		t.Error("subscriptions after Close end at once")
+	}
+}
@@ -0,0 +1,116 @@
+package statushub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// notifyChannel is the Postgres channel status changes are relayed on.
+const notifyChannel = "payment_status_changes"
+
+const (
+	notifyTimeout    = 2 * time.Second
+	minListenBackoff = time.Second
+	maxListenBackoff = 30 * time.Second
+)
+
+// notification is the payload of a relayed change.
+type notification struct {
+	Origin string `json:"origin"` // replica that made the change
+	Change Change `json:"change"`
+}
+
+// PostgresRelay forwards the changes published on this replica's hub to
+// the hubs of the other replicas with NOTIFY, and delivers theirs to this
+// hub with LISTEN. Changes made while the listening connection is down are
+// not replayed; streams catch up when their client reconnects.
+type PostgresRelay struct {
+	pool   *pgxpool.Pool
+	hub    *Hub
+	origin string
+	logger *slog.Logger
+}
+
+// NewPostgresRelay attaches a relay to the hub. Run must be started for
+// the changes of other replicas to arrive.
+func NewPostgresRelay(pool *pgxpool.Pool, hub *Hub, logger *slog.Logger) *PostgresRelay {
+	r := &PostgresRelay{pool: pool, hub: hub, origin: uuid.NewString(), logger: logger}
+	hub.mu.Lock()
+	hub.relay = r.notify
+	hub.mu.Unlock()
+	return r
+}
+
+// notify sends a local change to the other replicas. The change is already
+// committed and delivered locally, so a failure is only logged.
+func (r *PostgresRelay) notify(c Change) {
+	payload, err := json.Marshal(notification{Origin: r.origin, Change: c})
+	if err != nil {
+		r.logger.Error("failed to encode payment status change", "paymentId", c.PaymentID, "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if _, err := r.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, notifyChannel, string(payload)); err != nil {
+		r.logger.Error("failed to relay payment status change", "paymentId", c.PaymentID, "error", err)
+	}
+}
+
+// Run listens for the changes of the other replicas until ctx is
+// cancelled, reconnecting with backoff when the connection is lost.
+func (r *PostgresRelay) Run(ctx context.Context) {
+	backoff := minListenBackoff
+	for {
+		started := time.Now()
+		err := r.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > maxListenBackoff {
+			backoff = minListenBackoff // it was listening: a new outage
+		}
+		r.logger.Error("payment status relay disconnected", "error", err, "retryIn", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxListenBackoff)
+	}
+}
+
+func (r *PostgresRelay) listen(ctx context.Context) error {
+	pooled, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire connection: %w", err)
+	}
+	// The connection keeps listening: it must not go back to the pool
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	r.logger.Info("payment status relay listening", "channel", notifyChannel)
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var msg notification
+		if err := json.Unmarshal([]byte(n.Payload), &msg); err != nil {
+			r.logger.Warn("ignoring malformed payment status notification", "error", err)
+			continue
+		}
+		if msg.Origin != r.origin {
+			r.hub.deliver(msg.Change)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package statushub
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// Event is the data of a status event on the stream.
+type Event struct {
+	PaymentID      string    `json:"paymentId"`
+	Status         string    `json:"status"`
+	FailureCode    string    `json:"failureCode,omitempty"`
+	FailureMessage string    `json:"failureMessage,omitempty"` // patient-facing, localized
+	OccurredAt     time.Time `json:"occurredAt"`
+}
+
+// StreamOptions configures a status stream.
+type StreamOptions struct {
+	Heartbeat time.Duration // interval of the comments keeping idle connections open
+	Retry     time.Duration // reconnection delay suggested to the client
+}
+
+// StreamHandler streams the status of the payment in the :id parameter as
+// Server-Sent Events:
+//
+//	id: Processing
+//	event: status
+//	data: {"paymentId":"...","status":"Processing","occurredAt":"..."}
+//
+// The first event is the current status; a client resuming with
+// Last-Event-ID gets only the statuses after that one. The stream ends
+// after a final status (Completed or Failed), and a client resuming after
+// one is answered 204 No Content, which stops EventSource from reconnecting.
+// Callers must be authorized for the payment before the handler runs.
+func StreamHandler(hub *Hub, current Current, opts StreamOptions, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			problem.Write(c, problem.Invalid("id", "uuid", "invalid payment id"))
+			return
+		}
+		sent := 0 // stage of the last status the client has
+		if last, ok := domain.ParsePaymentStatus(c.GetHeader("Last-Event-ID")); ok {
+			sent = last.Stage()
+			if last.IsFinal() {
+				c.Status(http.StatusNoContent)
+				return
+			}
+		}
+
+		sub, backlog, err := hub.Follow(c.Request.Context(), id, current)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		defer sub.Close()
+
+		// The stream outlives the server's write timeout
+		_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no") // no proxy buffering
+		c.Status(http.StatusOK)
+		fmt.Fprintf(c.Writer, "retry: %d\n\n", opts.Retry.Milliseconds())
+
+		language := domain.NegotiateLanguage(c.GetHeader("Accept-Language"))
+		// send writes a change the client does not have yet and reports
+		// whether the stream goes on
+		send := func(change Change) bool {
+			if change.Status.Stage() <= sent {
+				return true
+			}
+			if err := writeEvent(c.Writer, change, language); err != nil {
+				logger.Debug("payment status stream closed", "paymentId", id, "error", err)
+				return false
+			}
+			sent = change.Status.Stage()
+			return !change.Status.IsFinal()
+		}
+
+		for _, change := range backlog {
+			if !send(change) {
+				return
+			}
+		}
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(opts.Heartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				c.Writer.Flush()
+			case change, ok := <-sub.C:
+				if !ok {
+					return // shutting down: the client resumes with Last-Event-ID
+				}
+				more := send(change)
+				c.Writer.Flush()
+				if !more {
+					return
+				}
+			}
+		}
+	}
+}
+
+func writeEvent(w gin.ResponseWriter, c Change, language string) error {
+	data, err := json.Marshal(Event{
+		PaymentID:      c.PaymentID.String(),
+		Status:         c.Status.String(),
+		FailureCode:    string(c.FailureCode),
+		FailureMessage: failureMessage(c.FailureCode, language),
+		OccurredAt:     c.At,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: status\ndata: %s\n\n", c.ID(), data)
+	return err
+}
+
+func failureMessage(code domain.FailureCode, language string) string {
+	if code == "" {
+		return ""
+	}
+	return code.Message(language)
+}
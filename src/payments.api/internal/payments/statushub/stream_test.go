@@ -0,0 +1,192 @@
+package statushub_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/payments/domain"
+	"github.com/smart-health/payments-api/internal/payments/statushub"
+)
+
+func streamServer(t *testing.T, hub *statushub.Hub, current statushub.Change) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/payments/:id/events", statushub.StreamHandler(hub,
+		func(context.Context, uuid.UUID) (statushub.Change, error) { return current, nil },
+		statushub.StreamOptions{Heartbeat: 20 * time.Millisecond, Retry: time.Second},
+		slog.New(slog.NewTextHandler(io.Discard, nil))))
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func open(t *testing.T, srv *httptest.Server, id uuid.UUID, lastEventID string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/payments/"+id.String()+"/events", nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// frame reads the next SSE frame: the lines up to a blank line.
+func frame(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v (after %q)", err, lines)
+		}
+		if line == "\n" {
+			return strings.Join(lines, "")
+		}
+		lines = append(lines, line)
+	}
+}
+
+// nextEvent skips comments and the retry field and decodes the next event.
+func nextEvent(t *testing.T, r *bufio.Reader) (string, statushub.Event) {
+	t.Helper()
+	for {
+		f := frame(t, r)
+		if strings.HasPrefix(f, ":") || strings.HasPrefix(f, "retry:") {
+			continue
+		}
+		var id string
+		var event statushub.Event
+		for _, line := range strings.Split(strings.TrimSpace(f), "\n") {
+			name, value, _ := strings.Cut(line, ": ")
+			switch name {
+			case "id":
+				id = value
+			case "event":
+				if value != "status" {
+					t.Errorf("event type = %q, want status", value)
+				}
+			case "data":
+				if err := json.Unmarshal([]byte(value), &event); err != nil {
+					t.Fatalf("decode %q: %v", value, err)
+				}
+			}
+		}
+		return id, event
+	}
+}
+
+func expectEnd(t *testing.T, r *bufio.Reader) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		if !strings.HasPrefix(line, ":") && line != "\n" {
+			t.Fatalf("stream goes on with %q, want it to end", line)
+		}
+	}
+}
+
+func TestStream_PushesStatusChanges(t *testing.T) {
+	hub := statushub.New(time.Minute)
+	id := uuid.New()
+	srv := streamServer(t, hub, change(id, domain.PaymentStatusPending))
+
+	resp, r := open(t, srv, id, "")
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if eventID, e := nextEvent(t, r); eventID != "Pending" || e.Status != "Pending" || e.PaymentID != id.String() {
+		t.Fatalf("first event = %s %+v, want the current status", eventID, e)
+	}
+
+	// Idle streams are kept open with comments
+	if f := frame(t, r); f != ": heartbeat\n" {
+		t.Errorf("frame = %q, want a heartbeat", f)
+	}
+
+	hub.Publish(change(id, domain.PaymentStatusProcessing))
+	failed := change(id, domain.PaymentStatusFailed)
+	failed.FailureCode = domain.FailureInsufficientFunds
+	hub.Publish(failed)
+
+	if eventID, e := nextEvent(t, r); eventID != "Processing" || e.Status != "Processing" {
+		t.Errorf("event = %s %+v, want Processing", eventID, e)
+	}
+	eventID, e := nextEvent(t, r)
+	if eventID != "Failed" || e.FailureCode != "insufficient_funds" || e.FailureMessage == "" {
+		t.Errorf("event = %s %+v, want Failed with its message", eventID, e)
+	}
+	expectEnd(t, r)
+}
+
+func TestStream_ResumesAfterLastEventID(t *testing.T) {
+	hub := statushub.New(time.Minute)
+	id := uuid.New()
+	hub.Publish(change(id, domain.PaymentStatusPending))
+	hub.Publish(change(id, domain.PaymentStatusRequiresAction))
+	hub.Publish(change(id, domain.PaymentStatusProcessing))
+	srv := streamServer(t, hub, change(id, domain.PaymentStatusProcessing))
+
+	_, r := open(t, srv, id, "Pending")
+	for _, want := range []string{"RequiresAction", "Processing"} {
+		if eventID, _ := nextEvent(t, r); eventID != want {
+			t.Errorf("event = %s, want %s", eventID, want)
+		}
+	}
+}
+
+func TestStream_CatchesUpWithChangesItMissed(t *testing.T) {
+	// Another replica completed the payment and the notification was lost
+	hub := statushub.New(time.Minute)
+	id := uuid.New()
+	hub.Publish(change(id, domain.PaymentStatusPending))
+	srv := streamServer(t, hub, change(id, domain.PaymentStatusCompleted))
+
+	_, r := open(t, srv, id, "Pending")
+	if eventID, _ := nextEvent(t, r); eventID != "Completed" {
+		t.Errorf("event = %s, want Completed", eventID)
+	}
+	expectEnd(t, r)
+}
+
+func TestStream_EndsForFinalStatuses(t *testing.T) {
+	id := uuid.New()
+	srv := streamServer(t, statushub.New(time.Minute), change(id, domain.PaymentStatusCompleted))
+
+	resp, _ := open(t, srv, id, "Completed")
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("resuming after Completed: status = %d, want 204", resp.StatusCode)
+	}
+}
+
+func TestStream_InvalidID(t *testing.T) {
+	srv := streamServer(t, statushub.New(time.Minute), statushub.Change{})
+	resp, err := http.Get(srv.URL + "/payments/not-a-uuid/events")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
@@ -0,0 +1,244 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidFeeAmount is returned when a fee schedule amount is invalid.
+var ErrInvalidFeeAmount = errors.New("fee amount must be greater than zero")
+
+// ErrInvalidFeeCurrency is returned when a fee schedule currency is not a 3-letter ISO code.
+var ErrInvalidFeeCurrency = errors.New("currency must be a 3-letter ISO code")
+
+// ErrInvalidEffectiveWindow is returned when EffectiveTo is not after EffectiveFrom.
+var ErrInvalidEffectiveWindow = errors.New("effectiveTo must be after effectiveFrom")
+
+// ErrFeeScheduleNotFound is returned when a fee schedule record cannot be found.
+type ErrFeeScheduleNotFound struct {
+	ID uuid.UUID
+}
+
+func (e *ErrFeeScheduleNotFound) Error() string {
+	return fmt.Sprintf("fee schedule %s was not found", e.ID)
+}
+
+// ErrNoFeeSchedule is returned when no fee schedule applies to a visit.
+type ErrNoFeeSchedule struct {
+	Key Key
+}
+
+func (e *ErrNoFeeSchedule) Error() string {
+	if e.Key.ItemCode != "" {
+		return fmt.Sprintf("no fee schedule applies to item %q for doctor %q, specialty %q, visit type %q in %s",
+			e.Key.ItemCode, e.Key.DoctorID, e.Key.Specialty, e.Key.VisitType, e.Key.Currency)
+	}
+	return fmt.Sprintf("no fee schedule applies to doctor %q, specialty %q, visit type %q in %s",
+		e.Key.DoctorID, e.Key.Specialty, e.Key.VisitType, e.Key.Currency)
+}
+
+// ErrAmountMismatch is returned when a supplied amount differs from the
+// fee schedule price by more than the configured tolerance.
+type ErrAmountMismatch struct {
+	Supplied float64
+	Expected float64
+	Currency string
+}
+
+func (e *ErrAmountMismatch) Error() string {
+	return fmt.Sprintf("supplied amount %.2f %s does not match fee schedule price %.2f %s",
+		e.Supplied, e.Currency, e.Expected, e.Currency)
+}
+
+// Key identifies the visit being priced, or with an ItemCode a line item of
+// the visit. Empty fields are unknown and only match wildcard fee schedules.
+type Key struct {
+	DoctorID  string
+	Specialty string
+	VisitType string
+	Currency  string
+	ItemCode  string // line item code, e.g. a lab test ("" = the consultation)
+}
+
+// -----------------------------------------------------------------------
+// FeeSchedule
+//
+// Architectural Decision: A fee schedule prices a class of visits. Empty
+// DoctorID, Specialty and VisitType act as wildcards, so a clinic can keep
+// a default price per currency, override it per specialty or visit type,
+// and override again per doctor. When several schedules apply, the most
+// specific one wins (doctor > specialty > visit type), and among equally
+// specific schedules the one that became effective most recently wins.
+//
+// A schedule with an ItemCode prices that line item (a lab test, a
+// medicine) instead of the consultation. The item code is never a
+// wildcard: a consultation price must not price a lab test, nor the other
+// way round, and a line item without a schedule of its own is unpriced.
+// -----------------------------------------------------------------------
+
+// FeeSchedule is a price for a class of visits within an effective window.
+type FeeSchedule struct {
+	ID            uuid.UUID
+	DoctorID      string
+	Specialty     string
+	VisitType     string
+	ItemCode      string // "" = prices the consultation
+	Currency      string
+	Amount        float64
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time // nil = open-ended
+	CreatedAt     time.Time
+	UpdatedAt     *time.Time
+}
+
+// NewFeeSchedule is the factory function – enforces invariants on creation.
+func NewFeeSchedule(key Key, amount float64, effectiveFrom time.Time, effectiveTo *time.Time) (*FeeSchedule, error) {
+	s := &FeeSchedule{
+		ID:            uuid.New(),
+		DoctorID:      key.DoctorID,
+		Specialty:     key.Specialty,
+		VisitType:     key.VisitType,
+		ItemCode:      key.ItemCode,
+		Currency:      strings.ToLower(key.Currency),
+		Amount:        amount,
+		EffectiveFrom: effectiveFrom.UTC(),
+		EffectiveTo:   utcPtr(effectiveTo),
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reprice changes the amount and effective window of the schedule.
+func (s *FeeSchedule) Reprice(amount float64, effectiveFrom time.Time, effectiveTo *time.Time) error {
+	updated := *s
+	updated.Amount = amount
+	updated.EffectiveFrom = effectiveFrom.UTC()
+	updated.EffectiveTo = utcPtr(effectiveTo)
+	if err := updated.validate(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	updated.UpdatedAt = &now
+	*s = updated
+	return nil
+}
+
+// Retire ends the schedule's effective window at the given time.
+// Retired schedules are kept so that historical prices remain explainable.
+func (s *FeeSchedule) Retire(at time.Time) {
+	at = at.UTC()
+	if s.EffectiveTo != nil && s.EffectiveTo.Before(at) {
+		return
+	}
+	if at.Before(s.EffectiveFrom) {
+		at = s.EffectiveFrom
+	}
+	s.EffectiveTo = &at
+	now := time.Now().UTC()
+	s.UpdatedAt = &now
+}
+
+// AppliesTo reports whether the schedule prices the given visit at the given time.
+func (s *FeeSchedule) AppliesTo(key Key, at time.Time) bool {
+	if !strings.EqualFold(s.Currency, key.Currency) || !strings.EqualFold(s.ItemCode, key.ItemCode) {
+		return false
+	}
+	if s.DoctorID != "" && s.DoctorID != key.DoctorID {
+		return false
+	}
+	if s.Specialty != "" && !strings.EqualFold(s.Specialty, key.Specialty) {
+		return false
+	}
+	if s.VisitType != "" && !strings.EqualFold(s.VisitType, key.VisitType) {
+		return false
+	}
+	return s.ActiveAt(at)
+}
+
+// ActiveAt reports whether the effective window contains the given time.
+func (s *FeeSchedule) ActiveAt(at time.Time) bool {
+	if at.Before(s.EffectiveFrom) {
+		return false
+	}
+	return s.EffectiveTo == nil || at.Before(*s.EffectiveTo)
+}
+
+// specificity ranks how narrowly the schedule is targeted.
+func (s *FeeSchedule) specificity() int {
+	rank := 0
+	if s.DoctorID != "" {
+		rank += 4
+	}
+	if s.Specialty != "" {
+		rank += 2
+	}
+	if s.VisitType != "" {
+		rank++
+	}
+	return rank
+}
+
+func (s *FeeSchedule) validate() error {
+	if s.Amount <= 0 {
+		return ErrInvalidFeeAmount
+	}
+	if len(s.Currency) != 3 {
+		return ErrInvalidFeeCurrency
+	}
+	if s.EffectiveTo != nil && !s.EffectiveTo.After(s.EffectiveFrom) {
+		return ErrInvalidEffectiveWindow
+	}
+	return nil
+}
+
+// SelectBest returns the schedule that prices the visit at the given time,
+// or nil when none applies.
+func SelectBest(schedules []*FeeSchedule, key Key, at time.Time) *FeeSchedule {
+	var best *FeeSchedule
+	for _, s := range schedules {
+		if !s.AppliesTo(key, at) {
+			continue
+		}
+		if best == nil ||
+			s.specificity() > best.specificity() ||
+			(s.specificity() == best.specificity() && s.EffectiveFrom.After(best.EffectiveFrom)) {
+			best = s
+		}
+	}
+	return best
+}
+
+// PriceChange is an entry in a fee schedule's price history.
+type PriceChange struct {
+	ID            uuid.UUID
+	FeeScheduleID uuid.UUID
+	Action        string // "created", "updated" or "retired"
+	Amount        float64
+	Currency      string
+	EffectiveFrom time.Time
+	EffectiveTo   *time.Time
+	ChangedAt     time.Time
+}
+
+// Price history actions.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionRetired = "retired"
+)
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,107 @@
+package pricing_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/pricing"
+)
+
+func mustSchedule(t *testing.T, key pricing.Key, amount float64, from time.Time, to *time.Time) *pricing.FeeSchedule {
+	t.Helper()
+	s, err := pricing.NewFeeSchedule(key, amount, from, to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return s
+}
+
+func TestNewFeeSchedule_InvalidWindow(t *testing.T) {
+	from := time.Now()
+	to := from.Add(-time.Hour)
+	if _, err := pricing.NewFeeSchedule(pricing.Key{Currency: "usd"}, 50, from, &to); err == nil {
+		t.Fatal("expected error for effectiveTo before effectiveFrom")
+	}
+}
+
+func TestNewFeeSchedule_InvalidAmount(t *testing.T) {
+	if _, err := pricing.NewFeeSchedule(pricing.Key{Currency: "usd"}, 0, time.Now(), nil); err == nil {
+		t.Fatal("expected error for zero amount")
+	}
+}
+
+func TestSelectBest_MostSpecificWins(t *testing.T) {
+	from := time.Now().Add(-24 * time.Hour)
+	def := mustSchedule(t, pricing.Key{Currency: "usd"}, 50, from, nil)
+	specialty := mustSchedule(t, pricing.Key{Specialty: "cardiology", Currency: "usd"}, 80, from, nil)
+	doctor := mustSchedule(t, pricing.Key{DoctorID: "doc-1", Currency: "usd"}, 120, from, nil)
+	all := []*pricing.FeeSchedule{def, specialty, doctor}
+
+	key := pricing.Key{DoctorID: "doc-1", Specialty: "Cardiology", Currency: "USD"}
+	if best := pricing.SelectBest(all, key, time.Now()); best != doctor {
+		t.Errorf("expected doctor schedule, got %+v", best)
+	}
+
+	key.DoctorID = "doc-2"
+	if best := pricing.SelectBest(all, key, time.Now()); best != specialty {
+		t.Errorf("expected specialty schedule, got %+v", best)
+	}
+
+	key.Specialty = "dermatology"
+	if best := pricing.SelectBest(all, key, time.Now()); best != def {
+		t.Errorf("expected default schedule, got %+v", best)
+	}
+}
+
+func TestSelectBest_ItemCodeIsNeverAWildcard(t *testing.T) {
+	from := time.Now().Add(-24 * time.Hour)
+	consultation := mustSchedule(t, pricing.Key{Currency: "usd"}, 50, from, nil)
+	bloodTest := mustSchedule(t, pricing.Key{Currency: "usd", ItemCode: "LAB-CBC"}, 25, from, nil)
+	all := []*pricing.FeeSchedule{consultation, bloodTest}
+
+	if best := pricing.SelectBest(all, pricing.Key{Currency: "usd"}, time.Now()); best != consultation {
+		t.Errorf("expected the consultation schedule, got %+v", best)
+	}
+	if best := pricing.SelectBest(all, pricing.Key{Currency: "usd", ItemCode: "lab-cbc"}, time.Now()); best != bloodTest {
+		t.Errorf("expected the item schedule, got %+v", best)
+	}
+	if best := pricing.SelectBest(all, pricing.Key{Currency: "usd", ItemCode: "MED-IBU"}, time.Now()); best != nil {
+		t.Errorf("expected no schedule for an unpriced item, got %+v", best)
+	}
+}
+
+func TestSelectBest_RespectsEffectiveWindow(t *testing.T) {
+	now := time.Now()
+	oldTo := now.Add(-time.Hour)
+	old := mustSchedule(t, pricing.Key{Currency: "usd"}, 50, now.Add(-48*time.Hour), &oldTo)
+	current := mustSchedule(t, pricing.Key{Currency: "usd"}, 60, now.Add(-time.Hour), nil)
+	future := mustSchedule(t, pricing.Key{Currency: "usd"}, 70, now.Add(time.Hour), nil)
+	all := []*pricing.FeeSchedule{old, current, future}
+
+	if best := pricing.SelectBest(all, pricing.Key{Currency: "usd"}, now); best != current {
+		t.Errorf("expected current schedule, got %+v", best)
+	}
+	if best := pricing.SelectBest(all, pricing.Key{Currency: "usd"}, now.Add(-2*time.Hour)); best != old {
+		t.Errorf("expected old schedule for past date, got %+v", best)
+	}
+	if best := pricing.SelectBest(all, pricing.Key{Currency: "eur"}, now); best != nil {
+		t.Errorf("expected no schedule for other currency, got %+v", best)
+	}
+}
+
+func TestFeeSchedule_Retire(t *testing.T) {
+	s := mustSchedule(t, pricing.Key{Currency: "usd"}, 50, time.Now().Add(-time.Hour), nil)
+	s.Retire(time.Now())
+	if s.ActiveAt(time.Now().Add(time.Second)) {
+		t.Error("expected retired schedule to be inactive")
+	}
+}
+
+func TestWithinTolerance(t *testing.T) {
+	if !pricing.WithinTolerance(100.00, 100.01, 0.01) {
+		t.Error("expected 100.00 and 100.01 to be within 0.01")
+	}
+	if pricing.WithinTolerance(100.00, 100.05, 0.01) {
+		t.Error("expected 100.00 and 100.05 to differ by more than 0.01")
+	}
+}
@@ -0,0 +1,268 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository defines the persistence contract for fee schedules.
+type Repository interface {
+	// Create persists a new fee schedule and records it in the price history.
+	Create(ctx context.Context, schedule *FeeSchedule) error
+	// Update persists changes to a fee schedule and records action in the price history.
+	Update(ctx context.Context, schedule *FeeSchedule, action string) error
+	FindByID(ctx context.Context, id uuid.UUID) (*FeeSchedule, error)
+	// List returns fee schedules matching the filter, newest first.
+	List(ctx context.Context, filter ListFilter) ([]*FeeSchedule, error)
+	// FindApplicable returns all schedules that may price the visit at the given time.
+	FindApplicable(ctx context.Context, key Key, at time.Time) ([]*FeeSchedule, error)
+	// History returns the price history of a fee schedule, oldest first.
+	History(ctx context.Context, id uuid.UUID) ([]*PriceChange, error)
+}
+
+// ListFilter narrows the fee schedules returned by List. Zero values are ignored.
+type ListFilter struct {
+	DoctorID  string
+	Specialty string
+	VisitType string
+	ItemCode  string
+	Currency  string
+	ActiveAt  *time.Time
+}
+
+// PostgresRepository implements Repository using PostgreSQL.
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new PostgreSQL-backed fee schedule repository.
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+const feeScheduleColumns = `id, COALESCE(doctor_id, ''), COALESCE(specialty, ''), COALESCE(visit_type, ''),
+	COALESCE(item_code, ''), currency, amount, effective_from, effective_to, created_at, updated_at`
+
+// Create persists a new fee schedule and its first price history entry in one transaction.
+func (r *PostgresRepository) Create(ctx context.Context, s *FeeSchedule) error {
+	return r.withTransaction(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO fee_schedules (id, doctor_id, specialty, visit_type, item_code, currency, amount, effective_from, effective_to, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			s.ID,
+			nilIfEmpty(s.DoctorID),
+			nilIfEmpty(s.Specialty),
+			nilIfEmpty(s.VisitType),
+			nilIfEmpty(s.ItemCode),
+			s.Currency,
+			s.Amount,
+			s.EffectiveFrom,
+			s.EffectiveTo,
+			s.CreatedAt,
+			s.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert fee schedule: %w", err)
+		}
+		return insertPriceChange(ctx, tx, s, ActionCreated)
+	})
+}
+
+// Update persists a changed fee schedule and its price history entry in one transaction.
+func (r *PostgresRepository) Update(ctx context.Context, s *FeeSchedule, action string) error {
+	return r.withTransaction(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx, `
+			UPDATE fee_schedules SET
+				amount = $2,
+				effective_from = $3,
+				effective_to = $4,
+				updated_at = $5
+			WHERE id = $1`,
+			s.ID,
+			s.Amount,
+			s.EffectiveFrom,
+			s.EffectiveTo,
+			s.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("update fee schedule: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return &ErrFeeScheduleNotFound{ID: s.ID}
+		}
+		return insertPriceChange(ctx, tx, s, action)
+	})
+}
+
+// FindByID retrieves a fee schedule by its primary key.
+func (r *PostgresRepository) FindByID(ctx context.Context, id uuid.UUID) (*FeeSchedule, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+feeScheduleColumns+` FROM fee_schedules WHERE id = $1`, id)
+	return scanFeeSchedule(row)
+}
+
+// List retrieves fee schedules matching the filter.
+func (r *PostgresRepository) List(ctx context.Context, filter ListFilter) ([]*FeeSchedule, error) {
+	var conditions []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.DoctorID != "" {
+		add("doctor_id = $%d", filter.DoctorID)
+	}
+	if filter.Specialty != "" {
+		add("LOWER(specialty) = LOWER($%d)", filter.Specialty)
+	}
+	if filter.VisitType != "" {
+		add("LOWER(visit_type) = LOWER($%d)", filter.VisitType)
+	}
+	if filter.ItemCode != "" {
+		add("LOWER(item_code) = LOWER($%d)", filter.ItemCode)
+	}
+	if filter.Currency != "" {
+		add("currency = LOWER($%d)", filter.Currency)
+	}
+	if filter.ActiveAt != nil {
+		add("effective_from <= $%d", *filter.ActiveAt)
+		add("(effective_to IS NULL OR effective_to > $%d)", *filter.ActiveAt)
+	}
+
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY effective_from DESC, created_at DESC`
+
+	return r.query(ctx, query, args...)
+}
+
+// FindApplicable retrieves the candidate schedules for a visit; SelectBest picks the winner.
+func (r *PostgresRepository) FindApplicable(ctx context.Context, key Key, at time.Time) ([]*FeeSchedule, error) {
+	return r.query(ctx, `
+		SELECT `+feeScheduleColumns+`
+		FROM fee_schedules
+		WHERE currency = LOWER($1)
+		  AND effective_from <= $2
+		  AND (effective_to IS NULL OR effective_to > $2)
+		  AND (doctor_id IS NULL OR doctor_id = $3)
+		  AND (specialty IS NULL OR LOWER(specialty) = LOWER($4))
+		  AND (visit_type IS NULL OR LOWER(visit_type) = LOWER($5))
+		  AND LOWER(COALESCE(item_code, '')) = LOWER($6)`,
+		key.Currency, at, key.DoctorID, key.Specialty, key.VisitType, key.ItemCode)
+}
+
+// History retrieves the price history of a fee schedule.
+func (r *PostgresRepository) History(ctx context.Context, id uuid.UUID) ([]*PriceChange, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT id, fee_schedule_id, action, amount, currency, effective_from, effective_to, changed_at
+		FROM fee_schedule_history
+		WHERE fee_schedule_id = $1
+		ORDER BY changed_at ASC`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query price history: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []*PriceChange
+	for rows.Next() {
+		var c PriceChange
+		if err := rows.Scan(
+			&c.ID, &c.FeeScheduleID, &c.Action, &c.Amount, &c.Currency,
+			&c.EffectiveFrom, &c.EffectiveTo, &c.ChangedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan price change: %w", err)
+		}
+		changes = append(changes, &c)
+	}
+	return changes, rows.Err()
+}
+
+func (r *PostgresRepository) query(ctx context.Context, query string, args ...any) ([]*FeeSchedule, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query fee schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*FeeSchedule
+	for rows.Next() {
+		s, err := scanFeeSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+func (r *PostgresRepository) withTransaction(ctx context.Context, fn func(pgx.Tx) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func insertPriceChange(ctx context.Context, tx pgx.Tx, s *FeeSchedule, action string) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO fee_schedule_history (id, fee_schedule_id, action, amount, currency, effective_from, effective_to, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		uuid.New(),
+		s.ID,
+		action,
+		s.Amount,
+		s.Currency,
+		s.EffectiveFrom,
+		s.EffectiveTo,
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert price history: %w", err)
+	}
+	return nil
+}
+
+func scanFeeSchedule(row pgx.Row) (*FeeSchedule, error) {
+	var s FeeSchedule
+	err := row.Scan(
+		&s.ID,
+		&s.DoctorID,
+		&s.Specialty,
+		&s.VisitType,
+		&s.ItemCode,
+		&s.Currency,
+		&s.Amount,
+		&s.EffectiveFrom,
+		&s.EffectiveTo,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan fee schedule: %w", err)
+	}
+	return &s, nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,334 @@
+package pricing
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/openapi"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// FeeScheduleResult is the read model returned by the fee schedule endpoints.
+type FeeScheduleResult struct {
+	ID            string     `json:"id"`
+	DoctorID      string     `json:"doctorId,omitempty"`
+	Specialty     string     `json:"specialty,omitempty"`
+	VisitType     string     `json:"visitType,omitempty"`
+	ItemCode      string     `json:"itemCode,omitempty"`
+	Currency      string     `json:"currency"`
+	Amount        float64    `json:"amount"`
+	EffectiveFrom time.Time  `json:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+}
+
+// PriceChangeResult is a price history entry returned by the history endpoint.
+type PriceChangeResult struct {
+	Action        string     `json:"action"`
+	Amount        float64    `json:"amount"`
+	Currency      string     `json:"currency"`
+	EffectiveFrom time.Time  `json:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo,omitempty"`
+	ChangedAt     time.Time  `json:"changedAt"`
+}
+
+// QuoteResult is returned by the quote endpoint.
+type QuoteResult struct {
+	FeeScheduleID string  `json:"feeScheduleId"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+}
+
+type feeScheduleRequest struct {
+	DoctorID      string     `json:"doctorId"`
+	Specialty     string     `json:"specialty"`
+	VisitType     string     `json:"visitType"`
+	ItemCode      string     `json:"itemCode"      binding:"max=64"`
+	Currency      string     `json:"currency"      binding:"required,len=3"`
+	Amount        float64    `json:"amount"        binding:"required,gt=0"`
+	EffectiveFrom *time.Time `json:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo"`
+}
+
+type repriceRequest struct {
+	Amount        float64    `json:"amount"        binding:"required,gt=0"`
+	EffectiveFrom *time.Time `json:"effectiveFrom"`
+	EffectiveTo   *time.Time `json:"effectiveTo"`
+}
+
+// RegisterRoutes mounts the fee schedule administration endpoints on the group.
+//
+//	GET    /fee-schedules              – list (filters: doctorId, specialty, visitType, itemCode, currency, activeAt)
+//	POST   /fee-schedules              – create
+//	GET    /fee-schedules/:id          – get by ID
+//	PUT    /fee-schedules/:id          – change amount / effective window
+//	DELETE /fee-schedules/:id          – retire (ends the effective window now)
+//	GET    /fee-schedules/:id/history  – price history
+//	GET    /quote                      – price a visit or line item (doctorId, specialty, visitType, itemCode, currency, at)
+func RegisterRoutes(rg *gin.RouterGroup, repo Repository, service Service) {
+	rg.GET("/fee-schedules", func(c *gin.Context) {
+		filter := ListFilter{
+			DoctorID:  c.Query("doctorId"),
+			Specialty: c.Query("specialty"),
+			VisitType: c.Query("visitType"),
+			ItemCode:  c.Query("itemCode"),
+			Currency:  c.Query("currency"),
+		}
+		if v := c.Query("activeAt"); v != "" {
+			at, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				problem.Write(c, problem.Invalid("activeAt", "datetime", "activeAt must be an RFC 3339 timestamp"))
+				return
+			}
+			filter.ActiveAt = &at
+		}
+
+		schedules, err := repo.List(c.Request.Context(), filter)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		results := make([]*FeeScheduleResult, 0, len(schedules))
+		for _, s := range schedules {
+			results = append(results, toResult(s))
+		}
+		c.JSON(http.StatusOK, results)
+	})
+
+	rg.POST("/fee-schedules", func(c *gin.Context) {
+		var req feeScheduleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		from := time.Now().UTC()
+		if req.EffectiveFrom != nil {
+			from = *req.EffectiveFrom
+		}
+		schedule, err := NewFeeSchedule(Key{
+			DoctorID:  req.DoctorID,
+			Specialty: req.Specialty,
+			VisitType: req.VisitType,
+			ItemCode:  req.ItemCode,
+			Currency:  req.Currency,
+		}, req.Amount, from, req.EffectiveTo)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		if err := repo.Create(c.Request.Context(), schedule); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, toResult(schedule))
+	})
+
+	rg.GET("/fee-schedules/:id", func(c *gin.Context) {
+		schedule, ok := loadSchedule(c, repo)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, toResult(schedule))
+	})
+
+	rg.PUT("/fee-schedules/:id", func(c *gin.Context) {
+		var req repriceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		schedule, ok := loadSchedule(c, repo)
+		if !ok {
+			return
+		}
+
+		from := schedule.EffectiveFrom
+		if req.EffectiveFrom != nil {
+			from = *req.EffectiveFrom
+		}
+		if err := schedule.Reprice(req.Amount, from, req.EffectiveTo); err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		if err := repo.Update(c.Request.Context(), schedule, ActionUpdated); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toResult(schedule))
+	})
+
+	rg.DELETE("/fee-schedules/:id", func(c *gin.Context) {
+		schedule, ok := loadSchedule(c, repo)
+		if !ok {
+			return
+		}
+
+		schedule.Retire(time.Now())
+		if err := repo.Update(c.Request.Context(), schedule, ActionRetired); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toResult(schedule))
+	})
+
+	rg.GET("/fee-schedules/:id/history", func(c *gin.Context) {
+		schedule, ok := loadSchedule(c, repo)
+		if !ok {
+			return
+		}
+
+		changes, err := repo.History(c.Request.Context(), schedule.ID)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		results := make([]PriceChangeResult, 0, len(changes))
+		for _, ch := range changes {
+			results = append(results, PriceChangeResult{
+				Action:        ch.Action,
+				Amount:        ch.Amount,
+				Currency:      ch.Currency,
+				EffectiveFrom: ch.EffectiveFrom,
+				EffectiveTo:   ch.EffectiveTo,
+				ChangedAt:     ch.ChangedAt,
+			})
+		}
+		c.JSON(http.StatusOK, results)
+	})
+
+	rg.GET("/quote", func(c *gin.Context) {
+		key := Key{
+			DoctorID:  c.Query("doctorId"),
+			Specialty: c.Query("specialty"),
+			VisitType: c.Query("visitType"),
+			ItemCode:  c.Query("itemCode"),
+			Currency:  c.Query("currency"),
+		}
+		if len(key.Currency) != 3 {
+			problem.Write(c, ErrInvalidFeeCurrency)
+			return
+		}
+
+		at := time.Now().UTC()
+		if v := c.Query("at"); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				problem.Write(c, problem.Invalid("at", "datetime", "at must be an RFC 3339 timestamp"))
+				return
+			}
+			at = parsed
+		}
+
+		quote, err := service.Quote(c.Request.Context(), key, at)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, QuoteResult{
+			FeeScheduleID: quote.FeeScheduleID.String(),
+			Amount:        quote.Amount,
+			Currency:      quote.Currency,
+		})
+	})
+}
+
+// Routes declares the endpoints RegisterRoutes mounts.
+func Routes() []openapi.Route {
+	visit := []openapi.Param{
+		{Name: "doctorId", Description: "the doctor"},
+		{Name: "specialty", Description: "the specialty"},
+		{Name: "visitType", Description: "the visit type"},
+		{Name: "itemCode", Description: "the line item (lab test, medicine); the consultation when omitted"},
+	}
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/fee-schedules", Summary: "List fee schedules",
+			Query: append(visit,
+				openapi.Param{Name: "currency", Description: "3-letter ISO code"},
+				openapi.Param{Name: "activeAt", Description: "in effect at this time", Format: "date-time"}),
+			Response: []*FeeScheduleResult{}},
+		{Method: http.MethodPost, Path: "/fee-schedules", Summary: "Create a fee schedule",
+			Request: feeScheduleRequest{}, Response: FeeScheduleResult{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/fee-schedules/:id", Summary: "Get a fee schedule",
+			Response: FeeScheduleResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/fee-schedules/:id", Summary: "Change the amount or effective window of a fee schedule",
+			Request: repriceRequest{}, Response: FeeScheduleResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodDelete, Path: "/fee-schedules/:id", Summary: "Retire a fee schedule (ends its effective window now)",
+			Response: FeeScheduleResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/fee-schedules/:id/history", Summary: "Price history of a fee schedule",
+			Response: []PriceChangeResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/quote", Summary: "Price a visit",
+			Query: append(visit,
+				openapi.Param{Name: "currency", Description: "3-letter ISO code", Required: true},
+				openapi.Param{Name: "at", Description: "price in effect at this time; now when omitted", Format: "date-time"}),
+			Response: QuoteResult{}, Errors: []int{http.StatusNotFound}},
+	}
+}
+
+// Problem maps the package's errors to problem responses.
+func Problem(err error) *problem.Problem {
+	var notFound *ErrFeeScheduleNotFound
+	var noSchedule *ErrNoFeeSchedule
+	var mismatch *ErrAmountMismatch
+	switch {
+	case errors.Is(err, ErrInvalidFeeAmount):
+		return problem.Invalid("amount", "gt", err.Error())
+	case errors.Is(err, ErrInvalidFeeCurrency):
+		return problem.Invalid("currency", "len", err.Error())
+	case errors.Is(err, ErrInvalidEffectiveWindow):
+		return problem.Invalid("effectiveTo", "gtfield", err.Error())
+	case errors.As(err, &notFound):
+		return problem.New(http.StatusNotFound, "fee_schedule_not_found", notFound.Error())
+	case errors.As(err, &noSchedule):
+		return problem.New(http.StatusNotFound, "no_fee_schedule", noSchedule.Error())
+	case errors.As(err, &mismatch):
+		return problem.New(http.StatusUnprocessableEntity, "amount_mismatch", mismatch.Error())
+	}
+	return nil
+}
+
+// loadSchedule resolves the :id route parameter, writing the error response on failure.
+func loadSchedule(c *gin.Context, repo Repository) (*FeeSchedule, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		problem.Write(c, problem.Invalid("id", "uuid", "invalid fee schedule id"))
+		return nil, false
+	}
+
+	schedule, err := repo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		problem.Write(c, err)
+		return nil, false
+	}
+	if schedule == nil {
+		problem.Write(c, &ErrFeeScheduleNotFound{ID: id})
+		return nil, false
+	}
+	return schedule, true
+}
+
+func toResult(s *FeeSchedule) *FeeScheduleResult {
+	return &FeeScheduleResult{
+		ID:            s.ID.String(),
+		DoctorID:      s.DoctorID,
+		Specialty:     s.Specialty,
+		VisitType:     s.VisitType,
+		ItemCode:      s.ItemCode,
+		Currency:      s.Currency,
+		Amount:        s.Amount,
+		EffectiveFrom: s.EffectiveFrom,
+		EffectiveTo:   s.EffectiveTo,
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+	}
+}
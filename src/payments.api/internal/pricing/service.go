@@ -0,0 +1,96 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Service defines the pricing contract used when creating payments.
+type Service interface {
+	// Quote returns the fee schedule price for a visit at the given time.
+	Quote(ctx context.Context, key Key, at time.Time) (*Quote, error)
+	// Resolve determines the amount to charge for a visit, or for one of its
+	// line items when the key has an ItemCode. A zero supplied amount is priced from the fee schedules; a non-zero
+	// supplied amount is verified against them within the configured tolerance.
+	Resolve(ctx context.Context, key Key, supplied float64, at time.Time) (*Quote, error)
+}
+
+// Quote is the price of a visit.
+type Quote struct {
+	FeeScheduleID uuid.UUID // uuid.Nil when the supplied amount was accepted without a schedule
+	Amount        float64
+	Currency      string
+}
+
+// PricingService implements Service on top of the fee schedule repository.
+//
+// Architectural Decision: Upstream events are not trusted to carry the price.
+// When a fee schedule applies, it is the source of truth and a supplied amount
+// is only accepted if it agrees within tolerance. When no schedule applies
+// the payment is refused (ErrNoFeeSchedule), unless requireSchedule is turned
+// off to roll pricing out clinic by clinic: then a supplied amount is charged
+// unverified, with a warning logged.
+type PricingService struct {
+	repo            Repository
+	tolerance       float64
+	requireSchedule bool
+	logger          *slog.Logger
+}
+
+// NewPricingService creates a new pricing service.
+// tolerance is the largest accepted absolute difference in the major currency unit.
+func NewPricingService(repo Repository, tolerance float64, requireSchedule bool, logger *slog.Logger) *PricingService {
+	return &PricingService{repo: repo, tolerance: tolerance, requireSchedule: requireSchedule, logger: logger}
+}
+
+// Quote returns the fee schedule price for a visit at the given time.
+func (s *PricingService) Quote(ctx context.Context, key Key, at time.Time) (*Quote, error) {
+	candidates, err := s.repo.FindApplicable(ctx, key, at)
+	if err != nil {
+		return nil, fmt.Errorf("find fee schedules: %w", err)
+	}
+
+	best := SelectBest(candidates, key, at)
+	if best == nil {
+		return nil, &ErrNoFeeSchedule{Key: key}
+	}
+
+	return &Quote{FeeScheduleID: best.ID, Amount: best.Amount, Currency: best.Currency}, nil
+}
+
+// Resolve determines the amount to charge for a visit.
+func (s *PricingService) Resolve(ctx context.Context, key Key, supplied float64, at time.Time) (*Quote, error) {
+	quote, err := s.Quote(ctx, key, at)
+	if err != nil {
+		var noSchedule *ErrNoFeeSchedule
+		if errors.As(err, &noSchedule) && supplied > 0 && !s.requireSchedule {
+			s.logger.Warn("no fee schedule applies, accepting supplied amount",
+				"doctorId", key.DoctorID,
+				"specialty", key.Specialty,
+				"visitType", key.VisitType,
+				"itemCode", key.ItemCode,
+				"amount", supplied,
+				"currency", key.Currency)
+			return &Quote{Amount: supplied, Currency: key.Currency}, nil
+		}
+		return nil, err
+	}
+
+	if supplied > 0 && !WithinTolerance(supplied, quote.Amount, s.tolerance) {
+		return nil, &ErrAmountMismatch{Supplied: supplied, Expected: quote.Amount, Currency: quote.Currency}
+	}
+
+	return quote, nil
+}
+
+// WithinTolerance reports whether two amounts differ by at most tolerance.
+func WithinTolerance(a, b, tolerance float64) bool {
+	// Round to cents before comparing so float noise never decides a charge.
+	return math.Abs(math.Round(a*100)-math.Round(b*100)) <= math.Round(tolerance*100)
+}
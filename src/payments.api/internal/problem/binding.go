@@ -0,0 +1,86 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/smart-health/payments-api/internal/shared"
+)
+
+// Binding errors name fields by their JSON name, as clients know them.
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			return name
+		})
+	}
+}
+
+// Binding translates an error of c.ShouldBindJSON or c.ShouldBindQuery: a
+// body that is not JSON, or fields violating their binding rules.
+func Binding(err error) *Problem {
+	var fields validator.ValidationErrors
+	var syntax *json.SyntaxError
+	var mistyped *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &fields):
+		p := New(http.StatusBadRequest, CodeValidationFailed, "the request has invalid fields")
+		for _, f := range fields {
+			p.Errors = append(p.Errors, fieldError(f))
+		}
+		return p
+	case errors.As(err, &mistyped):
+		p := New(http.StatusBadRequest, CodeValidationFailed, "the request has invalid fields")
+		p.Errors = []shared.FieldError{{
+			Field:   mistyped.Field,
+			Code:    "type",
+			Message: fmt.Sprintf("%s must be a %s", mistyped.Field, mistyped.Type),
+		}}
+		return p
+	case errors.As(err, &syntax), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return New(http.StatusBadRequest, CodeMalformedBody, "the request body is not valid JSON")
+	default:
+		return New(http.StatusBadRequest, CodeMalformedBody, err.Error())
+	}
+}
+
+// fieldError describes a violated binding rule; its code is the rule's tag.
+func fieldError(f validator.FieldError) shared.FieldError {
+	// The namespace starts with the request struct's name: "req.lineItems[0].code"
+	_, field, ok := strings.Cut(f.Namespace(), ".")
+	if !ok {
+		field = f.Field()
+	}
+
+	var message string
+	switch f.Tag() {
+	case "required":
+		message = field + " is required"
+	case "gt":
+		message = fmt.Sprintf("%s must be greater than %s", field, f.Param())
+	case "lt":
+		message = fmt.Sprintf("%s must be less than %s", field, f.Param())
+	case "gte", "min":
+		message = fmt.Sprintf("%s must be at least %s", field, f.Param())
+	case "lte", "max":
+		message = fmt.Sprintf("%s must be at most %s", field, f.Param())
+	case "len":
+		message = fmt.Sprintf("%s must be %s characters long", field, f.Param())
+	case "oneof":
+		message = fmt.Sprintf("%s must be one of: %s", field, f.Param())
+	default:
+		message = fmt.Sprintf("%s fails the %s rule", field, f.Tag())
+	}
+	return shared.FieldError{Field: field, Code: f.Tag(), Message: message}
+}
@@ -0,0 +1,141 @@
+package problem
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smart-health/payments-api/internal/shared"
+)
+
+// -----------------------------------------------------------------------
+// Problem details (RFC 7807)
+//
+// Architectural Decision: Every error response is an application/problem+json
+// document written by Write, never an error string composed by the handler.
+// The packages that own an error type translate it, in a Mapper registered
+// at startup, into a status and a stable machine-readable code; clients
+// branch on the code, not on the wording of the detail. Errors no mapper
+// knows are internal: the client gets a generic 500 and the error itself,
+// which may wrap SQL or provider messages, only reaches the log.
+// -----------------------------------------------------------------------
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// TypePrefix prefixes the code to form the problem type URI.
+const TypePrefix = "urn:smarthealth:problem:"
+
+// Codes of the problems the package maps itself.
+const (
+	CodeValidationFailed   = "validation_failed"
+	CodeMalformedBody      = "malformed_body"
+	CodeServiceUnavailable = "service_unavailable"
+	CodeTimeout            = "timeout"
+	CodeInternal           = "internal_error"
+)
+
+// Problem is a problem details object. It is an error, so handlers and
+// mappers can return one directly.
+type Problem struct {
+	Type     string              `json:"type"`
+	Title    string              `json:"title"`
+	Status   int                 `json:"status"`
+	Detail   string              `json:"detail,omitempty"`
+	Instance string              `json:"instance,omitempty"`
+	Code     string              `json:"code"`
+	Errors   []shared.FieldError `json:"errors,omitempty"` // the fields violating validation rules
+}
+
+// New creates a problem; code is a stable snake_case identifier.
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   TypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Invalid creates a validation problem for a single field or parameter.
+func Invalid(field, code, message string) *Problem {
+	p := New(http.StatusBadRequest, CodeValidationFailed, message)
+	p.Errors = []shared.FieldError{{Field: field, Code: code, Message: message}}
+	return p
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Code
+	}
+	return p.Code + ": " + p.Detail
+}
+
+// Mapper translates the errors its package owns into problems, returning nil
+// for any other error.
+type Mapper func(err error) *Problem
+
+var (
+	mu      sync.RWMutex
+	mappers []Mapper
+)
+
+// Register adds mappers consulted by From, in registration order.
+func Register(m ...Mapper) {
+	mu.Lock()
+	defer mu.Unlock()
+	mappers = append(mappers, m...)
+}
+
+// From translates err into a problem.
+func From(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	mu.RLock()
+	registered := mappers
+	mu.RUnlock()
+	for _, m := range registered {
+		if p := m(err); p != nil {
+			return p
+		}
+	}
+
+	var invalid *shared.ValidationError
+	var temporary interface{ Temporary() bool }
+	switch {
+	case errors.As(err, &invalid):
+		p := New(http.StatusBadRequest, CodeValidationFailed, "the request has invalid fields")
+		p.Errors = invalid.Fields
+		return p
+	case errors.As(err, &temporary) && temporary.Temporary():
+		return New(http.StatusServiceUnavailable, CodeServiceUnavailable, "a downstream service is unavailable; retry later")
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(http.StatusGatewayTimeout, CodeTimeout, "the request timed out")
+	default:
+		return New(http.StatusInternalServerError, CodeInternal, "an unexpected error occurred")
+	}
+}
+
+// Write writes err as a problem response. Server errors are attached to the
+// context for the request logger.
+func Write(c *gin.Context, err error) {
+	p := *From(err)
+	p.Instance = c.Request.URL.Path
+	if p.Status >= http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+	c.Header("Content-Type", ContentType)
+	c.JSON(p.Status, p)
+}
+
+// Abort writes err as a problem response and stops the handler chain.
+func Abort(c *gin.Context, err error) {
+	c.Abort()
+	Write(c, err)
+}
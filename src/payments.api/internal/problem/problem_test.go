@@ -0,0 +1,138 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smart-health/payments-api/internal/problem"
+	"github.com/smart-health/payments-api/internal/shared"
+)
+
+var errOrderNotFound = errors.New("order not found")
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "provider unavailable" }
+func (temporaryError) Temporary() bool { return true }
+
+func init() {
+	problem.Register(func(err error) *problem.Problem {
+		if errors.Is(err, errOrderNotFound) {
+			return problem.New(http.StatusNotFound, "order_not_found", err.Error())
+		}
+		return nil
+	})
+}
+
+// serve writes err for a request to /orders/42 and decodes the response.
+func serve(t *testing.T, err error) (*httptest.ResponseRecorder, problem.Problem) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/orders/:id", func(c *gin.Context) { problem.Write(c, err) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+
+	var p problem.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode response %q: %v", w.Body, err)
+	}
+	return w, p
+}
+
+func TestWrite_MapsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"registered mapper", fmt.Errorf("load order: %w", errOrderNotFound), http.StatusNotFound, "order_not_found"},
+		{"problem", problem.New(http.StatusConflict, "order_locked", "order is locked"), http.StatusConflict, "order_locked"},
+		{"temporary error", fmt.Errorf("charge: %w", temporaryError{}), http.StatusServiceUnavailable, problem.CodeServiceUnavailable},
+		{"unknown error", errors.New(`pq: relation "orders" does not exist`), http.StatusInternalServerError, problem.CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, p := serve(t, tt.err)
+			if w.Code != tt.status || p.Status != tt.status {
+				t.Errorf("expected status %d, got %d (body %d)", tt.status, w.Code, p.Status)
+			}
+			if p.Code != tt.code || p.Type != problem.TypePrefix+tt.code {
+				t.Errorf("expected code %s, got %s (type %s)", tt.code, p.Code, p.Type)
+			}
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, problem.ContentType) {
+				t.Errorf("expected content type %s, got %s", problem.ContentType, ct)
+			}
+			if p.Instance != "/orders/42" || p.Title != http.StatusText(tt.status) {
+				t.Errorf("unexpected instance %q or title %q", p.Instance, p.Title)
+			}
+		})
+	}
+}
+
+func TestWrite_HidesInternalErrors(t *testing.T) {
+	_, p := serve(t, errors.New(`pq: relation "orders" does not exist`))
+	if strings.Contains(p.Detail, "orders") {
+		t.Errorf("expected a generic detail, got %q", p.Detail)
+	}
+}
+
+func TestWrite_ValidationError(t *testing.T) {
+	var errs shared.ValidationError
+	errs.Add("amount", "gt", "amount must be greater than 0")
+	errs.Add("currency", "len", "currency must be a 3-letter ISO code")
+
+	w, p := serve(t, fmt.Errorf("validation error: %w", errs.OrNil()))
+	if w.Code != http.StatusBadRequest || p.Code != problem.CodeValidationFailed {
+		t.Fatalf("expected 400 %s, got %d %s", problem.CodeValidationFailed, w.Code, p.Code)
+	}
+	if len(p.Errors) != 2 || p.Errors[0].Field != "amount" || p.Errors[1].Code != "len" {
+		t.Errorf("unexpected field errors %+v", p.Errors)
+	}
+}
+
+func TestBinding(t *testing.T) {
+	type request struct {
+		Currency  string `json:"currency" binding:"required,len=3"`
+		LineItems []struct {
+			Quantity int `json:"quantity" binding:"required,gt=0"`
+		} `json:"lineItems" binding:"omitempty,dive"`
+	}
+	bind := func(body string) *problem.Problem {
+		gin.SetMode(gin.TestMode)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		var req request
+		err := c.ShouldBindJSON(&req)
+		if err == nil {
+			t.Fatalf("expected %s to fail binding", body)
+		}
+		return problem.Binding(err)
+	}
+
+	p := bind(`{"currency":"EURO","lineItems":[{"quantity":1},{"quantity":0}]}`)
+	if p.Code != problem.CodeValidationFailed || len(p.Errors) != 2 {
+		t.Fatalf("expected 2 field errors, got %+v", p)
+	}
+	if f := p.Errors[0]; f.Field != "currency" || f.Code != "len" {
+		t.Errorf("unexpected field error %+v", f)
+	}
+	if f := p.Errors[1]; f.Field != "lineItems[1].quantity" || f.Code != "required" {
+		t.Errorf("unexpected field error %+v", f)
+	}
+
+	if p := bind(`{"currency":`); p.Code != problem.CodeMalformedBody {
+		t.Errorf("expected %s for truncated JSON, got %s", problem.CodeMalformedBody, p.Code)
+	}
+	if p := bind(`{"currency":978}`); p.Code != problem.CodeValidationFailed || len(p.Errors) != 1 || p.Errors[0].Field != "currency" {
+		t.Errorf("expected a field error for a mistyped field, got %+v", p)
+	}
+}
@@ -0,0 +1,237 @@
+package resilience
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	StateClosed   State = 0 // calls pass; outcomes are counted
+	StateOpen     State = 1 // calls are rejected until OpenDuration has passed
+	StateHalfOpen State = 2 // a few probe calls decide between closing and reopening
+)
+
+// String returns the string representation of the state.
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// Outcome is the result of a call as far as the breaker is concerned.
+type Outcome int
+
+const (
+	OutcomeSuccess Outcome = iota // the provider answered, even if it declined
+	OutcomeFailure                // the provider failed: outage, timeout, throttling
+	OutcomeIgnored                // the call says nothing about the provider, e.g. the caller gave up
+)
+
+// BreakerOptions configures a Breaker.
+type BreakerOptions struct {
+	WindowSize     int           // number of most recent calls the failure rate is computed over
+	MinCalls       int           // calls in the window before the failure rate is judged
+	FailureRate    float64       // failure share (0–1) that opens the breaker
+	OpenDuration   time.Duration // how long the breaker rejects calls before probing
+	HalfOpenProbes int           // probe calls let through while half-open; all must succeed to close
+
+	// OnStateChange is called, with the breaker locked, on every transition.
+	OnStateChange func(name string, from, to State)
+}
+
+// DefaultBreakerOptions opens after half of at least 10 of the last 20 calls
+// failed and probes again after 30 seconds.
+var DefaultBreakerOptions = BreakerOptions{
+	WindowSize:     20,
+	MinCalls:       10,
+	FailureRate:    0.5,
+	OpenDuration:   30 * time.Second,
+	HalfOpenProbes: 2,
+}
+
+// ErrOpen is returned instead of calling the provider while the breaker is
+// open. It is temporary: the call may succeed after RetryAfter.
+type ErrOpen struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *ErrOpen) Error() string {
+	return fmt.Sprintf("circuit breaker %s is open; retry in %s", e.Name, e.RetryAfter.Round(time.Second))
+}
+func (e *ErrOpen) Temporary() bool { return true }
+
+// Breaker is a count-based circuit breaker. While closed it records the
+// outcome of the last WindowSize calls and opens when the failure rate
+// reaches FailureRate; after OpenDuration it lets HalfOpenProbes calls
+// through, closing when they all succeed and reopening on the first failure.
+type Breaker struct {
+	name string
+	opts BreakerOptions
+
+	mu         sync.Mutex
+	state      State
+	generation int // increases on every transition; outcomes of older calls are dropped
+	openedAt   time.Time
+	window     []bool // true = failure, a ring of the last calls while closed
+	next       int
+	calls      int
+	failures   int
+	probes     int // probe calls in flight or succeeded while half-open
+	succeeded  int // successful probes while half-open
+	opened     int64
+	rejected   int64
+}
+
+// NewBreaker creates a closed breaker. Zero options take their defaults.
+func NewBreaker(name string, opts BreakerOptions) *Breaker {
+	if opts.WindowSize <= 0 {
+		opts.WindowSize = DefaultBreakerOptions.WindowSize
+	}
+	if opts.MinCalls <= 0 || opts.MinCalls > opts.WindowSize {
+		opts.MinCalls = min(DefaultBreakerOptions.MinCalls, opts.WindowSize)
+	}
+	if opts.FailureRate <= 0 || opts.FailureRate > 1 {
+		opts.FailureRate = DefaultBreakerOptions.FailureRate
+	}
+	if opts.OpenDuration <= 0 {
+		opts.OpenDuration = DefaultBreakerOptions.OpenDuration
+	}
+	if opts.HalfOpenProbes <= 0 {
+		opts.HalfOpenProbes = DefaultBreakerOptions.HalfOpenProbes
+	}
+	return &Breaker{name: name, opts: opts, window: make([]bool, opts.WindowSize)}
+}
+
+// Name identifies the breaker, e.g. the provider it guards.
+func (b *Breaker) Name() string { return b.name }
+
+// State returns the current state. An open breaker whose OpenDuration has
+// passed reports half-open: the next call will probe.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.opts.OpenDuration {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// Allow asks to make a call. It returns *ErrOpen when the call must not be
+// made; otherwise the caller reports the call's outcome through done, exactly once.
+func (b *Breaker) Allow() (done func(Outcome), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		elapsed := time.Since(b.openedAt)
+		if elapsed < b.opts.OpenDuration {
+			b.rejected++
+			return nil, &ErrOpen{Name: b.name, RetryAfter: b.opts.OpenDuration - elapsed}
+		}
+		b.transition(StateHalfOpen)
+	}
+	if b.state == StateHalfOpen {
+		if b.probes >= b.opts.HalfOpenProbes {
+			b.rejected++
+			return nil, &ErrOpen{Name: b.name}
+		}
+		b.probes++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(o Outcome) {
+		once.Do(func() { b.record(generation, o) })
+	}, nil
+}
+
+func (b *Breaker) record(generation int, o Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		if o == OutcomeIgnored {
+			return
+		}
+		if b.calls == len(b.window) && b.window[b.next] {
+			b.failures--
+		}
+		b.window[b.next] = o == OutcomeFailure
+		b.next = (b.next + 1) % len(b.window)
+		b.calls = min(b.calls+1, len(b.window))
+		if o == OutcomeFailure {
+			b.failures++
+		}
+		if b.calls >= b.opts.MinCalls && float64(b.failures)/float64(b.calls) >= b.opts.FailureRate {
+			b.transition(StateOpen)
+		}
+
+	case StateHalfOpen:
+		switch o {
+		case OutcomeFailure:
+			b.transition(StateOpen)
+		case OutcomeIgnored:
+			b.probes-- // free the slot for another probe
+		case OutcomeSuccess:
+			b.succeeded++
+			if b.succeeded >= b.opts.HalfOpenProbes {
+				b.transition(StateClosed)
+			}
+		}
+	}
+}
+
+// transition moves to a new state and resets what the old one counted. Callers hold b.mu.
+func (b *Breaker) transition(to State) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.probes, b.succeeded = 0, 0
+	switch to {
+	case StateOpen:
+		b.openedAt = time.Now()
+		b.opened++
+	case StateClosed:
+		clear(b.window)
+		b.next, b.calls, b.failures = 0, 0, 0
+	}
+	if b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(b.name, from, to)
+	}
+}
+
+// BreakerStats is a snapshot of a breaker for monitoring.
+type BreakerStats struct {
+	State       State
+	FailureRate float64 // over the current window; 0 unless closed
+	Opened      int64   // times the breaker opened
+	Rejected    int64   // calls rejected without reaching the provider
+}
+
+// Stats returns a snapshot of the breaker.
+func (b *Breaker) Stats() BreakerStats {
+	state := b.State()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := BreakerStats{State: state, Opened: b.opened, Rejected: b.rejected}
+	if b.state == StateClosed && b.calls > 0 {
+		stats.FailureRate = float64(b.failures) / float64(b.calls)
+	}
+	return stats
+}
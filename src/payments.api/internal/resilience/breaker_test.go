@@ -0,0 +1,115 @@
+package resilience_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/resilience"
+)
+
+func call(t *testing.T, b *resilience.Breaker, o resilience.Outcome) {
+	t.Helper()
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected the call to be allowed, got %v", err)
+	}
+	done(o)
+}
+
+func TestBreaker_OpensAtFailureRate(t *testing.T) {
+	b := resilience.NewBreaker("stripe", resilience.BreakerOptions{WindowSize: 10, MinCalls: 4, FailureRate: 0.5, OpenDuration: time.Minute})
+
+	// Three failures are below the minimum number of calls
+	for i := 0; i < 3; i++ {
+		call(t, b, resilience.OutcomeFailure)
+	}
+	if b.State() != resilience.StateClosed {
+		t.Fatalf("expected closed before MinCalls, got %s", b.State())
+	}
+	call(t, b, resilience.OutcomeSuccess)
+	if b.State() != resilience.StateOpen {
+		t.Fatalf("expected open at 3/4 failures, got %s", b.State())
+	}
+
+	_, err := b.Allow()
+	var open *resilience.ErrOpen
+	if !errors.As(err, &open) || open.RetryAfter <= 0 {
+		t.Fatalf("expected ErrOpen with a retry delay, got %v", err)
+	}
+	if stats := b.Stats(); stats.Opened != 1 || stats.Rejected != 1 {
+		t.Errorf("expected one opening and one rejection, got %+v", stats)
+	}
+}
+
+func TestBreaker_IgnoredOutcomesDoNotCount(t *testing.T) {
+	b := resilience.NewBreaker("stripe", resilience.BreakerOptions{WindowSize: 4, MinCalls: 2, FailureRate: 0.5})
+	call(t, b, resilience.OutcomeSuccess)
+	call(t, b, resilience.OutcomeIgnored)
+	call(t, b, resilience.OutcomeIgnored)
+	call(t, b, resilience.OutcomeSuccess)
+	if b.State() != resilience.StateClosed || b.Stats().FailureRate != 0 {
+		t.Errorf("expected closed without failures, got %+v", b.Stats())
+	}
+}
+
+func TestBreaker_WindowForgetsOldFailures(t *testing.T) {
+	b := resilience.NewBreaker("stripe", resilience.BreakerOptions{WindowSize: 4, MinCalls: 4, FailureRate: 0.75})
+	call(t, b, resilience.OutcomeFailure)
+	call(t, b, resilience.OutcomeFailure)
+	for i := 0; i < 4; i++ {
+		call(t, b, resilience.OutcomeSuccess)
+	}
+	call(t, b, resilience.OutcomeFailure)
+	call(t, b, resilience.OutcomeFailure)
+	// The window holds success, success, failure, failure: 50%
+	if b.State() != resilience.StateClosed {
+		t.Errorf("expected the early failures to have left the window, got %s", b.State())
+	}
+}
+
+func TestBreaker_HalfOpenProbes(t *testing.T) {
+	opts := resilience.BreakerOptions{WindowSize: 2, MinCalls: 2, FailureRate: 1, OpenDuration: 20 * time.Millisecond, HalfOpenProbes: 2}
+	b := resilience.NewBreaker("stripe", opts)
+	call(t, b, resilience.OutcomeFailure)
+	call(t, b, resilience.OutcomeFailure)
+	if b.State() != resilience.StateOpen {
+		t.Fatalf("expected open, got %s", b.State())
+	}
+
+	time.Sleep(opts.OpenDuration)
+	if b.State() != resilience.StateHalfOpen {
+		t.Fatalf("expected half-open after the open duration, got %s", b.State())
+	}
+
+	// Two probes may be in flight; a third call is rejected
+	first, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected a probe, got %v", err)
+	}
+	second, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected a second probe, got %v", err)
+	}
+	if _, err := b.Allow(); err == nil {
+		t.Fatal("expected calls beyond the probes to be rejected")
+	}
+
+	first(resilience.OutcomeSuccess)
+	second(resilience.OutcomeSuccess)
+	if b.State() != resilience.StateClosed {
+		t.Fatalf("expected closed after successful probes, got %s", b.State())
+	}
+}
+
+func TestBreaker_FailedProbeReopens(t *testing.T) {
+	opts := resilience.BreakerOptions{WindowSize: 1, MinCalls: 1, FailureRate: 1, OpenDuration: 20 * time.Millisecond, HalfOpenProbes: 1}
+	b := resilience.NewBreaker("stripe", opts)
+	call(t, b, resilience.OutcomeFailure)
+
+	time.Sleep(opts.OpenDuration)
+	call(t, b, resilience.OutcomeFailure)
+	if b.State() != resilience.StateOpen || b.Stats().Opened != 2 {
+		t.Errorf("expected the breaker to reopen, got %+v", b.Stats())
+	}
+}
@@ -0,0 +1,127 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// MemoryBucket is a token bucket local to this process.
+type MemoryBucket struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryBucket creates a full bucket.
+func NewMemoryBucket(opts LimiterOptions) *MemoryBucket {
+	opts = opts.withDefaults()
+	return &MemoryBucket{
+		rate:    opts.Rate,
+		burst:   float64(opts.Burst),
+		tokens:  float64(opts.Burst),
+		updated: time.Now(),
+	}
+}
+
+// Take removes a token if at least reserve tokens remain afterwards.
+func (b *MemoryBucket) Take(_ context.Context, reserve float64) (time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.updated).Seconds()*b.rate)
+	b.updated = now
+	return takeFrom(&b.tokens, reserve, b.rate), nil
+}
+
+// takeFrom takes a token from level if reserve tokens remain, and otherwise
+// returns the time until they will.
+func takeFrom(level *float64, reserve, rate float64) time.Duration {
+	if *level-1 >= reserve {
+		*level--
+		return 0
+	}
+	return time.Duration((reserve + 1 - *level) / rate * float64(time.Second))
+}
+
+// PostgresBucket is a token bucket shared by all replicas: its level is a
+// row of rate_limit_buckets, refilled and taken from in a single statement.
+// When the database cannot be reached it falls back to a local bucket, so
+// an unavailable database never blocks payments.
+type PostgresBucket struct {
+	pool     *pgxpool.Pool
+	name     string
+	rate     float64
+	burst    float64
+	fallback *MemoryBucket
+	logger   *slog.Logger
+}
+
+// NewPostgresBucket creates the bucket stored under name.
+func NewPostgresBucket(pool *pgxpool.Pool, name string, opts LimiterOptions, logger *slog.Logger) *PostgresBucket {
+	opts = opts.withDefaults()
+	return &PostgresBucket{
+		pool:     pool,
+		name:     name,
+		rate:     opts.Rate,
+		burst:    float64(opts.Burst),
+		fallback: NewMemoryBucket(opts),
+		logger:   logger,
+	}
+}
+
+// The row is locked while the refilled level is read, so concurrent takes
+// from several replicas are serialized.
+const takeTokenSQL = `
+	WITH current AS (
+		SELECT LEAST($2::float8, tokens + GREATEST(0, EXTRACT(EPOCH FROM clock_timestamp() - updated_at))::float8 * $3::float8) AS level
+		FROM rate_limit_buckets WHERE name = $1
+		FOR UPDATE
+	)
+	UPDATE rate_limit_buckets b SET
+		tokens = current.level - CASE WHEN current.level - 1 >= $4::float8 THEN 1 ELSE 0 END,
+		updated_at = clock_timestamp()
+	FROM current
+	WHERE b.name = $1
+	RETURNING current.level`
+
+// Take removes a token if at least reserve tokens remain afterwards.
+func (b *PostgresBucket) Take(ctx context.Context, reserve float64) (time.Duration, error) {
+	level, err := b.take(ctx, reserve)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// First use of the bucket: create it full
+		_, err = b.pool.Exec(ctx, `
+			INSERT INTO rate_limit_buckets (name, tokens, updated_at) VALUES ($1, $2, clock_timestamp())
+			ON CONFLICT (name) DO NOTHING`, b.name, b.burst)
+		if err == nil {
+			level, err = b.take(ctx, reserve)
+		}
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		b.logger.Warn("shared rate limit unavailable, limiting locally", "bucket", b.name, "error", err)
+		return b.fallback.Take(ctx, reserve)
+	}
+	return takeFrom(&level, reserve, b.rate), nil
+}
+
+func (b *PostgresBucket) take(ctx context.Context, reserve float64) (float64, error) {
+	var level float64
+	if err := b.pool.QueryRow(ctx, takeTokenSQL, b.name, b.burst, b.rate, reserve).Scan(&level); err != nil {
+		return 0, fmt.Errorf("take rate limit token: %w", err)
+	}
+	return level, nil
+}
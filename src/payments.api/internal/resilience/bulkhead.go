@@ -0,0 +1,70 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBulkheadFull is returned when no call slot became free within the
+// bulkhead's wait time. It is temporary.
+type ErrBulkheadFull struct {
+	Name          string
+	MaxConcurrent int
+}
+
+func (e *ErrBulkheadFull) Error() string {
+	return fmt.Sprintf("bulkhead %s is full (%d calls in flight)", e.Name, e.MaxConcurrent)
+}
+func (e *ErrBulkheadFull) Temporary() bool { return true }
+
+// Bulkhead limits the number of concurrent calls to a provider, so a slow
+// provider ties up at most MaxConcurrent goroutines.
+type Bulkhead struct {
+	name     string
+	slots    chan struct{}
+	maxWait  time.Duration
+	rejected atomic.Int64
+}
+
+// NewBulkhead creates a bulkhead admitting maxConcurrent calls; further calls
+// wait up to maxWait for a slot.
+func NewBulkhead(name string, maxConcurrent int, maxWait time.Duration) *Bulkhead {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	return &Bulkhead{name: name, slots: make(chan struct{}, maxConcurrent), maxWait: maxWait}
+}
+
+// Acquire takes a call slot, waiting up to the bulkhead's wait time or until
+// ctx ends. The caller must call release when the call is over.
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case b.slots <- struct{}{}:
+		return b.release, nil
+	default:
+	}
+
+	if b.maxWait > 0 {
+		timer := time.NewTimer(b.maxWait)
+		defer timer.Stop()
+		select {
+		case b.slots <- struct{}{}:
+			return b.release, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	b.rejected.Add(1)
+	return nil, &ErrBulkheadFull{Name: b.name, MaxConcurrent: cap(b.slots)}
+}
+
+func (b *Bulkhead) release() { <-b.slots }
+
+// InFlight returns the number of calls holding a slot.
+func (b *Bulkhead) InFlight() int { return len(b.slots) }
+
+// Rejected returns the number of calls turned away for lack of a slot.
+func (b *Bulkhead) Rejected() int64 { return b.rejected.Load() }
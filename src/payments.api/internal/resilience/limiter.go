@@ -0,0 +1,175 @@
+package resilience
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// -----------------------------------------------------------------------
+// Outbound rate limiting
+//
+// Architectural Decision: Providers limit requests per account, not per
+// replica, and a backlog replay (thousands of queued events after an outage)
+// would exhaust the limit at once. Every request to the provider therefore
+// takes a token from one token bucket – in memory, or shared by all replicas
+// through a Postgres row. Calls carry a Priority in their context; lower
+// priorities may not drain the bucket below a reserve, so patient-facing
+// requests and webhooks find tokens even while a backfill saturates the
+// limit. Calls wait for a token instead of failing; their context (and the
+// policy timeout) bounds the wait.
+// -----------------------------------------------------------------------
+
+// Priority orders calls competing for the rate limit.
+type Priority int
+
+const (
+	PriorityLow    Priority = 0 // background work: consumed events, backlog replays, pending retries
+	PriorityNormal Priority = 1 // calls that set no priority
+	PriorityHigh   Priority = 2 // patient-facing requests and webhooks
+)
+
+// String returns the string representation of the priority.
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose provider calls have the priority.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority of the context's calls, PriorityNormal if none was set.
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// LimiterOptions configures a token bucket.
+type LimiterOptions struct {
+	Rate  float64 // tokens added per second: the sustained request rate
+	Burst int     // bucket capacity: requests allowed at once after a quiet period
+
+	// Shares of Burst that low and normal priority calls leave in the bucket
+	LowReserve    float64
+	NormalReserve float64
+}
+
+// DefaultLimiterOptions allows 25 requests per second, Stripe's test mode
+// limit, keeping half the bucket from background work.
+var DefaultLimiterOptions = LimiterOptions{Rate: 25, Burst: 25, LowReserve: 0.5, NormalReserve: 0.2}
+
+func (o LimiterOptions) withDefaults() LimiterOptions {
+	if o.Rate <= 0 {
+		o.Rate = DefaultLimiterOptions.Rate
+	}
+	if o.Burst <= 0 {
+		o.Burst = max(1, int(o.Rate))
+	}
+	return o
+}
+
+// Bucket stores the tokens of a limiter.
+type Bucket interface {
+	// Take removes a token if at least reserve tokens remain afterwards.
+	// Otherwise it returns how long until that will be the case.
+	Take(ctx context.Context, reserve float64) (wait time.Duration, err error)
+}
+
+// Limiter makes provider calls wait for a token from its bucket.
+type Limiter struct {
+	name     string
+	bucket   Bucket
+	reserves [3]float64 // tokens each priority leaves in the bucket
+
+	waits       [3]atomic.Int64 // calls that had to wait, per priority
+	waitedNanos [3]atomic.Int64
+}
+
+// NewLimiter creates a limiter over bucket; a nil bucket is an in-memory one.
+func NewLimiter(name string, opts LimiterOptions, bucket Bucket) *Limiter {
+	opts = opts.withDefaults()
+	if bucket == nil {
+		bucket = NewMemoryBucket(opts)
+	}
+	// A reserve must leave room for a token, or the priority never gets one
+	burst := float64(opts.Burst)
+	return &Limiter{
+		name:   name,
+		bucket: bucket,
+		reserves: [3]float64{
+			PriorityLow:    min(opts.LowReserve*burst, burst-1),
+			PriorityNormal: min(opts.NormalReserve*burst, burst-1),
+			PriorityHigh:   0,
+		},
+	}
+}
+
+// Wait blocks until the call may be made, or ctx ends.
+func (l *Limiter) Wait(ctx context.Context) error {
+	priority := min(max(PriorityFrom(ctx), PriorityLow), PriorityHigh)
+	var start time.Time
+	for {
+		wait, err := l.bucket.Take(ctx, l.reserves[priority])
+		if err != nil {
+			return err
+		}
+		if wait <= 0 {
+			if !start.IsZero() {
+				l.waits[priority].Add(1)
+				l.waitedNanos[priority].Add(int64(time.Since(start)))
+			}
+			return nil
+		}
+		if start.IsZero() {
+			start = time.Now()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("waiting for %s rate limit: %w", l.name, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// WriteMetrics writes the limiter's statistics in the Prometheus text format.
+func (l *Limiter) WriteMetrics(w io.Writer) error {
+	metrics := []struct {
+		name, help string
+		value      func(Priority) float64
+	}{
+		{"payments_provider_rate_limit_waits_total", "Calls that waited for a rate limit token.",
+			func(p Priority) float64 { return float64(l.waits[p].Load()) }},
+		{"payments_provider_rate_limit_wait_seconds_total", "Time calls spent waiting for a rate limit token.",
+			func(p Priority) float64 { return time.Duration(l.waitedNanos[p].Load()).Seconds() }},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", m.name, m.help, m.name); err != nil {
+			return err
+		}
+		for _, p := range []Priority{PriorityLow, PriorityNormal, PriorityHigh} {
+			if _, err := fmt.Fprintf(w, "%s{provider=%q,priority=%q} %g\n", m.name, l.name, p, m.value(p)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package resilience_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/resilience"
+)
+
+func TestLimiter_AllowsBurstThenWaits(t *testing.T) {
+	l := resilience.NewLimiter("stripe", resilience.LimiterOptions{Rate: 20, Burst: 3}, nil)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Fatalf("expected the burst to pass at once, took %v", elapsed)
+	}
+
+	// The bucket is empty: the next token arrives after 1/20s
+	start = time.Now()
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("expected to get a token, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("expected to wait for a refill, took %v", elapsed)
+	}
+}
+
+func TestLimiter_ReservesTokensForHighPriority(t *testing.T) {
+	l := resilience.NewLimiter("stripe", resilience.LimiterOptions{Rate: 0.1, Burst: 4, LowReserve: 0.5}, nil)
+	low := resilience.WithPriority(context.Background(), resilience.PriorityLow)
+	high := resilience.WithPriority(context.Background(), resilience.PriorityHigh)
+
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(low); err != nil {
+			t.Fatalf("low call %d: %v", i, err)
+		}
+	}
+
+	// Half the bucket is reserved: low priority has to wait...
+	ctx, cancel := context.WithTimeout(low, 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected low priority to wait for the reserve, got %v", err)
+	}
+
+	// ...while high priority still gets the remaining tokens
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(high, 20*time.Millisecond)
+		err := l.Wait(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("high call %d: expected a reserved token, got %v", i, err)
+		}
+	}
+}
+
+func TestLimiter_WriteMetrics(t *testing.T) {
+	l := resilience.NewLimiter("stripe", resilience.LimiterOptions{Rate: 50, Burst: 1}, nil)
+	ctx := resilience.WithPriority(context.Background(), resilience.PriorityLow)
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(ctx); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := l.WriteMetrics(&buf); err != nil {
+		t.Fatalf("write metrics: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`payments_provider_rate_limit_waits_total{provider="stripe",priority="low"} 1`,
+		`payments_provider_rate_limit_waits_total{provider="stripe",priority="high"} 0`,
+		`# TYPE payments_provider_rate_limit_wait_seconds_total counter`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/gateway"
+)
+
+// -----------------------------------------------------------------------
+// Provider resilience
+//
+// Architectural Decision: Every call to a payment provider passes a circuit
+// breaker, a bulkhead and a per-call timeout, in that order. When the
+// provider is degraded the breaker fails calls at once instead of letting
+// each consumed event wait for a slow provider, the bulkhead caps the
+// goroutines a slow provider can tie up, and the timeout bounds each call
+// (never extending the caller's own deadline). All three fail with temporary
+// errors, so the payment is left Pending and charged again later by the
+// pending-payment retrier – a provider outage never fails a payment.
+// Only temporary errors count as breaker failures: a decline is a healthy
+// answer.
+// -----------------------------------------------------------------------
+
+// ErrTimeout is returned when a call exceeded the policy's timeout. It is temporary.
+type ErrTimeout struct {
+	Name    string
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *ErrTimeout) Error() string {
+	return fmt.Sprintf("%s call timed out after %s: %v", e.Name, e.Timeout, e.Err)
+}
+func (e *ErrTimeout) Unwrap() error   { return e.Err }
+func (e *ErrTimeout) Temporary() bool { return true }
+
+// Options configures a Policy.
+type Options struct {
+	Breaker       BreakerOptions
+	MaxConcurrent int           // calls in flight at once
+	MaxWait       time.Duration // how long a call waits for a free slot
+	Timeout       time.Duration // per call; 0 leaves only the caller's deadline
+}
+
+// Policy guards the calls to one provider.
+type Policy struct {
+	name     string
+	breaker  *Breaker
+	bulkhead *Bulkhead
+	timeout  time.Duration
+
+	succeeded atomic.Int64
+	failed    atomic.Int64
+	timedOut  atomic.Int64
+}
+
+// NewPolicy creates the policy of a provider.
+func NewPolicy(name string, opts Options) *Policy {
+	return &Policy{
+		name:     name,
+		breaker:  NewBreaker(name, opts.Breaker),
+		bulkhead: NewBulkhead(name, opts.MaxConcurrent, opts.MaxWait),
+		timeout:  opts.Timeout,
+	}
+}
+
+// Name identifies the policy, e.g. the provider it guards.
+func (p *Policy) Name() string { return p.name }
+
+// Breaker returns the policy's circuit breaker.
+func (p *Policy) Breaker() *Breaker { return p.breaker }
+
+// Do calls fn unless the breaker is open or the bulkhead full, bounding it by
+// the policy's timeout, and records the outcome.
+func (p *Policy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	done, err := p.breaker.Allow()
+	if err != nil {
+		return err
+	}
+	release, err := p.bulkhead.Acquire(ctx)
+	if err != nil {
+		done(OutcomeIgnored)
+		return err
+	}
+	defer release()
+
+	callCtx := ctx
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+
+	err = fn(callCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		err = &ErrTimeout{Name: p.name, Timeout: p.timeout, Err: err}
+		p.timedOut.Add(1)
+	}
+
+	switch {
+	case err == nil:
+		p.succeeded.Add(1)
+		done(OutcomeSuccess)
+	case ctx.Err() != nil:
+		// The caller gave up; that says nothing about the provider
+		done(OutcomeIgnored)
+	case gateway.IsTemporary(err):
+		p.failed.Add(1)
+		done(OutcomeFailure)
+	default:
+		p.succeeded.Add(1)
+		done(OutcomeSuccess)
+	}
+	return err
+}
+
+// Stats is a snapshot of a policy for monitoring.
+type Stats struct {
+	Name             string
+	Breaker          BreakerStats
+	InFlight         int
+	BulkheadRejected int64
+	Succeeded        int64 // calls the provider answered, declines included
+	Failed           int64 // calls failing with a temporary error, timeouts included
+	TimedOut         int64
+}
+
+// Stats returns a snapshot of the policy.
+func (p *Policy) Stats() Stats {
+	return Stats{
+		Name:             p.name,
+		Breaker:          p.breaker.Stats(),
+		InFlight:         p.bulkhead.InFlight(),
+		BulkheadRejected: p.bulkhead.Rejected(),
+		Succeeded:        p.succeeded.Load(),
+		Failed:           p.failed.Load(),
+		TimedOut:         p.timedOut.Load(),
+	}
+}
+
+// WriteMetrics writes the policies' statistics in the Prometheus text format.
+func WriteMetrics(w io.Writer, policies ...*Policy) error {
+	stats := make([]Stats, 0, len(policies))
+	for _, p := range policies {
+		stats = append(stats, p.Stats())
+	}
+
+	metrics := []struct {
+		name, kind, help string
+		value            func(Stats) float64
+	}{
+		{"payments_provider_breaker_state", "gauge", "Circuit breaker state: 0 closed, 1 open, 2 half-open.",
+			func(s Stats) float64 { return float64(s.Breaker.State) }},
+		{"payments_provider_breaker_failure_rate", "gauge", "Failure rate over the breaker's window of recent calls.",
+			func(s Stats) float64 { return s.Breaker.FailureRate }},
+		{"payments_provider_breaker_opened_total", "counter", "Times the circuit breaker opened.",
+			func(s Stats) float64 { return float64(s.Breaker.Opened) }},
+		{"payments_provider_breaker_rejected_total", "counter", "Calls rejected by the open circuit breaker.",
+			func(s Stats) float64 { return float64(s.Breaker.Rejected) }},
+		{"payments_provider_bulkhead_in_flight", "gauge", "Provider calls in flight.",
+			func(s Stats) float64 { return float64(s.InFlight) }},
+		{"payments_provider_bulkhead_rejected_total", "counter", "Calls rejected because the bulkhead was full.",
+			func(s Stats) float64 { return float64(s.BulkheadRejected) }},
+		{"payments_provider_calls_succeeded_total", "counter", "Calls the provider answered, declines included.",
+			func(s Stats) float64 { return float64(s.Succeeded) }},
+		{"payments_provider_calls_failed_total", "counter", "Calls failing with a temporary error.",
+			func(s Stats) float64 { return float64(s.Failed) }},
+		{"payments_provider_calls_timed_out_total", "counter", "Calls exceeding the per-call timeout.",
+			func(s Stats) float64 { return float64(s.TimedOut) }},
+	}
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind); err != nil {
+			return err
+		}
+		for _, s := range stats {
+			if _, err := fmt.Fprintf(w, "%s{provider=%q} %g\n", m.name, s.Name, m.value(s)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
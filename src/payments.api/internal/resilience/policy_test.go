@@ -0,0 +1,122 @@
+package resilience_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/gateway"
+	"github.com/smart-health/payments-api/internal/resilience"
+)
+
+// outage is a temporary provider error.
+type outage struct{}
+
+func (outage) Error() string   { return "provider unavailable" }
+func (outage) Temporary() bool { return true }
+
+func TestPolicy_OpensOnTemporaryErrorsOnly(t *testing.T) {
+	p := resilience.NewPolicy("stripe", resilience.Options{
+		Breaker:       resilience.BreakerOptions{WindowSize: 4, MinCalls: 4, FailureRate: 0.5, OpenDuration: time.Minute},
+		MaxConcurrent: 1,
+	})
+	ctx := context.Background()
+	declined := errors.New("card declined")
+
+	// Declines are answers, not failures
+	for i := 0; i < 4; i++ {
+		if err := p.Do(ctx, func(context.Context) error { return declined }); !errors.Is(err, declined) {
+			t.Fatalf("expected the decline to pass through, got %v", err)
+		}
+	}
+	if p.Breaker().State() != resilience.StateClosed {
+		t.Fatalf("expected declines to keep the breaker closed")
+	}
+
+	for i := 0; i < 2; i++ {
+		_ = p.Do(ctx, func(context.Context) error { return outage{} })
+	}
+	called := false
+	err := p.Do(ctx, func(context.Context) error { called = true; return nil })
+	var open *resilience.ErrOpen
+	if !errors.As(err, &open) || called {
+		t.Fatalf("expected the open breaker to reject the call, got %v (called %v)", err, called)
+	}
+	if !gateway.IsTemporary(err) {
+		t.Error("an open breaker must be temporary, so payments stay pending")
+	}
+}
+
+func TestPolicy_Timeout(t *testing.T) {
+	p := resilience.NewPolicy("stripe", resilience.Options{MaxConcurrent: 1, Timeout: 10 * time.Millisecond})
+
+	err := p.Do(context.Background(), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	var timeout *resilience.ErrTimeout
+	if !errors.As(err, &timeout) || !gateway.IsTemporary(err) {
+		t.Fatalf("expected a temporary ErrTimeout, got %v", err)
+	}
+	if stats := p.Stats(); stats.TimedOut != 1 || stats.Failed != 1 {
+		t.Errorf("expected one timed out failure, got %+v", stats)
+	}
+
+	// A caller that gives up is not the provider's fault
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = p.Do(ctx, func(ctx context.Context) error { return ctx.Err() })
+	if !errors.Is(err, context.Canceled) || errors.As(err, &timeout) {
+		t.Errorf("expected the caller's cancellation, got %v", err)
+	}
+	if p.Stats().Failed != 1 {
+		t.Errorf("expected the cancellation not to count as a failure")
+	}
+}
+
+func TestPolicy_Bulkhead(t *testing.T) {
+	p := resilience.NewPolicy("stripe", resilience.Options{MaxConcurrent: 1, MaxWait: 10 * time.Millisecond})
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	go func() {
+		_ = p.Do(context.Background(), func(context.Context) error {
+			close(started)
+			<-finish
+			return nil
+		})
+	}()
+	<-started
+
+	err := p.Do(context.Background(), func(context.Context) error { return nil })
+	var full *resilience.ErrBulkheadFull
+	if !errors.As(err, &full) || !gateway.IsTemporary(err) {
+		t.Fatalf("expected a temporary ErrBulkheadFull, got %v", err)
+	}
+	if stats := p.Stats(); stats.InFlight != 1 || stats.BulkheadRejected != 1 {
+		t.Errorf("expected one call in flight and one rejected, got %+v", stats)
+	}
+	close(finish)
+}
+
+func TestWriteMetrics(t *testing.T) {
+	p := resilience.NewPolicy("stripe", resilience.Options{MaxConcurrent: 1})
+	_ = p.Do(context.Background(), func(context.Context) error { return outage{} })
+
+	var buf bytes.Buffer
+	if err := resilience.WriteMetrics(&buf, p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{
+		"# TYPE payments_provider_breaker_state gauge",
+		`payments_provider_breaker_state{provider="stripe"} 0`,
+		`payments_provider_calls_failed_total{provider="stripe"} 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package shared_test
+
+import (
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/shared"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     shared.Config
+		wantErr bool
+	}{
+		{"simulated in development", shared.Config{Environment: "development", StripeSimulated: true, StripeSecretKey: "sk_test_placeholder"}, false},
+		{"real key in production", shared.Config{Environment: "production", StripeSecretKey: "sk_live_123"}, false},
+		{"simulated in production", shared.Config{Environment: "production", StripeSimulated: true, StripeSecretKey: "sk_live_123"}, true},
+		{"placeholder key in production", shared.Config{Environment: "production", StripeSecretKey: "sk_test_placeholder"}, true},
+		{"placeholder key in staging", shared.Config{Environment: "staging", StripeSimulated: true, StripeSecretKey: "sk_test_placeholder"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadConfig_DoesNotSimulateByDefault(t *testing.T) {
+	t.Setenv("STRIPE_SECRET_KEY", "")
+	t.Setenv("STRIPE_API_URL", "")
+	t.Setenv("STRIPE_SIMULATED", "")
+	if shared.LoadConfig().StripeSimulated {
+		t.Error("StripeSimulated = true without STRIPE_SIMULATED")
+	}
+}
@@ -0,0 +1,37 @@
+package shared
+
+import "strings"
+
+// FieldError is a rule a request field violates.
+type FieldError struct {
+	Field   string `json:"field"`   // JSON name, with dots and indexes for nested fields: lineItems[0].quantity
+	Code    string `json:"code"`    // stable rule name: required, positive, length, ...
+	Message string `json:"message"` // human-readable explanation
+}
+
+// ValidationError lists every rule a command or request violates, so the
+// caller can fix them all at once.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		messages[i] = f.Field + ": " + f.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Add records a violated rule.
+func (e *ValidationError) Add(field, code, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Code: code, Message: message})
+}
+
+// OrNil returns the error if any rule was violated, otherwise nil.
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
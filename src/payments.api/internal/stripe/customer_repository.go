@@ -0,0 +1,96 @@
+package stripe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Customer maps a SmartHealth user to their Stripe Customer.
+type Customer struct {
+	UserID                 string
+	StripeCustomerID       string
+	DefaultPaymentMethodID string // charged off-session; empty when none is selected
+	CreatedAt              time.Time
+	UpdatedAt              *time.Time
+}
+
+// CustomerRepository defines the persistence contract for customer mappings.
+type CustomerRepository interface {
+	FindByUserID(ctx context.Context, userID string) (*Customer, error)
+	// Create stores a new mapping. If the user already has one (a concurrent
+	// first use), the existing mapping is kept and returned.
+	Create(ctx context.Context, customer *Customer) (*Customer, error)
+	SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) error
+}
+
+// PostgresCustomerRepository implements CustomerRepository using PostgreSQL.
+type PostgresCustomerRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresCustomerRepository creates a new PostgreSQL-backed customer repository.
+func NewPostgresCustomerRepository(pool *pgxpool.Pool) *PostgresCustomerRepository {
+	return &PostgresCustomerRepository{pool: pool}
+}
+
+const customerColumns = `user_id, stripe_customer_id, COALESCE(default_payment_method_id, ''), created_at, updated_at`
+
+// FindByUserID retrieves the customer mapping of a user.
+func (r *PostgresCustomerRepository) FindByUserID(ctx context.Context, userID string) (*Customer, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+customerColumns+` FROM customers WHERE user_id = $1`, userID)
+	return scanCustomer(row)
+}
+
+// Create persists a new customer mapping, keeping an existing one on conflict.
+func (r *PostgresCustomerRepository) Create(ctx context.Context, c *Customer) (*Customer, error) {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO customers (user_id, stripe_customer_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING`,
+		c.UserID,
+		c.StripeCustomerID,
+		c.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("insert customer: %w", err)
+	}
+	return r.FindByUserID(ctx, c.UserID)
+}
+
+// SetDefaultPaymentMethod stores the user's default payment method ("" clears it).
+func (r *PostgresCustomerRepository) SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE customers SET default_payment_method_id = $2, updated_at = $3
+		WHERE user_id = $1`,
+		userID,
+		nilIfEmpty(paymentMethodID),
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("update default payment method: %w", err)
+	}
+	return nil
+}
+
+func scanCustomer(row pgx.Row) (*Customer, error) {
+	var c Customer
+	err := row.Scan(&c.UserID, &c.StripeCustomerID, &c.DefaultPaymentMethodID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan customer: %w", err)
+	}
+	return &c, nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
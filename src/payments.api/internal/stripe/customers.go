@@ -0,0 +1,199 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	stripego "github.com/stripe/stripe-go/v81"
+)
+
+// -----------------------------------------------------------------------
+// Customers and saved payment methods
+//
+// Architectural Decision: Stripe Customers are created lazily, the first time
+// a user pays or saves a card, and the mapping is kept in our customers table
+// so the Stripe API is not searched on every charge. Creation uses an
+// idempotency key derived from the user ID, so two concurrent first payments
+// receive the same Stripe Customer and the second insert is a no-op.
+//
+// Cards are saved through SetupIntents confirmed by the frontend, never by
+// sending card details to this service.
+// -----------------------------------------------------------------------
+
+// EnsureCustomer returns the user's Stripe Customer ID, creating it on first use.
+func (s *StripeService) EnsureCustomer(ctx context.Context, userID string) (string, error) {
+	c, err := s.customer(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return c.StripeCustomerID, nil
+}
+
+// CreateSetupIntent starts saving a payment method for off-session charges.
+func (s *StripeService) CreateSetupIntent(ctx context.Context, userID string) (*SetupIntent, error) {
+	c, err := s.customer(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	params := &stripego.SetupIntentParams{
+		Customer: stripego.String(c.StripeCustomerID),
+		Usage:    stripego.String(string(stripego.SetupIntentUsageOffSession)),
+		AutomaticPaymentMethods: &stripego.SetupIntentAutomaticPaymentMethodsParams{
+			Enabled: stripego.Bool(true),
+		},
+		Metadata: map[string]string{
+			"userId":  userID,
+			"service": "smarthealth-payments",
+		},
+	}
+	params.Context = ctx
+
+	intent, err := s.api.setupIntents.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("stripe CreateSetupIntent: %w", classify(err))
+	}
+	return &SetupIntent{ID: intent.ID, ClientSecret: intent.ClientSecret}, nil
+}
+
+// ListPaymentMethods returns the cards saved on the user's customer.
+func (s *StripeService) ListPaymentMethods(ctx context.Context, userID string) ([]PaymentMethod, error) {
+	c, err := s.customers.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		// No customer yet means nothing was ever saved – don't create one just to list
+		return []PaymentMethod{}, nil
+	}
+
+	params := &stripego.CustomerListPaymentMethodsParams{
+		Customer: stripego.String(c.StripeCustomerID),
+		Type:     stripego.String(string(stripego.PaymentMethodTypeCard)),
+	}
+	params.Context = ctx
+
+	methods := []PaymentMethod{}
+	iter := s.api.customers.ListPaymentMethods(params)
+	for iter.Next() {
+		pm := iter.PaymentMethod()
+		method := PaymentMethod{ID: pm.ID, Default: pm.ID == c.DefaultPaymentMethodID}
+		if pm.Card != nil {
+			method.Brand = string(pm.Card.Brand)
+			method.Last4 = pm.Card.Last4
+			method.ExpMonth = pm.Card.ExpMonth
+			method.ExpYear = pm.Card.ExpYear
+		}
+		methods = append(methods, method)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("stripe ListPaymentMethods: %w", classify(err))
+	}
+	return methods, nil
+}
+
+// DetachPaymentMethod removes a saved card from the user's customer.
+func (s *StripeService) DetachPaymentMethod(ctx context.Context, userID, paymentMethodID string) error {
+	c, err := s.ownedPaymentMethod(ctx, userID, paymentMethodID)
+	if err != nil {
+		return err
+	}
+
+	params := &stripego.PaymentMethodDetachParams{}
+	params.Context = ctx
+	if _, err := s.api.paymentMethods.Detach(paymentMethodID, params); err != nil {
+		return fmt.Errorf("stripe DetachPaymentMethod: %w", classify(err))
+	}
+
+	if c.DefaultPaymentMethodID == paymentMethodID {
+		return s.customers.SetDefaultPaymentMethod(ctx, userID, "")
+	}
+	return nil
+}
+
+// SetDefaultPaymentMethod selects the saved card charged off-session.
+func (s *StripeService) SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) error {
+	c, err := s.ownedPaymentMethod(ctx, userID, paymentMethodID)
+	if err != nil {
+		return err
+	}
+
+	// Mirror the choice on the Stripe Customer so the dashboard agrees
+	params := &stripego.CustomerParams{
+		InvoiceSettings: &stripego.CustomerInvoiceSettingsParams{
+			DefaultPaymentMethod: stripego.String(paymentMethodID),
+		},
+	}
+	params.Context = ctx
+	if _, err := s.api.customers.Update(c.StripeCustomerID, params); err != nil {
+		return fmt.Errorf("stripe UpdateCustomer: %w", classify(err))
+	}
+
+	return s.customers.SetDefaultPaymentMethod(ctx, userID, paymentMethodID)
+}
+
+// customer loads the user's customer mapping, creating the Stripe Customer if there is none.
+func (s *StripeService) customer(ctx context.Context, userID string) (*Customer, error) {
+	c, err := s.customers.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if c != nil {
+		return c, nil
+	}
+
+	params := &stripego.CustomerParams{
+		Description: stripego.String(fmt.Sprintf("SmartHealth user %s", userID)),
+		Metadata: map[string]string{
+			"userId":  userID,
+			"service": "smarthealth-payments",
+		},
+	}
+	params.Context = ctx
+	params.SetIdempotencyKey("customer-" + userID)
+
+	var created *stripego.Customer
+	err = s.retry.do(ctx, s.logger, "CreateCustomer", func() (err error) {
+		created, err = s.api.customers.New(params)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("stripe CreateCustomer: %w", err)
+	}
+
+	s.logger.Info("Stripe customer created", "userId", userID, "customerId", created.ID)
+
+	return s.customers.Create(ctx, &Customer{
+		UserID:           userID,
+		StripeCustomerID: created.ID,
+		CreatedAt:        time.Now().UTC(),
+	})
+}
+
+// ownedPaymentMethod checks that the payment method is attached to the user's customer.
+func (s *StripeService) ownedPaymentMethod(ctx context.Context, userID, paymentMethodID string) (*Customer, error) {
+	c, err := s.customers.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, ErrPaymentMethodNotFound
+	}
+
+	params := &stripego.PaymentMethodParams{}
+	params.Context = ctx
+	pm, err := s.api.paymentMethods.Get(paymentMethodID, params)
+	if err != nil {
+		var stripeErr *stripego.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripego.ErrorCodeResourceMissing {
+			return nil, ErrPaymentMethodNotFound
+		}
+		return nil, fmt.Errorf("stripe GetPaymentMethod: %w", classify(err))
+	}
+	if pm.Customer == nil || pm.Customer.ID != c.StripeCustomerID {
+		return nil, ErrPaymentMethodNotFound
+	}
+	return c, nil
+}
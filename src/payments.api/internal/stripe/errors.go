@@ -0,0 +1,134 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	stripego "github.com/stripe/stripe-go/v81"
+)
+
+// -----------------------------------------------------------------------
+// Error taxonomy
+//
+// Architectural Decision: Every error leaving the service is classified into
+// one of the types below, so callers decide on the type instead of parsing
+// Stripe messages. Transient errors (rate limits, connection failures and
+// Stripe 5xx, idempotency conflicts) report Temporary() true: the service
+// retries them itself and callers leave the payment untouched when they
+// persist (see gateway.IsTemporary). Declines, authentication demands and
+// invalid requests will not change by retrying and are returned at once.
+// -----------------------------------------------------------------------
+
+// ErrCardDeclined is returned when the issuer declined the charge.
+type ErrCardDeclined struct {
+	PaymentIntentID string
+	Code            string // Stripe error code, e.g. "card_declined" or "expired_card"
+	DeclineCode     string // issuer reason, e.g. "insufficient_funds"; may be empty
+	Message         string // Stripe's message, safe to show to the cardholder
+}
+
+func (e *ErrCardDeclined) Error() string {
+	if e.DeclineCode != "" {
+		return fmt.Sprintf("card declined (%s): %s", e.DeclineCode, e.Message)
+	}
+	return fmt.Sprintf("card declined (%s): %s", e.Code, e.Message)
+}
+
+// ErrRateLimited is returned when Stripe throttled the request (HTTP 429).
+type ErrRateLimited struct {
+	Err error
+}
+
+func (e *ErrRateLimited) Error() string   { return fmt.Sprintf("stripe rate limit exceeded: %v", e.Err) }
+func (e *ErrRateLimited) Unwrap() error   { return e.Err }
+func (e *ErrRateLimited) Temporary() bool { return true }
+
+// ErrAPIConnection is returned when Stripe could not be reached, timed out,
+// or failed on its side (HTTP 5xx).
+type ErrAPIConnection struct {
+	Err error
+}
+
+func (e *ErrAPIConnection) Error() string   { return fmt.Sprintf("stripe unavailable: %v", e.Err) }
+func (e *ErrAPIConnection) Unwrap() error   { return e.Err }
+func (e *ErrAPIConnection) Temporary() bool { return true }
+
+// ErrIdempotencyConflict is returned when a request with the same
+// idempotency key is still being processed by Stripe (HTTP 409).
+type ErrIdempotencyConflict struct {
+	Err error
+}
+
+func (e *ErrIdempotencyConflict) Error() string {
+	return fmt.Sprintf("stripe idempotency conflict: %v", e.Err)
+}
+func (e *ErrIdempotencyConflict) Unwrap() error   { return e.Err }
+func (e *ErrIdempotencyConflict) Temporary() bool { return true }
+
+// ErrInvalidRequest is returned when Stripe rejected the request itself,
+// e.g. an unsupported currency or an intent in the wrong state.
+type ErrInvalidRequest struct {
+	Code    string
+	Param   string
+	Message string
+	Err     error
+}
+
+func (e *ErrInvalidRequest) Error() string {
+	if e.Param != "" {
+		return fmt.Sprintf("invalid Stripe request (%s, %s): %s", e.Code, e.Param, e.Message)
+	}
+	return fmt.Sprintf("invalid Stripe request (%s): %s", e.Code, e.Message)
+}
+func (e *ErrInvalidRequest) Unwrap() error { return e.Err }
+
+// classify converts an error of the Stripe SDK into the taxonomy above.
+// Errors that fit no class are returned unchanged.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var stripeErr *stripego.Error
+	if !errors.As(err, &stripeErr) {
+		var netErr net.Error
+		if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+			return &ErrAPIConnection{Err: err}
+		}
+		return err
+	}
+
+	switch {
+	case stripeErr.Code == stripego.ErrorCodeAuthenticationRequired && stripeErr.PaymentIntent != nil:
+		return &ErrAuthenticationRequired{
+			PaymentIntentID: stripeErr.PaymentIntent.ID,
+			ClientSecret:    stripeErr.PaymentIntent.ClientSecret,
+		}
+	case stripeErr.Type == stripego.ErrorTypeCard:
+		declined := &ErrCardDeclined{
+			Code:        string(stripeErr.Code),
+			DeclineCode: string(stripeErr.DeclineCode),
+			Message:     stripeErr.Msg,
+		}
+		if stripeErr.PaymentIntent != nil {
+			declined.PaymentIntentID = stripeErr.PaymentIntent.ID
+		}
+		return declined
+	case stripeErr.HTTPStatusCode == http.StatusTooManyRequests:
+		return &ErrRateLimited{Err: err}
+	case stripeErr.HTTPStatusCode == http.StatusConflict || stripeErr.Type == stripego.ErrorTypeIdempotency && stripeErr.Code == stripego.ErrorCodeIdempotencyKeyInUse:
+		return &ErrIdempotencyConflict{Err: err}
+	case stripeErr.HTTPStatusCode >= http.StatusInternalServerError || stripeErr.Type == stripego.ErrorTypeAPI:
+		return &ErrAPIConnection{Err: err}
+	default:
+		return &ErrInvalidRequest{
+			Code:    string(stripeErr.Code),
+			Param:   stripeErr.Param,
+			Message: stripeErr.Msg,
+			Err:     err,
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package fakeapi
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	stripego "github.com/stripe/stripe-go/v81"
+)
+
+// Test payment methods, named like Stripe's. Each use of one creates a new
+// payment method with the card's behaviour, as it does on Stripe.
+const (
+	PaymentMethodVisa                   = "pm_card_visa"
+	PaymentMethodMastercard             = "pm_card_mastercard"
+	PaymentMethodDeclined               = "pm_card_chargeDeclined"
+	PaymentMethodInsufficientFunds      = "pm_card_chargeDeclinedInsufficientFunds"
+	PaymentMethodAuthenticationRequired = "pm_card_authenticationRequired"
+)
+
+// card is how a test card behaves when charged.
+type card struct {
+	brand                  stripego.PaymentMethodCardBrand
+	last4                  string
+	declineCode            stripego.DeclineCode
+	message                string
+	requiresAuthentication bool // off-session charges fail with authentication_required
+}
+
+var testCards = map[string]card{
+	PaymentMethodVisa:       {brand: stripego.PaymentMethodCardBrandVisa, last4: "4242"},
+	PaymentMethodMastercard: {brand: stripego.PaymentMethodCardBrandMastercard, last4: "4444"},
+	PaymentMethodDeclined: {
+		brand: stripego.PaymentMethodCardBrandVisa, last4: "0002",
+		declineCode: stripego.DeclineCodeGenericDecline, message: "Your card was declined.",
+	},
+	PaymentMethodInsufficientFunds: {
+		brand: stripego.PaymentMethodCardBrandVisa, last4: "9995",
+		declineCode: stripego.DeclineCodeInsufficientFunds, message: "Your card has insufficient funds.",
+	},
+	PaymentMethodAuthenticationRequired: {brand: stripego.PaymentMethodCardBrandVisa, last4: "3184", requiresAuthentication: true},
+}
+
+// paymentMethod is a stored payment method and the card behind it.
+type paymentMethod struct {
+	*stripego.PaymentMethod
+	card card
+}
+
+// resolvePaymentMethod finds a stored payment method or creates one from a
+// test payment method name. Callers hold s.mu.
+func (s *Server) resolvePaymentMethod(id string) (*paymentMethod, bool) {
+	if pm, ok := s.paymentMethods[id]; ok {
+		return pm, true
+	}
+	tc, ok := testCards[id]
+	if !ok {
+		return nil, false
+	}
+
+	pm := &paymentMethod{
+		PaymentMethod: &stripego.PaymentMethod{
+			ID:      s.nextID("pm"),
+			Object:  "payment_method",
+			Type:    stripego.PaymentMethodTypeCard,
+			Created: time.Now().Unix(),
+			Card: &stripego.PaymentMethodCard{
+				Brand:    tc.brand,
+				Last4:    tc.last4,
+				ExpMonth: 12,
+				ExpYear:  int64(time.Now().Year() + 3),
+			},
+		},
+		card: tc,
+	}
+	s.paymentMethods[pm.ID] = pm
+	return pm, true
+}
+
+func (s *Server) createCustomer(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cust := &stripego.Customer{
+		ID:          s.nextID("cus"),
+		Object:      "customer",
+		Created:     time.Now().Unix(),
+		Description: c.Request.PostForm.Get("description"),
+		Email:       c.Request.PostForm.Get("email"),
+		Metadata:    formMetadata(c),
+	}
+	s.customers[cust.ID] = cust
+	s.record(stripego.EventTypeCustomerCreated, cust)
+	c.JSON(http.StatusOK, cust)
+}
+
+func (s *Server) getCustomer(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cust, ok := s.customers[c.Param("id")]
+	if !ok {
+		notFound(c, "customer", c.Param("id"))
+		return
+	}
+	c.JSON(http.StatusOK, cust)
+}
+
+func (s *Server) updateCustomer(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cust, ok := s.customers[c.Param("id")]
+	if !ok {
+		notFound(c, "customer", c.Param("id"))
+		return
+	}
+
+	form := c.Request.PostForm
+	if v, ok := form["description"]; ok {
+		cust.Description = v[0]
+	}
+	if v, ok := form["invoice_settings[default_payment_method]"]; ok {
+		if pm, found := s.paymentMethods[v[0]]; !found || pm.Customer == nil || pm.Customer.ID != cust.ID {
+			notFound(c, "payment_method", v[0])
+			return
+		}
+		cust.InvoiceSettings = &stripego.CustomerInvoiceSettings{
+			DefaultPaymentMethod: &stripego.PaymentMethod{ID: v[0]},
+		}
+	}
+	for k, v := range formMetadata(c) {
+		if cust.Metadata == nil {
+			cust.Metadata = map[string]string{}
+		}
+		cust.Metadata[k] = v
+	}
+
+	s.record(stripego.EventTypeCustomerUpdated, cust)
+	c.JSON(http.StatusOK, cust)
+}
+
+func (s *Server) listCustomerPaymentMethods(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customerID := c.Param("id")
+	if _, ok := s.customers[customerID]; !ok {
+		notFound(c, "customer", customerID)
+		return
+	}
+
+	methods := []*stripego.PaymentMethod{}
+	for _, pm := range s.paymentMethods {
+		if pm.Customer != nil && pm.Customer.ID == customerID {
+			methods = append(methods, pm.PaymentMethod)
+		}
+	}
+	// IDs are sequential, so the highest is the newest
+	sort.Slice(methods, func(i, j int) bool { return methods[i].ID > methods[j].ID })
+	c.JSON(http.StatusOK, listBody(c, methods, false))
+}
+
+func (s *Server) getPaymentMethod(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pm, ok := s.paymentMethods[c.Param("id")]
+	if !ok {
+		notFound(c, "payment_method", c.Param("id"))
+		return
+	}
+	c.JSON(http.StatusOK, pm.PaymentMethod)
+}
+
+// attachPaymentMethod saves a payment method on a customer, as a confirmed
+// SetupIntent does. Test payment method names are accepted.
+func (s *Server) attachPaymentMethod(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	customerID := c.Request.PostForm.Get("customer")
+	if _, ok := s.customers[customerID]; !ok {
+		notFound(c, "customer", customerID)
+		return
+	}
+	pm, ok := s.resolvePaymentMethod(c.Param("id"))
+	if !ok {
+		notFound(c, "payment_method", c.Param("id"))
+		return
+	}
+	if pm.Customer != nil && pm.Customer.ID != customerID {
+		invalidRequest(c, "", "payment_method", "The payment method you provided has already been attached to a customer.")
+		return
+	}
+
+	pm.Customer = &stripego.Customer{ID: customerID}
+	s.record(stripego.EventTypePaymentMethodAttached, pm.PaymentMethod)
+	c.JSON(http.StatusOK, pm.PaymentMethod)
+}
+
+func (s *Server) detachPaymentMethod(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pm, ok := s.paymentMethods[c.Param("id")]
+	if !ok {
+		notFound(c, "payment_method", c.Param("id"))
+		return
+	}
+	if pm.Customer == nil {
+		invalidRequest(c, "", "payment_method", "The payment method you provided is not attached to a customer so detachment is impossible.")
+		return
+	}
+
+	pm.Customer = nil
+	s.record(stripego.EventTypePaymentMethodDetached, pm.PaymentMethod)
+	c.JSON(http.StatusOK, pm.PaymentMethod)
+}
+
+func (s *Server) createSetupIntent(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID("seti")
+	si := &stripego.SetupIntent{
+		ID:           id,
+		Object:       "setup_intent",
+		ClientSecret: id + "_secret_fake",
+		Created:      time.Now().Unix(),
+		Metadata:     formMetadata(c),
+		Status:       stripego.SetupIntentStatusRequiresPaymentMethod,
+		Usage:        stripego.SetupIntentUsage(c.Request.PostForm.Get("usage")),
+	}
+	if customerID := c.Request.PostForm.Get("customer"); customerID != "" {
+		if _, ok := s.customers[customerID]; !ok {
+			notFound(c, "customer", customerID)
+			return
+		}
+		si.Customer = &stripego.Customer{ID: customerID}
+	}
+
+	s.setupIntents[id] = si
+	s.record(stripego.EventTypeSetupIntentCreated, si)
+	c.JSON(http.StatusOK, si)
+}
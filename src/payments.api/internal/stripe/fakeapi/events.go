@@ -0,0 +1,83 @@
+package fakeapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	stripego "github.com/stripe/stripe-go/v81"
+)
+
+const (
+	defaultListLimit = 10
+	maxListLimit     = 100
+)
+
+// listEvents lists events newest first, filtered by type and paged with
+// limit and starting_after like the Stripe API.
+func (s *Server) listEvents(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	limit := defaultListLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			invalidRequest(c, stripego.ErrorCodeParameterInvalidInteger, "limit", "Invalid integer: "+v)
+			return
+		}
+		limit = n
+	}
+	eventType := c.Query("type")
+	startingAfter := c.Query("starting_after")
+
+	events := []*stripego.Event{}
+	hasMore := false
+	skipping := startingAfter != ""
+	for i := len(s.events) - 1; i >= 0; i-- {
+		e := s.events[i]
+		if skipping {
+			skipping = e.ID != startingAfter
+			continue
+		}
+		if eventType != "" && string(e.Type) != eventType {
+			continue
+		}
+		if len(events) == limit {
+			hasMore = true
+			break
+		}
+		events = append(events, e)
+	}
+	c.JSON(http.StatusOK, listBody(c, events, hasMore))
+}
+
+func (s *Server) getEvent(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.events {
+		if e.ID == c.Param("id") {
+			c.JSON(http.StatusOK, e)
+			return
+		}
+	}
+	notFound(c, "event", c.Param("id"))
+}
+
+// Events returns the recorded events, oldest first.
+func (s *Server) Events() []stripego.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make([]stripego.Event, 0, len(s.events))
+	for _, e := range s.events {
+		events = append(events, *e)
+	}
+	return events
+}
+
+// listBody renders a Stripe list object.
+func listBody(c *gin.Context, data interface{}, hasMore bool) gin.H {
+	return gin.H{"object": "list", "url": c.Request.URL.Path, "has_more": hasMore, "data": data}
+}
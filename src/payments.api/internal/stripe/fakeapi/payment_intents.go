@@ -0,0 +1,300 @@
+package fakeapi
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	stripego "github.com/stripe/stripe-go/v81"
+)
+
+func (s *Server) createPaymentIntent(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	form := c.Request.PostForm
+	amount, ok := formInt64(c, "amount")
+	if !ok || amount <= 0 {
+		invalidRequest(c, stripego.ErrorCodeParameterMissing, "amount", "Missing required param: amount.")
+		return
+	}
+	if form.Get("currency") == "" {
+		invalidRequest(c, stripego.ErrorCodeParameterMissing, "currency", "Missing required param: currency.")
+		return
+	}
+
+	id := s.nextID("pi")
+	pi := &stripego.PaymentIntent{
+		ID:            id,
+		Object:        "payment_intent",
+		Amount:        amount,
+		Currency:      stripego.Currency(form.Get("currency")),
+		CaptureMethod: stripego.PaymentIntentCaptureMethodAutomatic,
+		ClientSecret:  id + "_secret_fake",
+		Created:       time.Now().Unix(),
+		Description:   form.Get("description"),
+		Metadata:      formMetadata(c),
+		Status:        stripego.PaymentIntentStatusRequiresPaymentMethod,
+	}
+	if form.Get("capture_method") == string(stripego.PaymentIntentCaptureMethodManual) {
+		pi.CaptureMethod = stripego.PaymentIntentCaptureMethodManual
+	}
+	if customerID := form.Get("customer"); customerID != "" {
+		if _, ok := s.customers[customerID]; !ok {
+			notFound(c, "customer", customerID)
+			return
+		}
+		pi.Customer = &stripego.Customer{ID: customerID}
+	}
+
+	var pm *paymentMethod
+	if pmID := form.Get("payment_method"); pmID != "" {
+		if pm, ok = s.resolvePaymentMethod(pmID); !ok {
+			notFound(c, "payment_method", pmID)
+			return
+		}
+		pi.PaymentMethod = &stripego.PaymentMethod{ID: pm.ID}
+		pi.Status = stripego.PaymentIntentStatusRequiresConfirmation
+	}
+
+	s.intents[id] = pi
+	s.record(stripego.EventTypePaymentIntentCreated, pi)
+
+	if form.Get("confirm") == "true" {
+		s.confirm(c, pi, pm, form.Get("off_session") == "true")
+		return
+	}
+	c.JSON(http.StatusOK, pi)
+}
+
+func (s *Server) getPaymentIntent(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pi, ok := s.intents[c.Param("id")]
+	if !ok {
+		notFound(c, "payment_intent", c.Param("id"))
+		return
+	}
+	c.JSON(http.StatusOK, pi)
+}
+
+// confirmPaymentIntent confirms an intent, as Stripe.js does for the patient.
+// On-session confirmation counts as a completed 3-D Secure challenge.
+func (s *Server) confirmPaymentIntent(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pi, ok := s.intents[c.Param("id")]
+	if !ok {
+		notFound(c, "payment_intent", c.Param("id"))
+		return
+	}
+	switch pi.Status {
+	case stripego.PaymentIntentStatusRequiresPaymentMethod,
+		stripego.PaymentIntentStatusRequiresConfirmation,
+		stripego.PaymentIntentStatusRequiresAction:
+	default:
+		unexpectedState(c, pi, "confirm")
+		return
+	}
+
+	pmID := c.Request.PostForm.Get("payment_method")
+	if pmID == "" && pi.PaymentMethod != nil {
+		pmID = pi.PaymentMethod.ID
+	}
+	if pmID == "" {
+		invalidRequest(c, stripego.ErrorCodePaymentIntentUnexpectedState, "payment_method",
+			"You cannot confirm this PaymentIntent because it's missing a payment method.")
+		return
+	}
+	pm, ok := s.resolvePaymentMethod(pmID)
+	if !ok {
+		notFound(c, "payment_method", pmID)
+		return
+	}
+	pi.PaymentMethod = &stripego.PaymentMethod{ID: pm.ID}
+	s.confirm(c, pi, pm, c.Request.PostForm.Get("off_session") == "true")
+}
+
+// confirm charges the payment method and answers with the intent, or with the
+// card error Stripe returns for declines. Callers hold s.mu.
+func (s *Server) confirm(c *gin.Context, pi *stripego.PaymentIntent, pm *paymentMethod, offSession bool) {
+	if pm == nil {
+		invalidRequest(c, stripego.ErrorCodePaymentIntentUnexpectedState, "payment_method",
+			"You cannot confirm this PaymentIntent because it's missing a payment method.")
+		return
+	}
+
+	switch {
+	case pm.card.declineCode != "":
+		s.fail(c, pi, &stripego.Error{
+			Type:        stripego.ErrorTypeCard,
+			Code:        stripego.ErrorCodeCardDeclined,
+			DeclineCode: pm.card.declineCode,
+			Msg:         pm.card.message,
+		})
+	case pm.card.requiresAuthentication && offSession:
+		// Stripe leaves the intent for an on-session retry with the client secret
+		s.fail(c, pi, &stripego.Error{
+			Type: stripego.ErrorTypeCard,
+			Code: stripego.ErrorCodeAuthenticationRequired,
+			Msg:  "Your card was declined. This transaction requires authentication.",
+		})
+	case pi.CaptureMethod == stripego.PaymentIntentCaptureMethodManual:
+		pi.LastPaymentError = nil
+		pi.AmountCapturable = pi.Amount
+		pi.Status = stripego.PaymentIntentStatusRequiresCapture
+		s.record(stripego.EventTypePaymentIntentAmountCapturableUpdated, pi)
+		c.JSON(http.StatusOK, pi)
+	default:
+		pi.LastPaymentError = nil
+		pi.AmountReceived = pi.Amount
+		pi.Status = stripego.PaymentIntentStatusSucceeded
+		s.record(stripego.EventTypePaymentIntentSucceeded, pi)
+		c.JSON(http.StatusOK, pi)
+	}
+}
+
+// fail records a failed confirmation and answers 402 with the card error. Callers hold s.mu.
+func (s *Server) fail(c *gin.Context, pi *stripego.PaymentIntent, cardErr *stripego.Error) {
+	pi.Status = stripego.PaymentIntentStatusRequiresPaymentMethod
+	lastErr := *cardErr
+	pi.LastPaymentError = &lastErr
+	s.record(stripego.EventTypePaymentIntentPaymentFailed, pi)
+
+	// The error carries the intent, which must not point back at the error
+	cardErr.PaymentIntent = pi
+	c.JSON(http.StatusPaymentRequired, errorBody(cardErr))
+}
+
+func (s *Server) capturePaymentIntent(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pi, ok := s.intents[c.Param("id")]
+	if !ok {
+		notFound(c, "payment_intent", c.Param("id"))
+		return
+	}
+	if pi.Status != stripego.PaymentIntentStatusRequiresCapture {
+		unexpectedState(c, pi, "capture")
+		return
+	}
+
+	amount := pi.AmountCapturable
+	if v, ok := formInt64(c, "amount_to_capture"); ok {
+		if v <= 0 || v > pi.AmountCapturable {
+			invalidRequest(c, stripego.ErrorCodeAmountTooLarge, "amount_to_capture",
+				fmt.Sprintf("The amount to capture must be between 1 and %d.", pi.AmountCapturable))
+			return
+		}
+		amount = v
+	}
+
+	pi.AmountReceived = amount
+	pi.AmountCapturable = 0
+	pi.Status = stripego.PaymentIntentStatusSucceeded
+	s.record(stripego.EventTypePaymentIntentSucceeded, pi)
+	c.JSON(http.StatusOK, pi)
+}
+
+func (s *Server) cancelPaymentIntent(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pi, ok := s.intents[c.Param("id")]
+	if !ok {
+		notFound(c, "payment_intent", c.Param("id"))
+		return
+	}
+	if pi.Status == stripego.PaymentIntentStatusSucceeded || pi.Status == stripego.PaymentIntentStatusCanceled {
+		unexpectedState(c, pi, "cancel")
+		return
+	}
+
+	pi.AmountCapturable = 0
+	pi.CanceledAt = time.Now().Unix()
+	pi.Status = stripego.PaymentIntentStatusCanceled
+	s.record(stripego.EventTypePaymentIntentCanceled, pi)
+	c.JSON(http.StatusOK, pi)
+}
+
+func (s *Server) createRefund(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	intentID := c.Request.PostForm.Get("payment_intent")
+	if intentID == "" {
+		invalidRequest(c, stripego.ErrorCodeParameterMissing, "payment_intent", "Missing required param: payment_intent.")
+		return
+	}
+	pi, ok := s.intents[intentID]
+	if !ok {
+		notFound(c, "payment_intent", intentID)
+		return
+	}
+	if pi.Status != stripego.PaymentIntentStatusSucceeded {
+		invalidRequest(c, stripego.ErrorCodePaymentIntentUnexpectedState, "payment_intent",
+			fmt.Sprintf("This PaymentIntent has no successful charge to refund (status %s).", pi.Status))
+		return
+	}
+
+	remaining := pi.AmountReceived - s.refunded(intentID)
+	if remaining <= 0 {
+		invalidRequest(c, stripego.ErrorCodeChargeAlreadyRefunded, "payment_intent", "This charge has already been refunded.")
+		return
+	}
+	amount := remaining
+	if v, ok := formInt64(c, "amount"); ok {
+		if v <= 0 || v > remaining {
+			invalidRequest(c, stripego.ErrorCodeAmountTooLarge, "amount",
+				fmt.Sprintf("Refund amount (%d) is greater than unrefunded amount on charge (%d).", v, remaining))
+			return
+		}
+		amount = v
+	}
+
+	r := &stripego.Refund{
+		ID:            s.nextID("re"),
+		Object:        "refund",
+		Amount:        amount,
+		Currency:      pi.Currency,
+		Created:       time.Now().Unix(),
+		Metadata:      formMetadata(c),
+		PaymentIntent: &stripego.PaymentIntent{ID: intentID},
+		Status:        stripego.RefundStatusSucceeded,
+	}
+	s.refunds[r.ID] = r
+	s.record(stripego.EventTypeRefundCreated, r)
+	c.JSON(http.StatusOK, r)
+}
+
+func (s *Server) getRefund(c *gin.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.refunds[c.Param("id")]
+	if !ok {
+		notFound(c, "refund", c.Param("id"))
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// refunded sums the refunds of an intent. Callers hold s.mu.
+func (s *Server) refunded(intentID string) int64 {
+	var total int64
+	for _, r := range s.refunds {
+		if r.PaymentIntent != nil && r.PaymentIntent.ID == intentID {
+			total += r.Amount
+		}
+	}
+	return total
+}
+
+func unexpectedState(c *gin.Context, pi *stripego.PaymentIntent, action string) {
+	invalidRequest(c, stripego.ErrorCodePaymentIntentUnexpectedState, "",
+		fmt.Sprintf("You cannot %s this PaymentIntent because it has a status of %s.", action, pi.Status))
+}
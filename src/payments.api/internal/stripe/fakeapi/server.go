@@ -0,0 +1,254 @@
+package fakeapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	stripego "github.com/stripe/stripe-go/v81"
+)
+
+// -----------------------------------------------------------------------
+// Fake Stripe API
+//
+// Architectural Decision: Where the simulator replaces stripe.Service, this
+// server replaces Stripe itself: it speaks the subset of the Stripe REST API
+// the service uses (payment intents, refunds, customers, payment methods,
+// setup intents, events), form-encoded requests and JSON responses rendered
+// from stripe-go's own types, so the real StripeService – parameters, SDK
+// decoding, error handling – is exercised end-to-end without network access.
+// Card behaviour follows Stripe's test payment methods (pm_card_visa,
+// pm_card_chargeDeclined, ...). State is kept in memory for the lifetime of
+// the server; nothing is validated beyond what the service relies on.
+// -----------------------------------------------------------------------
+
+// Server is an in-memory stand-in for the Stripe API.
+type Server struct {
+	engine *gin.Engine
+
+	mu             sync.Mutex
+	seq            map[string]int
+	intents        map[string]*stripego.PaymentIntent
+	refunds        map[string]*stripego.Refund
+	customers      map[string]*stripego.Customer
+	paymentMethods map[string]*paymentMethod
+	setupIntents   map[string]*stripego.SetupIntent
+	events         []*stripego.Event // oldest first
+	idempotent     map[string]*storedResponse
+	failures       []int // statuses the next requests fail with, see FailNext
+}
+
+// storedResponse is the answer to a request sent with an Idempotency-Key.
+type storedResponse struct {
+	fingerprint string
+	status      int
+	body        []byte
+}
+
+// New creates an empty fake Stripe API.
+func New() *Server {
+	s := &Server{
+		engine:         gin.New(),
+		seq:            make(map[string]int),
+		intents:        make(map[string]*stripego.PaymentIntent),
+		refunds:        make(map[string]*stripego.Refund),
+		customers:      make(map[string]*stripego.Customer),
+		paymentMethods: make(map[string]*paymentMethod),
+		setupIntents:   make(map[string]*stripego.SetupIntent),
+		idempotent:     make(map[string]*storedResponse),
+	}
+
+	v1 := s.engine.Group("/v1", s.authenticate, s.injectFailures, s.idempotency)
+	v1.POST("/payment_intents", s.createPaymentIntent)
+	v1.GET("/payment_intents/:id", s.getPaymentIntent)
+	v1.POST("/payment_intents/:id/confirm", s.confirmPaymentIntent)
+	v1.POST("/payment_intents/:id/capture", s.capturePaymentIntent)
+	v1.POST("/payment_intents/:id/cancel", s.cancelPaymentIntent)
+	v1.POST("/refunds", s.createRefund)
+	v1.GET("/refunds/:id", s.getRefund)
+	v1.POST("/customers", s.createCustomer)
+	v1.GET("/customers/:id", s.getCustomer)
+	v1.POST("/customers/:id", s.updateCustomer)
+	v1.GET("/customers/:id/payment_methods", s.listCustomerPaymentMethods)
+	v1.GET("/payment_methods/:id", s.getPaymentMethod)
+	v1.POST("/payment_methods/:id/attach", s.attachPaymentMethod)
+	v1.POST("/payment_methods/:id/detach", s.detachPaymentMethod)
+	v1.POST("/setup_intents", s.createSetupIntent)
+	v1.GET("/events", s.listEvents)
+	v1.GET("/events/:id", s.getEvent)
+	return s
+}
+
+// ServeHTTP serves the Stripe API; mount the server or wrap it in an httptest.Server.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.engine.ServeHTTP(w, r)
+}
+
+// authenticate requires an API key, as bearer token or basic auth user like
+// Stripe does. Any key is accepted.
+func (s *Server) authenticate(c *gin.Context) {
+	auth := c.GetHeader("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") && !strings.HasPrefix(auth, "Basic ") {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, errorBody(&stripego.Error{
+			Type: stripego.ErrorTypeInvalidRequest,
+			Msg:  "You did not provide an API key.",
+		}))
+		return
+	}
+	if err := c.Request.ParseForm(); err != nil {
+		c.AbortWithStatusJSON(http.StatusBadRequest, errorBody(&stripego.Error{
+			Type: stripego.ErrorTypeInvalidRequest,
+			Msg:  err.Error(),
+		}))
+		return
+	}
+}
+
+// FailNext makes the next n requests fail with the HTTP status – 429 for a
+// rate limit, 5xx for a Stripe outage – before they change any state.
+func (s *Server) FailNext(n, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < n; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+func (s *Server) injectFailures(c *gin.Context) {
+	s.mu.Lock()
+	if len(s.failures) == 0 {
+		s.mu.Unlock()
+		return
+	}
+	status := s.failures[0]
+	s.failures = s.failures[1:]
+	s.mu.Unlock()
+
+	stripeErr := &stripego.Error{Type: stripego.ErrorTypeAPI, Msg: "An unknown error occurred."}
+	if status == http.StatusTooManyRequests {
+		stripeErr = &stripego.Error{
+			Type: stripego.ErrorTypeInvalidRequest,
+			Code: stripego.ErrorCodeRateLimit,
+			Msg:  "Too many requests hit the API too quickly.",
+		}
+	}
+	c.AbortWithStatusJSON(status, errorBody(stripeErr))
+}
+
+// idempotency replays the stored response of a POST repeated with the same
+// Idempotency-Key, and rejects the key when it comes with different parameters.
+func (s *Server) idempotency(c *gin.Context) {
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" || c.Request.Method != http.MethodPost {
+		c.Next()
+		return
+	}
+	fingerprint := c.Request.URL.Path + "?" + c.Request.PostForm.Encode()
+
+	s.mu.Lock()
+	stored, ok := s.idempotent[key]
+	s.mu.Unlock()
+	if ok {
+		if stored.fingerprint != fingerprint {
+			c.AbortWithStatusJSON(http.StatusBadRequest, errorBody(&stripego.Error{
+				Type: stripego.ErrorTypeIdempotency,
+				Msg:  "Keys for idempotent requests can only be used with the same parameters they were first used with.",
+			}))
+			return
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(stored.status, "application/json", stored.body)
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	c.Next()
+
+	s.mu.Lock()
+	s.idempotent[key] = &storedResponse{fingerprint: fingerprint, status: c.Writer.Status(), body: recorder.body.Bytes()}
+	s.mu.Unlock()
+}
+
+// responseRecorder keeps a copy of the response body for idempotent replays.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// nextID issues sequential IDs per prefix, e.g. "pi_fake_000001". Callers hold s.mu.
+func (s *Server) nextID(prefix string) string {
+	s.seq[prefix]++
+	return fmt.Sprintf("%s_fake_%06d", prefix, s.seq[prefix])
+}
+
+// record appends an event announcing the object's new state. Callers hold s.mu.
+func (s *Server) record(eventType stripego.EventType, object interface{}) {
+	raw, err := json.Marshal(object)
+	if err != nil {
+		return
+	}
+	var fields map[string]interface{}
+	_ = json.Unmarshal(raw, &fields)
+
+	s.events = append(s.events, &stripego.Event{
+		ID:         s.nextID("evt"),
+		Object:     "event",
+		APIVersion: stripego.APIVersion,
+		Created:    time.Now().Unix(),
+		Type:       eventType,
+		Data:       &stripego.EventData{Raw: raw, Object: fields},
+	})
+}
+
+// errorBody wraps an error as Stripe does.
+func errorBody(err *stripego.Error) gin.H {
+	return gin.H{"error": err}
+}
+
+func notFound(c *gin.Context, kind, id string) {
+	c.JSON(http.StatusNotFound, errorBody(&stripego.Error{
+		Type:  stripego.ErrorTypeInvalidRequest,
+		Code:  stripego.ErrorCodeResourceMissing,
+		Msg:   fmt.Sprintf("No such %s: '%s'", kind, id),
+		Param: "id",
+	}))
+}
+
+func invalidRequest(c *gin.Context, code stripego.ErrorCode, param, message string) {
+	c.JSON(http.StatusBadRequest, errorBody(&stripego.Error{
+		Type:  stripego.ErrorTypeInvalidRequest,
+		Code:  code,
+		Msg:   message,
+		Param: param,
+	}))
+}
+
+// formInt64 reads an integer parameter; ok is false when it is missing or invalid.
+func formInt64(c *gin.Context, name string) (int64, bool) {
+	v, err := strconv.ParseInt(c.Request.PostForm.Get(name), 10, 64)
+	return v, err == nil
+}
+
+// formMetadata collects the metadata[key]=value parameters.
+func formMetadata(c *gin.Context) map[string]string {
+	metadata := map[string]string{}
+	for name, values := range c.Request.PostForm {
+		if key, ok := strings.CutPrefix(name, "metadata["); ok && strings.HasSuffix(key, "]") && len(values) > 0 {
+			metadata[strings.TrimSuffix(key, "]")] = values[0]
+		}
+	}
+	return metadata
+}
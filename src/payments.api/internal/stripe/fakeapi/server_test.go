@@ -0,0 +1,386 @@
+package fakeapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/gateway"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+	"github.com/smart-health/payments-api/internal/stripe/fakeapi"
+	stripego "github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/paymentmethod"
+)
+
+// memoryCustomers is an in-memory CustomerRepository.
+type memoryCustomers struct {
+	mu        sync.Mutex
+	customers map[string]*stripeservice.Customer
+}
+
+func (m *memoryCustomers) FindByUserID(_ context.Context, userID string) (*stripeservice.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.customers[userID]; ok {
+		copied := *c
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *memoryCustomers) Create(_ context.Context, c *stripeservice.Customer) (*stripeservice.Customer, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.customers[c.UserID]; ok {
+		return existing, nil
+	}
+	m.customers[c.UserID] = c
+	return c, nil
+}
+
+func (m *memoryCustomers) SetDefaultPaymentMethod(_ context.Context, userID, paymentMethodID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.customers[userID].DefaultPaymentMethodID = paymentMethodID
+	return nil
+}
+
+var fastRetries = stripeservice.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+type fixture struct {
+	fake    *fakeapi.Server
+	backend stripego.Backend
+	service *stripeservice.StripeService
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	fake := fakeapi.New()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	backend := stripeservice.NewBackend(server.URL)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := &memoryCustomers{customers: map[string]*stripeservice.Customer{}}
+	return &fixture{
+		fake:    fake,
+		backend: backend,
+		service: stripeservice.NewStripeService("sk_test_fake", backend, fastRetries, repo, logger),
+	}
+}
+
+// saveCard attaches a test card to the user's customer and makes it the default.
+func (f *fixture) saveCard(t *testing.T, userID, testCard string) {
+	t.Helper()
+	ctx := context.Background()
+
+	customerID, err := f.service.EnsureCustomer(ctx, userID)
+	if err != nil {
+		t.Fatalf("ensure customer: %v", err)
+	}
+	methods := &paymentmethod.Client{B: f.backend, Key: "sk_test_fake"}
+	pm, err := methods.Attach(testCard, &stripego.PaymentMethodAttachParams{Customer: stripego.String(customerID)})
+	if err != nil {
+		t.Fatalf("attach payment method: %v", err)
+	}
+	if err := f.service.SetDefaultPaymentMethod(ctx, userID, pm.ID); err != nil {
+		t.Fatalf("set default payment method: %v", err)
+	}
+}
+
+func request(userID string, amount float64) stripeservice.PaymentIntentRequest {
+	return stripeservice.PaymentIntentRequest{
+		PaymentID:     uuid.New(),
+		UserID:        userID,
+		AppointmentID: uuid.New(),
+		Amount:        amount,
+		Currency:      "usd",
+	}
+}
+
+func TestStripeService_IntentWithoutSavedCardAwaitsConfirmation(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	intent, err := f.service.CreatePaymentIntent(ctx, request("user-1", 150))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if intent.Status != stripeservice.IntentRequiresPaymentMethod || intent.ClientSecret == "" {
+		t.Errorf("expected intent awaiting a payment method with a client secret, got %+v", intent)
+	}
+
+	fetched, err := f.service.GetPaymentIntent(ctx, intent.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fetched.ID != intent.ID || fetched.Status != intent.Status {
+		t.Errorf("expected %+v, got %+v", intent, fetched)
+	}
+}
+
+func TestStripeService_ChargesSavedCardOffSession(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	f.saveCard(t, "user-1", fakeapi.PaymentMethodVisa)
+
+	intent, err := f.service.CreatePaymentIntent(ctx, request("user-1", 150))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if intent.Status != stripeservice.IntentSucceeded {
+		t.Errorf("expected succeeded, got %q", intent.Status)
+	}
+
+	methods, err := f.service.ListPaymentMethods(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(methods) != 1 || !methods[0].Default || methods[0].Last4 != "4242" {
+		t.Errorf("expected the saved default visa, got %+v", methods)
+	}
+
+	var succeeded bool
+	for _, e := range f.fake.Events() {
+		succeeded = succeeded || e.Type == stripego.EventTypePaymentIntentSucceeded
+	}
+	if !succeeded {
+		t.Error("expected a payment_intent.succeeded event")
+	}
+}
+
+func TestStripeService_DeclinedCard(t *testing.T) {
+	f := newFixture(t)
+	f.saveCard(t, "user-1", fakeapi.PaymentMethodInsufficientFunds)
+
+	_, err := f.service.CreatePaymentIntent(context.Background(), request("user-1", 150))
+	var declined *stripeservice.ErrCardDeclined
+	if !errors.As(err, &declined) {
+		t.Fatalf("expected ErrCardDeclined, got %v", err)
+	}
+	if declined.Code != "card_declined" || declined.DeclineCode != "insufficient_funds" || declined.PaymentIntentID == "" {
+		t.Errorf("expected card_declined/insufficient_funds with the intent, got %+v", declined)
+	}
+	if gateway.IsTemporary(err) {
+		t.Error("a decline must not be temporary")
+	}
+}
+
+func TestStripeService_RetriesTransientErrors(t *testing.T) {
+	f := newFixture(t)
+	f.saveCard(t, "user-1", fakeapi.PaymentMethodVisa)
+
+	// A rate limit and an outage, then success within the three attempts
+	f.fake.FailNext(1, http.StatusTooManyRequests)
+	f.fake.FailNext(1, http.StatusInternalServerError)
+	intent, err := f.service.CreatePaymentIntent(context.Background(), request("user-1", 150))
+	if err != nil {
+		t.Fatalf("expected the retries to succeed, got %v", err)
+	}
+	if intent.Status != stripeservice.IntentSucceeded {
+		t.Errorf("expected succeeded, got %q", intent.Status)
+	}
+}
+
+func TestStripeService_GivesUpOnPersistentOutage(t *testing.T) {
+	f := newFixture(t)
+
+	f.fake.FailNext(3, http.StatusServiceUnavailable)
+	_, err := f.service.GetPaymentIntent(context.Background(), "pi_any")
+	var unavailable *stripeservice.ErrAPIConnection
+	if !errors.As(err, &unavailable) || !gateway.IsTemporary(err) {
+		t.Fatalf("expected a temporary ErrAPIConnection, got %v", err)
+	}
+}
+
+func TestStripeService_RetriedCreateIsIdempotent(t *testing.T) {
+	f := newFixture(t)
+	f.saveCard(t, "user-1", fakeapi.PaymentMethodVisa)
+	req := request("user-1", 150)
+
+	first, err := f.service.CreatePaymentIntent(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Charging the same payment again (e.g. after a lost response) replays the intent
+	second, err := f.service.CreatePaymentIntent(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first.ID != second.ID {
+		t.Errorf("expected the same intent, got %s and %s", first.ID, second.ID)
+	}
+}
+
+func TestStripeService_NewAttemptIsANewIntent(t *testing.T) {
+	f := newFixture(t)
+	f.saveCard(t, "user-1", fakeapi.PaymentMethodDeclined)
+	req := request("user-1", 150)
+
+	if _, err := f.service.CreatePaymentIntent(context.Background(), req); err == nil {
+		t.Fatal("expected the charge to be declined")
+	}
+	// Within the attempt Stripe answers as it did the first time, whatever changed since
+	f.saveCard(t, "user-1", fakeapi.PaymentMethodVisa)
+	if _, err := f.service.CreatePaymentIntent(context.Background(), req); err == nil {
+		t.Fatal("expected the refused attempt not to be charged again")
+	}
+
+	req.Attempt = 1
+	intent, err := f.service.CreatePaymentIntent(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if intent.Status != "succeeded" {
+		t.Errorf("expected the new attempt to charge the saved card, got %s", intent.Status)
+	}
+}
+
+func TestStripeService_InvalidRequestIsTerminal(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.service.CapturePaymentIntent(context.Background(), "pi_unknown", 10)
+	var invalid *stripeservice.ErrInvalidRequest
+	if !errors.As(err, &invalid) || invalid.Code != "resource_missing" {
+		t.Fatalf("expected ErrInvalidRequest resource_missing, got %v", err)
+	}
+	if gateway.IsTemporary(err) {
+		t.Error("an invalid request must not be temporary")
+	}
+}
+
+func TestStripeService_AuthenticationRequired(t *testing.T) {
+	f := newFixture(t)
+	f.saveCard(t, "user-1", fakeapi.PaymentMethodAuthenticationRequired)
+
+	_, err := f.service.CreatePaymentIntent(context.Background(), request("user-1", 150))
+	var authRequired *stripeservice.ErrAuthenticationRequired
+	if !errors.As(err, &authRequired) {
+		t.Fatalf("expected ErrAuthenticationRequired, got %v", err)
+	}
+	if authRequired.PaymentIntentID == "" || authRequired.ClientSecret == "" {
+		t.Errorf("expected intent ID and client secret, got %+v", authRequired)
+	}
+}
+
+func TestStripeService_CaptureAndRefund(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	f.saveCard(t, "user-1", fakeapi.PaymentMethodVisa)
+
+	req := request("user-1", 150)
+	req.ManualCapture = true
+	intent, err := f.service.CreatePaymentIntent(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if intent.Status != stripeservice.IntentRequiresCapture {
+		t.Fatalf("expected requires_capture, got %q", intent.Status)
+	}
+
+	captured, err := f.service.CapturePaymentIntent(ctx, intent.ID, 120)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if captured.Status != stripeservice.IntentSucceeded {
+		t.Errorf("expected succeeded, got %q", captured.Status)
+	}
+
+	refund, err := f.service.CreateRefund(ctx, intent.ID, 100, "cancelled appointment", "payment-1-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if refund.Amount != 100 || refund.Status != "succeeded" {
+		t.Errorf("expected a succeeded refund of 100, got %+v", refund)
+	}
+
+	// Only 20 of the captured 120 remain
+	if _, err := f.service.CreateRefund(ctx, intent.ID, 50, "again", "payment-1-2"); err == nil {
+		t.Error("expected refunding more than was captured to fail")
+	}
+}
+
+func TestStripeService_RefundIsIssuedOncePerReference(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	f.saveCard(t, "user-1", fakeapi.PaymentMethodVisa)
+
+	req := request("user-1", 120)
+	req.ManualCapture = true
+	intent, err := f.service.CreatePaymentIntent(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := f.service.CapturePaymentIntent(ctx, intent.ID, 120); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A capture requested again is replayed
+	if _, err := f.service.CapturePaymentIntent(ctx, intent.ID, 120); err != nil {
+		t.Errorf("expected the repeated capture to be replayed, got %v", err)
+	}
+
+	first, err := f.service.CreateRefund(ctx, intent.ID, 50, "cancelled appointment", "payment-1-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The caller retries after a timeout: the same refund, not a second one
+	again, err := f.service.CreateRefund(ctx, intent.ID, 50, "cancelled appointment", "payment-1-1")
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("expected refund %s replayed, got %+v, %v", first.ID, again, err)
+	}
+
+	// 70 of the captured 120 remain
+	if _, err := f.service.CreateRefund(ctx, intent.ID, 70, "rest", "payment-1-2"); err != nil {
+		t.Errorf("expected the second refund to succeed, got %v", err)
+	}
+}
+
+func TestStripeService_CancelIntent(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	intent, err := f.service.CreatePaymentIntent(ctx, request("user-1", 150))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	canceled, err := f.service.CancelPaymentIntent(ctx, intent.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if canceled.Status != stripeservice.IntentCanceled {
+		t.Errorf("expected canceled, got %q", canceled.Status)
+	}
+
+	if _, err := f.service.GetPaymentIntent(ctx, "pi_unknown"); err == nil {
+		t.Error("expected an error for an unknown intent")
+	}
+}
+
+func TestServer_IdempotentCustomerCreation(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+
+	// A second service with its own (empty) mapping table, as after a lost insert
+	other := stripeservice.NewStripeService("sk_test_fake", f.backend, fastRetries,
+		&memoryCustomers{customers: map[string]*stripeservice.Customer{}},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	first, err := f.service.EnsureCustomer(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := other.EnsureCustomer(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if first != second {
+		t.Errorf("expected the idempotency key to return customer %s, got %s", first, second)
+	}
+}
@@ -0,0 +1,143 @@
+package stripe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/smart-health/payments-api/internal/gateway"
+)
+
+// ProviderName identifies Stripe in routing rules and on payments.
+const ProviderName = "stripe"
+
+// Gateway adapts a Stripe Service to the provider-neutral gateway.PaymentGateway.
+// A transaction ID is a PaymentIntent ID.
+type Gateway struct {
+	service Service
+}
+
+// NewGateway creates the Stripe payment gateway adapter.
+func NewGateway(service Service) *Gateway {
+	return &Gateway{service: service}
+}
+
+// Name returns "stripe".
+func (g *Gateway) Name() string { return ProviderName }
+
+// Authorize creates a PaymentIntent. An off-session charge that needs 3-D
+// Secure is not an error here: it yields a RequiresAction transaction
+// carrying the client secret for on-session confirmation. A declined card
+// yields a Failed transaction.
+func (g *Gateway) Authorize(ctx context.Context, req gateway.AuthorizeRequest) (*gateway.Transaction, error) {
+	lineItems := make([]LineItem, 0, len(req.LineItems))
+	for _, item := range req.LineItems {
+		lineItems = append(lineItems, LineItem(item))
+	}
+
+	intent, err := g.service.CreatePaymentIntent(ctx, PaymentIntentRequest{
+		PaymentID:     req.PaymentID,
+		UserID:        req.UserID,
+		AppointmentID: req.AppointmentID,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		LineItems:     lineItems,
+		Metadata:      req.Metadata,
+		ManualCapture: !req.Capture,
+		Attempt:       req.Attempt,
+	})
+	if err != nil {
+		var authRequired *ErrAuthenticationRequired
+		if errors.As(err, &authRequired) {
+			return &gateway.Transaction{
+				ID:           authRequired.PaymentIntentID,
+				Status:       gateway.StatusRequiresAction,
+				ClientSecret: authRequired.ClientSecret,
+			}, nil
+		}
+		var declined *ErrCardDeclined
+		if errors.As(err, &declined) {
+			return &gateway.Transaction{
+				ID:            declined.PaymentIntentID,
+				Status:        gateway.StatusFailed,
+				FailureReason: declined.Message,
+				FailureCode:   declineCode(declined.Code, declined.DeclineCode),
+			}, nil
+		}
+		return nil, err
+	}
+	return toTransaction(intent)
+}
+
+// Capture captures an intent authorized with manual capture.
+func (g *Gateway) Capture(ctx context.Context, transactionID string, amount float64) (*gateway.Transaction, error) {
+	intent, err := g.service.CapturePaymentIntent(ctx, transactionID, amount)
+	if err != nil {
+		return nil, err
+	}
+	return toTransaction(intent)
+}
+
+// Refund refunds a captured intent.
+func (g *Gateway) Refund(ctx context.Context, transactionID string, amount float64, reason, reference string) (*gateway.Refund, error) {
+	r, err := g.service.CreateRefund(ctx, transactionID, amount, reason, reference)
+	if err != nil {
+		return nil, err
+	}
+	return &gateway.Refund{ID: r.ID, TransactionID: transactionID, Amount: r.Amount, Status: r.Status}, nil
+}
+
+// Void cancels an intent that has not been captured.
+func (g *Gateway) Void(ctx context.Context, transactionID string) (*gateway.Transaction, error) {
+	intent, err := g.service.CancelPaymentIntent(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	return toTransaction(intent)
+}
+
+// Status re-fetches the intent.
+func (g *Gateway) Status(ctx context.Context, transactionID string) (*gateway.Transaction, error) {
+	intent, err := g.service.GetPaymentIntent(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	return toTransaction(intent)
+}
+
+// toTransaction maps PaymentIntent statuses to gateway statuses. An intent
+// back in requires_payment_method with a last error was declined.
+func toTransaction(intent *PaymentIntent) (*gateway.Transaction, error) {
+	tx := &gateway.Transaction{ID: intent.ID}
+	switch intent.Status {
+	case IntentSucceeded:
+		tx.Status = gateway.StatusSucceeded
+	case IntentProcessing:
+		tx.Status = gateway.StatusPending
+	case IntentRequiresCapture:
+		tx.Status = gateway.StatusAuthorized
+	case IntentRequiresPaymentMethod, IntentRequiresConfirmation, IntentRequiresAction:
+		if intent.LastError != "" {
+			tx.Status = gateway.StatusFailed
+			tx.FailureReason = intent.LastError
+			tx.FailureCode = intent.DeclineCode
+		} else {
+			tx.Status = gateway.StatusRequiresAction
+			tx.ClientSecret = intent.ClientSecret
+		}
+	case IntentCanceled:
+		tx.Status = gateway.StatusCanceled
+		tx.FailureCode = intent.CancelReason
+	default:
+		return nil, fmt.Errorf("unexpected payment intent status %q", intent.Status)
+	}
+	return tx, nil
+}
+
+// declineCode prefers the issuer's decline code over the generic error code.
+func declineCode(code, declineCode string) string {
+	if declineCode != "" {
+		return declineCode
+	}
+	return code
+}
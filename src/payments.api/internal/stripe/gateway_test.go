@@ -0,0 +1,104 @@
+package stripe_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/gateway"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+)
+
+// fakeService returns a fixed intent, or error, from CreatePaymentIntent.
+type fakeService struct {
+	stripeservice.Service
+	intent *stripeservice.PaymentIntent
+	err    error
+	req    stripeservice.PaymentIntentRequest
+}
+
+func (f *fakeService) CreatePaymentIntent(_ context.Context, req stripeservice.PaymentIntentRequest) (*stripeservice.PaymentIntent, error) {
+	f.req = req
+	return f.intent, f.err
+}
+
+func TestGateway_AuthorizeMapsIntentStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		intent stripeservice.PaymentIntent
+		want   string
+	}{
+		{"succeeded", stripeservice.PaymentIntent{Status: stripeservice.IntentSucceeded}, gateway.StatusSucceeded},
+		{"processing", stripeservice.PaymentIntent{Status: stripeservice.IntentProcessing}, gateway.StatusPending},
+		{"requires capture", stripeservice.PaymentIntent{Status: stripeservice.IntentRequiresCapture}, gateway.StatusAuthorized},
+		{"3-D Secure", stripeservice.PaymentIntent{Status: stripeservice.IntentRequiresAction}, gateway.StatusRequiresAction},
+		{"awaiting card", stripeservice.PaymentIntent{Status: stripeservice.IntentRequiresPaymentMethod}, gateway.StatusRequiresAction},
+		{"declined", stripeservice.PaymentIntent{Status: stripeservice.IntentRequiresPaymentMethod, LastError: "card declined"}, gateway.StatusFailed},
+		{"canceled", stripeservice.PaymentIntent{Status: stripeservice.IntentCanceled}, gateway.StatusCanceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.intent.ID = "pi_123"
+			g := stripeservice.NewGateway(&fakeService{intent: &tt.intent})
+			tx, err := g.Authorize(context.Background(), gateway.AuthorizeRequest{Amount: 10, Currency: "eur", Capture: true})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tx.Status != tt.want || tx.ID != "pi_123" {
+				t.Errorf("expected %s for pi_123, got %+v", tt.want, tx)
+			}
+		})
+	}
+}
+
+func TestGateway_AuthenticationRequiredIsAnAction(t *testing.T) {
+	fake := &fakeService{err: &stripeservice.ErrAuthenticationRequired{PaymentIntentID: "pi_3ds", ClientSecret: "secret"}}
+	g := stripeservice.NewGateway(fake)
+
+	tx, err := g.Authorize(context.Background(), gateway.AuthorizeRequest{Amount: 10, Currency: "eur"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Status != gateway.StatusRequiresAction || tx.ID != "pi_3ds" || tx.ClientSecret != "secret" {
+		t.Errorf("expected requires_action with client secret, got %+v", tx)
+	}
+	if !fake.req.ManualCapture {
+		t.Error("expected an authorize-only request to use manual capture")
+	}
+}
+
+func TestGateway_DeclineIsAFailedTransaction(t *testing.T) {
+	fake := &fakeService{err: fmt.Errorf("stripe CreatePaymentIntent: %w", &stripeservice.ErrCardDeclined{
+		PaymentIntentID: "pi_declined",
+		Code:            "card_declined",
+		DeclineCode:     "insufficient_funds",
+		Message:         "Your card has insufficient funds.",
+	})}
+	g := stripeservice.NewGateway(fake)
+
+	tx, err := g.Authorize(context.Background(), gateway.AuthorizeRequest{Amount: 10, Currency: "eur", Capture: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tx.Status != gateway.StatusFailed || tx.ID != "pi_declined" || tx.FailureCode != "insufficient_funds" {
+		t.Errorf("expected a failed transaction with the decline code, got %+v", tx)
+	}
+}
+
+func TestGateway_TransientErrorsStayErrors(t *testing.T) {
+	for _, transient := range []error{
+		&stripeservice.ErrRateLimited{Err: errors.New("429")},
+		&stripeservice.ErrAPIConnection{Err: errors.New("503")},
+		// The first request with the key is still in flight; retrying finds its intent
+		&stripeservice.ErrIdempotencyConflict{Err: errors.New("409")},
+	} {
+		g := stripeservice.NewGateway(&fakeService{err: transient})
+
+		_, err := g.Authorize(context.Background(), gateway.AuthorizeRequest{Amount: 10, Currency: "eur", Capture: true})
+		if !gateway.IsTemporary(err) {
+			t.Errorf("expected a temporary error, got %v", err)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package stripe
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 )
@@ -9,7 +11,120 @@ import (
 // Service defines the Stripe payment processing contract.
 // This abstraction enables testing without hitting the Stripe API.
 type Service interface {
-	// CreatePaymentIntent creates a Stripe PaymentIntent in test mode.
-	// Returns the PaymentIntent ID on success.
-	CreatePaymentIntent(ctx context.Context, amount float64, currency string, appointmentID uuid.UUID) (string, error)
+	// CreatePaymentIntent creates a Stripe PaymentIntent in test mode for the
+	// user's Stripe Customer. When the user has a default saved payment method
+	// the intent is confirmed off-session against it.
+	// Line items are forwarded as metadata and in the description.
+	// An intent that is not confirmed off-session is returned with its client
+	// secret, for the patient to confirm in the frontend.
+	// Errors are classified (see errors.go): *ErrCardDeclined for declines,
+	// *ErrAuthenticationRequired when 3-D Secure is needed, and temporary
+	// errors once the retries are used up.
+	CreatePaymentIntent(ctx context.Context, req PaymentIntentRequest) (*PaymentIntent, error)
+	// GetPaymentIntent re-fetches an intent, e.g. after frontend confirmation.
+	GetPaymentIntent(ctx context.Context, intentID string) (*PaymentIntent, error)
+	// CapturePaymentIntent captures an intent created with ManualCapture.
+	CapturePaymentIntent(ctx context.Context, intentID string, amount float64) (*PaymentIntent, error)
+	// CancelPaymentIntent cancels an intent that has not been captured.
+	CancelPaymentIntent(ctx context.Context, intentID string) (*PaymentIntent, error)
+	// CreateRefund refunds part or all of a captured intent. reference
+	// identifies the refund: retried with the same reference, it is issued
+	// once. An empty reference identifies it by the intent and amount.
+	CreateRefund(ctx context.Context, intentID string, amount float64, reason, reference string) (*Refund, error)
+}
+
+// CustomerService manages the Stripe Customer of each user and the payment
+// methods saved on it.
+type CustomerService interface {
+	// EnsureCustomer returns the user's Stripe Customer ID, creating the customer on first use.
+	EnsureCustomer(ctx context.Context, userID string) (string, error)
+	// CreateSetupIntent starts saving a new payment method for off-session use.
+	// The frontend confirms the returned client secret with Stripe.js.
+	CreateSetupIntent(ctx context.Context, userID string) (*SetupIntent, error)
+	ListPaymentMethods(ctx context.Context, userID string) ([]PaymentMethod, error)
+	DetachPaymentMethod(ctx context.Context, userID, paymentMethodID string) error
+	// SetDefaultPaymentMethod selects the method charged off-session.
+	SetDefaultPaymentMethod(ctx context.Context, userID, paymentMethodID string) error
+}
+
+// PaymentIntentRequest describes the charge of one payment.
+type PaymentIntentRequest struct {
+	PaymentID     uuid.UUID // stored as "paymentId" metadata; webhooks find the payment by it
+	UserID        string
+	AppointmentID uuid.UUID
+	Amount        float64 // in the major currency unit
+	Currency      string
+	LineItems     []LineItem
+	Metadata      map[string]string // additional intent metadata
+	ManualCapture bool              // authorize only; funds are captured with CapturePaymentIntent
+	Attempt       int               // charge attempt of the payment; part of the idempotency key
+}
+
+// LineItem is a billed service as shown on the Stripe dashboard.
+type LineItem struct {
+	Code        string
+	Description string
+	Quantity    int
+	Total       float64 // amount charged for the line, in the major currency unit
+}
+
+// PaymentIntent statuses, as reported by Stripe.
+const (
+	IntentRequiresPaymentMethod = "requires_payment_method"
+	IntentRequiresConfirmation  = "requires_confirmation"
+	IntentRequiresAction        = "requires_action"
+	IntentProcessing            = "processing"
+	IntentRequiresCapture       = "requires_capture"
+	IntentSucceeded             = "succeeded"
+	IntentCanceled              = "canceled"
+)
+
+// PaymentIntent is the state of a Stripe PaymentIntent.
+type PaymentIntent struct {
+	ID           string
+	Status       string
+	ClientSecret string // lets the frontend confirm the intent; never persisted
+	LastError    string // message of the last failed confirmation attempt, if any
+	DeclineCode  string // reason of the last decline, e.g. "insufficient_funds"
+	CancelReason string // why a canceled intent was canceled, e.g. "abandoned"
+}
+
+// Refund is a Stripe refund of a PaymentIntent.
+type Refund struct {
+	ID     string
+	Amount float64 // in the major currency unit
+	Status string
+}
+
+// SetupIntent is a pending request to save a payment method.
+type SetupIntent struct {
+	ID           string
+	ClientSecret string
+}
+
+// PaymentMethod is a card saved on a user's Stripe Customer.
+type PaymentMethod struct {
+	ID       string
+	Brand    string
+	Last4    string
+	ExpMonth int64
+	ExpYear  int64
+	Default  bool
+}
+
+// ErrPaymentMethodNotFound is returned when a payment method does not exist
+// or is not attached to the user's customer.
+var ErrPaymentMethodNotFound = errors.New("payment method not found for this user")
+
+// ErrAuthenticationRequired is returned when an off-session charge is
+// declined because the card issuer requires the patient to authenticate
+// (3-D Secure). The PaymentIntent is left unconfirmed for an on-session retry:
+// the patient confirms it in the frontend with ClientSecret.
+type ErrAuthenticationRequired struct {
+	PaymentIntentID string
+	ClientSecret    string
+}
+
+func (e *ErrAuthenticationRequired) Error() string {
+	return fmt.Sprintf("payment intent %s requires customer authentication", e.PaymentIntentID)
 }
@@ -0,0 +1,65 @@
+package stripe
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/smart-health/payments-api/internal/resilience"
+	stripego "github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/form"
+)
+
+// rateLimitedBackend takes a token from the limiter before every request to
+// Stripe – payment intents, customers and payment methods alike, and every
+// retry – with the priority of the request's context.
+type rateLimitedBackend struct {
+	stripego.Backend
+	limiter *resilience.Limiter
+}
+
+// NewRateLimitedBackend limits the requests backend sends to Stripe.
+func NewRateLimitedBackend(backend stripego.Backend, limiter *resilience.Limiter) stripego.Backend {
+	return &rateLimitedBackend{Backend: backend, limiter: limiter}
+}
+
+func (b *rateLimitedBackend) Call(method, path, key string, params stripego.ParamsContainer, v stripego.LastResponseSetter) error {
+	if err := b.limiter.Wait(requestContext(params)); err != nil {
+		return err
+	}
+	return b.Backend.Call(method, path, key, params, v)
+}
+
+func (b *rateLimitedBackend) CallStreaming(method, path, key string, params stripego.ParamsContainer, v stripego.StreamingLastResponseSetter) error {
+	if err := b.limiter.Wait(requestContext(params)); err != nil {
+		return err
+	}
+	return b.Backend.CallStreaming(method, path, key, params, v)
+}
+
+func (b *rateLimitedBackend) CallRaw(method, path, key string, body *form.Values, params *stripego.Params, v stripego.LastResponseSetter) error {
+	if err := b.limiter.Wait(requestContext(params)); err != nil {
+		return err
+	}
+	return b.Backend.CallRaw(method, path, key, body, params, v)
+}
+
+func (b *rateLimitedBackend) CallMultipart(method, path, key, boundary string, body *bytes.Buffer, params *stripego.Params, v stripego.LastResponseSetter) error {
+	if err := b.limiter.Wait(requestContext(params)); err != nil {
+		return err
+	}
+	return b.Backend.CallMultipart(method, path, key, boundary, body, params, v)
+}
+
+// requestContext returns the context the service set on the request's params.
+func requestContext(params stripego.ParamsContainer) context.Context {
+	if params == nil {
+		return context.Background()
+	}
+	if p, ok := params.(*stripego.Params); ok && p == nil {
+		return context.Background()
+	}
+	if p := params.GetParams(); p != nil && p.Context != nil {
+		return p.Context
+	}
+	return context.Background()
+}
@@ -0,0 +1,59 @@
+package stripe
+
+import (
+	"context"
+
+	"github.com/smart-health/payments-api/internal/resilience"
+)
+
+// ResilientService guards a Service – the Stripe client or the simulator –
+// with a resilience policy: circuit breaker, bulkhead and per-call timeout.
+type ResilientService struct {
+	next   Service
+	policy *resilience.Policy
+}
+
+// NewResilientService wraps next with policy.
+func NewResilientService(next Service, policy *resilience.Policy) *ResilientService {
+	return &ResilientService{next: next, policy: policy}
+}
+
+func (s *ResilientService) CreatePaymentIntent(ctx context.Context, req PaymentIntentRequest) (intent *PaymentIntent, err error) {
+	err = s.policy.Do(ctx, func(ctx context.Context) (err error) {
+		intent, err = s.next.CreatePaymentIntent(ctx, req)
+		return err
+	})
+	return intent, err
+}
+
+func (s *ResilientService) GetPaymentIntent(ctx context.Context, intentID string) (intent *PaymentIntent, err error) {
+	err = s.policy.Do(ctx, func(ctx context.Context) (err error) {
+		intent, err = s.next.GetPaymentIntent(ctx, intentID)
+		return err
+	})
+	return intent, err
+}
+
+func (s *ResilientService) CapturePaymentIntent(ctx context.Context, intentID string, amount float64) (intent *PaymentIntent, err error) {
+	err = s.policy.Do(ctx, func(ctx context.Context) (err error) {
+		intent, err = s.next.CapturePaymentIntent(ctx, intentID, amount)
+		return err
+	})
+	return intent, err
+}
+
+func (s *ResilientService) CancelPaymentIntent(ctx context.Context, intentID string) (intent *PaymentIntent, err error) {
+	err = s.policy.Do(ctx, func(ctx context.Context) (err error) {
+		intent, err = s.next.CancelPaymentIntent(ctx, intentID)
+		return err
+	})
+	return intent, err
+}
+
+func (s *ResilientService) CreateRefund(ctx context.Context, intentID string, amount float64, reason, reference string) (refund *Refund, err error) {
+	err = s.policy.Do(ctx, func(ctx context.Context) (err error) {
+		refund, err = s.next.CreateRefund(ctx, intentID, amount, reason, reference)
+		return err
+	})
+	return refund, err
+}
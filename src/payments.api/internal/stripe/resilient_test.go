@@ -0,0 +1,37 @@
+package stripe_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/gateway"
+	"github.com/smart-health/payments-api/internal/resilience"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+)
+
+func TestResilientService_OutageOpensBreaker(t *testing.T) {
+	fake := &fakeService{err: &stripeservice.ErrAPIConnection{Err: errors.New("503")}}
+	policy := resilience.NewPolicy("stripe", resilience.Options{
+		Breaker:       resilience.BreakerOptions{WindowSize: 2, MinCalls: 2, FailureRate: 1, OpenDuration: time.Minute},
+		MaxConcurrent: 1,
+	})
+	g := stripeservice.NewGateway(stripeservice.NewResilientService(fake, policy))
+	req := gateway.AuthorizeRequest{Amount: 10, Currency: "eur", Capture: true}
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.Authorize(context.Background(), req); !gateway.IsTemporary(err) {
+			t.Fatalf("expected a temporary error, got %v", err)
+		}
+	}
+
+	// Stripe is not called while the breaker is open
+	fake.err = nil
+	fake.intent = &stripeservice.PaymentIntent{ID: "pi_123", Status: stripeservice.IntentSucceeded}
+	_, err := g.Authorize(context.Background(), req)
+	var open *resilience.ErrOpen
+	if !errors.As(err, &open) || !gateway.IsTemporary(err) {
+		t.Fatalf("expected the open breaker, got %v", err)
+	}
+}
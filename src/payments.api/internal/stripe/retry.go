@@ -0,0 +1,51 @@
+package stripe
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/smart-health/payments-api/internal/gateway"
+)
+
+// RetryPolicy controls how transient Stripe errors are retried: up to
+// MaxAttempts calls in total, waiting an exponentially growing, jittered
+// delay between them.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration // delay before the first retry
+	MaxDelay    time.Duration // cap on the delay between retries
+}
+
+// DefaultRetryPolicy makes three attempts within about two seconds.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseDelay: 500 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// do calls fn until it succeeds, fails with a non-temporary error, the
+// attempts are used up or ctx ends. The error returned is classified.
+func (p RetryPolicy) do(ctx context.Context, logger *slog.Logger, op string, fn func() error) error {
+	delay := p.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := classify(fn())
+		if err == nil || !gateway.IsTemporary(err) || attempt >= p.MaxAttempts {
+			return err
+		}
+
+		// Full jitter in [delay/2, delay) spreads retries of concurrent calls
+		wait := delay/2 + time.Duration(rand.Int64N(int64(delay/2)+1))
+		logger.Warn("transient Stripe error, retrying",
+			"operation", op,
+			"attempt", attempt,
+			"retryIn", wait,
+			"error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay = min(delay*2, p.MaxDelay)
+	}
+}
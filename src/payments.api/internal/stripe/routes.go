@@ -0,0 +1,129 @@
+package stripe
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smart-health/payments-api/internal/openapi"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// PaymentMethodResult is a saved card as returned by the customer endpoints.
+type PaymentMethodResult struct {
+	ID       string `json:"id"`
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int64  `json:"expMonth"`
+	ExpYear  int64  `json:"expYear"`
+	Default  bool   `json:"default"`
+}
+
+// SetupIntentResult carries the client secret the frontend confirms with Stripe.js.
+type SetupIntentResult struct {
+	SetupIntentID string `json:"setupIntentId"`
+	ClientSecret  string `json:"clientSecret"`
+}
+
+type defaultPaymentMethodRequest struct {
+	PaymentMethodID string `json:"paymentMethodId" binding:"required"`
+}
+
+// RegisterCustomerRoutes mounts the saved payment method endpoints on the group.
+//
+//	GET    /:userId/payment-methods        – list saved cards
+//	POST   /:userId/payment-methods        – start saving a card (SetupIntent)
+//	DELETE /:userId/payment-methods/:pmId  – detach a saved card
+//	PUT    /:userId/default-payment-method – select the card charged off-session
+func RegisterCustomerRoutes(rg *gin.RouterGroup, service CustomerService) {
+	rg.GET("/:userId/payment-methods", func(c *gin.Context) {
+		methods, err := service.ListPaymentMethods(c.Request.Context(), c.Param("userId"))
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		results := make([]PaymentMethodResult, 0, len(methods))
+		for _, m := range methods {
+			results = append(results, PaymentMethodResult(m))
+		}
+		c.JSON(http.StatusOK, results)
+	})
+
+	rg.POST("/:userId/payment-methods", func(c *gin.Context) {
+		intent, err := service.CreateSetupIntent(c.Request.Context(), c.Param("userId"))
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, SetupIntentResult{SetupIntentID: intent.ID, ClientSecret: intent.ClientSecret})
+	})
+
+	rg.DELETE("/:userId/payment-methods/:pmId", func(c *gin.Context) {
+		err := service.DetachPaymentMethod(c.Request.Context(), c.Param("userId"), c.Param("pmId"))
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	rg.PUT("/:userId/default-payment-method", func(c *gin.Context) {
+		var req defaultPaymentMethodRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		if err := service.SetDefaultPaymentMethod(c.Request.Context(), c.Param("userId"), req.PaymentMethodID); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+}
+
+// CustomerRoutes declares the endpoints RegisterCustomerRoutes mounts.
+func CustomerRoutes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/:userId/payment-methods", Summary: "List saved cards",
+			Response: []PaymentMethodResult{}, Errors: []int{http.StatusServiceUnavailable}},
+		{Method: http.MethodPost, Path: "/:userId/payment-methods", Summary: "Start saving a card",
+			Description: "Creates a SetupIntent; the frontend confirms it with Stripe.js using the client secret.",
+			Response:    SetupIntentResult{}, Status: http.StatusCreated, Errors: []int{http.StatusServiceUnavailable}},
+		{Method: http.MethodDelete, Path: "/:userId/payment-methods/:pmId", Summary: "Detach a saved card",
+			Status: http.StatusNoContent, Errors: []int{http.StatusNotFound, http.StatusServiceUnavailable}},
+		{Method: http.MethodPut, Path: "/:userId/default-payment-method", Summary: "Select the card charged off-session",
+			Request: defaultPaymentMethodRequest{}, Status: http.StatusNoContent,
+			Errors: []int{http.StatusNotFound, http.StatusServiceUnavailable}},
+	}
+}
+
+// Problem maps the error taxonomy to problem responses. Declines carry
+// Stripe's message, which is meant for the cardholder; other provider
+// messages are only logged.
+func Problem(err error) *problem.Problem {
+	var declined *ErrCardDeclined
+	var authRequired *ErrAuthenticationRequired
+	var rateLimited *ErrRateLimited
+	var connection *ErrAPIConnection
+	var conflict *ErrIdempotencyConflict
+	var invalid *ErrInvalidRequest
+	switch {
+	case errors.As(err, &declined):
+		return problem.New(http.StatusPaymentRequired, "card_declined", declined.Message)
+	case errors.As(err, &authRequired):
+		return problem.New(http.StatusPaymentRequired, "authentication_required", "the card issuer requires the patient to authenticate the payment")
+	case errors.Is(err, ErrPaymentMethodNotFound):
+		return problem.New(http.StatusNotFound, "payment_method_not_found", ErrPaymentMethodNotFound.Error())
+	case errors.As(err, &rateLimited):
+		return problem.New(http.StatusServiceUnavailable, "provider_rate_limited", "the payment provider is throttling requests; retry later")
+	case errors.As(err, &connection):
+		return problem.New(http.StatusServiceUnavailable, "provider_unavailable", "the payment provider is unavailable; retry later")
+	case errors.As(err, &conflict):
+		return problem.New(http.StatusConflict, "provider_request_in_progress", "the payment provider is still processing an identical request")
+	case errors.As(err, &invalid):
+		return problem.New(http.StatusBadGateway, "provider_rejected_request", "the payment provider rejected the request")
+	}
+	return nil
+}
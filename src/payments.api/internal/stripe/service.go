@@ -2,12 +2,20 @@ package stripe
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	stripego "github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/customer"
 	"github.com/stripe/stripe-go/v81/paymentintent"
+	"github.com/stripe/stripe-go/v81/paymentmethod"
+	"github.com/stripe/stripe-go/v81/refund"
+	"github.com/stripe/stripe-go/v81/setupintent"
 )
 
 // StripeService implements the Service interface using the Stripe Go SDK.
@@ -15,55 +23,326 @@ import (
 // Architectural Decision: The Stripe SDK is wrapped in this service to:
 //  1. Isolate Stripe-specific types from the domain layer.
 //  2. Enable mocking in tests via the Service interface.
-//  3. Centralise Stripe SDK configuration (API key, logging).
+//  3. Centralise Stripe SDK configuration (API key, backend, logging).
+//
+// It implements both Service and CustomerService: every intent is created for
+// the user's Stripe Customer, so saved payment methods can be charged off-session.
+//
+// The SDK is used through per-resource clients bound to a backend rather than
+// the package-level functions and global key, so a service can talk to a
+// local stand-in of the Stripe API (see the fakeapi package) in tests and
+// development while another talks to Stripe.
 type StripeService struct {
-	secretKey string
+	api       apiClients
+	retry     RetryPolicy
+	customers CustomerRepository
 	logger    *slog.Logger
 }
 
+// apiClients are the Stripe API resources the service uses.
+type apiClients struct {
+	paymentIntents *paymentintent.Client
+	refunds        *refund.Client
+	customers      *customer.Client
+	paymentMethods *paymentmethod.Client
+	setupIntents   *setupintent.Client
+}
+
 // NewStripeService creates a new Stripe service configured with the given secret key.
-// Use a "sk_test_*" key for test mode.
-func NewStripeService(secretKey string, logger *slog.Logger) *StripeService {
-	stripego.Key = secretKey
-	return &StripeService{secretKey: secretKey, logger: logger}
+// Use a "sk_test_*" key for test mode. A nil backend calls the Stripe API;
+// see NewBackend for pointing the service at another base URL. Transient
+// errors are retried according to retry.
+func NewStripeService(secretKey string, backend stripego.Backend, retry RetryPolicy, customers CustomerRepository, logger *slog.Logger) *StripeService {
+	if backend == nil {
+		backend = NewBackend("")
+	}
+	return &StripeService{
+		api: apiClients{
+			paymentIntents: &paymentintent.Client{B: backend, Key: secretKey},
+			refunds:        &refund.Client{B: backend, Key: secretKey},
+			customers:      &customer.Client{B: backend, Key: secretKey},
+			paymentMethods: &paymentmethod.Client{B: backend, Key: secretKey},
+			setupIntents:   &setupintent.Client{B: backend, Key: secretKey},
+		},
+		retry:     retry,
+		customers: customers,
+		logger:    logger,
+	}
+}
+
+// NewBackend returns a Stripe API backend sending requests to baseURL, e.g.
+// the address of a fakeapi server. An empty baseURL means the Stripe API.
+// The SDK's own retries are disabled: the service retries per its RetryPolicy.
+func NewBackend(baseURL string) stripego.Backend {
+	config := &stripego.BackendConfig{MaxNetworkRetries: stripego.Int64(0)}
+	if baseURL != "" {
+		config.URL = stripego.String(baseURL)
+	}
+	return stripego.GetBackendWithConfig(stripego.APIBackend, config)
 }
 
 // CreatePaymentIntent creates a Stripe PaymentIntent for the given amount and currency.
 // Amount is in the major currency unit (e.g. 10.00 for $10.00 USD).
-// Returns the PaymentIntent ID on success.
-func (s *StripeService) CreatePaymentIntent(ctx context.Context, amount float64, currency string, appointmentID uuid.UUID) (string, error) {
+// If the user has a default saved payment method, the intent is confirmed
+// off-session; an issuer demanding authentication yields *ErrAuthenticationRequired
+// and a decline *ErrCardDeclined. The request is idempotent per payment and
+// charge attempt, so retrying it – now or after a lost response – never
+// creates a second intent; an attempt Stripe refused is replayed as refused
+// for 24 hours, so the payment's next charge comes with the next attempt.
+func (s *StripeService) CreatePaymentIntent(ctx context.Context, req PaymentIntentRequest) (*PaymentIntent, error) {
+	customer, err := s.customer(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("stripe CreatePaymentIntent: %w", err)
+	}
+
 	// Stripe uses the smallest currency unit (cents for USD)
-	amountCents := int64(amount * 100)
+	amountCents := int64(math.Round(req.Amount * 100))
 
 	params := &stripego.PaymentIntentParams{
-		Amount:   stripego.Int64(amountCents),
-		Currency: stripego.String(currency),
-		AutomaticPaymentMethods: &stripego.PaymentIntentAutomaticPaymentMethodsParams{
+		Amount:      stripego.Int64(amountCents),
+		Currency:    stripego.String(req.Currency),
+		Customer:    stripego.String(customer.StripeCustomerID),
+		Metadata:    intentMetadata(req),
+		Description: stripego.String(describe(req.AppointmentID, req.LineItems)),
+	}
+	params.Context = ctx
+	if req.PaymentID != uuid.Nil {
+		params.SetIdempotencyKey(intentIdempotencyKey(req))
+	}
+	if req.ManualCapture {
+		params.CaptureMethod = stripego.String(string(stripego.PaymentIntentCaptureMethodManual))
+	}
+	if customer.DefaultPaymentMethodID != "" {
+		// Charge the saved card without the patient present
+		params.PaymentMethod = stripego.String(customer.DefaultPaymentMethodID)
+		params.Confirm = stripego.Bool(true)
+		params.OffSession = stripego.Bool(true)
+	} else {
+		params.AutomaticPaymentMethods = &stripego.PaymentIntentAutomaticPaymentMethodsParams{
 			Enabled: stripego.Bool(true),
-		},
-		Metadata: map[string]string{
-			"appointmentId": appointmentID.String(),
-			"service":       "smarthealth-payments",
-		},
-		Description: stripego.String(fmt.Sprintf("SmartHealth appointment payment for %s", appointmentID)),
+		}
 	}
 
 	s.logger.Info("creating Stripe PaymentIntent",
-		"appointmentId", appointmentID,
-		"amount", amount,
-		"currency", currency)
+		"appointmentId", req.AppointmentID,
+		"amount", req.Amount,
+		"currency", req.Currency,
+		"lineItems", len(req.LineItems),
+		"offSession", customer.DefaultPaymentMethodID != "")
 
-	intent, err := paymentintent.New(params)
+	var intent *stripego.PaymentIntent
+	err = s.retry.do(ctx, s.logger, "CreatePaymentIntent", func() (err error) {
+		intent, err = s.api.paymentIntents.New(params)
+		return err
+	})
 	if err != nil {
+		var authRequired *ErrAuthenticationRequired
+		if errors.As(err, &authRequired) {
+			s.logger.Warn("off-session charge requires authentication",
+				"appointmentId", req.AppointmentID,
+				"intentId", authRequired.PaymentIntentID)
+			return nil, authRequired
+		}
+		var declined *ErrCardDeclined
+		if errors.As(err, &declined) {
+			s.logger.Warn("Stripe declined the charge",
+				"appointmentId", req.AppointmentID,
+				"intentId", declined.PaymentIntentID,
+				"code", declined.Code,
+				"declineCode", declined.DeclineCode)
+			return nil, fmt.Errorf("stripe CreatePaymentIntent: %w", err)
+		}
+
 		s.logger.Error("Stripe PaymentIntent creation failed",
-			"appointmentId", appointmentID,
+			"appointmentId", req.AppointmentID,
 			"error", err)
-		return "", fmt.Errorf("stripe CreatePaymentIntent: %w", err)
+		return nil, fmt.Errorf("stripe CreatePaymentIntent: %w", err)
 	}
 
 	s.logger.Info("Stripe PaymentIntent created",
 		"intentId", intent.ID,
-		"appointmentId", appointmentID)
+		"status", intent.Status,
+		"appointmentId", req.AppointmentID)
+
+	return toPaymentIntent(intent), nil
+}
+
+// intentIdempotencyKey keys the intent of a payment's charge attempt. The
+// first attempt keeps the key intents had before attempts were counted.
+func intentIdempotencyKey(req PaymentIntentRequest) string {
+	key := "payment-intent-" + req.PaymentID.String()
+	if req.Attempt > 0 {
+		key += "-" + strconv.Itoa(req.Attempt)
+	}
+	return key
+}
+
+// GetPaymentIntent retrieves the current state of a PaymentIntent.
+func (s *StripeService) GetPaymentIntent(ctx context.Context, intentID string) (*PaymentIntent, error) {
+	params := &stripego.PaymentIntentParams{}
+	params.Context = ctx
+
+	var intent *stripego.PaymentIntent
+	err := s.retry.do(ctx, s.logger, "GetPaymentIntent", func() (err error) {
+		intent, err = s.api.paymentIntents.Get(intentID, params)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("stripe GetPaymentIntent: %w", err)
+	}
+	return toPaymentIntent(intent), nil
+}
+
+// CapturePaymentIntent captures the amount of an authorized PaymentIntent.
+func (s *StripeService) CapturePaymentIntent(ctx context.Context, intentID string, amount float64) (*PaymentIntent, error) {
+	params := &stripego.PaymentIntentCaptureParams{
+		AmountToCapture: stripego.Int64(int64(math.Round(amount * 100))),
+	}
+	params.Context = ctx
+	// An intent is captured once: a retried capture replays the first
+	params.SetIdempotencyKey("capture-" + intentID)
+
+	var intent *stripego.PaymentIntent
+	err := s.retry.do(ctx, s.logger, "CapturePaymentIntent", func() (err error) {
+		intent, err = s.api.paymentIntents.Capture(intentID, params)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("stripe CapturePaymentIntent: %w", err)
+	}
+	return toPaymentIntent(intent), nil
+}
+
+// CancelPaymentIntent cancels a PaymentIntent that has not been captured.
+func (s *StripeService) CancelPaymentIntent(ctx context.Context, intentID string) (*PaymentIntent, error) {
+	params := &stripego.PaymentIntentCancelParams{}
+	params.Context = ctx
+	params.SetIdempotencyKey("cancel-" + intentID)
+
+	var intent *stripego.PaymentIntent
+	err := s.retry.do(ctx, s.logger, "CancelPaymentIntent", func() (err error) {
+		intent, err = s.api.paymentIntents.Cancel(intentID, params)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("stripe CancelPaymentIntent: %w", err)
+	}
+	return toPaymentIntent(intent), nil
+}
+
+// CreateRefund refunds an amount of a captured PaymentIntent.
+func (s *StripeService) CreateRefund(ctx context.Context, intentID string, amount float64, reason, reference string) (*Refund, error) {
+	amountCents := int64(math.Round(amount * 100))
+	params := &stripego.RefundParams{
+		PaymentIntent: stripego.String(intentID),
+		Amount:        stripego.Int64(amountCents),
+		Metadata: map[string]string{
+			"reason":  truncate(reason, maxMetadataValueChars),
+			"service": "smarthealth-payments",
+		},
+	}
+	params.Context = ctx
+	// The key names the refund, so the same refund requested again – by a
+	// retry here or by the caller after a timeout – is issued once. Stripe
+	// keeps keys for 24 hours.
+	if reference == "" {
+		reference = intentID + "-" + strconv.FormatInt(amountCents, 10)
+	}
+	params.SetIdempotencyKey("refund-" + reference)
 
-	return intent.ID, nil
+	var r *stripego.Refund
+	err := s.retry.do(ctx, s.logger, "CreateRefund", func() (err error) {
+		r, err = s.api.refunds.New(params)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("stripe CreateRefund: %w", err)
+	}
+	return &Refund{ID: r.ID, Amount: float64(r.Amount) / 100, Status: string(r.Status)}, nil
+}
+
+func toPaymentIntent(intent *stripego.PaymentIntent) *PaymentIntent {
+	pi := &PaymentIntent{
+		ID:           intent.ID,
+		Status:       string(intent.Status),
+		ClientSecret: intent.ClientSecret,
+		CancelReason: string(intent.CancellationReason),
+	}
+	if intent.LastPaymentError != nil {
+		pi.LastError = intent.LastPaymentError.Msg
+		pi.DeclineCode = string(intent.LastPaymentError.DeclineCode)
+		if pi.DeclineCode == "" {
+			pi.DeclineCode = string(intent.LastPaymentError.Code)
+		}
+	}
+	return pi
+}
+
+// Stripe accepts at most 50 metadata keys and 500 characters per value.
+const (
+	maxLineItemKeys       = 40
+	maxMetadataValueChars = 500
+	maxDescriptionChars   = 1000
+)
+
+// intentMetadata builds the PaymentIntent metadata: the request's own entries,
+// the payment and appointment IDs, and one "line_N" entry per line item
+// ("CBC x2 = 40.00 – Complete blood count"), capped to Stripe's limits.
+func intentMetadata(req PaymentIntentRequest) map[string]string {
+	metadata := make(map[string]string, len(req.Metadata)+4)
+	for k, v := range req.Metadata {
+		metadata[k] = truncate(v, maxMetadataValueChars)
+	}
+	metadata["appointmentId"] = req.AppointmentID.String()
+	metadata["service"] = "smarthealth-payments"
+	if req.PaymentID != uuid.Nil {
+		metadata["paymentId"] = req.PaymentID.String()
+	}
+
+	lineItems := req.LineItems
+	if len(lineItems) == 0 {
+		return metadata
+	}
+
+	metadata["lineItems"] = strconv.Itoa(len(lineItems))
+	for i, item := range lineItems {
+		if i == maxLineItemKeys {
+			break
+		}
+		value := fmt.Sprintf("%s x%d = %.2f", item.Code, item.Quantity, item.Total)
+		if item.Description != "" {
+			value += " – " + item.Description
+		}
+		metadata[fmt.Sprintf("line_%d", i+1)] = truncate(value, maxMetadataValueChars)
+	}
+	return metadata
+}
+
+// describe builds the PaymentIntent description from the line item descriptions.
+func describe(appointmentID uuid.UUID, lineItems []LineItem) string {
+	description := fmt.Sprintf("SmartHealth appointment payment for %s", appointmentID)
+	if len(lineItems) == 0 {
+		return description
+	}
+
+	services := make([]string, 0, len(lineItems))
+	for _, item := range lineItems {
+		service := item.Description
+		if service == "" {
+			service = item.Code
+		}
+		if item.Quantity > 1 {
+			service += fmt.Sprintf(" x%d", item.Quantity)
+		}
+		services = append(services, service)
+	}
+	return truncate(description+": "+strings.Join(services, ", "), maxDescriptionChars)
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
 }
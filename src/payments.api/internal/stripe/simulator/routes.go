@@ -0,0 +1,67 @@
+package simulator
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smart-health/payments-api/internal/openapi"
+	"github.com/smart-health/payments-api/internal/problem"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+)
+
+type authenticateRequest struct {
+	Succeed *bool `json:"succeed" binding:"required"`
+}
+
+// RegisterRoutes mounts the simulator's development endpoints on the group.
+//
+//	GET  /payment_intents/:id               – simulated intent state
+//	POST /payment_intents/:id/authenticate  – pass or fail its 3-D Secure challenge
+func RegisterRoutes(rg *gin.RouterGroup, s *Simulator) {
+	rg.GET("/payment_intents/:id", func(c *gin.Context) {
+		intent, err := s.GetPaymentIntent(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, intent)
+	})
+
+	rg.POST("/payment_intents/:id/authenticate", func(c *gin.Context) {
+		var req authenticateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		intent, err := s.Authenticate(c.Param("id"), *req.Succeed)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, intent)
+	})
+}
+
+// Routes declares the endpoints RegisterRoutes mounts.
+func Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/payment_intents/:id", Summary: "State of a simulated PaymentIntent",
+			Response: stripeservice.PaymentIntent{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPost, Path: "/payment_intents/:id/authenticate", Summary: "Pass or fail the 3-D Secure challenge of a simulated PaymentIntent",
+			Request: authenticateRequest{}, Response: stripeservice.PaymentIntent{},
+			Errors: []int{http.StatusNotFound, http.StatusConflict}},
+	}
+}
+
+// Problem maps the simulator's errors to problem responses.
+func Problem(err error) *problem.Problem {
+	switch {
+	case errors.Is(err, ErrIntentNotFound):
+		return problem.New(http.StatusNotFound, "simulated_intent_not_found", ErrIntentNotFound.Error())
+	case errors.Is(err, ErrInvalidState):
+		return problem.New(http.StatusConflict, "simulated_intent_state", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package simulator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+)
+
+// Outcomes, selected by the cents of the amount or the "simulate" metadata entry.
+const (
+	OutcomeSucceed           = "succeed"            // any other amount
+	OutcomeDecline           = "decline"            // x.02
+	OutcomeInsufficientFunds = "insufficient_funds" // x.51
+	OutcomeRequires3DS       = "requires_3ds"       // x.30
+	OutcomeTimeout           = "timeout"            // x.40
+	OutcomeDelayed           = "delayed"            // x.60
+)
+
+// MetadataKey is the intent metadata entry that overrides the amount-based outcome.
+const MetadataKey = "simulate"
+
+var outcomesByCents = map[int64]string{
+	2:  OutcomeDecline,
+	51: OutcomeInsufficientFunds,
+	30: OutcomeRequires3DS,
+	40: OutcomeTimeout,
+	60: OutcomeDelayed,
+}
+
+// ErrNetworkTimeout is returned, as a temporary *stripe.ErrAPIConnection,
+// for the timeout outcome. It wraps context.DeadlineExceeded, as a real
+// client timeout does.
+var ErrNetworkTimeout = fmt.Errorf("simulated network timeout: %w", context.DeadlineExceeded)
+
+// ErrIntentNotFound is returned for unknown intent IDs.
+var ErrIntentNotFound = errors.New("simulated payment intent not found")
+
+// ErrInvalidState is returned when an intent cannot change as requested.
+var ErrInvalidState = errors.New("simulated payment intent is in the wrong state")
+
+// Options tune the simulator's timing.
+type Options struct {
+	Latency      time.Duration // added to every call
+	WebhookDelay time.Duration // how long delayed intents stay processing
+}
+
+// -----------------------------------------------------------------------
+// Simulator
+//
+// Architectural Decision: The simulator implements stripe.Service, not the
+// payment gateway, so the Stripe adapter's status mapping is exercised in
+// development exactly as in production. Outcomes are deterministic – chosen
+// by the cents of the amount like Stripe's test cards are chosen by number –
+// and IDs are sequential, so a scenario replays identically. State lives in
+// memory and is lost on restart, like test-mode data nobody looks at twice.
+// -----------------------------------------------------------------------
+
+// Simulator is an in-memory stand-in for Stripe PaymentIntents.
+type Simulator struct {
+	opts    Options
+	emitter Emitter
+	logger  *slog.Logger
+
+	mu      sync.Mutex
+	intents map[string]*intent
+	seq     map[string]int // last number issued per ID prefix
+}
+
+type intent struct {
+	stripeservice.PaymentIntent
+	Amount   float64
+	Currency string
+	Refunded float64
+	Metadata map[string]string
+}
+
+// New creates a simulator. Webhooks are sent through the emitter, which may be nil.
+func New(opts Options, emitter Emitter, logger *slog.Logger) *Simulator {
+	return &Simulator{opts: opts, emitter: emitter, logger: logger, intents: make(map[string]*intent), seq: make(map[string]int)}
+}
+
+// Outcome returns the outcome simulated for an amount and metadata.
+func Outcome(amount float64, metadata map[string]string) string {
+	if o := metadata[MetadataKey]; o != "" {
+		return o
+	}
+	cents := int64(math.Round(amount*100)) % 100
+	if o, ok := outcomesByCents[cents]; ok {
+		return o
+	}
+	return OutcomeSucceed
+}
+
+// CreatePaymentIntent creates an intent and settles it according to its outcome.
+func (s *Simulator) CreatePaymentIntent(ctx context.Context, req stripeservice.PaymentIntentRequest) (*stripeservice.PaymentIntent, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{"appointmentId": req.AppointmentID.String()}
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	if req.PaymentID != uuid.Nil {
+		metadata["paymentId"] = req.PaymentID.String()
+	}
+
+	outcome := Outcome(req.Amount, metadata)
+	if outcome == OutcomeTimeout {
+		s.logger.Warn("simulated Stripe timeout", "appointmentId", req.AppointmentID)
+		return nil, fmt.Errorf("stripe CreatePaymentIntent: %w", &stripeservice.ErrAPIConnection{Err: ErrNetworkTimeout})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID("pi_sim")
+	in := &intent{
+		PaymentIntent: stripeservice.PaymentIntent{ID: id, ClientSecret: id + "_secret_sim"},
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Metadata:      metadata,
+	}
+	s.intents[id] = in
+
+	switch outcome {
+	case OutcomeDecline:
+		s.decline(in, "generic_decline", "Your card was declined.")
+	case OutcomeInsufficientFunds:
+		s.decline(in, "insufficient_funds", "Your card has insufficient funds.")
+	case OutcomeRequires3DS:
+		s.transition(in, stripeservice.IntentRequiresAction)
+		s.logger.Info("simulated Stripe intent requires authentication", "intentId", id)
+		return nil, &stripeservice.ErrAuthenticationRequired{PaymentIntentID: id, ClientSecret: in.ClientSecret}
+	case OutcomeDelayed:
+		s.transition(in, stripeservice.IntentProcessing)
+		time.AfterFunc(s.opts.WebhookDelay, func() { s.settleDelayed(id, req.ManualCapture) })
+	default:
+		s.succeed(in, req.ManualCapture)
+	}
+
+	s.logger.Info("simulated Stripe PaymentIntent created",
+		"intentId", id,
+		"outcome", outcome,
+		"status", in.Status,
+		"amount", req.Amount,
+		"currency", req.Currency)
+
+	pi := in.PaymentIntent
+	return &pi, nil
+}
+
+// GetPaymentIntent returns the current state of an intent.
+func (s *Simulator) GetPaymentIntent(ctx context.Context, intentID string) (*stripeservice.PaymentIntent, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.update(intentID, func(in *intent) error { return nil })
+}
+
+// CapturePaymentIntent captures an intent awaiting capture.
+func (s *Simulator) CapturePaymentIntent(ctx context.Context, intentID string, amount float64) (*stripeservice.PaymentIntent, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.update(intentID, func(in *intent) error {
+		if in.Status != stripeservice.IntentRequiresCapture || amount > in.Amount {
+			return fmt.Errorf("%w: cannot capture %.2f of %s intent", ErrInvalidState, amount, in.Status)
+		}
+		in.Amount = amount
+		s.transition(in, stripeservice.IntentSucceeded)
+		return nil
+	})
+}
+
+// CancelPaymentIntent cancels an intent that has not succeeded.
+func (s *Simulator) CancelPaymentIntent(ctx context.Context, intentID string) (*stripeservice.PaymentIntent, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.update(intentID, func(in *intent) error {
+		if in.Status == stripeservice.IntentSucceeded || in.Status == stripeservice.IntentCanceled {
+			return fmt.Errorf("%w: cannot cancel %s intent", ErrInvalidState, in.Status)
+		}
+		s.transition(in, stripeservice.IntentCanceled)
+		return nil
+	})
+}
+
+// CreateRefund refunds part of a succeeded intent.
+func (s *Simulator) CreateRefund(ctx context.Context, intentID string, amount float64, reason, reference string) (*stripeservice.Refund, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	in, ok := s.intents[intentID]
+	if !ok {
+		return nil, fmt.Errorf("stripe CreateRefund: %w", ErrIntentNotFound)
+	}
+	if in.Status != stripeservice.IntentSucceeded || amount <= 0 || in.Refunded+amount > in.Amount+0.005 {
+		return nil, fmt.Errorf("stripe CreateRefund: %w: cannot refund %.2f", ErrInvalidState, amount)
+	}
+	in.Refunded += amount
+	return &stripeservice.Refund{ID: s.nextID("re_sim"), Amount: amount, Status: "succeeded"}, nil
+}
+
+// Authenticate completes (or fails) the 3-D Secure challenge of an intent,
+// standing in for the patient confirming it with Stripe.js.
+func (s *Simulator) Authenticate(intentID string, succeed bool) (*stripeservice.PaymentIntent, error) {
+	return s.update(intentID, func(in *intent) error {
+		if in.Status != stripeservice.IntentRequiresAction {
+			return fmt.Errorf("%w: %s intent needs no authentication", ErrInvalidState, in.Status)
+		}
+		if succeed {
+			s.succeed(in, false)
+		} else {
+			s.decline(in, "authentication_required", "The customer failed 3-D Secure authentication.")
+		}
+		return nil
+	})
+}
+
+// settleDelayed completes a delayed intent, as a bank would after clearing.
+func (s *Simulator) settleDelayed(intentID string, manualCapture bool) {
+	_, err := s.update(intentID, func(in *intent) error {
+		if in.Status != stripeservice.IntentProcessing {
+			return nil // canceled meanwhile
+		}
+		s.succeed(in, manualCapture)
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("settle simulated intent", "intentId", intentID, "error", err)
+	}
+}
+
+func (s *Simulator) update(intentID string, fn func(in *intent) error) (*stripeservice.PaymentIntent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	in, ok := s.intents[intentID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrIntentNotFound, intentID)
+	}
+	if err := fn(in); err != nil {
+		return nil, err
+	}
+	pi := in.PaymentIntent
+	return &pi, nil
+}
+
+func (s *Simulator) succeed(in *intent, manualCapture bool) {
+	if manualCapture {
+		s.transition(in, stripeservice.IntentRequiresCapture)
+		return
+	}
+	s.transition(in, stripeservice.IntentSucceeded)
+}
+
+func (s *Simulator) decline(in *intent, code, message string) {
+	in.LastError = message
+	in.DeclineCode = code
+	s.transition(in, stripeservice.IntentRequiresPaymentMethod)
+}
+
+// transition changes the intent status and emits the matching webhook.
+// Callers hold s.mu.
+func (s *Simulator) transition(in *intent, status string) {
+	in.Status = status
+	if s.emitter == nil {
+		return
+	}
+	if eventType, ok := eventTypes[status]; ok {
+		s.emitter.Emit(newEvent(s.nextID("evt_sim"), eventType, in))
+	}
+}
+
+// nextID issues sequential IDs per prefix, e.g. "pi_sim_000001". Callers hold s.mu.
+func (s *Simulator) nextID(prefix string) string {
+	s.seq[prefix]++
+	return fmt.Sprintf("%s_%06d", prefix, s.seq[prefix])
+}
+
+// wait applies the configured latency, giving up when the context ends.
+func (s *Simulator) wait(ctx context.Context) error {
+	if s.opts.Latency <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(s.opts.Latency)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,161 @@
+package simulator_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+	"github.com/smart-health/payments-api/internal/stripe/simulator"
+	stripego "github.com/stripe/stripe-go/v81"
+)
+
+// chanEmitter hands emitted events to the test.
+type chanEmitter chan stripego.Event
+
+func (e chanEmitter) Emit(event stripego.Event) { e <- event }
+
+func newSimulator(opts simulator.Options) (*simulator.Simulator, chanEmitter) {
+	events := make(chanEmitter, 16)
+	return simulator.New(opts, events, slog.New(slog.NewTextHandler(io.Discard, nil))), events
+}
+
+func request(amount float64) stripeservice.PaymentIntentRequest {
+	return stripeservice.PaymentIntentRequest{
+		PaymentID:     uuid.New(),
+		AppointmentID: uuid.New(),
+		Amount:        amount,
+		Currency:      "usd",
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		metadata map[string]string
+		want     string
+	}{
+		{150.00, nil, simulator.OutcomeSucceed},
+		{150.02, nil, simulator.OutcomeDecline},
+		{150.51, nil, simulator.OutcomeInsufficientFunds},
+		{150.30, nil, simulator.OutcomeRequires3DS},
+		{150.40, nil, simulator.OutcomeTimeout},
+		{150.60, nil, simulator.OutcomeDelayed},
+		{150.00, map[string]string{simulator.MetadataKey: simulator.OutcomeDecline}, simulator.OutcomeDecline},
+		{150.02, map[string]string{simulator.MetadataKey: simulator.OutcomeSucceed}, simulator.OutcomeSucceed},
+	}
+
+	for _, tt := range tests {
+		if got := simulator.Outcome(tt.amount, tt.metadata); got != tt.want {
+			t.Errorf("Outcome(%.2f, %v) = %q, want %q", tt.amount, tt.metadata, got, tt.want)
+		}
+	}
+}
+
+func TestSimulator_SucceedEmitsWebhookWithPaymentID(t *testing.T) {
+	s, events := newSimulator(simulator.Options{})
+	req := request(150)
+
+	intent, err := s.CreatePaymentIntent(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if intent.Status != stripeservice.IntentSucceeded {
+		t.Errorf("expected succeeded, got %q", intent.Status)
+	}
+
+	event := <-events
+	if event.Type != stripego.EventTypePaymentIntentSucceeded {
+		t.Errorf("expected payment_intent.succeeded, got %q", event.Type)
+	}
+	if event.GetObjectValue("metadata", "paymentId") != req.PaymentID.String() {
+		t.Errorf("expected paymentId %s in event metadata", req.PaymentID)
+	}
+}
+
+func TestSimulator_DeclineSetsLastError(t *testing.T) {
+	s, _ := newSimulator(simulator.Options{})
+
+	intent, err := s.CreatePaymentIntent(context.Background(), request(150.51))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if intent.Status != stripeservice.IntentRequiresPaymentMethod || intent.LastError == "" {
+		t.Errorf("expected declined intent, got %+v", intent)
+	}
+}
+
+func TestSimulator_Requires3DSThenAuthenticate(t *testing.T) {
+	s, _ := newSimulator(simulator.Options{})
+
+	_, err := s.CreatePaymentIntent(context.Background(), request(150.30))
+	var authRequired *stripeservice.ErrAuthenticationRequired
+	if !errors.As(err, &authRequired) {
+		t.Fatalf("expected ErrAuthenticationRequired, got %v", err)
+	}
+	if authRequired.ClientSecret == "" {
+		t.Error("expected a client secret")
+	}
+
+	intent, err := s.Authenticate(authRequired.PaymentIntentID, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if intent.Status != stripeservice.IntentSucceeded {
+		t.Errorf("expected succeeded, got %q", intent.Status)
+	}
+
+	if _, err := s.Authenticate(authRequired.PaymentIntentID, true); !errors.Is(err, simulator.ErrInvalidState) {
+		t.Errorf("expected ErrInvalidState on second authentication, got %v", err)
+	}
+}
+
+func TestSimulator_TimeoutWrapsDeadlineExceeded(t *testing.T) {
+	s, _ := newSimulator(simulator.Options{})
+
+	_, err := s.CreatePaymentIntent(context.Background(), request(150.40))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestSimulator_DelayedSettlesLater(t *testing.T) {
+	s, events := newSimulator(simulator.Options{WebhookDelay: 10 * time.Millisecond})
+
+	intent, err := s.CreatePaymentIntent(context.Background(), request(150.60))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if intent.Status != stripeservice.IntentProcessing {
+		t.Fatalf("expected processing, got %q", intent.Status)
+	}
+
+	<-events // processing
+	select {
+	case event := <-events:
+		if event.Type != stripego.EventTypePaymentIntentSucceeded {
+			t.Errorf("expected payment_intent.succeeded, got %q", event.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("delayed intent did not settle")
+	}
+
+	intent, _ = s.GetPaymentIntent(context.Background(), intent.ID)
+	if intent.Status != stripeservice.IntentSucceeded {
+		t.Errorf("expected succeeded, got %q", intent.Status)
+	}
+}
+
+func TestSimulator_LatencyHonoursContext(t *testing.T) {
+	s, _ := newSimulator(simulator.Options{Latency: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := s.CreatePaymentIntent(ctx, request(150)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package simulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	stripeservice "github.com/smart-health/payments-api/internal/stripe"
+	stripego "github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/webhook"
+)
+
+// Emitter delivers simulated webhook events.
+type Emitter interface {
+	Emit(event stripego.Event)
+}
+
+// eventTypes maps the intent statuses that Stripe announces to their event type.
+var eventTypes = map[string]stripego.EventType{
+	stripeservice.IntentRequiresAction:        stripego.EventTypePaymentIntentRequiresAction,
+	stripeservice.IntentRequiresPaymentMethod: stripego.EventTypePaymentIntentPaymentFailed,
+	stripeservice.IntentProcessing:            stripego.EventTypePaymentIntentProcessing,
+	stripeservice.IntentRequiresCapture:       stripego.EventTypePaymentIntentAmountCapturableUpdated,
+	stripeservice.IntentSucceeded:             stripego.EventTypePaymentIntentSucceeded,
+	stripeservice.IntentCanceled:              stripego.EventTypePaymentIntentCanceled,
+}
+
+// newEvent renders the intent as a Stripe event.
+func newEvent(id string, eventType stripego.EventType, in *intent) stripego.Event {
+	object := &stripego.PaymentIntent{
+		ID:           in.ID,
+		Object:       "payment_intent",
+		Amount:       int64(math.Round(in.Amount * 100)),
+		Currency:     stripego.Currency(strings.ToLower(in.Currency)),
+		Status:       stripego.PaymentIntentStatus(in.Status),
+		ClientSecret: in.ClientSecret,
+		Metadata:     in.Metadata,
+	}
+	if in.LastError != "" {
+		object.LastPaymentError = &stripego.Error{
+			Msg:         in.LastError,
+			Type:        stripego.ErrorTypeCard,
+			Code:        stripego.ErrorCodeCardDeclined,
+			DeclineCode: stripego.DeclineCode(in.DeclineCode),
+		}
+	}
+	raw, _ := json.Marshal(object)
+	var fields map[string]interface{}
+	_ = json.Unmarshal(raw, &fields)
+
+	return stripego.Event{
+		ID:         id,
+		Object:     "event",
+		APIVersion: stripego.APIVersion,
+		Created:    time.Now().Unix(),
+		Type:       eventType,
+		Data:       &stripego.EventData{Raw: raw, Object: fields},
+	}
+}
+
+// HTTPEmitter posts events to a webhook endpoint, signed like Stripe signs
+// them, retrying failed deliveries with a doubling backoff.
+type HTTPEmitter struct {
+	url      string
+	secret   string
+	attempts int
+	backoff  time.Duration
+	client   *http.Client
+	logger   *slog.Logger
+}
+
+// NewHTTPEmitter creates an emitter posting to url, signed with the endpoint secret.
+func NewHTTPEmitter(url, secret string, logger *slog.Logger) *HTTPEmitter {
+	return &HTTPEmitter{
+		url:      url,
+		secret:   secret,
+		attempts: 5,
+		backoff:  time.Second,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+	}
+}
+
+// Emit delivers the event in the background.
+func (e *HTTPEmitter) Emit(event stripego.Event) {
+	go e.deliver(event)
+}
+
+func (e *HTTPEmitter) deliver(event stripego.Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		e.logger.Error("marshal simulated webhook", "eventId", event.ID, "error", err)
+		return
+	}
+
+	backoff := e.backoff
+	for attempt := 1; attempt <= e.attempts; attempt++ {
+		status, err := e.post(payload)
+		if err == nil && status < 300 {
+			e.logger.Debug("simulated webhook delivered", "eventId", event.ID, "type", event.Type)
+			return
+		}
+		e.logger.Warn("simulated webhook delivery failed",
+			"eventId", event.ID,
+			"type", event.Type,
+			"attempt", attempt,
+			"status", status,
+			"error", err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (e *HTTPEmitter) post(payload []byte) (int, error) {
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{Payload: payload, Secret: e.secret})
+
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Stripe-Signature", signed.Header)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
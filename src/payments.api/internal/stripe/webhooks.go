@@ -0,0 +1,69 @@
+package stripe
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/problem"
+	stripego "github.com/stripe/stripe-go/v81"
+	"github.com/stripe/stripe-go/v81/webhook"
+)
+
+// maxWebhookBytes bounds the webhook body read into memory.
+const maxWebhookBytes = 64 << 10
+
+// WebhookHandler receives Stripe webhooks, verifies their signature with the
+// endpoint secret and calls onIntent with the payment of every payment_intent.*
+// event. Intents without a paymentId (created outside this service) are
+// acknowledged and ignored. An onIntent error answers 500 so Stripe retries.
+func WebhookHandler(secret string, onIntent func(ctx context.Context, paymentID uuid.UUID) error, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBytes))
+		if err != nil {
+			problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeMalformedBody, err.Error()))
+			return
+		}
+
+		event, err := webhook.ConstructEventWithOptions(payload, c.GetHeader("Stripe-Signature"), secret,
+			webhook.ConstructEventOptions{IgnoreAPIVersionMismatch: true})
+		if err != nil {
+			problem.Write(c, problem.New(http.StatusBadRequest, "invalid_webhook_signature", err.Error()))
+			return
+		}
+
+		if !strings.HasPrefix(string(event.Type), "payment_intent.") {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		var intent stripego.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &intent); err != nil {
+			problem.Write(c, problem.New(http.StatusBadRequest, problem.CodeMalformedBody, "the event does not carry a payment intent"))
+			return
+		}
+		paymentID, err := uuid.Parse(intent.Metadata["paymentId"])
+		if err != nil {
+			c.Status(http.StatusNoContent)
+			return
+		}
+
+		logger.Info("Stripe webhook received",
+			"eventId", event.ID,
+			"type", event.Type,
+			"intentId", intent.ID,
+			"paymentId", paymentID)
+
+		if err := onIntent(c.Request.Context(), paymentID); err != nil {
+			logger.Warn("Stripe webhook not applied", "eventId", event.ID, "paymentId", paymentID, "error", err)
+			problem.Write(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,67 @@
+package tax
+
+import (
+	"math"
+
+	"github.com/smart-health/payments-api/internal/payments/domain"
+)
+
+// Calculation is the result of taxing an amount.
+type Calculation struct {
+	Net   float64          // amount before any tax
+	Tax   float64          // sum of all tax lines
+	Total float64          // amount to charge: the taxed amount plus exclusive taxes
+	Lines []domain.TaxLine // one line per applicable rate
+}
+
+// Calculate taxes amount with the given rates, which must already be
+// filtered to the jurisdiction and service category (see Rate.AppliesTo).
+//
+// Inclusive rates are backed out of amount to find the net base; exclusive
+// rates are then charged on that same net base and added on top. When
+// inclusive lines are rounded, the rounding residual is assigned to the last
+// inclusive line so that Net plus inclusive taxes equals amount exactly.
+func Calculate(rates []*Rate, amount float64, category string) *Calculation {
+	var inclusivePercent float64
+	for _, r := range rates {
+		if r.Inclusive {
+			inclusivePercent += r.Percent
+		}
+	}
+
+	net := round(amount/(1+inclusivePercent/100), RoundHalfUp)
+	calc := &Calculation{Net: net, Total: amount}
+
+	remainingInclusive := round(amount-net, RoundHalfUp)
+	lastInclusive := -1
+	for _, r := range rates {
+		taxAmount := round(net*r.Percent/100, r.Rounding)
+		if r.Inclusive {
+			remainingInclusive = round(remainingInclusive-taxAmount, RoundHalfUp)
+			lastInclusive = len(calc.Lines)
+		} else {
+			calc.Total = round(calc.Total+taxAmount, RoundHalfUp)
+		}
+
+		calc.Lines = append(calc.Lines, domain.TaxLine{
+			Name:          r.Name,
+			Jurisdiction:  r.Jurisdiction,
+			Category:      category,
+			Percent:       r.Percent,
+			Inclusive:     r.Inclusive,
+			TaxableAmount: net,
+			Amount:        taxAmount,
+		})
+	}
+
+	if lastInclusive >= 0 && math.Abs(remainingInclusive) >= 0.005 {
+		calc.Lines[lastInclusive].Amount = round(calc.Lines[lastInclusive].Amount+remainingInclusive, RoundHalfUp)
+	}
+
+	for _, l := range calc.Lines {
+		calc.Tax += l.Amount
+	}
+	calc.Tax = round(calc.Tax, RoundHalfUp)
+
+	return calc
+}
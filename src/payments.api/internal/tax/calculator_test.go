@@ -0,0 +1,75 @@
+package tax_test
+
+import (
+	"testing"
+
+	"github.com/smart-health/payments-api/internal/tax"
+)
+
+func mustRate(t *testing.T, jurisdiction, category string, percent float64, inclusive bool, rounding string) *tax.Rate {
+	t.Helper()
+	r, err := tax.NewRate(jurisdiction, category, jurisdiction+" tax", percent, inclusive, rounding)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return r
+}
+
+func TestCalculate_Exclusive(t *testing.T) {
+	rates := []*tax.Rate{
+		mustRate(t, "US-CA", "", 6, false, ""),
+		mustRate(t, "US-CA-SF", "", 1.25, false, ""),
+	}
+	calc := tax.Calculate(rates, 100, "consultation")
+
+	if calc.Net != 100 || calc.Tax != 7.25 || calc.Total != 107.25 {
+		t.Errorf("expected net 100, tax 7.25, total 107.25; got %+v", calc)
+	}
+	if len(calc.Lines) != 2 {
+		t.Fatalf("expected 2 tax lines, got %d", len(calc.Lines))
+	}
+}
+
+func TestCalculate_Inclusive(t *testing.T) {
+	rates := []*tax.Rate{mustRate(t, "DE", "", 19, true, "")}
+	calc := tax.Calculate(rates, 119, "consultation")
+
+	if calc.Net != 100 || calc.Tax != 19 || calc.Total != 119 {
+		t.Errorf("expected net 100, tax 19, total 119; got %+v", calc)
+	}
+}
+
+func TestCalculate_InclusiveResidualKeepsTotal(t *testing.T) {
+	rates := []*tax.Rate{mustRate(t, "DE", "", 19, true, "")}
+	calc := tax.Calculate(rates, 10, "consultation")
+
+	if calc.Net+calc.Tax != 10 {
+		t.Errorf("expected net + tax to equal 10, got %v + %v", calc.Net, calc.Tax)
+	}
+}
+
+func TestCalculate_RoundingModes(t *testing.T) {
+	// 10.05 * 5 % = 0.5025
+	up := tax.Calculate([]*tax.Rate{mustRate(t, "X", "", 5, false, tax.RoundUp)}, 10.05, "")
+	down := tax.Calculate([]*tax.Rate{mustRate(t, "X", "", 5, false, tax.RoundDown)}, 10.05, "")
+
+	if up.Tax != 0.51 {
+		t.Errorf("expected 0.51 rounding up, got %v", up.Tax)
+	}
+	if down.Tax != 0.50 {
+		t.Errorf("expected 0.50 rounding down, got %v", down.Tax)
+	}
+}
+
+func TestRate_AppliesTo(t *testing.T) {
+	r := mustRate(t, "US-CA", "lab", 6, false, "")
+	if !r.AppliesTo("us-ca-sf", "LAB") {
+		t.Error("expected state rate to apply to a city in the state")
+	}
+	if r.AppliesTo("US-CAX", "lab") {
+		t.Error("expected rate not to apply to a jurisdiction sharing only a prefix")
+	}
+	if r.AppliesTo("US-CA", "consultation") {
+		t.Error("expected category-specific rate not to apply to another category")
+	}
+}
@@ -0,0 +1,143 @@
+package tax
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rounding modes applied when a tax amount is rounded to cents.
+const (
+	RoundHalfUp   = "half_up"
+	RoundHalfEven = "half_even"
+	RoundUp       = "up"
+	RoundDown     = "down"
+)
+
+// ErrInvalidRate is returned when a tax rate definition violates its invariants.
+var ErrInvalidRate = errors.New("invalid tax rate")
+
+// ErrRateNotFound is returned when a tax rate record cannot be found.
+type ErrRateNotFound struct {
+	ID uuid.UUID
+}
+
+func (e *ErrRateNotFound) Error() string {
+	return fmt.Sprintf("tax rate %s was not found", e.ID)
+}
+
+// -----------------------------------------------------------------------
+// Rate
+//
+// Architectural Decision: Jurisdictions are hierarchical codes separated by
+// "-" (e.g. "US", "US-CA", "US-CA-SF"). A rate applies to its jurisdiction
+// and every jurisdiction below it, so a clinic in "US-CA-SF" pays the state
+// and the city rate as separate tax lines. An empty Category applies to
+// every service category.
+// -----------------------------------------------------------------------
+
+// Rate is a configurable tax rate for a jurisdiction and service category.
+type Rate struct {
+	ID           uuid.UUID
+	Jurisdiction string
+	Category     string  // empty = all service categories
+	Name         string  // printed on tax lines, e.g. "CA sales tax"
+	Percent      float64 // e.g. 7.25 for 7.25 %
+	Inclusive    bool    // true: the price already includes this tax
+	Rounding     string
+	Active       bool
+	CreatedAt    time.Time
+	UpdatedAt    *time.Time
+}
+
+// NewRate is the factory function – enforces invariants on creation.
+func NewRate(jurisdiction, category, name string, percent float64, inclusive bool, rounding string) (*Rate, error) {
+	if rounding == "" {
+		rounding = RoundHalfUp
+	}
+	r := &Rate{
+		ID:           uuid.New(),
+		Jurisdiction: strings.ToUpper(strings.TrimSpace(jurisdiction)),
+		Category:     strings.ToLower(strings.TrimSpace(category)),
+		Name:         name,
+		Percent:      percent,
+		Inclusive:    inclusive,
+		Rounding:     rounding,
+		Active:       true,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Change updates the rate's name, percentage, mode, rounding and active flag.
+func (r *Rate) Change(name string, percent float64, inclusive bool, rounding string, active bool) error {
+	updated := *r
+	updated.Name = name
+	updated.Percent = percent
+	updated.Inclusive = inclusive
+	updated.Rounding = rounding
+	updated.Active = active
+	if err := updated.validate(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	updated.UpdatedAt = &now
+	*r = updated
+	return nil
+}
+
+// AppliesTo reports whether the rate taxes a service category in a jurisdiction.
+func (r *Rate) AppliesTo(jurisdiction, category string) bool {
+	if !r.Active {
+		return false
+	}
+	j := strings.ToUpper(jurisdiction)
+	if j != r.Jurisdiction && !strings.HasPrefix(j, r.Jurisdiction+"-") {
+		return false
+	}
+	return r.Category == "" || strings.EqualFold(r.Category, category)
+}
+
+func (r *Rate) validate() error {
+	if r.Jurisdiction == "" {
+		return fmt.Errorf("%w: jurisdiction is required", ErrInvalidRate)
+	}
+	if r.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRate)
+	}
+	if r.Percent <= 0 || r.Percent >= 100 {
+		return fmt.Errorf("%w: percent must be in (0, 100)", ErrInvalidRate)
+	}
+	switch r.Rounding {
+	case RoundHalfUp, RoundHalfEven, RoundUp, RoundDown:
+	default:
+		return fmt.Errorf("%w: rounding must be one of %s, %s, %s, %s",
+			ErrInvalidRate, RoundHalfUp, RoundHalfEven, RoundUp, RoundDown)
+	}
+	return nil
+}
+
+// round rounds a monetary amount to cents using the given mode.
+func round(v float64, mode string) float64 {
+	// Snap away float noise (e.g. 0.145 stored as 0.14499...) before applying the mode.
+	cents := math.Round(v*100*1e6) / 1e6
+	switch mode {
+	case RoundHalfEven:
+		cents = math.RoundToEven(cents)
+	case RoundUp:
+		cents = math.Ceil(cents)
+	case RoundDown:
+		cents = math.Floor(cents)
+	default:
+		cents = math.Round(cents)
+	}
+	return cents / 100
+}
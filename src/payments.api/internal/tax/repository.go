@@ -0,0 +1,153 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository defines the persistence contract for tax rates.
+type Repository interface {
+	Create(ctx context.Context, rate *Rate) error
+	Update(ctx context.Context, rate *Rate) error
+	FindByID(ctx context.Context, id uuid.UUID) (*Rate, error)
+	// List returns all rates, optionally narrowed to one jurisdiction code.
+	List(ctx context.Context, jurisdiction string) ([]*Rate, error)
+	// FindActive returns the active rates of the jurisdiction and all its parents.
+	FindActive(ctx context.Context, jurisdiction string) ([]*Rate, error)
+}
+
+// PostgresRepository implements Repository using PostgreSQL.
+type PostgresRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresRepository creates a new PostgreSQL-backed tax rate repository.
+func NewPostgresRepository(pool *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{pool: pool}
+}
+
+const rateColumns = `id, jurisdiction, COALESCE(category, ''), name, percent, inclusive, rounding, active, created_at, updated_at`
+
+// Create persists a new tax rate.
+func (r *PostgresRepository) Create(ctx context.Context, rate *Rate) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO tax_rates (id, jurisdiction, category, name, percent, inclusive, rounding, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		rate.ID,
+		rate.Jurisdiction,
+		nilIfEmpty(rate.Category),
+		rate.Name,
+		rate.Percent,
+		rate.Inclusive,
+		rate.Rounding,
+		rate.Active,
+		rate.CreatedAt,
+		rate.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert tax rate: %w", err)
+	}
+	return nil
+}
+
+// Update persists changes to a tax rate.
+func (r *PostgresRepository) Update(ctx context.Context, rate *Rate) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE tax_rates SET
+			name = $2,
+			percent = $3,
+			inclusive = $4,
+			rounding = $5,
+			active = $6,
+			updated_at = $7
+		WHERE id = $1`,
+		rate.ID,
+		rate.Name,
+		rate.Percent,
+		rate.Inclusive,
+		rate.Rounding,
+		rate.Active,
+		rate.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update tax rate: %w", err)
+	}
+	return nil
+}
+
+// FindByID retrieves a tax rate by its primary key.
+func (r *PostgresRepository) FindByID(ctx context.Context, id uuid.UUID) (*Rate, error) {
+	row := r.pool.QueryRow(ctx, `SELECT `+rateColumns+` FROM tax_rates WHERE id = $1`, id)
+	return scanRate(row)
+}
+
+// List retrieves tax rates ordered by jurisdiction.
+func (r *PostgresRepository) List(ctx context.Context, jurisdiction string) ([]*Rate, error) {
+	if jurisdiction == "" {
+		return r.query(ctx, `SELECT `+rateColumns+` FROM tax_rates ORDER BY jurisdiction, name`)
+	}
+	return r.query(ctx, `SELECT `+rateColumns+` FROM tax_rates WHERE jurisdiction = UPPER($1) ORDER BY name`, jurisdiction)
+}
+
+// FindActive retrieves the active rates of a jurisdiction and its parents,
+// broadest jurisdiction first so tax lines read country → state → city.
+func (r *PostgresRepository) FindActive(ctx context.Context, jurisdiction string) ([]*Rate, error) {
+	return r.query(ctx, `
+		SELECT `+rateColumns+`
+		FROM tax_rates
+		WHERE active = true
+		  AND (UPPER($1) = jurisdiction OR UPPER($1) LIKE jurisdiction || '-%')
+		ORDER BY LENGTH(jurisdiction), name`, jurisdiction)
+}
+
+func (r *PostgresRepository) query(ctx context.Context, query string, args ...any) ([]*Rate, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query tax rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []*Rate
+	for rows.Next() {
+		rate, err := scanRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+func scanRate(row pgx.Row) (*Rate, error) {
+	var rate Rate
+	err := row.Scan(
+		&rate.ID,
+		&rate.Jurisdiction,
+		&rate.Category,
+		&rate.Name,
+		&rate.Percent,
+		&rate.Inclusive,
+		&rate.Rounding,
+		&rate.Active,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan tax rate: %w", err)
+	}
+	return &rate, nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,256 @@
+package tax
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/smart-health/payments-api/internal/openapi"
+	"github.com/smart-health/payments-api/internal/problem"
+)
+
+// RateResult is the read model returned by the tax rate endpoints.
+type RateResult struct {
+	ID           string     `json:"id"`
+	Jurisdiction string     `json:"jurisdiction"`
+	Category     string     `json:"category,omitempty"`
+	Name         string     `json:"name"`
+	Percent      float64    `json:"percent"`
+	Inclusive    bool       `json:"inclusive"`
+	Rounding     string     `json:"rounding"`
+	Active       bool       `json:"active"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty"`
+}
+
+// LineResult is an itemized tax line.
+type LineResult struct {
+	Name          string  `json:"name"`
+	Jurisdiction  string  `json:"jurisdiction"`
+	Category      string  `json:"category,omitempty"`
+	Percent       float64 `json:"percent"`
+	Inclusive     bool    `json:"inclusive"`
+	TaxableAmount float64 `json:"taxableAmount"`
+	Amount        float64 `json:"amount"`
+}
+
+// CalculationResult is returned by the tax preview endpoint.
+type CalculationResult struct {
+	Net   float64      `json:"net"`
+	Tax   float64      `json:"tax"`
+	Total float64      `json:"total"`
+	Lines []LineResult `json:"lines"`
+}
+
+type createRateRequest struct {
+	Jurisdiction string  `json:"jurisdiction" binding:"required"`
+	Category     string  `json:"category"`
+	Name         string  `json:"name"         binding:"required"`
+	Percent      float64 `json:"percent"      binding:"required,gt=0,lt=100"`
+	Inclusive    bool    `json:"inclusive"`
+	Rounding     string  `json:"rounding"     binding:"omitempty,oneof=half_up half_even up down"`
+}
+
+type updateRateRequest struct {
+	Name      string  `json:"name"      binding:"required"`
+	Percent   float64 `json:"percent"   binding:"required,gt=0,lt=100"`
+	Inclusive bool    `json:"inclusive"`
+	Rounding  string  `json:"rounding"  binding:"required,oneof=half_up half_even up down"`
+	Active    *bool   `json:"active"    binding:"required"`
+}
+
+// RegisterRoutes mounts the tax administration endpoints on the group.
+//
+//	GET    /rates      – list (filter: jurisdiction)
+//	POST   /rates      – create
+//	GET    /rates/:id  – get by ID
+//	PUT    /rates/:id  – change name, percent, mode, rounding, active flag
+//	DELETE /rates/:id  – deactivate
+//	GET    /calculate  – preview tax lines (jurisdiction, category, amount)
+func RegisterRoutes(rg *gin.RouterGroup, repo Repository, service Service) {
+	rg.GET("/rates", func(c *gin.Context) {
+		rates, err := repo.List(c.Request.Context(), c.Query("jurisdiction"))
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		results := make([]*RateResult, 0, len(rates))
+		for _, r := range rates {
+			results = append(results, toResult(r))
+		}
+		c.JSON(http.StatusOK, results)
+	})
+
+	rg.POST("/rates", func(c *gin.Context) {
+		var req createRateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		rate, err := NewRate(req.Jurisdiction, req.Category, req.Name, req.Percent, req.Inclusive, req.Rounding)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		if err := repo.Create(c.Request.Context(), rate); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, toResult(rate))
+	})
+
+	rg.GET("/rates/:id", func(c *gin.Context) {
+		rate, ok := loadRate(c, repo)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, toResult(rate))
+	})
+
+	rg.PUT("/rates/:id", func(c *gin.Context) {
+		var req updateRateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			problem.Write(c, problem.Binding(err))
+			return
+		}
+
+		rate, ok := loadRate(c, repo)
+		if !ok {
+			return
+		}
+
+		if err := rate.Change(req.Name, req.Percent, req.Inclusive, req.Rounding, *req.Active); err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		if err := repo.Update(c.Request.Context(), rate); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toResult(rate))
+	})
+
+	rg.DELETE("/rates/:id", func(c *gin.Context) {
+		rate, ok := loadRate(c, repo)
+		if !ok {
+			return
+		}
+
+		if err := rate.Change(rate.Name, rate.Percent, rate.Inclusive, rate.Rounding, false); err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		if err := repo.Update(c.Request.Context(), rate); err != nil {
+			problem.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, toResult(rate))
+	})
+
+	rg.GET("/calculate", func(c *gin.Context) {
+		amount, err := strconv.ParseFloat(c.Query("amount"), 64)
+		if err != nil || amount <= 0 {
+			problem.Write(c, problem.Invalid("amount", "gt", "amount must be a number greater than zero"))
+			return
+		}
+
+		calc, err := service.Calculate(c.Request.Context(), c.Query("jurisdiction"), c.Query("category"), amount)
+		if err != nil {
+			problem.Write(c, err)
+			return
+		}
+
+		lines := make([]LineResult, 0, len(calc.Lines))
+		for _, l := range calc.Lines {
+			lines = append(lines, LineResult{
+				Name:          l.Name,
+				Jurisdiction:  l.Jurisdiction,
+				Category:      l.Category,
+				Percent:       l.Percent,
+				Inclusive:     l.Inclusive,
+				TaxableAmount: l.TaxableAmount,
+				Amount:        l.Amount,
+			})
+		}
+		c.JSON(http.StatusOK, CalculationResult{Net: calc.Net, Tax: calc.Tax, Total: calc.Total, Lines: lines})
+	})
+}
+
+// Routes declares the endpoints RegisterRoutes mounts.
+func Routes() []openapi.Route {
+	return []openapi.Route{
+		{Method: http.MethodGet, Path: "/rates", Summary: "List tax rates",
+			Query:    []openapi.Param{{Name: "jurisdiction", Description: "only the rates of this jurisdiction"}},
+			Response: []*RateResult{}},
+		{Method: http.MethodPost, Path: "/rates", Summary: "Create a tax rate",
+			Request: createRateRequest{}, Response: RateResult{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/rates/:id", Summary: "Get a tax rate",
+			Response: RateResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/rates/:id", Summary: "Change a tax rate",
+			Request: updateRateRequest{}, Response: RateResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodDelete, Path: "/rates/:id", Summary: "Deactivate a tax rate",
+			Response: RateResult{}, Errors: []int{http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/calculate", Summary: "Preview the tax lines of an amount",
+			Query: []openapi.Param{
+				{Name: "jurisdiction", Description: "the clinic's jurisdiction"},
+				{Name: "category", Description: "the service category"},
+				{Name: "amount", Description: "the net amount, greater than zero", Type: "number", Required: true},
+			},
+			Response: CalculationResult{}},
+	}
+}
+
+// Problem maps the package's errors to problem responses.
+func Problem(err error) *problem.Problem {
+	var notFound *ErrRateNotFound
+	switch {
+	case errors.Is(err, ErrInvalidRate):
+		return problem.New(http.StatusBadRequest, "invalid_tax_rate", err.Error())
+	case errors.As(err, &notFound):
+		return problem.New(http.StatusNotFound, "tax_rate_not_found", notFound.Error())
+	}
+	return nil
+}
+
+// loadRate resolves the :id route parameter, writing the error response on failure.
+func loadRate(c *gin.Context, repo Repository) (*Rate, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		problem.Write(c, problem.Invalid("id", "uuid", "invalid tax rate id"))
+		return nil, false
+	}
+
+	rate, err := repo.FindByID(c.Request.Context(), id)
+	if err != nil {
+		problem.Write(c, err)
+		return nil, false
+	}
+	if rate == nil {
+		problem.Write(c, &ErrRateNotFound{ID: id})
+		return nil, false
+	}
+	return rate, true
+}
+
+func toResult(r *Rate) *RateResult {
+	return &RateResult{
+		ID:           r.ID.String(),
+		Jurisdiction: r.Jurisdiction,
+		Category:     r.Category,
+		Name:         r.Name,
+		Percent:      r.Percent,
+		Inclusive:    r.Inclusive,
+		Rounding:     r.Rounding,
+		Active:       r.Active,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+}
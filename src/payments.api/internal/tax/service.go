@@ -0,0 +1,44 @@
+package tax
+
+import (
+	"context"
+	"fmt"
+)
+
+// Service defines the tax contract used when creating payments.
+type Service interface {
+	// Calculate taxes amount for a service category in a jurisdiction.
+	// An empty jurisdiction, or one without applicable rates, yields no tax lines.
+	Calculate(ctx context.Context, jurisdiction, category string, amount float64) (*Calculation, error)
+}
+
+// TaxService implements Service on top of the tax rate repository.
+type TaxService struct {
+	repo Repository
+}
+
+// NewTaxService creates a new tax service.
+func NewTaxService(repo Repository) *TaxService {
+	return &TaxService{repo: repo}
+}
+
+// Calculate loads the applicable rates and computes the tax lines.
+func (s *TaxService) Calculate(ctx context.Context, jurisdiction, category string, amount float64) (*Calculation, error) {
+	if jurisdiction == "" {
+		return &Calculation{Net: amount, Total: amount}, nil
+	}
+
+	candidates, err := s.repo.FindActive(ctx, jurisdiction)
+	if err != nil {
+		return nil, fmt.Errorf("find tax rates: %w", err)
+	}
+
+	var rates []*Rate
+	for _, r := range candidates {
+		if r.AppliesTo(jurisdiction, category) {
+			rates = append(rates, r)
+		}
+	}
+
+	return Calculate(rates, amount, category), nil
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS fee_schedule_history;
+DROP TABLE IF EXISTS fee_schedules;
@@ -0,0 +1,30 @@
+-- Fee schedules: price per doctor / specialty / visit type and currency.
+-- NULL doctor_id, specialty or visit_type act as wildcards.
+CREATE TABLE IF NOT EXISTS fee_schedules (
+    id             UUID          PRIMARY KEY,
+    doctor_id      VARCHAR(256),
+    specialty      VARCHAR(128),
+    visit_type     VARCHAR(128),
+    currency       VARCHAR(3)    NOT NULL,
+    amount         NUMERIC(18,2) NOT NULL,
+    effective_from TIMESTAMPTZ   NOT NULL,
+    effective_to   TIMESTAMPTZ,                       -- NULL = open-ended
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_schedules_lookup ON fee_schedules(currency, effective_from);
+
+-- Price history: one row per create / update / retire of a fee schedule
+CREATE TABLE IF NOT EXISTS fee_schedule_history (
+    id              UUID          PRIMARY KEY,
+    fee_schedule_id UUID          NOT NULL REFERENCES fee_schedules(id),
+    action          VARCHAR(32)   NOT NULL,
+    amount          NUMERIC(18,2) NOT NULL,
+    currency        VARCHAR(3)    NOT NULL,
+    effective_from  TIMESTAMPTZ   NOT NULL,
+    effective_to    TIMESTAMPTZ,
+    changed_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fee_schedule_history_schedule ON fee_schedule_history(fee_schedule_id, changed_at);
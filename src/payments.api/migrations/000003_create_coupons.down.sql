@@ -0,0 +1,8 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE payments DROP COLUMN IF EXISTS discount_value;
+ALTER TABLE payments DROP COLUMN IF EXISTS discount_kind;
+ALTER TABLE payments DROP COLUMN IF EXISTS discount_code;
+ALTER TABLE payments DROP COLUMN IF EXISTS discount_coupon_id;
+ALTER TABLE payments DROP COLUMN IF EXISTS subtotal;
+DROP TABLE IF EXISTS coupon_redemptions;
+DROP TABLE IF EXISTS coupons;
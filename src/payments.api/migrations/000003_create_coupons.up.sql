@@ -0,0 +1,38 @@
+-- Coupons: percentage or fixed discounts with validity windows and usage limits
+CREATE TABLE IF NOT EXISTS coupons (
+    id                       UUID          PRIMARY KEY,
+    code                     VARCHAR(64)   NOT NULL UNIQUE,   -- stored upper-case
+    kind                     VARCHAR(16)   NOT NULL,          -- 'percentage' | 'fixed'
+    value                    NUMERIC(18,2) NOT NULL,
+    currency                 VARCHAR(3),                      -- fixed coupons only
+    valid_from               TIMESTAMPTZ   NOT NULL,
+    valid_to                 TIMESTAMPTZ,
+    max_redemptions          INT           NOT NULL DEFAULT 0, -- 0 = unlimited
+    max_redemptions_per_user INT           NOT NULL DEFAULT 0, -- 0 = unlimited
+    active                   BOOLEAN       NOT NULL DEFAULT true,
+    created_at               TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at               TIMESTAMPTZ
+);
+
+-- Coupon redemptions: one per discounted payment
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id              UUID          PRIMARY KEY,
+    coupon_id       UUID          NOT NULL REFERENCES coupons(id),
+    payment_id      UUID          NOT NULL UNIQUE,
+    user_id         VARCHAR(256)  NOT NULL,
+    discount_amount NUMERIC(18,2) NOT NULL,
+    currency        VARCHAR(3)    NOT NULL,
+    status          VARCHAR(16)   NOT NULL,   -- 'reserved' | 'redeemed' | 'released'
+    created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    settled_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon ON coupon_redemptions(coupon_id, status, user_id);
+
+-- Discount breakdown on payments (amount = subtotal - discount_amount)
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS subtotal           NUMERIC(18,2);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_coupon_id UUID;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_code      VARCHAR(64);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_kind      VARCHAR(16);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_value     NUMERIC(18,2);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS discount_amount    NUMERIC(18,2);
@@ -0,0 +1,3 @@
+ALTER TABLE payments DROP COLUMN IF EXISTS tax_amount;
+DROP TABLE IF EXISTS payment_tax_lines;
+DROP TABLE IF EXISTS tax_rates;
@@ -0,0 +1,31 @@
+-- Tax rates per jurisdiction (hierarchical, e.g. 'US-CA-SF') and service category
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id           UUID         PRIMARY KEY,
+    jurisdiction VARCHAR(64)  NOT NULL,
+    category     VARCHAR(128),                         -- NULL = all categories
+    name         VARCHAR(128) NOT NULL,
+    percent      NUMERIC(7,4) NOT NULL,
+    inclusive    BOOLEAN      NOT NULL DEFAULT false,
+    rounding     VARCHAR(16)  NOT NULL DEFAULT 'half_up', -- 'half_up' | 'half_even' | 'up' | 'down'
+    active       BOOLEAN      NOT NULL DEFAULT true,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_rates_jurisdiction ON tax_rates(jurisdiction) WHERE active = true;
+
+-- Itemized tax lines stored with each payment
+CREATE TABLE IF NOT EXISTS payment_tax_lines (
+    payment_id     UUID          NOT NULL REFERENCES payments(id),
+    position       INT           NOT NULL,
+    name           VARCHAR(128)  NOT NULL,
+    jurisdiction   VARCHAR(64)   NOT NULL,
+    category       VARCHAR(128),
+    percent        NUMERIC(7,4)  NOT NULL,
+    inclusive      BOOLEAN       NOT NULL,
+    taxable_amount NUMERIC(18,2) NOT NULL,
+    amount         NUMERIC(18,2) NOT NULL,
+    PRIMARY KEY (payment_id, position)
+);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(18,2);
@@ -0,0 +1,2 @@
+ALTER TABLE payment_tax_lines DROP COLUMN IF EXISTS item_code;
+DROP TABLE IF EXISTS payment_line_items;
@@ -0,0 +1,18 @@
+-- Line items billed on a payment (consultation, lab tests, medicines)
+CREATE TABLE IF NOT EXISTS payment_line_items (
+    payment_id      UUID          NOT NULL REFERENCES payments(id),
+    position        INT           NOT NULL,
+    code            VARCHAR(64)   NOT NULL,
+    description     VARCHAR(255)  NOT NULL DEFAULT '',
+    category        VARCHAR(128),
+    quantity        INT           NOT NULL,
+    unit_price      NUMERIC(18,2) NOT NULL,
+    discount_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+    tax_amount      NUMERIC(18,2) NOT NULL DEFAULT 0,
+    total           NUMERIC(18,2) NOT NULL,
+    PRIMARY KEY (payment_id, position),
+    UNIQUE (payment_id, code)
+);
+
+-- Line item each tax line was computed on (NULL for payment-level taxes)
+ALTER TABLE payment_tax_lines ADD COLUMN IF NOT EXISTS item_code VARCHAR(64);
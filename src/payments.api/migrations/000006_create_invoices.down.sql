@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS invoices_immutable ON invoices;
+DROP FUNCTION IF EXISTS forbid_invoice_change();
+DROP TABLE IF EXISTS invoices;
+DROP TABLE IF EXISTS invoice_sequences;
+ALTER TABLE payments DROP COLUMN IF EXISTS clinic_id;
@@ -0,0 +1,48 @@
+-- Billing clinic of a payment; selects the invoice number series
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS clinic_id VARCHAR(64);
+
+-- Gapless number series per clinic and document kind ('invoice' | 'credit_note').
+-- Numbers are allocated by incrementing the row inside the issuing transaction.
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    clinic_id   VARCHAR(64) NOT NULL,
+    kind        VARCHAR(16) NOT NULL,
+    last_number BIGINT      NOT NULL,
+    PRIMARY KEY (clinic_id, kind)
+);
+
+-- Issued invoices and credit notes: immutable snapshots of completed payments
+CREATE TABLE IF NOT EXISTS invoices (
+    id                  UUID          PRIMARY KEY,
+    kind                VARCHAR(16)   NOT NULL,
+    clinic_id           VARCHAR(64)   NOT NULL,
+    sequence            BIGINT        NOT NULL,
+    number              VARCHAR(96)   NOT NULL UNIQUE,
+    payment_id          UUID          NOT NULL REFERENCES payments(id),
+    appointment_id      UUID          NOT NULL,
+    patient_id          VARCHAR(255)  NOT NULL,
+    currency            VARCHAR(3)    NOT NULL,
+    subtotal            NUMERIC(18,2) NOT NULL,
+    discount_amount     NUMERIC(18,2) NOT NULL,
+    tax_amount          NUMERIC(18,2) NOT NULL,
+    total               NUMERIC(18,2) NOT NULL,
+    transaction_id      VARCHAR(255),
+    credited_invoice_id UUID          REFERENCES invoices(id),  -- credit notes only
+    reason              TEXT,                                   -- credit notes only
+    snapshot            JSONB         NOT NULL,                 -- printed lines and tax lines
+    issued_at           TIMESTAMPTZ   NOT NULL,
+    UNIQUE (clinic_id, kind, sequence)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_payment ON invoices(payment_id) WHERE kind = 'invoice';
+CREATE INDEX IF NOT EXISTS idx_invoices_credited ON invoices(credited_invoice_id) WHERE credited_invoice_id IS NOT NULL;
+
+-- Issued documents are never edited or deleted; corrections are credit notes
+CREATE OR REPLACE FUNCTION forbid_invoice_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'invoices are immutable; issue a credit note instead';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS invoices_immutable ON invoices;
+CREATE TRIGGER invoices_immutable BEFORE UPDATE OR DELETE ON invoices
+    FOR EACH ROW EXECUTE FUNCTION forbid_invoice_change();
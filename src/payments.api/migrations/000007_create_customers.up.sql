@@ -0,0 +1,8 @@
+-- Maps SmartHealth users to their Stripe Customer (created lazily on first use)
+CREATE TABLE IF NOT EXISTS customers (
+    user_id                   VARCHAR(255) PRIMARY KEY,
+    stripe_customer_id        VARCHAR(255) NOT NULL UNIQUE,
+    default_payment_method_id VARCHAR(255),               -- charged off-session; NULL = none
+    created_at                TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at                TIMESTAMPTZ
+);
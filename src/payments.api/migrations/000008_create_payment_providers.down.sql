@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS bank_transfers;
+DROP TABLE IF EXISTS payment_routing_rules;
+ALTER TABLE payments DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,27 @@
+-- Payment provider charging each payment; NULL on rows created before providers were pluggable (Stripe)
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider VARCHAR(32);
+
+-- Routes payments to providers; empty criteria are wildcards, the most specific active rule wins
+CREATE TABLE IF NOT EXISTS payment_routing_rules (
+    id         UUID        PRIMARY KEY,
+    provider   VARCHAR(32) NOT NULL,
+    currency   VARCHAR(3),                      -- NULL = any currency
+    country    VARCHAR(2),                      -- NULL = any country (first segment of the tax jurisdiction)
+    clinic_id  VARCHAR(64),                     -- NULL = any clinic
+    active     BOOLEAN     NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ
+);
+
+-- Bank transfers expected from patients; the reference is the payment's transaction ID
+CREATE TABLE IF NOT EXISTS bank_transfers (
+    reference       VARCHAR(32)   PRIMARY KEY,
+    payment_id      UUID          NOT NULL UNIQUE REFERENCES payments(id),
+    amount          NUMERIC(18,2) NOT NULL,
+    currency        VARCHAR(3)    NOT NULL,
+    status          VARCHAR(16)   NOT NULL,     -- awaiting_funds, received, canceled
+    received_amount NUMERIC(18,2) NOT NULL DEFAULT 0,
+    refunded_amount NUMERIC(18,2) NOT NULL DEFAULT 0,  -- recorded for manual payout
+    created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ
+);
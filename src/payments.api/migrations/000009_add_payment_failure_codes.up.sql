@@ -0,0 +1,4 @@
+-- Why a payment failed: card_declined, insufficient_funds, expired_card, fraud_suspected,
+-- provider_unavailable, timeout or cancelled; NULL on payments failed before codes were recorded.
+-- failure_reason keeps the provider's explanation for support staff only.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS failure_code VARCHAR(32);
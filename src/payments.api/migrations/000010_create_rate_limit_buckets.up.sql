@@ -0,0 +1,7 @@
+-- Token buckets shared by all replicas (RATE_LIMIT_SHARED); one row per provider,
+-- refilled lazily from updated_at on every take
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    name       VARCHAR(64)      PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_payments_provider_reference;
+DROP INDEX IF EXISTS idx_payments_status_created;
+DROP INDEX IF EXISTS idx_payments_user_created;
+DROP INDEX IF EXISTS idx_payments_amount;
+DROP INDEX IF EXISTS idx_payments_created;
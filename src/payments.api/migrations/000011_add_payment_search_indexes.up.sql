@@ -0,0 +1,11 @@
+-- Keyset pagination of GET /api/payments: every sort key is indexed together
+-- with id, the tie-breaker of the cursor. B-tree indexes serve both directions.
+CREATE INDEX IF NOT EXISTS idx_payments_created ON payments(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_payments_amount ON payments(amount, id);
+
+-- The most common support searches: a patient's payments, payments in a status
+-- (also the pending-payment retrier), and the lookup by provider reference
+CREATE INDEX IF NOT EXISTS idx_payments_user_created ON payments(user_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_payments_status_created ON payments(status, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_payments_provider_reference ON payments(stripe_payment_intent_id)
+    WHERE stripe_payment_intent_id IS NOT NULL;
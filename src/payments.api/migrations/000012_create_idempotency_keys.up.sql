@@ -0,0 +1,15 @@
+-- Idempotency-Key header of mutating requests: the fingerprint of the first
+-- request, and its response once stored (NULL while the request runs, which
+-- holds the key until locked_until)
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key                   VARCHAR(255)  PRIMARY KEY,
+    fingerprint           CHAR(64)      NOT NULL,   -- SHA-256 of method, URI, caller and body
+    response_status       INT,
+    response_content_type VARCHAR(255),
+    response_location     VARCHAR(1000),
+    response_body         BYTEA,
+    locked_until          TIMESTAMPTZ   NOT NULL,
+    created_at            TIMESTAMPTZ   NOT NULL,
+    expires_at            TIMESTAMPTZ   NOT NULL    -- IDEMPOTENCY_KEY_TTL after created_at
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
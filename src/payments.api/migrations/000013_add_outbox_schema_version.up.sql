@@ -0,0 +1,3 @@
+-- Contract version of the payload, published in the schema-version header;
+-- messages stored before versions were introduced are version 1
+ALTER TABLE outbox_messages ADD COLUMN IF NOT EXISTS schema_version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+-- Keys used by several callers cannot be kept under a global key
+DELETE FROM idempotency_keys a USING idempotency_keys b
+WHERE a.key = b.key AND (a.created_at, a.scope) > (b.created_at, b.scope);
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD CONSTRAINT idempotency_keys_pkey PRIMARY KEY (key);
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS scope;
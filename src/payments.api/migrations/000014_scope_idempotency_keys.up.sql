@@ -0,0 +1,5 @@
+-- Keys are chosen by clients: they are unique per caller (the token subject;
+-- empty for anonymous requests), not across callers
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS scope VARCHAR(256) NOT NULL DEFAULT '';
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD CONSTRAINT idempotency_keys_pkey PRIMARY KEY (scope, key);
@@ -0,0 +1,3 @@
+-- Charges of the payment the provider refused; part of the idempotency key
+-- of the next charge, so a refused charge is not replayed
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS charge_attempt INT NOT NULL DEFAULT 0;
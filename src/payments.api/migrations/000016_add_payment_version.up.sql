@@ -0,0 +1,3 @@
+-- Changes saved to the payment; an update names the version it read and
+-- fails if the payment was changed since
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE fee_schedules DROP COLUMN IF EXISTS item_code;
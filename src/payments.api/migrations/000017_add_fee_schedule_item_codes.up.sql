@@ -0,0 +1,3 @@
+-- Fee schedules pricing a line item (lab test, medicine) by its code;
+-- NULL prices the consultation
+ALTER TABLE fee_schedules ADD COLUMN IF NOT EXISTS item_code VARCHAR(64);